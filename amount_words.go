@@ -0,0 +1,193 @@
+package libtools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	cnCapitalDigits = []string{"零", "壹", "贰", "叁", "肆", "伍", "陆", "柒", "捌", "玖"}
+	cnCapitalUnits  = []string{"", "拾", "佰", "仟"}
+	cnCapitalGroups = []string{"", "万", "亿", "万亿"}
+
+	enOnes = []string{"Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine",
+		"Ten", "Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	enTens   = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+	enGroups = []string{"", "Thousand", "Million", "Billion", "Trillion"}
+)
+
+// parseAmountCents 解析金额字符串, 四舍五入到分
+func parseAmountCents(amount string) (cents int64, negative bool, err error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		err = fmt.Errorf("invalid amount: %q", amount)
+		return
+	}
+
+	d = d.Round(2)
+	if d.Abs().GreaterThanOrEqual(decimal.New(1, 16)) {
+		err = fmt.Errorf("amount too large: %s", amount)
+		return
+	}
+
+	negative = d.IsNegative()
+	cents = d.Abs().Mul(decimal.NewFromInt(100)).IntPart()
+	return
+}
+
+// AmountToChineseCapital 金额转中文大写, 用于合同与收据, 如 "10005.60" => "壹万零伍元陆角"
+// 金额按四舍五入保留到分, 支持到万亿级别
+func AmountToChineseCapital(amount string) (string, error) {
+	cents, negative, err := parseAmountCents(amount)
+	if err != nil {
+		return "", err
+	}
+
+	if cents == 0 {
+		return "零元整", nil
+	}
+
+	yuan := cents / 100
+	jiao := cents % 100 / 10
+	fen := cents % 10
+
+	var sb strings.Builder
+	if negative {
+		sb.WriteString("负")
+	}
+
+	if yuan > 0 {
+		var groups []int64
+		for n := yuan; n > 0; n /= 10000 {
+			groups = append(groups, n%10000)
+		}
+
+		needZero := false
+		for i := len(groups) - 1; i >= 0; i-- {
+			group := groups[i]
+			if group == 0 {
+				needZero = true
+				continue
+			}
+			// 非最高组且不足千位, 或前面有整组为零, 需要补 "零"
+			if i < len(groups)-1 && (needZero || group < 1000) {
+				sb.WriteString("零")
+			}
+			sb.WriteString(cnCapitalGroup(group))
+			sb.WriteString(cnCapitalGroups[i])
+			needZero = false
+		}
+		sb.WriteString("元")
+	}
+
+	if jiao == 0 && fen == 0 {
+		sb.WriteString("整")
+		return sb.String(), nil
+	}
+
+	if jiao > 0 {
+		sb.WriteString(cnCapitalDigits[jiao])
+		sb.WriteString("角")
+	} else if yuan > 0 {
+		sb.WriteString("零")
+	}
+	if fen > 0 {
+		sb.WriteString(cnCapitalDigits[fen])
+		sb.WriteString("分")
+	}
+
+	return sb.String(), nil
+}
+
+// cnCapitalGroup 转换 4 位以内的数字, 中间连续的零只读一个, 末尾的零不读
+func cnCapitalGroup(n int64) string {
+	var sb strings.Builder
+	zero := false
+	for pos := 3; pos >= 0; pos-- {
+		div := int64(1)
+		for i := 0; i < pos; i++ {
+			div *= 10
+		}
+		digit := n / div % 10
+
+		if digit == 0 {
+			if sb.Len() > 0 {
+				zero = true
+			}
+			continue
+		}
+		if zero {
+			sb.WriteString("零")
+			zero = false
+		}
+		sb.WriteString(cnCapitalDigits[digit])
+		sb.WriteString(cnCapitalUnits[pos])
+	}
+
+	return sb.String()
+}
+
+// AmountToEnglishWords 金额转英文, 分以 xx/100 表示, 如 "1234.50" => "One Thousand Two Hundred Thirty-Four and 50/100"
+func AmountToEnglishWords(amount string) (string, error) {
+	cents, negative, err := parseAmountCents(amount)
+	if err != nil {
+		return "", err
+	}
+
+	integer := cents / 100
+	fraction := cents % 100
+
+	var words []string
+	if negative && cents > 0 {
+		words = append(words, "Minus")
+	}
+
+	if integer == 0 {
+		words = append(words, enOnes[0])
+	} else {
+		var parts []string
+		for i := 0; integer > 0; i++ {
+			group := integer % 1000
+			integer /= 1000
+			if group == 0 {
+				continue
+			}
+			part := enWordsGroup(group)
+			if enGroups[i] != "" {
+				part += " " + enGroups[i]
+			}
+			parts = append([]string{part}, parts...)
+		}
+		words = append(words, parts...)
+	}
+
+	result := strings.Join(words, " ")
+	if fraction > 0 {
+		result += fmt.Sprintf(" and %02d/100", fraction)
+	}
+
+	return result, nil
+}
+
+// enWordsGroup 转换 1~999
+func enWordsGroup(n int64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, enOnes[n/100], "Hundred")
+		n %= 100
+	}
+
+	if n >= 20 {
+		word := enTens[n/10]
+		if n%10 > 0 {
+			word += "-" + enOnes[n%10]
+		}
+		words = append(words, word)
+	} else if n > 0 {
+		words = append(words, enOnes[n])
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,49 @@
+package libtools
+
+import "testing"
+
+func TestAmountToChineseCapital(t *testing.T) {
+	cases := map[string]string{
+		"0":            "零元整",
+		"0.05":         "伍分",
+		"1.5":          "壹元伍角",
+		"10005.60":     "壹万零伍元陆角",
+		"100010000":    "壹亿零壹万元整",
+		"100001000":    "壹亿零壹仟元整",
+		"1010.01":      "壹仟零壹拾元零壹分",
+		"123456789.12": "壹亿贰仟叁佰肆拾伍万陆仟柒佰捌拾玖元壹角贰分",
+		"-20":          "负贰拾元整",
+	}
+
+	for amount, expect := range cases {
+		got, err := AmountToChineseCapital(amount)
+		if err != nil {
+			t.Errorf("amount %s get err: %v", amount, err)
+			continue
+		}
+		if got != expect {
+			t.Errorf("amount %s: got %s, expect %s", amount, got, expect)
+		}
+	}
+}
+
+func TestAmountToEnglishWords(t *testing.T) {
+	cases := map[string]string{
+		"0":          "Zero",
+		"1234.50":    "One Thousand Two Hundred Thirty-Four and 50/100",
+		"1000001":    "One Million One",
+		"-15.05":     "Minus Fifteen and 05/100",
+		"2000000000": "Two Billion",
+	}
+
+	for amount, expect := range cases {
+		got, err := AmountToEnglishWords(amount)
+		if err != nil {
+			t.Errorf("amount %s get err: %v", amount, err)
+			continue
+		}
+		if got != expect {
+			t.Errorf("amount %s: got %s, expect %s", amount, got, expect)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package libtools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// AuditChange 单个字段的变更
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEvent 后台操作审计事件
+type AuditEvent struct {
+	ID       string `json:"id"`
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	Object   string `json:"object"`
+	ObjectID string `json:"object_id,omitempty"`
+	// Before/After 为 WithDiff 传入的原始对象, 不序列化, 避免 diff tag 脱敏的字段原样写入审计记录
+	Before    interface{}            `json:"-"`
+	After     interface{}            `json:"-"`
+	Changes   []AuditChange          `json:"changes,omitempty"`
+	IP        string                 `json:"ip,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+	Timestamp int64                  `json:"timestamp"` // 毫秒
+}
+
+// NewAuditEvent 如: NewAuditEvent("admin:1001", "update", "loan_application", "20240512001")
+func NewAuditEvent(actor, action, object, objectID string) *AuditEvent {
+	return &AuditEvent{
+		ID:        GetGuid(),
+		Actor:     actor,
+		Action:    action,
+		Object:    object,
+		ObjectID:  objectID,
+		Timestamp: GetUnixMillis(),
+	}
+}
+
+func (e *AuditEvent) WithIP(ip string) *AuditEvent {
+	e.IP = ip
+	return e
+}
+
+func (e *AuditEvent) WithRequestID(requestID string) *AuditEvent {
+	e.RequestID = requestID
+	return e
+}
+
+func (e *AuditEvent) WithExtra(key string, value interface{}) *AuditEvent {
+	if e.Extra == nil {
+		e.Extra = make(map[string]interface{})
+	}
+	e.Extra[key] = value
+	return e
+}
+
+// WithDiff 记录变更前后的对象,并计算出有变化的字段, 序列化时只输出 Changes
+// 结构体使用 DiffStructs 比较(支持嵌套字段与 diff tag 脱敏), 其他类型按 json 顶层字段比较
+func (e *AuditEvent) WithDiff(before, after interface{}) *AuditEvent {
+	e.Before = before
+	e.After = after
+
+	fieldChanges, err := DiffStructs(before, after)
+	if err != nil {
+		e.Changes = auditDiff(before, after)
+		return e
+	}
+
+	e.Changes = nil
+	for _, c := range fieldChanges {
+		e.Changes = append(e.Changes, AuditChange{Field: c.Path, Before: c.Old, After: c.New})
+	}
+	return e
+}
+
+func (e *AuditEvent) JSON() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// auditDiff 按 json 序列化后的顶层字段比较
+func auditDiff(before, after interface{}) []AuditChange {
+	beforeMap := auditToMap(before)
+	afterMap := auditToMap(after)
+
+	keys := make(map[string]bool)
+	for k := range beforeMap {
+		keys[k] = true
+	}
+	for k := range afterMap {
+		keys[k] = true
+	}
+
+	var fields []string
+	for k := range keys {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	var changes []AuditChange
+	for _, field := range fields {
+		b, a := beforeMap[field], afterMap[field]
+		if !reflect.DeepEqual(b, a) {
+			changes = append(changes, AuditChange{Field: field, Before: b, After: a})
+		}
+	}
+
+	return changes
+}
+
+func auditToMap(obj interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	if obj == nil {
+		return m
+	}
+
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		logs.Warning("[auditToMap] marshal fail, obj: %#v, err: %v", obj, err)
+		return m
+	}
+	_ = json.Unmarshal(buf, &m)
+
+	return m
+}
+
+// AuditSink 审计事件的落地方式
+type AuditSink interface {
+	Write(event *AuditEvent) error
+}
+
+// AuditSinkFunc 将普通函数适配为 AuditSink,方便接入 kafka 等自定义通道
+type AuditSinkFunc func(event *AuditEvent) error
+
+func (f AuditSinkFunc) Write(event *AuditEvent) error {
+	return f(event)
+}
+
+// writerAuditSink 每个事件一行 json
+type writerAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerAuditSink{w: w}
+}
+
+// NewFileAuditSink 以追加方式写入文件
+func NewFileAuditSink(filename string) (AuditSink, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterAuditSink(f), nil
+}
+
+func (s *writerAuditSink) Write(event *AuditEvent) error {
+	buf, err := event.JSON()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(buf, '\n'))
+	return err
+}
+
+type httpAuditSink struct {
+	url     string
+	headers map[string]string
+	timeout time.Duration
+}
+
+// NewHttpAuditSink 以 json POST 到审计服务,非 2xx 视为失败
+func NewHttpAuditSink(url string, headers map[string]string, timeout time.Duration) AuditSink {
+	return &httpAuditSink{url: url, headers: headers, timeout: timeout}
+}
+
+func (s *httpAuditSink) Write(event *AuditEvent) error {
+	_, code, err := HttpRequest(HttpMethodPOST, s.url, s.headers, HttpApplicationJSON, event, s.timeout)
+	if err != nil {
+		return err
+	}
+
+	if code < 200 || code >= 300 {
+		return fmt.Errorf("audit sink get unexpected status code: %d", code)
+	}
+
+	return nil
+}
+
+type multiAuditSink []AuditSink
+
+// MultiAuditSink 依次写入所有 sink, 单个失败不影响其他 sink, 返回第一个错误
+func MultiAuditSink(sinks ...AuditSink) AuditSink {
+	return multiAuditSink(sinks)
+}
+
+func (m multiAuditSink) Write(event *AuditEvent) error {
+	var first error
+	for _, sink := range m {
+		if err := sink.Write(event); err != nil {
+			logs.Error("[MultiAuditSink] write event fail, id: %s, err: %v", event.ID, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
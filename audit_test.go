@@ -0,0 +1,117 @@
+package libtools
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestAuditEventWithDiffMask(t *testing.T) {
+	type borrower struct {
+		Name   string `json:"name"`
+		Mobile string `json:"mobile" diff:",mask=mobile"`
+		Pin    string `json:"pin" diff:",mask=all"`
+	}
+	before := borrower{Name: "budi", Mobile: "08123456789", Pin: "123456"}
+	after := borrower{Name: "budi", Mobile: "08123450000", Pin: "654321"}
+
+	buf, err := NewAuditEvent("admin:1", "update", "borrower", "1").WithDiff(&before, &after).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []string{"08123456789", "08123450000", "123456", "654321"} {
+		if strings.Contains(string(buf), raw) {
+			t.Errorf("audit json leaks %s: %s", raw, buf)
+		}
+	}
+	if !strings.Contains(string(buf), "081****0000") {
+		t.Errorf("audit json should contain masked change: %s", buf)
+	}
+}
+
+func TestAuditEventMapDiff(t *testing.T) {
+	event := NewAuditEvent("admin:1", "update", "config", "").
+		WithIP("10.0.0.1").
+		WithExtra("reason", "ops").
+		WithDiff(map[string]interface{}{"rate": 1.5, "limit": 100, "name": "a"}, map[string]interface{}{"rate": 1.8, "limit": 100, "enabled": true})
+
+	want := []AuditChange{
+		{Field: "enabled", Before: nil, After: true},
+		{Field: "name", Before: "a", After: nil},
+		{Field: "rate", Before: 1.5, After: 1.8},
+	}
+	if len(event.Changes) != len(want) {
+		t.Fatalf("unexpected changes: %+v", event.Changes)
+	}
+	for i, c := range want {
+		if event.Changes[i] != c {
+			t.Errorf("change %d: got %+v, want %+v", i, event.Changes[i], c)
+		}
+	}
+	if event.ID == "" || event.Timestamp == 0 || event.Extra["reason"] != "ops" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestAuditSinks(t *testing.T) {
+	filename := filepath.Join(testutil.TempDirT(t), "audit.log")
+	fileSink, err := NewFileAuditSink(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "t" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer srv.Close()
+
+	failing := AuditSinkFunc(func(*AuditEvent) error { return errors.New("kafka down") })
+	sink := MultiAuditSink(failing, fileSink, NewHttpAuditSink(srv.URL, map[string]string{"X-Token": "t"}, 0))
+
+	for _, id := range []string{"1", "2"} {
+		// 单个 sink 失败时其他 sink 仍然写入, 返回第一个错误
+		if err = sink.Write(NewAuditEvent("admin:1", "approve", "loan", id)); err == nil || err.Error() != "kafka down" {
+			t.Errorf("expect first error, got: %v", err)
+		}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("each line should be a json event: %s", scanner.Text())
+		}
+		ids = append(ids, event.ObjectID)
+	}
+	if strings.Join(ids, ",") != "1,2" {
+		t.Errorf("unexpected file events: %v", ids)
+	}
+	if len(received) != 2 || !strings.Contains(received[1], `"object_id":"2"`) {
+		t.Errorf("unexpected http events: %v", received)
+	}
+
+	if err = NewHttpAuditSink(srv.URL, nil, 0).Write(NewAuditEvent("admin:1", "approve", "loan", "3")); err == nil {
+		t.Error("non-2xx response should fail")
+	}
+}
@@ -0,0 +1,34 @@
+package libtools
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 用例来自 AWS SigV4 官方测试集 (aws-sig-v4-test-suite)
+func TestSignAWSV4(t *testing.T) {
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	cases := []struct {
+		url       string
+		signature string
+	}{
+		{"https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest(http.MethodGet, c.url, nil)
+		if err := signAWSV4(req, creds, "us-east-1", "service", nil, now); err != nil {
+			t.Fatal(err)
+		}
+
+		auth := req.Header.Get("Authorization")
+		if !strings.Contains(auth, "SignedHeaders=host;x-amz-date,") || !strings.HasSuffix(auth, "Signature="+c.signature) {
+			t.Errorf("%s: unexpected authorization %s", c.url, auth)
+		}
+	}
+}
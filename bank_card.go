@@ -0,0 +1,167 @@
+package libtools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// 卡种
+const (
+	BankCardDebit  = "debit"
+	BankCardCredit = "credit"
+)
+
+// BankBIN 发卡行识别码
+type BankBIN struct {
+	BIN      string `json:"bin"`
+	BankCode string `json:"bank_code"`
+	BankName string `json:"bank_name"`
+	CardType string `json:"card_type"`
+}
+
+// BankCardInfo BankFromCard 的结果, BankCode 为空表示 BIN 表中没有这张卡
+type BankCardInfo struct {
+	CardNo   string `json:"card_no"`
+	BIN      string `json:"bin"`
+	BankCode string `json:"bank_code"`
+	BankName string `json:"bank_name"`
+	CardType string `json:"card_type"`
+	Valid    bool   `json:"valid"` // 长度与 Luhn 校验是否通过
+}
+
+// bankBINs 内置的常见 BIN, 只覆盖主要银行的部分卡 BIN, 完整数据由 LoadBankBINs 加载(可覆盖内置项)
+var bankBINs = map[string]BankBIN{
+	"622202": {"622202", "ICBC", "中国工商银行", BankCardDebit},
+	"622848": {"622848", "ABC", "中国农业银行", BankCardDebit},
+	"622700": {"622700", "CCB", "中国建设银行", BankCardDebit},
+	"621700": {"621700", "CCB", "中国建设银行", BankCardDebit},
+	"436742": {"436742", "CCB", "中国建设银行", BankCardCredit},
+	"456351": {"456351", "BOC", "中国银行", BankCardDebit},
+	"621661": {"621661", "BOC", "中国银行", BankCardDebit},
+	"622588": {"622588", "CMB", "招商银行", BankCardDebit},
+	"621483": {"621483", "CMB", "招商银行", BankCardDebit},
+	"622575": {"622575", "CMB", "招商银行", BankCardCredit},
+	"622262": {"622262", "COMM", "交通银行", BankCardDebit},
+	"622150": {"622150", "PSBC", "中国邮政储蓄银行", BankCardDebit},
+	"621799": {"621799", "PSBC", "中国邮政储蓄银行", BankCardDebit},
+}
+
+var (
+	bankBINMu     sync.RWMutex
+	bankBINMaxLen = 6
+)
+
+// LoadBankBINs 加载 BIN 表, 每行 "bin,bank_code,bank_name,card_type", # 开头为注释, 相同 BIN 覆盖内置数据
+func LoadBankBINs(r io.Reader) error {
+	loaded := make(map[string]BankBIN)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		if len(fields) < 4 {
+			return fmt.Errorf("invalid bin line %d: %s", line, text)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if !IsNumber(fields[0]) {
+			return fmt.Errorf("invalid bin at line %d: %s", line, fields[0])
+		}
+
+		loaded[fields[0]] = BankBIN{
+			BIN:      fields[0],
+			BankCode: fields[1],
+			BankName: fields[2],
+			CardType: strings.ToLower(fields[3]),
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	bankBINMu.Lock()
+	for bin, item := range loaded {
+		bankBINs[bin] = item
+		if len(bin) > bankBINMaxLen {
+			bankBINMaxLen = len(bin)
+		}
+	}
+	bankBINMu.Unlock()
+
+	return nil
+}
+
+// LuhnValid 银行卡号 Luhn(模 10) 校验
+func LuhnValid(number string) bool {
+	if number == "" {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// LuhnCheckDigit 计算 number 末尾应追加的 Luhn 校验位, number 含非数字字符时返回 -1
+func LuhnCheckDigit(number string) int {
+	for c := 0; c <= 9; c++ {
+		if LuhnValid(number + string(rune('0'+c))) {
+			return c
+		}
+	}
+
+	return -1
+}
+
+// BankFromCard 识别银行卡所属银行与卡种, 按最长前缀匹配 BIN; 卡号中的空格与横线会被忽略
+func BankFromCard(cardNo string) BankCardInfo {
+	cardNo = strings.NewReplacer(" ", "", "-", "").Replace(cardNo)
+	info := BankCardInfo{
+		CardNo: cardNo,
+		Valid:  len(cardNo) >= 13 && len(cardNo) <= 19 && LuhnValid(cardNo),
+	}
+
+	bankBINMu.RLock()
+	defer bankBINMu.RUnlock()
+
+	for l := bankBINMaxLen; l >= 3; l-- {
+		if len(cardNo) < l {
+			continue
+		}
+		if item, ok := bankBINs[cardNo[:l]]; ok {
+			info.BIN = item.BIN
+			info.BankCode = item.BankCode
+			info.BankName = item.BankName
+			info.CardType = item.CardType
+			break
+		}
+	}
+
+	return info
+}
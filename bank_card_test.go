@@ -0,0 +1,86 @@
+package libtools
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// withBankBINsT 还原 LoadBankBINs 对全局 BIN 表的修改
+func withBankBINsT(t *testing.T) {
+	t.Helper()
+	bankBINMu.Lock()
+	saved := make(map[string]BankBIN, len(bankBINs))
+	for bin, item := range bankBINs {
+		saved[bin] = item
+	}
+	savedMaxLen := bankBINMaxLen
+	bankBINMu.Unlock()
+
+	t.Cleanup(func() {
+		bankBINMu.Lock()
+		bankBINs, bankBINMaxLen = saved, savedMaxLen
+		bankBINMu.Unlock()
+	})
+}
+
+// bankCardNoT 在 prefix 后补 0 到 length-1 位, 再追加 Luhn 校验位
+func bankCardNoT(prefix string, length int) string {
+	number := prefix + strings.Repeat("0", length-1-len(prefix))
+	return number + strconv.Itoa(LuhnCheckDigit(number))
+}
+
+func TestLuhn(t *testing.T) {
+	for _, number := range []string{"4111111111111111", "79927398713", "5500005555555559"} {
+		if !LuhnValid(number) {
+			t.Errorf("%s should be valid", number)
+		}
+	}
+	for _, number := range []string{"", "4111111111111112", "4111-1111"} {
+		if LuhnValid(number) {
+			t.Errorf("%s should be invalid", number)
+		}
+	}
+	if d := LuhnCheckDigit("7992739871"); d != 3 {
+		t.Errorf("check digit of 7992739871 should be 3, got: %d", d)
+	}
+	if d := LuhnCheckDigit("12a"); d != -1 {
+		t.Errorf("non-digit should return -1, got: %d", d)
+	}
+}
+
+func TestBankFromCard(t *testing.T) {
+	withBankBINsT(t)
+
+	cardNo := bankCardNoT("622202", 19)
+	info := BankFromCard(cardNo[:4] + " " + cardNo[4:8] + "-" + cardNo[8:])
+	if info.CardNo != cardNo || !info.Valid || info.BankCode != "ICBC" || info.CardType != BankCardDebit {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	wrongDigit := strconv.Itoa(int(cardNo[18]-'0'+1) % 10)
+	if info = BankFromCard(cardNo[:18] + wrongDigit); info.Valid || info.BankCode != "ICBC" {
+		t.Errorf("wrong check digit should be invalid but still match bin: %+v", info)
+	}
+	if info = BankFromCard(bankCardNoT("999999", 16)); !info.Valid || info.BankCode != "" {
+		t.Errorf("unknown bin should be valid but without bank: %+v", info)
+	}
+
+	err := LoadBankBINs(strings.NewReader("# bin,bank_code,bank_name,card_type\n62220211, TEST, 测试银行, CREDIT\n\n622202,ICBC2,工商银行,debit\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 最长前缀优先, 加载的数据覆盖内置数据
+	if info = BankFromCard(bankCardNoT("62220211", 16)); info.BIN != "62220211" || info.BankCode != "TEST" || info.CardType != BankCardCredit {
+		t.Errorf("longest bin should match: %+v", info)
+	}
+	if info = BankFromCard(cardNo); info.BankCode != "ICBC2" {
+		t.Errorf("loaded bin should override builtin: %+v", info)
+	}
+
+	for _, bad := range []string{"622202,ICBC\n", "62a202,ICBC,工商银行,debit\n"} {
+		if err = LoadBankBINs(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q should fail", bad)
+		}
+	}
+}
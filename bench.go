@@ -0,0 +1,223 @@
+package libtools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// BenchResult BenchProfile 的结果
+// AllocsPerOp、BytesPerOp 由测量前后整个进程的 MemStats 相减得到, 包含同期其他 goroutine 的分配,
+// 在繁忙的服务中只能作为上限参考; 需要精确值时在空闲进程或 go test -bench 中测量
+type BenchResult struct {
+	Name        string
+	Ops         int64
+	Duration    time.Duration
+	OpsPerSec   float64
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64 // 每次调用分配的内存
+	MBPerSec    float64
+	Ratio       float64 // 压缩后大小/原大小, 只有压缩测试有值
+}
+
+func (r BenchResult) String() string {
+	s := fmt.Sprintf("%s\t%d ops\t%.0f ops/s\t%d ns/op\t%d B/op\t%d allocs/op", r.Name, r.Ops, r.OpsPerSec, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+	if r.MBPerSec > 0 {
+		s += fmt.Sprintf("\t%.2f MB/s", r.MBPerSec)
+	}
+	if r.Ratio > 0 {
+		s += fmt.Sprintf("\tratio %.3f", r.Ratio)
+	}
+	return s
+}
+
+// BenchCase 参与对比的一项, Bytes 为每次调用处理的数据量, 大于 0 时计算 MB/s
+type BenchCase struct {
+	Name  string
+	Fn    func()
+	Bytes int64
+	Ratio float64
+}
+
+// BenchProfile 在 duration 内反复调用 fn, 统计吞吐与内存分配, 用于在服务内用真实数据比较实现的开销
+// 与 go test -bench 不同, 可以在程序中直接调用; 测量期间会触发一次 GC, 不要在线上高峰期执行
+// 内存分配统计是进程级的, 其他 goroutine 的分配也会计入, 见 BenchResult
+func BenchProfile(fn func(), duration time.Duration) BenchResult {
+	if duration <= 0 {
+		duration = time.Second
+	}
+
+	// 预热, 排除首次调用的初始化开销
+	fn()
+	runtime.GC()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var ops int64
+	batch := int64(1)
+	start := time.Now()
+	for {
+		for i := int64(0); i < batch; i++ {
+			fn()
+		}
+		ops += batch
+		spent := time.Since(start)
+		if spent >= duration {
+			break
+		}
+		// 逐步放大每批的次数以减少读取时间的开销, 但不超过剩余时间内预计能执行的次数
+		next := int64(duration-spent) / (int64(spent)/ops + 1)
+		if next > batch*2 {
+			next = batch * 2
+		}
+		if next < 1 {
+			next = 1
+		}
+		batch = next
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return BenchResult{
+		Ops:         ops,
+		Duration:    elapsed,
+		OpsPerSec:   float64(ops) / elapsed.Seconds(),
+		NsPerOp:     elapsed.Nanoseconds() / ops,
+		AllocsPerOp: int64(after.Mallocs-before.Mallocs) / ops,
+		BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / ops,
+	}
+}
+
+// BenchCompare 依次测量每一项, 结果按 OpsPerSec 从高到低排序
+func BenchCompare(cases []BenchCase, duration time.Duration) []BenchResult {
+	results := make([]BenchResult, 0, len(cases))
+	for _, c := range cases {
+		r := BenchProfile(c.Fn, duration)
+		r.Name = c.Name
+		r.Ratio = c.Ratio
+		if c.Bytes > 0 && r.NsPerOp > 0 {
+			r.MBPerSec = float64(c.Bytes) * r.OpsPerSec / 1e6
+		}
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].OpsPerSec > results[j].OpsPerSec
+	})
+	return results
+}
+
+// HashBenchCases 用 data 比较常用摘要算法: md5、sha1、sha256、sha512 与非加密的 xxh64、xxh3
+func HashBenchCases(data []byte) []BenchCase {
+	algos := []struct {
+		name string
+		new  func() hash.Hash
+	}{
+		{"md5", md5.New},
+		{"sha1", sha1.New},
+		{"sha256", sha256.New},
+		{"sha512", sha512.New},
+	}
+
+	cases := make([]BenchCase, 0, len(algos))
+	for _, algo := range algos {
+		newHash := algo.new
+		cases = append(cases, BenchCase{
+			Name: algo.name,
+			Fn: func() {
+				h := newHash()
+				_, _ = h.Write(data)
+				_ = h.Sum(nil)
+			},
+			Bytes: int64(len(data)),
+		})
+	}
+
+	cases = append(cases,
+		BenchCase{Name: "xxh64", Fn: func() { _ = XXH64(data) }, Bytes: int64(len(data))},
+		BenchCase{Name: "xxh3", Fn: func() { _ = XXH3(data) }, Bytes: int64(len(data))},
+	)
+
+	return cases
+}
+
+// NewCompressWriter 创建压缩 writer, 如 zstd 可以传入
+//
+//	func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+type NewCompressWriter func(w io.Writer) (io.WriteCloser, error)
+
+// GzipCompressors 指定压缩级别的 gzip, 名称为 gzip-<level>; 不传时比较 1、6、9 三个级别
+func GzipCompressors(levels ...int) map[string]NewCompressWriter {
+	if len(levels) == 0 {
+		levels = []int{gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression}
+	}
+
+	compressors := make(map[string]NewCompressWriter, len(levels))
+	for _, level := range levels {
+		level := level
+		if level == gzip.DefaultCompression {
+			level = 6
+		}
+		compressors["gzip-"+strconv.Itoa(level)] = func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		}
+	}
+
+	return compressors
+}
+
+// CompressBenchCases 用 data 比较压缩算法, 每项的 Ratio 为压缩率; 压缩器返回错误时整体失败
+func CompressBenchCases(data []byte, compressors map[string]NewCompressWriter) ([]BenchCase, error) {
+	names := make([]string, 0, len(compressors))
+	for name := range compressors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	compress := func(newWriter NewCompressWriter, buf *bytes.Buffer) error {
+		buf.Reset()
+		w, err := newWriter(buf)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(data); err != nil {
+			_ = w.Close()
+			return err
+		}
+		return w.Close()
+	}
+
+	cases := make([]BenchCase, 0, len(names))
+	for _, name := range names {
+		newWriter := compressors[name]
+		var buf bytes.Buffer
+		if err := compress(newWriter, &buf); err != nil {
+			return nil, fmt.Errorf("compressor %s fail: %v", name, err)
+		}
+
+		var ratio float64
+		if len(data) > 0 {
+			ratio = float64(buf.Len()) / float64(len(data))
+		}
+		cases = append(cases, BenchCase{
+			Name:  name,
+			Fn:    func() { _ = compress(newWriter, &buf) },
+			Bytes: int64(len(data)),
+			Ratio: ratio,
+		})
+	}
+
+	return cases, nil
+}
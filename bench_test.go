@@ -0,0 +1,37 @@
+package libtools
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBenchProfile(t *testing.T) {
+	r := BenchProfile(func() { _ = make([]byte, 1024) }, 20*time.Millisecond)
+	if r.Ops <= 0 || r.OpsPerSec <= 0 || r.Duration < 20*time.Millisecond {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func TestBenchCompare(t *testing.T) {
+	data := bytes.Repeat([]byte("libtools bench data "), 512)
+
+	cases := HashBenchCases(data)
+	compressCases, err := CompressBenchCases(data, GzipCompressors(1, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressCases) != 2 || compressCases[0].Name != "gzip-1" || compressCases[0].Ratio <= 0 || compressCases[0].Ratio >= 1 {
+		t.Fatalf("unexpected compress cases: %+v", compressCases)
+	}
+
+	results := BenchCompare(append(cases, compressCases...), 5*time.Millisecond)
+	if len(results) != 8 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	for i, r := range results {
+		if r.Name == "" || r.MBPerSec <= 0 || (i > 0 && r.OpsPerSec > results[i-1].OpsPerSec) {
+			t.Fatalf("unexpected result: %v", r)
+		}
+	}
+}
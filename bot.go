@@ -0,0 +1,150 @@
+package libtools
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BotScoreOptions 爬虫打分配置, 零值使用默认值
+type BotScoreOptions struct {
+	// DatacenterCIDRs 机房/云厂商网段, 按云厂商公布的地址段配置, 正常用户极少从机房 ip 访问 H5
+	DatacenterCIDRs []string
+	// RateWindow 统计单 ip 请求频率的窗口, 默认 1 分钟
+	RateWindow time.Duration
+	// RateLimit 窗口内超过该请求数开始加分, 默认 60
+	RateLimit int64
+}
+
+// BotScorer 综合 UA、请求头、ip 与访问频率给请求打分, 0-100, 分数越高越像爬虫
+type BotScorer struct {
+	opts  BotScoreOptions
+	mu    sync.Mutex
+	rates *TTLCache // ip => *WindowCounter
+}
+
+var botUAKeywords = []string{
+	"bot", "spider", "crawler", "curl", "wget", "python", "java/", "go-http-client", "okhttp", "httpclient",
+	"scrapy", "headless", "phantomjs", "selenium", "puppeteer", "playwright", "postman", "libwww", "node-fetch", "axios",
+}
+
+func NewBotScorer(opts ...BotScoreOptions) *BotScorer {
+	var opt BotScoreOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.RateWindow <= 0 {
+		opt.RateWindow = time.Minute
+	}
+	if opt.RateLimit <= 0 {
+		opt.RateLimit = 60
+	}
+
+	return &BotScorer{
+		opts:  opt,
+		rates: NewTTLCache(2 * opt.RateWindow),
+	}
+}
+
+var defaultBotScorer = NewBotScorer()
+
+// SetBotScoreOptions 替换 BotScore 使用的默认配置, 已有的频率统计会被清空
+func SetBotScoreOptions(opts BotScoreOptions) {
+	defaultBotScorer = NewBotScorer(opts)
+}
+
+// BotScore 使用默认配置打分, 每次调用都会计入该 ip 的访问频率, 同一请求只应调用一次
+func BotScore(r *http.Request) int {
+	score, _ := defaultBotScorer.Score(r)
+	return score
+}
+
+// Score 返回分数与命中的规则, 规则只用于日志与排查
+func (b *BotScorer) Score(r *http.Request) (int, []string) {
+	score := 0
+	var reasons []string
+	hit := func(reason string, n int) {
+		score += n
+		reasons = append(reasons, reason)
+	}
+
+	ua := strings.TrimSpace(r.UserAgent())
+	lowerUA := strings.ToLower(ua)
+	isApp := VerifyHttpUserAgent(ua)
+	switch {
+	case ua == "":
+		hit("empty_ua", 40)
+	case isApp:
+		// 自家 app 的 UA 不做浏览器请求头的检查
+	default:
+		for _, kw := range botUAKeywords {
+			if strings.Contains(lowerUA, kw) {
+				hit("bot_ua", 50)
+				break
+			}
+		}
+		if len(ua) < 20 {
+			hit("short_ua", 15)
+		}
+	}
+
+	// 浏览器一定会带的请求头
+	if !isApp && strings.HasPrefix(ua, "Mozilla/") {
+		if r.Header.Get("Accept-Language") == "" {
+			hit("no_accept_language", 15)
+		}
+		if r.Header.Get("Accept-Encoding") == "" {
+			hit("no_accept_encoding", 10)
+		}
+		if strings.Contains(ua, "Chrome/") && r.Header.Get("Connection") == "close" && r.ProtoMajor < 2 {
+			hit("connection_close", 5)
+		}
+	}
+	if r.Header.Get("Accept") == "" {
+		hit("no_accept", 10)
+	}
+
+	ip := ClientIP(r)
+	if IsPrivateIP(ip) {
+		hit("private_ip", 10)
+	} else {
+		for _, cidr := range b.opts.DatacenterCIDRs {
+			if CIDRContains(cidr, ip) {
+				hit("datacenter_ip", 30)
+				break
+			}
+		}
+	}
+
+	count := b.recordRate(ip)
+	switch {
+	case count > b.opts.RateLimit:
+		hit("high_rate", 30)
+	case count > b.opts.RateLimit/2:
+		hit("elevated_rate", 15)
+	}
+
+	if score > 100 {
+		score = 100
+	}
+
+	return score, reasons
+}
+
+// recordRate 计入一次访问, 返回窗口内的访问次数
+func (b *BotScorer) recordRate(ip string) int64 {
+	b.mu.Lock()
+	var counter *WindowCounter
+	if v, ok := b.rates.Get(ip); ok {
+		counter = v.(*WindowCounter)
+	} else {
+		counter = NewWindowCounter(b.opts.RateWindow, 12)
+	}
+	// 每次访问都续期, 长时间不访问的 ip 自动淘汰
+	b.rates.Set(ip, counter)
+	b.mu.Unlock()
+
+	counter.Incr()
+	return counter.Count()
+}
@@ -0,0 +1,35 @@
+package libtools
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+)
+
+// bucketHash 对 实验名:用户 做 sha1 取前 8 字节, 与语言/服务无关, 其他服务按同样方式实现即可得到一致的分桶
+// 实验名参与哈希, 保证不同实验之间的分桶相互独立
+func bucketHash(userID, experiment string) uint64 {
+	sum := sha1.Sum([]byte(experiment + ":" + userID))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// Bucket 将用户稳定地分到 [0, nBuckets) 中的某个桶, nBuckets <= 0 时返回 0
+func Bucket(userID string, experiment string, nBuckets int) int {
+	if nBuckets <= 0 {
+		return 0
+	}
+
+	return int(bucketHash(userID, experiment) % uint64(nBuckets))
+}
+
+// InPercent 用户是否落在实验的前 pct% 流量中, 精度为 0.01%
+// 对同一实验, pct 调大时原来命中的用户仍然命中, 便于逐步放量
+func InPercent(userID, experiment string, pct float64) bool {
+	if pct <= 0 {
+		return false
+	}
+	if pct >= 100 {
+		return true
+	}
+
+	return Bucket(userID, experiment, 10000) < int(pct*100)
+}
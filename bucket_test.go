@@ -0,0 +1,26 @@
+package libtools
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBucket(t *testing.T) {
+	if Bucket("13800138000", "exp_a", 10) != Bucket("13800138000", "exp_a", 10) {
+		t.Errorf("Bucket should be stable")
+	}
+
+	hit := 0
+	for i := 0; i < 10000; i++ {
+		userID := fmt.Sprintf("user_%d", i)
+		if InPercent(userID, "exp_a", 20) {
+			hit++
+			if !InPercent(userID, "exp_a", 50) {
+				t.Errorf("user %s in 20%% should also in 50%%", userID)
+			}
+		}
+	}
+	if hit < 1800 || hit > 2200 {
+		t.Errorf("InPercent 20%% hit %d of 10000", hit)
+	}
+}
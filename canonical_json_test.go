@@ -0,0 +1,32 @@
+package libtools
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	raw := json.RawMessage(`{"b": [1.50, 1e2, -0.0, 1e-7, 1e21], "a": {"z": "<&>", "y": null}, "id": 9007199254740993}`)
+
+	got, err := CanonicalJSON(raw)
+	want := `{"a":{"y":null,"z":"<&>"},"b":[1.5,100,0,1e-7,1e+21],"id":9007199254740993}`
+	if err != nil || string(got) != want {
+		t.Errorf("CanonicalJSON get: %s, err: %v, want: %s", got, err, want)
+	}
+
+	type payload struct {
+		OrderNo string  `json:"order_no"`
+		Amount  float64 `json:"amount"`
+	}
+	h1, _ := JSONHash(payload{OrderNo: "A1", Amount: 10})
+	h2, _ := JSONHash(map[string]interface{}{"amount": 10.0, "order_no": "A1"})
+	if h1 == "" || h1 != h2 {
+		t.Errorf("JSONHash should be stable, get %s and %s", h1, h2)
+	}
+
+	// 按 UTF-16 排序时, U+10000 以上的字符排在 U+E000 之前
+	got, _ = CanonicalJSON(map[string]int{"\uE000": 1, "\U0001F600": 2})
+	if string(got) != "{\"\U0001F600\":2,\"\uE000\":1}" {
+		t.Errorf("CanonicalJSON key order get: %s", got)
+	}
+}
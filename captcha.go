@@ -0,0 +1,334 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CaptchaType int
+
+const (
+	CaptchaDigits CaptchaType = iota // 4 位数字
+	CaptchaArith                     // 10 以内的加减乘, 如 3+5=?
+	CaptchaSlide                     // 滑块拼图, 答案为滑块的横坐标
+)
+
+const (
+	captchaTTL          = 5 * time.Minute
+	captchaKeyPrefix    = "captcha:"
+	captchaSlideOffset  = 5 // 滑块允许的误差像素
+	captchaSlideWidth   = 300
+	captchaSlideHeight  = 150
+	captchaSlidePieceSz = 44
+)
+
+var captchaStore = NewMemoryKV()
+
+// SetCaptchaStore 设置验证码答案的存储, 多实例部署时需设置为 redis 实现, 建议实现 KVGetDeleter
+func SetCaptchaStore(store KVStore) {
+	captchaStore = store
+}
+
+// Captcha 验证码, Image 为 PNG
+// 滑块类型时 Image 为带缺口的背景图, Piece 为滑块图片, 前端将滑块放在 (x, PieceY) 处, 用户拖动结束后提交 x
+type Captcha struct {
+	Token  string `json:"token"`
+	Type   string `json:"type"`
+	Image  []byte `json:"image"`
+	Piece  []byte `json:"piece,omitempty"`
+	PieceY int    `json:"piece_y,omitempty"`
+}
+
+// GenerateCaptcha 生成验证码, 默认为数字类型, 答案保存 5 分钟
+func GenerateCaptcha(captchaType ...CaptchaType) (*Captcha, error) {
+	t := CaptchaDigits
+	if len(captchaType) > 0 {
+		t = captchaType[0]
+	}
+
+	rnd := mrand.New(mrand.NewSource(time.Now().UnixNano()))
+
+	var (
+		c      = &Captcha{Token: GetGuid()}
+		answer string
+		err    error
+	)
+	switch t {
+	case CaptchaDigits:
+		var text string
+		for i := 0; i < 4; i++ {
+			n, err := secureIntn(10)
+			if err != nil {
+				return nil, err
+			}
+			text += strconv.FormatInt(n, 10)
+		}
+		c.Type, answer = "digits", text
+		c.Image, err = captchaTextImage(text, rnd)
+
+	case CaptchaArith:
+		var text string
+		text, answer, err = captchaArith()
+		if err != nil {
+			return nil, err
+		}
+		c.Type = "arith"
+		c.Image, err = captchaTextImage(text, rnd)
+
+	case CaptchaSlide:
+		var x int
+		c.Type = "slide"
+		c.Image, c.Piece, x, c.PieceY, err = captchaSlideImages(rnd)
+		answer = strconv.Itoa(x)
+
+	default:
+		return nil, fmt.Errorf("unsupported captcha type: %d", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token == "" {
+		return nil, fmt.Errorf("could not generate captcha token")
+	}
+	value := []byte(c.Type + ":" + answer)
+	if err = captchaStore.Set(context.Background(), captchaKeyPrefix+c.Token, value, captchaTTL); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// VerifyCaptcha 校验答案, 每个验证码只能校验一次, 无论对错校验后即失效
+func VerifyCaptcha(token, answer string) bool {
+	if token == "" {
+		return false
+	}
+
+	// 读取与删除是原子的, 同一个验证码并发提交时只有一个请求能拿到答案
+	value, ok, err := kvGetDel(context.Background(), captchaStore, captchaKeyPrefix+token, captchaTTL)
+	if err != nil || !ok {
+		return false
+	}
+
+	parts := strings.SplitN(string(value), ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	answer = strings.TrimSpace(answer)
+
+	if parts[0] == "slide" {
+		expected, _ := strconv.Atoi(parts[1])
+		x, err := strconv.Atoi(answer)
+		if err != nil {
+			return false
+		}
+		return x >= expected-captchaSlideOffset && x <= expected+captchaSlideOffset
+	}
+
+	return answer == parts[1]
+}
+
+func captchaArith() (text, answer string, err error) {
+	nums := make([]int64, 3)
+	for i, n := range []int64{9, 9, 3} {
+		if nums[i], err = secureIntn(n); err != nil {
+			return
+		}
+	}
+	a, b := nums[0]+1, nums[1]+1
+
+	switch nums[2] {
+	case 0:
+		return fmt.Sprintf("%d+%d=?", a, b), strconv.FormatInt(a+b, 10), nil
+	case 1:
+		if a < b {
+			a, b = b, a
+		}
+		return fmt.Sprintf("%d-%d=?", a, b), strconv.FormatInt(a-b, 10), nil
+	default:
+		return fmt.Sprintf("%dx%d=?", a, b), strconv.FormatInt(a*b, 10), nil
+	}
+}
+
+// captchaGlyphs 5x7 点阵字体
+var captchaGlyphs = map[rune][7]string{
+	'0': {"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	'1': {"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	'2': {"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	'3': {"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	'4': {"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	'5': {"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	'6': {"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	'7': {"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	'8': {"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	'9': {"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+	'+': {"00000", "00100", "00100", "11111", "00100", "00100", "00000"},
+	'-': {"00000", "00000", "00000", "11111", "00000", "00000", "00000"},
+	'x': {"00000", "10001", "01010", "00100", "01010", "10001", "00000"},
+	'=': {"00000", "00000", "11111", "00000", "11111", "00000", "00000"},
+	'?': {"01110", "10001", "00001", "00010", "00100", "00000", "00100"},
+}
+
+// captchaTextImage 绘制文字并加入波浪扭曲、干扰线与噪点
+func captchaTextImage(text string, rnd *mrand.Rand) ([]byte, error) {
+	const scale, charGap, padding, height = 4, 6, 10, 48
+
+	width := padding*2 + len(text)*(5*scale+charGap)
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.RGBA{R: 245, G: 245, B: 240, A: 255}}, image.Point{}, draw.Src)
+
+	x := padding
+	for _, ch := range text {
+		glyph, ok := captchaGlyphs[ch]
+		if !ok {
+			return nil, fmt.Errorf("unsupported captcha char: %c", ch)
+		}
+		fg := color.RGBA{R: uint8(rnd.Intn(120)), G: uint8(rnd.Intn(120)), B: uint8(rnd.Intn(120)), A: 255}
+		y := 6 + rnd.Intn(height-7*scale-10)
+		for row := 0; row < 7; row++ {
+			for col := 0; col < 5; col++ {
+				if glyph[row][col] == '1' {
+					draw.Draw(canvas, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), &image.Uniform{C: fg}, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += 5*scale + charGap
+	}
+
+	// 正弦扭曲, 增加机器识别难度
+	warped := image.NewRGBA(canvas.Bounds())
+	amplitude, period, phase := 2+rnd.Float64()*2, 20+rnd.Float64()*20, rnd.Float64()*math.Pi
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			sx := px + int(amplitude*math.Sin(float64(py)/period*2*math.Pi+phase))
+			sy := py + int(amplitude*math.Cos(float64(px)/period*2*math.Pi+phase))
+			if sx < 0 || sx >= width || sy < 0 || sy >= height {
+				warped.Set(px, py, color.RGBA{R: 245, G: 245, B: 240, A: 255})
+				continue
+			}
+			warped.Set(px, py, canvas.At(sx, sy))
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		captchaLine(warped, rnd.Intn(width), rnd.Intn(height), rnd.Intn(width), rnd.Intn(height),
+			color.RGBA{R: uint8(rnd.Intn(200)), G: uint8(rnd.Intn(200)), B: uint8(rnd.Intn(200)), A: 255})
+	}
+	for i := 0; i < width*height/20; i++ {
+		warped.Set(rnd.Intn(width), rnd.Intn(height), color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, warped); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// captchaLine Bresenham 画线
+func captchaLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := int(AbsInt64(int64(x1-x0))), -int(AbsInt64(int64(y1-y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// captchaSlideImages 生成随机背景, 在 (x, y) 处挖出方形缺口, 返回背景图、滑块图与缺口位置
+func captchaSlideImages(rnd *mrand.Rand) (background, piece []byte, x, y int, err error) {
+	const w, h, size = captchaSlideWidth, captchaSlideHeight, captchaSlidePieceSz
+
+	bg := image.NewRGBA(image.Rect(0, 0, w, h))
+	c1 := color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+	c2 := color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			t := float64(px+py) / float64(w+h)
+			bg.Set(px, py, color.RGBA{
+				R: uint8(float64(c1.R)*(1-t) + float64(c2.R)*t),
+				G: uint8(float64(c1.G)*(1-t) + float64(c2.G)*t),
+				B: uint8(float64(c1.B)*(1-t) + float64(c2.B)*t),
+				A: 255,
+			})
+		}
+	}
+	// 随机圆形色块, 让缺口位置无法通过纯色背景推断
+	for i := 0; i < 12; i++ {
+		cx, cy, r := rnd.Intn(w), rnd.Intn(h), 8+rnd.Intn(30)
+		fill := color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+		for py := cy - r; py <= cy+r; py++ {
+			for px := cx - r; px <= cx+r; px++ {
+				if (px-cx)*(px-cx)+(py-cy)*(py-cy) <= r*r && image.Pt(px, py).In(bg.Bounds()) {
+					bg.Set(px, py, fill)
+				}
+			}
+		}
+	}
+
+	// 缺口不与滑块的初始位置(最左侧)重叠; 缺口位置即答案, 使用 crypto/rand 生成
+	offsetX, err := secureIntn(w - size*3 - 10)
+	if err != nil {
+		return
+	}
+	offsetY, err := secureIntn(h - size - 10)
+	if err != nil {
+		return
+	}
+	x, y = size*2+int(offsetX), 5+int(offsetY)
+	rect := image.Rect(x, y, x+size, y+size)
+
+	pieceImg := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(pieceImg, pieceImg.Bounds(), bg, rect.Min, draw.Src)
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	for i := 0; i < size; i++ {
+		pieceImg.Set(i, 0, white)
+		pieceImg.Set(i, size-1, white)
+		pieceImg.Set(0, i, white)
+		pieceImg.Set(size-1, i, white)
+	}
+
+	// 缺口处压暗
+	draw.Draw(bg, rect, &image.Uniform{C: color.RGBA{A: 140}}, image.Point{}, draw.Over)
+
+	var bgBuf, pieceBuf bytes.Buffer
+	if err = png.Encode(&bgBuf, bg); err != nil {
+		return
+	}
+	if err = png.Encode(&pieceBuf, pieceImg); err != nil {
+		return
+	}
+
+	return bgBuf.Bytes(), pieceBuf.Bytes(), x, y, nil
+}
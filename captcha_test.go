@@ -0,0 +1,83 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// captchaAnswerT 从存储中读取答案
+func captchaAnswerT(t *testing.T, token string) string {
+	t.Helper()
+	v, ok, err := captchaStore.Get(context.Background(), captchaKeyPrefix+token)
+	if err != nil || !ok {
+		t.Fatalf("captcha answer not found: %v", err)
+	}
+	return strings.SplitN(string(v), ":", 2)[1]
+}
+
+func TestVerifyCaptcha(t *testing.T) {
+	for name, store := range map[string]KVStore{"getdel": NewMemoryKV(), "claim": claimOnlyKV{NewMemoryKV()}} {
+		SetCaptchaStore(store)
+
+		c, err := GenerateCaptcha()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = png.Decode(bytes.NewReader(c.Image)); err != nil || c.Type != "digits" {
+			t.Fatalf("%s: invalid captcha image: %v", name, err)
+		}
+		answer := captchaAnswerT(t, c.Token)
+		if VerifyCaptcha(c.Token, answer+"0") || VerifyCaptcha(c.Token, answer) {
+			t.Errorf("%s: captcha should be invalid after a wrong answer", name)
+		}
+
+		// 并发提交同一个验证码只有一个成功
+		c, _ = GenerateCaptcha(CaptchaArith)
+		answer = captchaAnswerT(t, c.Token)
+		var passed int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if VerifyCaptcha(c.Token, answer) {
+					atomic.AddInt32(&passed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		if passed != 1 {
+			t.Errorf("%s: concurrent verify passed %d times", name, passed)
+		}
+	}
+	SetCaptchaStore(NewMemoryKV())
+}
+
+func TestVerifyCaptchaSlide(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		c, err := GenerateCaptcha(CaptchaSlide)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, _ := strconv.Atoi(captchaAnswerT(t, c.Token))
+		if x < captchaSlidePieceSz*2 || x > captchaSlideWidth-captchaSlidePieceSz || len(c.Piece) == 0 {
+			t.Fatalf("unexpected slide x: %d", x)
+		}
+		answer := x + captchaSlideOffset
+		if i == 1 {
+			answer = x + captchaSlideOffset + 1
+		}
+		if got := VerifyCaptcha(c.Token, strconv.Itoa(answer)); got != (i != 1) {
+			t.Errorf("slide answer %d for %d: got %v", answer, x, got)
+		}
+	}
+	if VerifyCaptcha("", "") {
+		t.Error("empty token should fail")
+	}
+}
@@ -0,0 +1,258 @@
+package libtools
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 校验文件约定: 单个文件旁放 file.sha256 这样的 sidecar, 目录放 SHA256SUMS, 内容格式与 sha256sum 等命令一致, 对方可直接用 sha256sum -c 校验
+
+// 支持的校验算法, 同时作为 sidecar 文件的后缀
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA1   = "sha1"
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+var (
+	ErrChecksumNotFound = errors.New("checksum file not found")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// checksumAlgos 按强度从高到低, VerifyChecksumFile 按此顺序查找 sidecar
+var checksumAlgos = []string{ChecksumSHA512, ChecksumSHA256, ChecksumSHA1, ChecksumMD5}
+
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algo: %s", algo)
+}
+
+// FileChecksum 流式计算文件的校验值, 返回小写 hex
+func FileChecksum(path, algo string) (string, error) {
+	h, err := newChecksumHash(algo)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = io.CopyBuffer(h, f, make([]byte, fileChunk)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumFilePath sidecar 文件路径, 如 a.csv 对应 a.csv.sha256
+func ChecksumFilePath(path, algo string) string {
+	return path + "." + algo
+}
+
+// WriteChecksumFile 计算文件的校验值并写入 sidecar 文件, 返回校验值
+func WriteChecksumFile(path, algo string) (string, error) {
+	sum, err := FileChecksum(path, algo)
+	if err != nil {
+		return "", err
+	}
+	line := checksumLine(sum, filepath.Base(path))
+	if err = os.WriteFile(ChecksumFilePath(path, algo), []byte(line), 0644); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// VerifyChecksumFile 用 sidecar 文件校验文件, 有多个 sidecar 时使用最强的算法
+// 没有 sidecar 时返回 ErrChecksumNotFound, 不一致时返回的错误 errors.Is(err, ErrChecksumMismatch) 为 true
+func VerifyChecksumFile(path string) error {
+	for _, algo := range checksumAlgos {
+		content, err := os.ReadFile(ChecksumFilePath(path, algo))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// sidecar 中只取第一行的校验值, 文件名部分不校验, 文件改名后 sidecar 仍然可用
+		want, _, ok := parseChecksumLine(strings.SplitN(string(content), "\n", 2)[0])
+		if !ok {
+			return fmt.Errorf("invalid checksum file: %s", ChecksumFilePath(path, algo))
+		}
+		got, err := FileChecksum(path, algo)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("%w: %s %s, got: %s, want: %s", ErrChecksumMismatch, filepath.Base(path), algo, got, want)
+		}
+		return nil
+	}
+
+	return ErrChecksumNotFound
+}
+
+// ChecksumSumsName 目录校验文件名, 如 SHA256SUMS
+func ChecksumSumsName(algo string) string {
+	return strings.ToUpper(algo) + "SUMS"
+}
+
+// WriteDirChecksums 为目录下所有普通文件(含子目录)生成 SHA256SUMS 这样的校验文件, 路径使用 / 分隔的相对路径
+// 已有的 SUMS 文件与 sidecar 文件不计入; 返回生成的校验文件路径
+func WriteDirChecksums(dir, algo string) (string, error) {
+	if _, err := newChecksumHash(algo); err != nil {
+		return "", err
+	}
+	files, err := checksumDirFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, name := range files {
+		sum, err := FileChecksum(filepath.Join(dir, filepath.FromSlash(name)), algo)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(checksumLine(sum, name))
+	}
+
+	sumsFile := filepath.Join(dir, ChecksumSumsName(algo))
+	if err = os.WriteFile(sumsFile, []byte(sb.String()), 0644); err != nil {
+		return "", err
+	}
+	return sumsFile, nil
+}
+
+// VerifyDirChecksums 用目录下的 SHA256SUMS 这样的校验文件校验目录, 返回不一致或缺失的文件
+// 目录中新增的、未记录在校验文件中的文件不检查
+func VerifyDirChecksums(dir, algo string) (failed []string, err error) {
+	if _, err = newChecksumHash(algo); err != nil {
+		return
+	}
+	f, err := os.Open(filepath.Join(dir, ChecksumSumsName(algo)))
+	if os.IsNotExist(err) {
+		return nil, ErrChecksumNotFound
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		want, name, ok := parseChecksumLine(line)
+		if !ok {
+			return failed, fmt.Errorf("invalid checksum line %d: %s", lineNo, line)
+		}
+		if !isSafeRelPath(name) {
+			return failed, fmt.Errorf("unsafe path in checksum file: %s", name)
+		}
+
+		got, sumErr := FileChecksum(filepath.Join(dir, filepath.FromSlash(name)), algo)
+		if sumErr != nil && !os.IsNotExist(sumErr) {
+			return failed, sumErr
+		}
+		if got != want {
+			failed = append(failed, name)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(failed, ", "))
+	}
+	return
+}
+
+// checksumLine sha256sum 的输出格式, 校验值与文件名之间两个空格
+func checksumLine(sum, name string) string {
+	return sum + "  " + name + "\n"
+}
+
+// parseChecksumLine 解析 "<hex>  <name>" 或二进制模式的 "<hex> *<name>"
+func parseChecksumLine(line string) (sum, name string, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	i := strings.IndexByte(line, ' ')
+	if i <= 0 || i+2 > len(line) {
+		return "", "", false
+	}
+	sum, name = strings.ToLower(line[:i]), line[i+2:]
+	if _, err := hex.DecodeString(sum); err != nil || name == "" {
+		return "", "", false
+	}
+	if line[i+1] != ' ' && line[i+1] != '*' {
+		return "", "", false
+	}
+	return sum, name, true
+}
+
+// checksumDirFiles 目录下参与校验的文件, 排序后的 / 分隔相对路径
+func checksumDirFiles(dir string) ([]string, error) {
+	skip := map[string]bool{}
+	for _, algo := range checksumAlgos {
+		skip[ChecksumSumsName(algo)] = true
+	}
+
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skip[rel] {
+			return nil
+		}
+		for _, algo := range checksumAlgos {
+			if strings.HasSuffix(rel, "."+algo) {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// isSafeRelPath 相对路径且不会跳出目录
+func isSafeRelPath(name string) bool {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	return !filepath.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,114 @@
+package libtools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestChecksumFile(t *testing.T) {
+	path := testutil.WriteTempFileT(t, "settle.csv", []byte("abc"))
+
+	if err := VerifyChecksumFile(path); err != ErrChecksumNotFound {
+		t.Fatalf("expect not found, got: %v", err)
+	}
+
+	sum, err := WriteChecksumFile(path, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("unexpected sha256: %s", sum)
+	}
+	content, _ := os.ReadFile(path + ".sha256")
+	if string(content) != sum+"  settle.csv\n" {
+		t.Fatalf("unexpected sidecar: %q", content)
+	}
+	if err = VerifyChecksumFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 有多个 sidecar 时用最强的算法, md5 sidecar 错误不影响结果
+	_ = os.WriteFile(path+".md5", []byte("00000000000000000000000000000000  settle.csv\n"), 0644)
+	if err = VerifyChecksumFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = os.WriteFile(path, []byte("abd"), 0644)
+	if err = VerifyChecksumFile(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expect mismatch, got: %v", err)
+	}
+
+	if _, err = WriteChecksumFile(path, "crc32"); err == nil {
+		t.Fatal("unsupported algo should fail")
+	}
+}
+
+func TestDirChecksums(t *testing.T) {
+	dir := filepath.Join(testutil.TempDirT(t), "batch")
+	files := map[string]string{"a.csv": "a", "b.csv": "b", "sub/c.csv": "c"}
+	for name, content := range files {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		_ = os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	_, _ = WriteChecksumFile(filepath.Join(dir, "a.csv"), ChecksumMD5)
+
+	sumsFile, err := WriteDirChecksums(dir, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(sumsFile) != "SHA256SUMS" {
+		t.Fatalf("unexpected sums file: %s", sumsFile)
+	}
+	content, _ := os.ReadFile(sumsFile)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "  a.csv") || !strings.HasSuffix(lines[2], "  sub/c.csv") {
+		t.Fatalf("unexpected sums: %s", content)
+	}
+
+	if failed, err := VerifyDirChecksums(dir, ChecksumSHA256); err != nil || len(failed) != 0 {
+		t.Fatalf("verify fail: %v %v", failed, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "b.csv"), []byte("x"), 0644)
+	_ = os.Remove(filepath.Join(dir, "sub", "c.csv"))
+	_ = os.WriteFile(filepath.Join(dir, "new.csv"), []byte("new"), 0644)
+	failed, err := VerifyDirChecksums(dir, ChecksumSHA256)
+	if !errors.Is(err, ErrChecksumMismatch) || !reflect.DeepEqual(failed, []string{"b.csv", "sub/c.csv"}) {
+		t.Fatalf("unexpected result: %v %v", failed, err)
+	}
+
+	if _, err = VerifyDirChecksums(dir, ChecksumSHA512); err != ErrChecksumNotFound {
+		t.Fatalf("expect not found, got: %v", err)
+	}
+
+	_ = os.WriteFile(sumsFile, []byte(strings.Repeat("0", 64)+"  ../outside.csv\n"), 0644)
+	if _, err = VerifyDirChecksums(dir, ChecksumSHA256); err == nil {
+		t.Fatal("path outside dir should fail")
+	}
+}
+
+func TestParseChecksumLine(t *testing.T) {
+	cases := []struct {
+		line, sum, name string
+		ok              bool
+	}{
+		{"ABCD  a.csv", "abcd", "a.csv", true},
+		{"abcd *a b.bin\r", "abcd", "a b.bin", true},
+		{"abcd a.csv", "", "", false},
+		{"xyz  a.csv", "", "", false},
+		{"abcd  ", "", "", false},
+		{"", "", "", false},
+	}
+	for _, c := range cases {
+		sum, name, ok := parseChecksumLine(c.line)
+		if sum != c.sum || name != c.name || ok != c.ok {
+			t.Fatalf("%q: got %q %q %v", c.line, sum, name, ok)
+		}
+	}
+}
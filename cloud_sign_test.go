@@ -0,0 +1,59 @@
+package libtools
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 用例来自阿里云文档 "V3 版本请求体&签名机制" 的 RunInstances 示例
+func TestSignAliyunACS3(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://ecs.cn-shanghai.aliyuncs.com/?ImageId=win2019_1809_x64_dtc_zh-cn_40G_alibase_20230811.vhd&RegionId=cn-shanghai", nil)
+	req.Header.Set("x-acs-action", "RunInstances")
+	req.Header.Set("x-acs-version", "2014-05-26")
+
+	now := time.Date(2023, 10, 26, 10, 22, 32, 0, time.UTC)
+	if err := signAliyunACS3(req, "YourAccessKeyId", "YourAccessKeySecret", nil, now, "3156853299f313e23d1673dc12e1703d"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ACS3-HMAC-SHA256 Credential=YourAccessKeyId," +
+		"SignedHeaders=host;x-acs-action;x-acs-content-sha256;x-acs-date;x-acs-signature-nonce;x-acs-version," +
+		"Signature=06563a9e1b43f5dfe96b81484da74bceab24a1d853912eee15083a6f0f3283c0"
+	if auth := req.Header.Get("Authorization"); auth != want {
+		t.Errorf("unexpected authorization:\n%s\nwant:\n%s", auth, want)
+	}
+}
+
+// 用例来自腾讯云文档 "签名方法 v3" 的 DescribeInstances 示例, 请求体哈希 35e9c5b0... 与规范请求哈希 5ffe6a04... 与文档一致
+// 文档中的 SecretKey 已打码, 签名值为按文档步骤用示例密钥计算的结果
+func TestSignTencentTC3(t *testing.T) {
+	payload := `{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`
+	req, _ := http.NewRequest(http.MethodPost, "https://cvm.tencentcloudapi.com/", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	now := time.Unix(1551113065, 0)
+	if err := signTencentTC3(req, "AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE", "Gu5t9xGARNpq86cd98joQYCN3EXAMPLE", "", []byte(payload), now); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE/2019-02-25/cvm/tc3_request, " +
+		"SignedHeaders=content-type;host, Signature=72e494ea809ad7a8c8f7a4507b9bddcbaa8e581f516e8da2f66e2c5a96525168"
+	if auth := req.Header.Get("Authorization"); auth != want {
+		t.Errorf("unexpected authorization:\n%s\nwant:\n%s", auth, want)
+	}
+	if req.Header.Get("X-TC-Timestamp") != "1551113065" {
+		t.Errorf("unexpected timestamp: %s", req.Header.Get("X-TC-Timestamp"))
+	}
+
+	// 规范头的值转为小写, 大小写不同的 Content-Type 签名相同
+	upper, _ := http.NewRequest(http.MethodPost, "https://cvm.tencentcloudapi.com/", strings.NewReader(payload))
+	upper.Header.Set("Content-Type", "Application/JSON; charset=UTF-8")
+	if err := signTencentTC3(upper, "AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE", "Gu5t9xGARNpq86cd98joQYCN3EXAMPLE", "", []byte(payload), now); err != nil {
+		t.Fatal(err)
+	}
+	if auth := upper.Header.Get("Authorization"); auth != want {
+		t.Errorf("header values should be lowercased, get %s", auth)
+	}
+}
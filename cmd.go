@@ -0,0 +1,166 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CmdOptions RunCmd 的参数, 零值使用默认值
+type CmdOptions struct {
+	// Timeout 默认 10 分钟, 超时后结束整个进程组(含子进程)
+	Timeout time.Duration
+	// Env 追加到当前进程环境变量之后, 格式 KEY=VALUE
+	Env []string
+	// Dir 工作目录
+	Dir   string
+	Stdin io.Reader
+	// MaxOutput stdout 与 stderr 各自最多保留的字节数, 默认 10MB, 超出部分丢弃
+	MaxOutput int
+	// AllowedCommands 允许执行的命令名, 为空时使用 SetCmdAllowlist 设置的全局名单
+	AllowedCommands []string
+}
+
+// CmdResult 命令的执行结果, 命令启动后即使失败也会返回
+type CmdResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int // 未能启动或被超时结束时为 -1
+	Duration time.Duration
+}
+
+var (
+	cmdAllowlistMu sync.RWMutex
+	cmdAllowlist   []string
+)
+
+// SetCmdAllowlist 设置全局允许执行的命令名(如 ffmpeg、git), 为空表示不限制; 参数来自用户输入时务必设置
+func SetCmdAllowlist(names ...string) {
+	cmdAllowlistMu.Lock()
+	cmdAllowlist = append([]string(nil), names...)
+	cmdAllowlistMu.Unlock()
+}
+
+func cmdAllowed(name string, allowed []string) bool {
+	if len(allowed) == 0 {
+		cmdAllowlistMu.RLock()
+		allowed = cmdAllowlist
+		cmdAllowlistMu.RUnlock()
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+
+	// 名单可以写命令名或完整路径, 写命令名时不限制路径
+	for _, a := range allowed {
+		if a == name || (!strings.Contains(a, string(os.PathSeparator)) && a == filepath.Base(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cmdLimitedBuffer 超过 limit 后丢弃写入的数据, 但不返回错误, 避免子进程因管道写失败而退出
+type cmdLimitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *cmdLimitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+// RunCmd 执行外部命令并收集输出, 不经过 shell, 参数无需转义
+// 退出码非 0、超时等情况返回 error, 同时返回已收集到的输出
+func RunCmd(ctx context.Context, name string, args []string, opts ...CmdOptions) (*CmdResult, error) {
+	var opt CmdOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Minute
+	}
+	if opt.MaxOutput <= 0 {
+		opt.MaxOutput = 10 * 1024 * 1024
+	}
+
+	result := &CmdResult{ExitCode: -1}
+	if !cmdAllowed(name, opt.AllowedCommands) {
+		return result, fmt.Errorf("command %s is not allowed", name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opt.Timeout)
+	defer cancel()
+
+	stdout := &cmdLimitedBuffer{limit: opt.MaxOutput}
+	stderr := &cmdLimitedBuffer{limit: opt.MaxOutput}
+	cmd := exec.Command(name, args...)
+	cmd.Dir = opt.Dir
+	cmd.Stdin = opt.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if len(opt.Env) > 0 {
+		cmd.Env = append(os.Environ(), opt.Env...)
+	}
+	cmdSetProcessGroup(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return result, fmt.Errorf("could not start command %s: %v", name, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		cmdKillProcessGroup(cmd)
+		<-done
+		err = ctx.Err()
+	}
+
+	result.Duration = time.Since(start)
+	result.Stdout = stdout.buf.Bytes()
+	result.Stderr = stderr.buf.Bytes()
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return result, fmt.Errorf("command %s aborted after %v: %v", name, result.Duration.Round(time.Millisecond), err)
+	}
+
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	if err != nil {
+		return result, fmt.Errorf("command %s exit with code %d: %s", name, result.ExitCode, cmdTail(result.Stderr, 512))
+	}
+
+	return result, nil
+}
+
+// cmdTail 错误信息中只保留 stderr 的末尾部分
+func cmdTail(b []byte, n int) string {
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+
+	return strings.TrimSpace(string(b))
+}
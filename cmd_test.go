@@ -0,0 +1,31 @@
+package libtools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCmd(t *testing.T) {
+	result, err := RunCmd(context.Background(), "sh", []string{"-c", "echo $GREETING; echo oops >&2"}, CmdOptions{Env: []string{"GREETING=hello"}})
+	if err != nil || strings.TrimSpace(string(result.Stdout)) != "hello" || strings.TrimSpace(string(result.Stderr)) != "oops" || result.ExitCode != 0 {
+		t.Fatalf("RunCmd get unexpected result: %+v, err: %v", result, err)
+	}
+
+	result, err = RunCmd(context.Background(), "sh", []string{"-c", "echo failed >&2; exit 3"})
+	if err == nil || result.ExitCode != 3 || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("RunCmd exit code get unexpected result: %+v, err: %v", result, err)
+	}
+
+	start := time.Now()
+	// 子进程 sleep 持有输出管道, 超时后需要连同子进程一起结束
+	result, err = RunCmd(context.Background(), "sh", []string{"-c", "sleep 5; echo done"}, CmdOptions{Timeout: 100 * time.Millisecond})
+	if err == nil || result.ExitCode != -1 || time.Since(start) > 2*time.Second {
+		t.Errorf("RunCmd timeout get unexpected result: %+v, err: %v", result, err)
+	}
+
+	if _, err = RunCmd(context.Background(), "rm", []string{"-rf", "/tmp/none"}, CmdOptions{AllowedCommands: []string{"ffmpeg", "git"}}); err == nil {
+		t.Errorf("RunCmd should reject command not in allowlist")
+	}
+}
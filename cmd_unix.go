@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package libtools
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// cmdSetProcessGroup 子进程使用独立的进程组, 超时时可以连同其派生的进程一起结束
+func cmdSetProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func cmdKillProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package libtools
+
+import (
+	"os/exec"
+)
+
+func cmdSetProcessGroup(cmd *exec.Cmd) {}
+
+func cmdKillProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	_ = cmd.Process.Kill()
+}
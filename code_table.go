@@ -0,0 +1,102 @@
+package libtools
+
+import (
+	"encoding/json"
+)
+
+// CodeItem 码表中的一项, Labels 为多语言文案, key 为语言, 如 "zh", "en"
+type CodeItem[T comparable] struct {
+	Code   T
+	Labels map[string]string
+}
+
+// CodeLabel 码值与文案对, 用于输出给前端下拉框
+type CodeLabel[T comparable] struct {
+	Code  T      `json:"code"`
+	Label string `json:"label"`
+}
+
+// CodeTable 码值与文案的双向映射, 替代后台中大量的 switch 语句
+// 创建后只读, 可并发使用
+//
+//	var LoanStatus = NewCodeTable("zh",
+//		CodeItem[int]{Code: 1, Labels: map[string]string{"zh": "审核中", "en": "Reviewing"}},
+//		CodeItem[int]{Code: 2, Labels: map[string]string{"zh": "已放款", "en": "Disbursed"}},
+//	)
+type CodeTable[T comparable] struct {
+	defaultLang string
+	items       []CodeItem[T]
+	byCode      map[T]int
+	byLabel     map[string]T
+}
+
+// NewCodeTable defaultLang 为未指定语言或语言缺失时使用的语言, 码值重复时以后出现的为准
+func NewCodeTable[T comparable](defaultLang string, items ...CodeItem[T]) *CodeTable[T] {
+	t := &CodeTable[T]{
+		defaultLang: defaultLang,
+		byCode:      make(map[T]int, len(items)),
+		byLabel:     make(map[string]T),
+	}
+
+	for _, item := range items {
+		if idx, ok := t.byCode[item.Code]; ok {
+			t.items[idx] = item
+		} else {
+			t.byCode[item.Code] = len(t.items)
+			t.items = append(t.items, item)
+		}
+
+		for _, label := range item.Labels {
+			t.byLabel[label] = item.Code
+		}
+	}
+
+	return t
+}
+
+// Label 返回码值对应的文案, 不存在时返回空字符串
+func (t *CodeTable[T]) Label(code T, lang ...string) string {
+	idx, ok := t.byCode[code]
+	if !ok {
+		return ""
+	}
+
+	return t.label(t.items[idx], lang...)
+}
+
+// Code 根据任意语言的文案反查码值
+func (t *CodeTable[T]) Code(label string) (code T, ok bool) {
+	code, ok = t.byLabel[label]
+	return
+}
+
+// Has 码值是否合法, 可用于参数校验
+func (t *CodeTable[T]) Has(code T) bool {
+	_, ok := t.byCode[code]
+	return ok
+}
+
+// All 按定义顺序返回全部码值与文案
+func (t *CodeTable[T]) All(lang ...string) []CodeLabel[T] {
+	list := make([]CodeLabel[T], 0, len(t.items))
+	for _, item := range t.items {
+		list = append(list, CodeLabel[T]{Code: item.Code, Label: t.label(item, lang...)})
+	}
+
+	return list
+}
+
+// MarshalJSON 输出默认语言的 [{"code": 1, "label": "审核中"}, ...]
+func (t *CodeTable[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.All())
+}
+
+func (t *CodeTable[T]) label(item CodeItem[T], lang ...string) string {
+	if len(lang) > 0 && lang[0] != "" {
+		if label, ok := item.Labels[lang[0]]; ok {
+			return label
+		}
+	}
+
+	return item.Labels[t.defaultLang]
+}
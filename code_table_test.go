@@ -0,0 +1,32 @@
+package libtools
+
+import "testing"
+
+func TestCodeTable(t *testing.T) {
+	table := NewCodeTable("zh",
+		CodeItem[int]{Code: 1, Labels: map[string]string{"zh": "审核中", "en": "Reviewing"}},
+		CodeItem[int]{Code: 2, Labels: map[string]string{"zh": "已放款"}},
+	)
+
+	if got := table.Label(1, "en"); got != "Reviewing" {
+		t.Errorf("Label(1, en) = %s", got)
+	}
+	if got := table.Label(2, "en"); got != "已放款" {
+		t.Errorf("Label(2, en) should fallback to default lang, got %s", got)
+	}
+	if code, ok := table.Code("Reviewing"); !ok || code != 1 {
+		t.Errorf("Code(Reviewing) = %d, %v", code, ok)
+	}
+	if _, ok := table.Code("unknown"); ok {
+		t.Errorf("Code(unknown) should not exist")
+	}
+
+	buf, err := table.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `[{"code":1,"label":"审核中"},{"code":2,"label":"已放款"}]`
+	if string(buf) != expect {
+		t.Errorf("MarshalJSON = %s, expect %s", buf, expect)
+	}
+}
@@ -0,0 +1,195 @@
+package libtools
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrCookieInvalid = errors.New("cookie is invalid or expired")
+
+// CookieOptions cookie 的属性, 使用 DefaultCookieOptions 作为基础修改
+type CookieOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   time.Duration // 0 为会话 cookie, 关闭浏览器后失效
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieOptions Path=/, HttpOnly, Secure, SameSite=Lax
+func DefaultCookieOptions() CookieOptions {
+	return CookieOptions{
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+var (
+	cookieKeyMu   sync.RWMutex
+	cookieSignKey []byte
+	cookieEncKey  []byte
+)
+
+func init() {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	SetCookieSecret(secret)
+}
+
+// SetCookieSecret 设置签名与加密使用的密钥, 默认每次启动随机生成, 多实例部署时需设置为相同的值
+func SetCookieSecret(secret []byte) {
+	sign := sha256.Sum256(append([]byte("cookie-sign:"), secret...))
+	enc := sha256.Sum256(append([]byte("cookie-encrypt:"), secret...))
+
+	cookieKeyMu.Lock()
+	cookieSignKey, cookieEncKey = sign[:], enc[:]
+	cookieKeyMu.Unlock()
+}
+
+// SetSignedCookie 写入带签名的 cookie, 值对用户可见但无法篡改
+func SetSignedCookie(w http.ResponseWriter, name, value string, opts ...CookieOptions) {
+	opt := cookieOptions(opts)
+	payload := cookiePayload(value, opt.MaxAge)
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, cookieBuild(name, encoded+"."+cookieSign(name, encoded), opt))
+}
+
+// GetSignedCookie 读取 SetSignedCookie 写入的 cookie, 签名错误或已过期时返回 ErrCookieInvalid
+func GetSignedCookie(r *http.Request, name string) (string, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.SplitN(c.Value, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(cookieSign(name, parts[0]))) {
+		return "", ErrCookieInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+
+	return cookieParsePayload(payload)
+}
+
+// SetEncryptedCookie 写入 AES-GCM 加密的 cookie, 值对用户不可见且无法篡改, 适合保存用户 ID 等敏感信息
+func SetEncryptedCookie(w http.ResponseWriter, name, value string, opts ...CookieOptions) error {
+	opt := cookieOptions(opts)
+
+	cookieKeyMu.RLock()
+	key := cookieEncKey
+	cookieKeyMu.RUnlock()
+
+	// cookie 名称作为附加认证数据, 防止不同 cookie 之间互换
+	ciphertext, err := AesGCMEncrypt(cookiePayload(value, opt.MaxAge), key, []byte(name))
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, cookieBuild(name, base64.RawURLEncoding.EncodeToString(ciphertext), opt))
+	return nil
+}
+
+// GetEncryptedCookie 读取 SetEncryptedCookie 写入的 cookie
+func GetEncryptedCookie(r *http.Request, name string) (string, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+
+	cookieKeyMu.RLock()
+	key := cookieEncKey
+	cookieKeyMu.RUnlock()
+
+	payload, err := AesGCMDecrypt(ciphertext, key, []byte(name))
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+
+	return cookieParsePayload(payload)
+}
+
+// DeleteCookie 删除 cookie, Path 与 Domain 需与写入时一致
+func DeleteCookie(w http.ResponseWriter, name string, opts ...CookieOptions) {
+	c := cookieBuild(name, "", cookieOptions(opts))
+	c.MaxAge = -1
+	c.Expires = time.Unix(0, 0)
+	http.SetCookie(w, c)
+}
+
+func cookieOptions(opts []CookieOptions) CookieOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return DefaultCookieOptions()
+}
+
+func cookieBuild(name, value string, opt CookieOptions) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opt.Path,
+		Domain:   opt.Domain,
+		Secure:   opt.Secure || opt.SameSite == http.SameSiteNoneMode,
+		HttpOnly: opt.HttpOnly,
+		SameSite: opt.SameSite,
+	}
+	if opt.MaxAge > 0 {
+		c.MaxAge = int(opt.MaxAge.Seconds())
+		c.Expires = time.Now().Add(opt.MaxAge)
+	}
+
+	return c
+}
+
+// cookiePayload 8 字节过期时间(秒, 0 表示不过期) + 值, 服务端同样校验有效期, 不依赖浏览器
+func cookiePayload(value string, maxAge time.Duration) []byte {
+	payload := make([]byte, 8+len(value))
+	if maxAge > 0 {
+		binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(maxAge).Unix()))
+	}
+	copy(payload[8:], value)
+
+	return payload
+}
+
+func cookieParsePayload(payload []byte) (string, error) {
+	if len(payload) < 8 {
+		return "", ErrCookieInvalid
+	}
+
+	expires := int64(binary.BigEndian.Uint64(payload))
+	if expires > 0 && time.Now().Unix() > expires {
+		return "", ErrCookieInvalid
+	}
+
+	return string(payload[8:]), nil
+}
+
+func cookieSign(name, encoded string) string {
+	cookieKeyMu.RLock()
+	mac := hmac.New(sha256.New, cookieSignKey)
+	cookieKeyMu.RUnlock()
+
+	mac.Write([]byte(name + "=" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
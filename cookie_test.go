@@ -0,0 +1,116 @@
+package libtools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// cookieRoundTripT 把响应写入的 cookie 带到新的请求上
+func cookieRoundTripT(rec *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestSignedAndEncryptedCookie(t *testing.T) {
+	cookieKeyMu.RLock()
+	signKey, encKey := cookieSignKey, cookieEncKey
+	cookieKeyMu.RUnlock()
+	t.Cleanup(func() {
+		cookieKeyMu.Lock()
+		cookieSignKey, cookieEncKey = signKey, encKey
+		cookieKeyMu.Unlock()
+	})
+	SetCookieSecret([]byte("test-secret"))
+
+	opt := DefaultCookieOptions()
+	opt.MaxAge = time.Hour
+	rec := httptest.NewRecorder()
+	SetSignedCookie(rec, "uid", "10086", opt)
+	if err := SetEncryptedCookie(rec, "token", "secret-user-id", opt); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 2 || !cookies[0].Secure || !cookies[0].HttpOnly || cookies[0].MaxAge != 3600 {
+		t.Fatalf("unexpected cookies: %+v", cookies)
+	}
+	if bytes.Contains([]byte(cookies[1].Value), []byte("secret-user-id")) {
+		t.Error("encrypted cookie should not contain plaintext")
+	}
+
+	req := cookieRoundTripT(rec)
+	if v, err := GetSignedCookie(req, "uid"); err != nil || v != "10086" {
+		t.Errorf("GetSignedCookie: %s, %v", v, err)
+	}
+	if v, err := GetEncryptedCookie(req, "token"); err != nil || v != "secret-user-id" {
+		t.Errorf("GetEncryptedCookie: %s, %v", v, err)
+	}
+
+	// 篡改、换名、过期都视为无效
+	tampered := httptest.NewRequest(http.MethodGet, "/", nil)
+	tampered.AddCookie(&http.Cookie{Name: "uid", Value: "X" + cookies[0].Value[1:]})
+	tampered.AddCookie(&http.Cookie{Name: "session", Value: cookies[1].Value})
+	if _, err := GetSignedCookie(tampered, "uid"); err != ErrCookieInvalid {
+		t.Errorf("tampered signed cookie should be invalid, got: %v", err)
+	}
+	if _, err := GetEncryptedCookie(tampered, "session"); err != ErrCookieInvalid {
+		t.Errorf("encrypted cookie under another name should be invalid, got: %v", err)
+	}
+
+	payload := make([]byte, 8+len("10086"))
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(-time.Minute).Unix()))
+	copy(payload[8:], "10086")
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	expired := httptest.NewRequest(http.MethodGet, "/", nil)
+	expired.AddCookie(&http.Cookie{Name: "uid", Value: encoded + "." + cookieSign("uid", encoded)})
+	if _, err := GetSignedCookie(expired, "uid"); err != ErrCookieInvalid {
+		t.Errorf("expired cookie should be invalid, got: %v", err)
+	}
+
+	// 更换密钥后旧 cookie 失效
+	SetCookieSecret([]byte("rotated-secret"))
+	if _, err := GetEncryptedCookie(req, "token"); err != ErrCookieInvalid {
+		t.Errorf("cookie of old secret should be invalid, got: %v", err)
+	}
+	if _, err := GetSignedCookie(httptest.NewRequest(http.MethodGet, "/", nil), "uid"); err != http.ErrNoCookie {
+		t.Errorf("missing cookie should return http.ErrNoCookie, got: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	DeleteCookie(rec, "uid")
+	if c := rec.Result().Cookies(); len(c) != 1 || c[0].MaxAge != -1 {
+		t.Errorf("unexpected delete cookie: %+v", c)
+	}
+}
+
+func TestAesGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	ciphertext, err := AesGCMEncrypt([]byte("hello"), key, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := AesGCMDecrypt(ciphertext, key, []byte("ad")); err != nil || string(plaintext) != "hello" {
+		t.Fatalf("AesGCMDecrypt: %s, %v", plaintext, err)
+	}
+
+	if _, err = AesGCMDecrypt(ciphertext, key, []byte("other")); err == nil {
+		t.Error("mismatched additional data should fail")
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err = AesGCMDecrypt(ciphertext, key, []byte("ad")); err == nil {
+		t.Error("tampered ciphertext should fail")
+	}
+	if _, err = AesGCMDecrypt(ciphertext[:8], key); err == nil {
+		t.Error("short ciphertext should fail")
+	}
+	if _, err = AesGCMEncrypt([]byte("hello"), []byte("short")); err == nil {
+		t.Error("invalid key length should fail")
+	}
+}
@@ -0,0 +1,162 @@
+package libtools
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions 跨域配置, 零值使用默认值
+type CORSOptions struct {
+	// AllowedOrigins 允许的来源, 支持 "*"、"https://h5.example.com"、"*.example.com"(任意协议、任意端口的子域名)
+	// 为空时允许 InternalH5Domain()
+	AllowedOrigins []string
+	// AllowedMethods 默认 GET POST PUT PATCH DELETE
+	AllowedMethods []string
+	// AllowedHeaders 预检允许的请求头, "*" 表示允许请求中声明的全部头
+	AllowedHeaders []string
+	// ExposedHeaders 允许前端读取的响应头
+	ExposedHeaders []string
+	// AllowCredentials 允许携带 cookie, 此时 AllowedOrigins 不能包含 "*", 否则 CORS 直接 panic
+	AllowCredentials bool
+	// MaxAge 预检结果的缓存时长, 默认 10 分钟
+	MaxAge time.Duration
+}
+
+var corsDefaultHeaders = []string{"Content-Type", "Authorization", "X-Requested-With", RequestIDHeader, "X-CSRF-Token"}
+
+// CORS 跨域中间件, 处理预检请求并为允许的来源添加响应头
+//
+//	handler = CORS(CORSOptions{AllowedOrigins: []string{"*.example.com"}, AllowCredentials: true})(handler)
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		if domain := InternalH5Domain(); domain != "" {
+			opts.AllowedOrigins = []string{domain}
+		}
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = corsDefaultHeaders
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 10 * time.Minute
+	}
+
+	methods := make(map[string]bool)
+	for _, m := range opts.AllowedMethods {
+		methods[strings.ToUpper(m)] = true
+	}
+	allowAnyHeader := false
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			allowAnyHeader = true
+		}
+	}
+	allowAnyOrigin := false
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			allowAnyOrigin = true
+		}
+	}
+	if allowAnyOrigin && opts.AllowCredentials {
+		// 回显任意 Origin 并允许携带 cookie 等于任何网站都能以登录态读取接口
+		panic(`CORS: AllowedOrigins "*" can not be used with AllowCredentials`)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowAnyOrigin && !corsOriginAllowed(origin, opts.AllowedOrigins) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if allowAnyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if len(opts.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if !methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", strings.Join(opts.AllowedMethods, ", "))
+			if allowAnyHeader {
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					h.Set("Access-Control-Allow-Headers", requested)
+				}
+			} else {
+				h.Set("Access-Control-Allow-Headers", strings.Join(opts.AllowedHeaders, ", "))
+			}
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// corsOriginAllowed 配置项不带协议时匹配任意协议, 以 *. 开头时匹配子域名(不含自身), 不带端口时匹配任意端口
+func corsOriginAllowed(origin string, patterns []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+		patternHost := pattern
+		if i := strings.Index(pattern, "://"); i >= 0 {
+			if pattern[:i] != scheme {
+				continue
+			}
+			patternHost = pattern[i+3:]
+		}
+
+		if strings.HasPrefix(patternHost, "*.") {
+			originHost := host
+			if !strings.Contains(patternHost, ":") {
+				originHost = strings.ToLower(u.Hostname())
+			}
+			if strings.HasSuffix(originHost, patternHost[1:]) {
+				return true
+			}
+			continue
+		}
+		if patternHost == host {
+			return true
+		}
+	}
+
+	return false
+}
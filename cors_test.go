@@ -0,0 +1,70 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorsOriginAllowed(t *testing.T) {
+	patterns := []string{"*.example.com", "https://h5.partner.cn", "http://localhost:8080"}
+
+	cases := map[string]bool{
+		"https://m.example.com":               true,
+		"http://a.b.example.com":              true,
+		"https://m.example.com:8443":          true,
+		"https://m.example.com.evil.com:8443": false,
+		"https://example.com":                 false,
+		"https://evilexample.com":             false,
+		"https://h5.partner.cn":               true,
+		"http://h5.partner.cn":                false,
+		"http://localhost:8080":               true,
+		"http://localhost:3000":               false,
+		"null":                                false,
+		"https://example.com.evil.":           false,
+	}
+	for origin, want := range cases {
+		if got := corsOriginAllowed(origin, patterns); got != want {
+			t.Errorf("corsOriginAllowed(%s) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error(`CORS should panic on "*" with AllowCredentials`)
+			}
+		}()
+		CORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	}()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := CORS(CORSOptions{AllowedOrigins: []string{"*.example.com"}, AllowCredentials: true})(ok)
+	cases := map[string]string{
+		"https://m.example.com:8443": "https://m.example.com:8443",
+		"null":                       "",
+		"https://evil.com":           "",
+	}
+	for origin, want := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("%s: allow origin = %q, want %q", origin, got, want)
+		}
+		if want == "" && w.Header().Get("Access-Control-Allow-Credentials") != "" {
+			t.Errorf("%s: should not allow credentials", origin)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "null")
+	w := httptest.NewRecorder()
+	CORS(CORSOptions{AllowedOrigins: []string{"*"}})(ok).ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("any origin should get *, header: %v", w.Header())
+	}
+}
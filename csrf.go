@@ -0,0 +1,174 @@
+package libtools
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csrfMaxAge token 的有效期
+const csrfMaxAge = 12 * time.Hour
+
+var (
+	csrfSecretMu sync.RWMutex
+	csrfSecret   = func() []byte {
+		buf := make([]byte, 32)
+		_, _ = rand.Read(buf)
+		return buf
+	}()
+)
+
+// SetCSRFSecret 设置签名密钥, 默认每次启动随机生成, 多实例部署时需设置为相同的值
+func SetCSRFSecret(secret []byte) {
+	csrfSecretMu.Lock()
+	csrfSecret = append([]byte(nil), secret...)
+	csrfSecretMu.Unlock()
+}
+
+// GenerateCSRFToken 生成与会话绑定的 token, session 为会话标识(如 session token 或用户 ID), 不需要服务端存储
+func GenerateCSRFToken(session string) string {
+	payload := make([]byte, 24)
+	_, _ = rand.Read(payload[:16])
+	binary.BigEndian.PutUint64(payload[16:], uint64(time.Now().Unix()))
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + csrfSign(session, encoded)
+}
+
+// ValidateCSRFToken 校验 token 是否由当前会话生成且未过期
+func ValidateCSRFToken(session, token string) bool {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(csrfSign(session, parts[0]))) {
+		return false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) != 24 {
+		return false
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+
+	return time.Since(issued) < csrfMaxAge
+}
+
+func csrfSign(session, payload string) string {
+	csrfSecretMu.RLock()
+	mac := hmac.New(sha256.New, csrfSecret)
+	csrfSecretMu.RUnlock()
+
+	mac.Write([]byte(session + "|" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SameSiteCookie 生成常用安全属性的 cookie: HttpOnly、Path=/, SameSite=None 时浏览器要求必须 Secure
+func SameSiteCookie(name, value string, maxAge time.Duration, mode http.SameSite) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   mode == http.SameSiteNoneMode,
+		SameSite: mode,
+	}
+}
+
+// CSRFOptions CSRF 中间件的配置, 零值使用默认值
+type CSRFOptions struct {
+	// SessionOf 取出当前请求的会话标识, 默认读取名为 session 的 cookie
+	SessionOf func(r *http.Request) string
+	// CookieName 下发 token 的 cookie, 默认 csrf_token; 前端 js 需要读取, 因此不设置 HttpOnly
+	CookieName string
+	// HeaderName 提交 token 的 header, 默认 X-CSRF-Token
+	HeaderName string
+	// FormField 表单提交时 token 的字段名, 默认 _csrf
+	FormField string
+	// Domain cookie 的域名, 默认为 InternalH5Domain()
+	Domain string
+	// Secure 仅通过 https 下发 cookie
+	Secure bool
+	// SameSite 默认 Lax
+	SameSite http.SameSite
+}
+
+type csrfCtxKey struct{}
+
+// CSRFTokenFrom 取出中间件为当前请求生成的 token, 用于渲染到表单的隐藏字段中
+func CSRFTokenFrom(r *http.Request) string {
+	token, _ := r.Context().Value(csrfCtxKey{}).(string)
+	return token
+}
+
+// CSRFMiddleware GET 等安全请求时下发 token, POST 等修改请求时校验 header 或表单中的 token
+func CSRFMiddleware(opts CSRFOptions) func(http.Handler) http.Handler {
+	if opts.SessionOf == nil {
+		opts.SessionOf = func(r *http.Request) string {
+			if c, err := r.Cookie("session"); err == nil {
+				return c.Value
+			}
+			return ""
+		}
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "csrf_token"
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = "X-CSRF-Token"
+	}
+	if opts.FormField == "" {
+		opts.FormField = "_csrf"
+	}
+	if opts.Domain == "" {
+		opts.Domain = InternalH5Domain()
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := opts.SessionOf(r)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				token := ""
+				if c, err := r.Cookie(opts.CookieName); err == nil && ValidateCSRFToken(session, c.Value) {
+					token = c.Value
+				} else {
+					token = GenerateCSRFToken(session)
+					cookie := SameSiteCookie(opts.CookieName, token, csrfMaxAge, opts.SameSite)
+					cookie.HttpOnly = false
+					cookie.Domain = opts.Domain
+					cookie.Secure = cookie.Secure || opts.Secure
+					http.SetCookie(w, cookie)
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfCtxKey{}, token)))
+
+			default:
+				token := r.Header.Get(opts.HeaderName)
+				if token == "" {
+					token = r.FormValue(opts.FormField)
+				}
+				if !ValidateCSRFToken(session, token) {
+					w.Header().Set("Content-Type", "application/json; charset=utf-8")
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"code":403,"message":"invalid csrf token"}`))
+					return
+				}
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
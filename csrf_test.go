@@ -0,0 +1,91 @@
+package libtools
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSRFToken(t *testing.T) {
+	token := GenerateCSRFToken("session-a")
+	if !ValidateCSRFToken("session-a", token) {
+		t.Fatal("token should be valid for its session")
+	}
+	if ValidateCSRFToken("session-b", token) {
+		t.Error("token should be bound to session")
+	}
+	if ValidateCSRFToken("session-a", token+"0") || ValidateCSRFToken("session-a", "") {
+		t.Error("tampered token should be invalid")
+	}
+
+	// 签名正确但已过期
+	payload := make([]byte, 24)
+	binary.BigEndian.PutUint64(payload[16:], uint64(time.Now().Add(-csrfMaxAge-time.Minute).Unix()))
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	if ValidateCSRFToken("session-a", encoded+"."+csrfSign("session-a", encoded)) {
+		t.Error("expired token should be invalid")
+	}
+
+	if cookie := SameSiteCookie("sid", "v", time.Hour, http.SameSiteNoneMode); !cookie.Secure || !cookie.HttpOnly || cookie.MaxAge != 3600 {
+		t.Errorf("SameSite=None cookie should be secure: %+v", cookie)
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	var rendered string
+	handler := CSRFMiddleware(CSRFOptions{Domain: "h5.example.com"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rendered = CSRFTokenFrom(r)
+	}))
+	session := &http.Cookie{Name: "session", Value: "session-a"}
+
+	// GET 下发 token, 前端 js 需要读取因此不是 HttpOnly
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(session)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf_token" || cookies[0].HttpOnly || cookies[0].Domain != "h5.example.com" || cookies[0].Value != rendered {
+		t.Fatalf("unexpected csrf cookie: %+v, rendered: %s", cookies, rendered)
+	}
+	token := rendered
+
+	// 已有有效 token 时不重新下发
+	req = httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(session)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if len(rec.Result().Cookies()) != 0 || rendered != token {
+		t.Errorf("valid token should be reused, rendered: %s", rendered)
+	}
+
+	post := func(header, form string, sess *http.Cookie) int {
+		req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(url.Values{"_csrf": {form}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		req.AddCookie(sess)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("", "", session); code != http.StatusForbidden {
+		t.Errorf("missing token should be rejected, got: %d", code)
+	}
+	if code := post(token, "", session); code != http.StatusOK {
+		t.Errorf("header token should pass, got: %d", code)
+	}
+	if code := post("", token, session); code != http.StatusOK {
+		t.Errorf("form token should pass, got: %d", code)
+	}
+	if code := post(token, "", &http.Cookie{Name: "session", Value: "session-b"}); code != http.StatusForbidden {
+		t.Errorf("token of another session should be rejected, got: %d", code)
+	}
+}
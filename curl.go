@@ -0,0 +1,238 @@
+package libtools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 需要脱敏的字段名: 字段名按 - _ . 分隔后取最后一段, 以 curlSecretSuffixes 结尾或等于 curlSecretWords 时脱敏
+// 如 access_token、appSecret、X-Api-Key、sign 脱敏, monkey、design、sign_type 不脱敏
+var (
+	curlSecretSuffixes = []string{"authorization", "cookie", "token", "secret", "password", "passwd", "signature"}
+	curlSecretWords    = []string{"sign", "sig", "key", "pwd"}
+)
+
+// ToCurl 根据 HttpRequest 的参数生成等价的 curl 命令,便于排查与合作方接口的差异
+// redact 为 true 时,对 Authorization/Cookie/token/secret 等敏感字段做脱敏处理,包括 url 中的 query 参数
+func ToCurl(method, urlStr string, headers map[string]string, contentType ContentType, body interface{}, redact ...bool) string {
+	var needRedact bool
+	if len(redact) > 0 {
+		needRedact = redact[0]
+	}
+
+	box := []string{"curl", "-X", shellQuote(strings.ToUpper(method)), shellQuote(curlRedactURL(urlStr, needRedact))}
+
+	headerKeys := make([]string, 0, len(headers))
+	for k := range headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+
+	// multipart 的 Content-Type 带 boundary,由 curl 自行生成
+	hasContentType := false
+	for _, k := range headerKeys {
+		if strings.EqualFold(k, "Content-Type") {
+			hasContentType = true
+		}
+		box = append(box, "-H", shellQuote(fmt.Sprintf("%s: %s", k, curlRedact(k, headers[k], needRedact))))
+	}
+	if !hasContentType && contentType != HttpMultipartForm && contentType != "" {
+		box = append(box, "-H", shellQuote(fmt.Sprintf("Content-Type: %s", contentType)))
+	}
+
+	switch contentType {
+	case HttpApplicationJSON:
+		if body != nil {
+			jsonBody, err := json.Marshal(curlRedactJSON(body, needRedact))
+			if err != nil {
+				box = append(box, "--data-raw", shellQuote(fmt.Sprintf("<could not marshal json: %v>", err)))
+			} else {
+				box = append(box, "--data-raw", shellQuote(string(jsonBody)))
+			}
+		}
+
+	case HttpMultipartForm:
+		data, _ := body.(map[string]interface{})
+		for _, key := range sortedInterfaceMapKeys(data) {
+			for _, field := range curlMultipartFields(key, data[key], needRedact) {
+				box = append(box, "-F", shellQuote(field))
+			}
+		}
+
+	case HttpApplicationFormEncoded:
+		formData := url.Values{}
+		switch data := body.(type) {
+		case map[string]string:
+			for key, val := range data {
+				formData.Set(key, curlRedact(key, val, needRedact))
+			}
+		case url.Values:
+			for key, values := range data {
+				for _, val := range values {
+					formData.Add(key, curlRedact(key, val, needRedact))
+				}
+			}
+		}
+		if len(formData) > 0 {
+			box = append(box, "--data-raw", shellQuote(formData.Encode()))
+		}
+	}
+
+	return strings.Join(box, " ")
+}
+
+// curlMultipartFields 与 writeMultipartField 支持的类型一致, 切片展开为同名的多个 -F
+// FormFile 的内容来自 Reader, 命令中以 @文件名 占位, 执行前需准备同名文件
+func curlMultipartFields(key string, val interface{}, needRedact bool) []string {
+	var fields []string
+	switch v := val.(type) {
+	case string:
+		fields = append(fields, fmt.Sprintf("%s=%s", key, curlRedact(key, v, needRedact)))
+	case []string:
+		for _, item := range v {
+			fields = append(fields, curlMultipartFields(key, item, needRedact)...)
+		}
+	case *os.File:
+		fields = append(fields, fmt.Sprintf("%s=@%s", key, v.Name()))
+	case []*os.File:
+		for _, item := range v {
+			fields = append(fields, curlMultipartFields(key, item, needRedact)...)
+		}
+	case *FormFile:
+		fields = append(fields, curlMultipartFields(key, *v, needRedact)...)
+	case []FormFile:
+		for _, item := range v {
+			fields = append(fields, curlMultipartFields(key, item, needRedact)...)
+		}
+	case FormFile:
+		name := v.Name
+		if name == "" {
+			name = key
+		}
+		filename := v.Filename
+		if filename == "" {
+			filename = name
+		}
+		field := fmt.Sprintf("%s=@%s", name, filename)
+		if v.ContentType != "" {
+			field += ";type=" + v.ContentType
+		}
+		fields = append(fields, field)
+	default:
+		fields = append(fields, fmt.Sprintf("%s=<unsupported field type %T>", key, v))
+	}
+
+	return fields
+}
+
+// shellQuote 用单引号包裹参数,内部的单引号先闭合再转义
+func shellQuote(s string) string {
+	return `'` + strings.Replace(s, `'`, `'\''`, -1) + `'`
+}
+
+func isCurlSecretKey(key string) bool {
+	last := key
+	if i := strings.LastIndexAny(key, "-_."); i >= 0 {
+		last = key[i+1:]
+	}
+	lower := strings.ToLower(last)
+	for _, suffix := range curlSecretSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	for _, word := range curlSecretWords {
+		// 整段相等, 或驼峰命名的最后一个单词, 如 apiKey、paySign
+		if lower == word || (len(last) > len(word) && last[len(last)-len(word):] == strings.ToUpper(word[:1])+word[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// curlRedactURL 对 query 中的敏感参数脱敏, 其他参数保持原样与原顺序
+func curlRedactURL(urlStr string, needRedact bool) string {
+	if !needRedact {
+		return urlStr
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil || u.RawQuery == "" {
+		return urlStr
+	}
+
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, keyErr := url.QueryUnescape(kv[0])
+		value, valueErr := url.QueryUnescape(kv[1])
+		if keyErr != nil || valueErr != nil || !isCurlSecretKey(key) {
+			continue
+		}
+		// * 在 query 中无需转义, 保留以便阅读
+		pairs[i] = kv[0] + "=" + strings.Replace(url.QueryEscape(curlRedact(key, value, needRedact)), "%2A", "*", -1)
+	}
+	u.RawQuery = strings.Join(pairs, "&")
+
+	return u.String()
+}
+
+func curlRedact(key, value string, needRedact bool) string {
+	if !needRedact || !isCurlSecretKey(key) {
+		return value
+	}
+
+	masked := SecretKeyMask(value)
+	if masked == value {
+		// 太短的值没法保留首尾,直接全部隐藏
+		masked = "***"
+	}
+
+	return masked
+}
+
+// curlRedactJSON 仅处理顶层为 map 的 json body,其他类型原样返回
+func curlRedactJSON(body interface{}, needRedact bool) interface{} {
+	if !needRedact {
+		return body
+	}
+
+	switch data := body.(type) {
+	case map[string]string:
+		after := make(map[string]string, len(data))
+		for k, v := range data {
+			after[k] = curlRedact(k, v, needRedact)
+		}
+		return after
+
+	case map[string]interface{}:
+		after := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			if s, ok := v.(string); ok {
+				after[k] = curlRedact(k, s, needRedact)
+			} else {
+				after[k] = v
+			}
+		}
+		return after
+	}
+
+	return body
+}
+
+func sortedInterfaceMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,158 @@
+package libtools
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestToCurl(t *testing.T) {
+	headers := map[string]string{
+		"Authorization": "Bearer abcdefghijklmn",
+		"X-Trace":       "it's",
+	}
+	body := map[string]string{
+		"user":     "chester",
+		"password": "12345678901",
+	}
+
+	cmd := ToCurl("post", "https://example.com/api", headers, HttpApplicationFormEncoded, body)
+	want := `curl -X 'POST' 'https://example.com/api' -H 'Authorization: Bearer abcdefghijklmn' -H 'X-Trace: it'\''s' -H 'Content-Type: application/x-www-form-urlencoded' --data-raw 'password=12345678901&user=chester'`
+	if cmd != want {
+		t.Errorf("ToCurl get unexpected result:\n%s\nwant:\n%s", cmd, want)
+	}
+
+	redacted := ToCurl("POST", "https://example.com/api", headers, HttpApplicationJSON, body, true)
+	if strings.Contains(redacted, "abcdefghijklmn") || strings.Contains(redacted, "12345678901") {
+		t.Errorf("ToCurl should redact secrets, get: %s", redacted)
+	}
+	if !strings.Contains(redacted, `"user":"chester"`) {
+		t.Errorf("ToCurl should keep normal fields, get: %s", redacted)
+	}
+}
+
+func TestToCurlMultipartAndValues(t *testing.T) {
+	f, err := os.Open(testutil.WriteTempFileT(t, "ktp.jpg", []byte("jpg")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	body := map[string]interface{}{
+		"tags":     []string{"a", "b"},
+		"token":    "abcdefghijklmn",
+		"files":    []*os.File{f},
+		"selfie":   FormFile{Filename: "selfie.png", ContentType: "image/png", Reader: strings.NewReader("png")},
+		"contract": &FormFile{Name: "doc", Reader: strings.NewReader("pdf")},
+	}
+	cmd := ToCurl("POST", "https://example.com/upload", nil, HttpMultipartForm, body, true)
+	for _, want := range []string{
+		"-F 'tags=a' -F 'tags=b'",
+		"-F 'files=@" + f.Name() + "'",
+		"-F 'selfie=@selfie.png;type=image/png'",
+		"-F 'doc=@doc'",
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("ToCurl should contain %s, get: %s", want, cmd)
+		}
+	}
+	if strings.Contains(cmd, "abcdefghijklmn") || strings.Contains(cmd, "{") {
+		t.Errorf("ToCurl should redact token and not dump structs, get: %s", cmd)
+	}
+
+	values := url.Values{"id": {"1", "2"}, "password": {"12345678901"}}
+	cmd = ToCurl("POST", "https://example.com/api", nil, HttpApplicationFormEncoded, values, true)
+	if !strings.Contains(cmd, "id=1&id=2") || strings.Contains(cmd, "12345678901") {
+		t.Errorf("ToCurl should encode url.Values with redaction, get: %s", cmd)
+	}
+}
+
+func TestBuildHttpRequestBodyMultipart(t *testing.T) {
+	path := testutil.WriteTempFileT(t, "ktp.jpg", []byte("jpg-content"))
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	body := map[string]interface{}{
+		"tags":   []string{"a", "b"},
+		"files":  []*os.File{f},
+		"selfie": FormFile{Filename: `se"lfie.png`, Reader: strings.NewReader("png-content")},
+	}
+	reader, contentType, err := buildHttpRequestBody(HttpMultipartForm, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, _ := mime.ParseMediaType(contentType)
+	form, err := multipart.NewReader(reader, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tags := form.Value["tags"]; len(tags) != 2 || tags[0] != "a" || tags[1] != "b" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	selfie := form.File["selfie"]
+	if len(selfie) != 1 || selfie[0].Filename != `se"lfie.png` || selfie[0].Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("unexpected selfie: %+v", selfie)
+	}
+	if len(form.File["files"]) != 1 || form.File["files"][0].Filename != filepath.Base(path) {
+		t.Fatalf("unexpected files: %+v", form.File["files"])
+	}
+	content, _ := form.File["files"][0].Open()
+	if buf, _ := ioutil.ReadAll(content); string(buf) != "jpg-content" {
+		t.Errorf("unexpected file content: %s", buf)
+	}
+
+	if _, _, err = buildHttpRequestBody(HttpMultipartForm, map[string]interface{}{"x": 1}); err == nil {
+		t.Error("unsupported field type should return error")
+	}
+}
+
+func TestIsCurlSecretKey(t *testing.T) {
+	cases := map[string]bool{
+		"Authorization": true,
+		"Set-Cookie":    true,
+		"access_token":  true,
+		"accessToken":   true,
+		"appsecret":     true,
+		"client_secret": true,
+		"X-Api-Key":     true,
+		"apiKey":        true,
+		"key":           true,
+		"sign":          true,
+		"paySign":       true,
+		"X-Signature":   true,
+		"password":      true,
+		"monkey":        false,
+		"design":        false,
+		"sign_type":     false,
+		"keyword":       false,
+		"user":          false,
+	}
+	for key, want := range cases {
+		if got := isCurlSecretKey(key); got != want {
+			t.Errorf("isCurlSecretKey(%s): got %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestToCurlRedactURL(t *testing.T) {
+	urlStr := "https://example.com/api?id=1&token=abcdefghijklmn&sign=0123456789abcdef&design=blue"
+	cmd := ToCurl("GET", urlStr, nil, "", nil, true)
+	if strings.Contains(cmd, "abcdefghijklmn") || strings.Contains(cmd, "0123456789abcdef") {
+		t.Errorf("ToCurl should redact query secrets, get: %s", cmd)
+	}
+	if !strings.Contains(cmd, "?id=1&token=") || !strings.Contains(cmd, "&design=blue'") {
+		t.Errorf("ToCurl should keep normal query params in order, get: %s", cmd)
+	}
+
+	if cmd = ToCurl("GET", urlStr, nil, "", nil); !strings.Contains(cmd, urlStr) {
+		t.Errorf("ToCurl without redact should keep url, get: %s", cmd)
+	}
+}
@@ -0,0 +1,50 @@
+package libtools
+
+import (
+	"context"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const dailySeqKeyPrefix = "daily_seq:"
+
+// dailySeqTTL 序号 key 的保留时间, 覆盖跨零点仍在使用前一天日期的请求
+const dailySeqTTL = 48 * time.Hour
+
+// dailySeqFallbackBase 存储出错时退回的随机序号范围为 [base, 10*base), 位数多于正常序号, 不会与正常序号重复
+const dailySeqFallbackBase = 100000000
+
+var dailySeqStore = NewMemoryKV()
+
+// SetDailySeqStore 设置 DailySeq 使用的存储, 多实例部署时需设置为 redis 实现, 否则各实例的序号会重复
+// 存储实现了 KVIncrementer 时使用 Incr, 否则通过 SetNX 逐个占号
+func SetDailySeqStore(store KVStore) {
+	dailySeqStore = store
+}
+
+// DailySeq 返回 name 当天(本地时区)的下一个序号, 从 1 开始, 每天零点重置, 用于 YYYYMMDD-000123 这类单号
+// 存储出错时记录日志并返回 9 位的随机序号, 单号仍可用但不再连续; 需要处理错误时使用 NextDailySeq
+func DailySeq(name string) int64 {
+	seq, err := NextDailySeq(context.Background(), name)
+	if err == nil {
+		return seq
+	}
+
+	n, randErr := secureIntn(9 * dailySeqFallbackBase)
+	if randErr != nil {
+		n = time.Now().UnixNano() % (9 * dailySeqFallbackBase)
+	}
+	seq = dailySeqFallbackBase + n
+	logs.Error("[DailySeq] get next seq fail, use random seq %d, name: %s, err: %v", seq, name, err)
+
+	return seq
+}
+
+// NextDailySeq 与 DailySeq 相同, 返回存储错误
+func NextDailySeq(ctx context.Context, name string) (int64, error) {
+	begin := GetDateTimeByBegin(GetUnixMillis())
+	key := dailySeqKeyPrefix + name + ":" + time.Unix(begin, 0).Format("20060102")
+
+	return kvIncr(ctx, dailySeqStore, key, dailySeqTTL)
+}
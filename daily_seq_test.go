@@ -0,0 +1,82 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// claimOnlyKV 屏蔽 Incr, 用于测试 SetNX 占号
+type claimOnlyKV struct {
+	KVStore
+}
+
+func TestDailySeq(t *testing.T) {
+	for name, store := range map[string]KVStore{"incr": NewMemoryKV(), "claim": claimOnlyKV{NewMemoryKV()}} {
+		SetDailySeqStore(store)
+
+		var mu sync.Mutex
+		seen := make(map[int64]bool)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				seq, err := NextDailySeq(context.Background(), "order")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[seq] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		if len(seen) != 50 || !seen[1] || !seen[50] {
+			t.Errorf("%s: got %d distinct seq", name, len(seen))
+		}
+		if seq := DailySeq("refund"); seq != 1 {
+			t.Errorf("%s: other name should start from 1, get %d", name, seq)
+		}
+	}
+	SetDailySeqStore(NewMemoryKV())
+}
+
+// failingKV 所有操作都返回错误, 模拟存储不可用
+type failingKV struct {
+	KVStore
+}
+
+func (failingKV) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestDailySeqFallback(t *testing.T) {
+	SetDailySeqStore(failingKV{NewMemoryKV()})
+	defer SetDailySeqStore(NewMemoryKV())
+
+	if _, err := NextDailySeq(context.Background(), "order"); err == nil {
+		t.Fatal("NextDailySeq should return store error")
+	}
+	a, b := DailySeq("order"), DailySeq("order")
+	if a < dailySeqFallbackBase || a >= 10*dailySeqFallbackBase || b < dailySeqFallbackBase || a == b {
+		t.Errorf("fallback seq should be random 9 digits, get %d, %d", a, b)
+	}
+}
+
+func TestMemoryKVIncr(t *testing.T) {
+	kv := NewMemoryKV().(KVIncrementer)
+	ctx := context.Background()
+	for i := int64(1); i <= 3; i++ {
+		if n, err := kv.Incr(ctx, "counter", time.Minute); err != nil || n != i {
+			t.Fatalf("Incr: %d, %v", n, err)
+		}
+	}
+	if v, _, _ := kv.(KVStore).Get(ctx, "counter"); string(v) != "3" {
+		t.Errorf("stored value: %q", v)
+	}
+}
@@ -536,27 +536,30 @@ func CalculateAgeByBirthday(birthday string) int {
 // see: https://www.php.net/manual/zh/function.date.php
 // 采用类 linux 时间格式
 // 仅取以下值:
-// 日: d, D, l, j
-// 月: m, M, n
-// 年:  Y, y
-// 时间: a, H, i, s
-// 时区: e
+// 日: d, D, l, j, N, w
+// 月: m, M, n, t
+// 年:  Y, y, L
+// 时间: a, H, i, s, v(毫秒), u(微秒), U
+// 时区: e, P(+07:00), O(+0700)
+// 格式化见 phpDateGoLayouts 与 phpDateTokens, 这里的对照表用于 Date2UnixMsec 解析
 var (
 	find = []string{
+		`P`, `O`, // 需在 a 之前替换, a 的替换结果中含有 P
 		`a`, `M`, `n`, // 需要优先替换,否则出现误替换
 		`d`, `D`, `l`, `j`,
 		`m`,
 		`Y`, `y`,
-		`H`, `i`, `s`,
+		`H`, `i`, `s`, `v`, `u`,
 		`e`,
 	}
 
 	replace = []string{
+		`-07:00`, `-0700`,
 		`3:04PM`, `Jan`, `1`,
 		`02`, `Mon`, `Monday`, `2`,
 		`01`,
 		`2006`, `06`,
-		`15`, `04`, `05`,
+		`15`, `04`, `05`, `000`, `000000`,
 		`MST`,
 	}
 )
@@ -567,15 +570,20 @@ func UnixMsec2Date(um int64, layout string) string {
 		return `-`
 	}
 
-	tm := time.Unix(timestamp, 0)
+	// 保留毫秒, 供 u 使用
+	tm := time.UnixMilli(um)
 	local, _ := time.LoadLocation("Local")
 
-	for i, f := range find {
-		layout = strings.Replace(layout, f, replace[i], -1)
+	return formatPHPDate(tm.In(local), layout)
+}
+
+// UnixMsec2DateUTC 与 UnixMsec2Date 相同, 按 UTC 输出, 如导出给数仓时使用 UnixMsec2DateUTC(um, "Y-m-d H:i:s.v")
+func UnixMsec2DateUTC(um int64, layout string) string {
+	if um/1000 <= 0 {
+		return `-`
 	}
 
-	//logs.Debug("[UnixMsec2Date] layout: %s", layout)
-	return tm.In(local).Format(layout)
+	return formatPHPDate(time.UnixMilli(um).UTC(), layout)
 }
 
 func Date2UnixMsec(dateStr, layout string) int64 {
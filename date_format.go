@@ -0,0 +1,169 @@
+package libtools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// phpDateGoLayouts UnixMsec2Date 最初支持的 PHP date() 格式字符, 替换为对应的 Go layout 后整体 Format, 输出与旧版本保持一致
+// 注意 a 输出 "1:05PM" 而不是 PHP 的 "pm"; 旧版本依次替换时 M、l 的结果会被再次替换(如 "Ja3", "Monda26"), 现已修正为 "Mar", "Saturday"
+var phpDateGoLayouts = map[byte]string{
+	'a': "3:04PM",
+	'M': "Jan",
+	'n': "1",
+	'd': "02",
+	'D': "Mon",
+	'l': "Monday",
+	'j': "2",
+	'm': "01",
+	'Y': "2006",
+	'y': "06",
+	'H': "15",
+	'i': "04",
+	's': "05",
+	'e': "MST",
+}
+
+// phpDateTokens 后续增加的 PHP date() 格式字符, 见 https://www.php.net/manual/zh/function.date.php
+// 直接输出计算结果, 不参与 Go layout 解释
+var phpDateTokens = map[byte]func(t time.Time) string{
+	// N: ISO-8601 星期几, 1(周一) 到 7(周日); w: 0(周日) 到 6(周六)
+	'N': func(t time.Time) string { return strconv.Itoa((int(t.Weekday())+6)%7 + 1) },
+	'w': func(t time.Time) string { return strconv.Itoa(int(t.Weekday())) },
+	// t: 当月天数
+	't': func(t time.Time) string {
+		return strconv.Itoa(GetMonthLastDay(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())))
+	},
+	// L: 是否闰年 1/0
+	'L': func(t time.Time) string {
+		if y := t.Year(); y%4 == 0 && (y%100 != 0 || y%400 == 0) {
+			return "1"
+		}
+		return "0"
+	},
+	// v: 毫秒; u: 微秒, 由毫秒时间戳得到, 后三位总是 0
+	'v': func(t time.Time) string { return padInt(t.Nanosecond()/int(time.Millisecond), 3) },
+	'u': func(t time.Time) string { return padInt(t.Nanosecond()/1000, 6) },
+	// U: Unix 时间戳(秒)
+	'U': func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	// 时区, P: 与 UTC 的差, 如 +07:00; O: 同 P, 没有冒号, 如 +0700
+	'P': func(t time.Time) string { return t.Format("-07:00") },
+	'O': func(t time.Time) string { return t.Format("-0700") },
+}
+
+// formatPHPDate 按 PHP date() 的格式字符格式化 t
+// 与旧版本兼容, 未转义的非格式字符仍按 Go layout 解释, 如 "Y-m-d 1" 中的 1 输出月份; 需要原样输出时用 \ 转义, 如 "Y\年m\月"
+func formatPHPDate(t time.Time, layout string) string {
+	var b, goLayout strings.Builder
+	flush := func() {
+		if goLayout.Len() > 0 {
+			b.WriteString(t.Format(goLayout.String()))
+			goLayout.Reset()
+		}
+	}
+
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c == '\\' && i+1 < len(layout) {
+			flush()
+			i++
+			b.WriteByte(layout[i])
+			continue
+		}
+		if elem, ok := phpDateGoLayouts[c]; ok {
+			goLayout.WriteString(elem)
+			continue
+		}
+		if fn, ok := phpDateTokens[c]; ok {
+			flush()
+			b.WriteString(fn(t))
+			continue
+		}
+		goLayout.WriteByte(c)
+	}
+	flush()
+
+	return b.String()
+}
+
+func padInt(n, width int) string {
+	s := strconv.Itoa(n)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+
+// strftimeTokens Strftime 支持的转换说明, 与 C/Python 的 strftime 一致
+var strftimeTokens = map[byte]func(t time.Time) string{
+	'a': func(t time.Time) string { return t.Format("Mon") },
+	'A': func(t time.Time) string { return t.Format("Monday") },
+	'b': func(t time.Time) string { return t.Format("Jan") },
+	'B': func(t time.Time) string { return t.Format("January") },
+	'h': func(t time.Time) string { return t.Format("Jan") },
+	'c': func(t time.Time) string { return t.Format("Mon Jan _2 15:04:05 2006") },
+	'C': func(t time.Time) string { return padInt(t.Year()/100, 2) },
+	'd': func(t time.Time) string { return t.Format("02") },
+	'D': func(t time.Time) string { return t.Format("01/02/06") },
+	'e': func(t time.Time) string { return t.Format("_2") },
+	'f': func(t time.Time) string { return padInt(t.Nanosecond()/1000, 6) },
+	'F': func(t time.Time) string { return t.Format("2006-01-02") },
+	'H': func(t time.Time) string { return t.Format("15") },
+	'I': func(t time.Time) string { return t.Format("03") },
+	'j': func(t time.Time) string { return padInt(t.YearDay(), 3) },
+	'k': func(t time.Time) string { return fmt.Sprintf("%2d", t.Hour()) },
+	'l': func(t time.Time) string { return t.Format("_3") },
+	'm': func(t time.Time) string { return t.Format("01") },
+	'M': func(t time.Time) string { return t.Format("04") },
+	'n': func(t time.Time) string { return "\n" },
+	'p': func(t time.Time) string { return t.Format("PM") },
+	'P': func(t time.Time) string { return strings.ToLower(t.Format("PM")) },
+	'R': func(t time.Time) string { return t.Format("15:04") },
+	's': func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	'S': func(t time.Time) string { return t.Format("05") },
+	't': func(t time.Time) string { return "\t" },
+	'T': func(t time.Time) string { return t.Format("15:04:05") },
+	'u': func(t time.Time) string { return strconv.Itoa((int(t.Weekday())+6)%7 + 1) },
+	'w': func(t time.Time) string { return strconv.Itoa(int(t.Weekday())) },
+	'V': func(t time.Time) string {
+		_, week := t.ISOWeek()
+		return padInt(week, 2)
+	},
+	'G': func(t time.Time) string {
+		year, _ := t.ISOWeek()
+		return strconv.Itoa(year)
+	},
+	'y': func(t time.Time) string { return t.Format("06") },
+	'Y': func(t time.Time) string { return t.Format("2006") },
+	'z': func(t time.Time) string { return t.Format("-0700") },
+	'Z': func(t time.Time) string { return t.Format("MST") },
+	'%': func(t time.Time) string { return "%" },
+}
+
+// Strftime 按 C/Python strftime 的格式格式化毫秒时间戳(本地时区), 如 Strftime(ts, "%Y-%m-%d %H:%M:%S %z")
+// 支持 %a %A %b %B %c %C %d %D %e %f %F %G %h %H %I %j %k %l %m %M %n %p %P %R %s %S %t %T %u %V %w %y %Y %z %Z %%,
+// 不支持的转换说明原样输出
+func Strftime(ts int64, layout string) string {
+	t := time.UnixMilli(ts).In(time.Local)
+
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' || i+1 == len(layout) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if fn, ok := strftimeTokens[layout[i]]; ok {
+			b.WriteString(fn(t))
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(layout[i])
+		}
+	}
+
+	return b.String()
+}
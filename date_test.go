@@ -1,7 +1,9 @@
 package libtools
 
 import (
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestHumanUnixMillis(t *testing.T) {
@@ -13,3 +15,92 @@ func TestHumanUnixMillis(t *testing.T) {
 		t.Logf("[HumanUnixMillis] get ret: %s", display)
 	}
 }
+
+func TestUnixMsec2DateTokens(t *testing.T) {
+	// 2024-02-29 是周四, 闰年
+	ts := time.Date(2024, 2, 29, 13, 5, 9, 123*int(time.Millisecond), time.Local).UnixMilli()
+
+	cases := map[string]string{
+		"Y-m-d H:i:s":  "2024-02-29 13:05:09",
+		"y/n/j D l M":  "24/2/29 Thu Thursday Feb",
+		"N w t L":      "4 4 29 1",
+		"U":            strconv.FormatInt(ts/1000, 10),
+		"s.u":          "09.123000",
+		`Y\年m\月d\日 \Y`: "2024年02月29日 Y",
+		`Y-m-d \1`:     "2024-02-29 1",
+	}
+	for layout, want := range cases {
+		if got := UnixMsec2Date(ts, layout); got != want {
+			t.Errorf("UnixMsec2Date(%q) = %q, want %q", layout, got, want)
+		}
+	}
+
+	if got := UnixMsec2Date(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local).UnixMilli(), "L t N w"); got != "0 31 7 0" {
+		t.Errorf("UnixMsec2Date non-leap sunday: %q", got)
+	}
+}
+
+// TestUnixMsec2DateLegacy 固定旧版本(格式字符替换为 Go layout)的输出, 避免再次破坏兼容
+func TestUnixMsec2DateLegacy(t *testing.T) {
+	// 2026-03-07 是周六
+	ts := time.Date(2026, 3, 7, 13, 5, 9, 0, time.Local).UnixMilli()
+
+	cases := map[string]string{
+		"Y-m-d H:i:s": "2026-03-07 13:05:09",
+		"Ymd":         "20260307",
+		"Y年m月d日":      "2026年03月07日",
+		"n/j D":       "3/7 Sat",
+		"H:i a":       "13:05 1:05PM",
+		"a":           "1:05PM",
+		// 非格式字符按 Go layout 解释
+		"Y-m-d 1":     "2026-03-07 3",
+		"Y-m-d 15:04": "2026-03-07 13:05",
+		"h:i":         "h:05",
+	}
+	for layout, want := range cases {
+		if got := UnixMsec2Date(ts, layout); got != want {
+			t.Errorf("UnixMsec2Date(%q) = %q, want %q", layout, got, want)
+		}
+	}
+}
+
+func TestStrftime(t *testing.T) {
+	ts := time.Date(2024, 2, 9, 8, 5, 9, 123*int(time.Millisecond), time.Local).UnixMilli()
+	zone := time.UnixMilli(ts).Format("-0700")
+
+	cases := map[string]string{
+		"%Y-%m-%d %H:%M:%S": "2024-02-09 08:05:09",
+		"%a %A %b %B":       "Fri Friday Feb February",
+		"%y %j %e %k %I %p": "24 040  9  8 08 AM",
+		"%F %T.%f":          "2024-02-09 08:05:09.123000",
+		"%u %w %V %G":       "5 5 06 2024",
+		"%z":                zone,
+		"%s":                strconv.FormatInt(ts/1000, 10),
+		"100%% %q":          "100% %q",
+	}
+	for layout, want := range cases {
+		if got := Strftime(ts, layout); got != want {
+			t.Errorf("Strftime(%q) = %q, want %q", layout, got, want)
+		}
+	}
+}
+
+func TestUnixMsec2DateMillisAndZone(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	ts := time.Date(2024, 5, 12, 1, 2, 3, 45*int(time.Millisecond), loc).UnixMilli()
+
+	if got := UnixMsec2DateUTC(ts, "Y-m-d H:i:s.v P O"); got != "2024-05-11 18:02:03.045 +00:00 +0000" {
+		t.Errorf("UnixMsec2DateUTC: %q", got)
+	}
+	if got, want := UnixMsec2Date(ts, "s.v u P O"), "03.045 045000 "+time.UnixMilli(ts).Format("-07:00 -0700"); got != want {
+		t.Errorf("UnixMsec2Date: %q, want %q", got, want)
+	}
+
+	// 解析保留毫秒与时区
+	if got := Date2UnixMsec("2024-05-12 01:02:03.045 +07:00", "Y-m-d H:i:s.v P"); got != ts {
+		t.Errorf("Date2UnixMsec: %d, want %d", got, ts)
+	}
+	if got := Date2UnixMsec("2024-05-12T01:02:03.045000+0700", "Y-m-dTH:i:s.uO"); got != ts {
+		t.Errorf("Date2UnixMsec with u and O: %d, want %d", got, ts)
+	}
+}
@@ -1,6 +1,13 @@
 package libtools
 
-import "strings"
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 func SqlPlaceholderWithArray(length int) string {
 	var box []string
@@ -10,3 +17,82 @@ func SqlPlaceholderWithArray(length int) string {
 
 	return strings.Join(box, ", ")
 }
+
+// BuildInPlaceholders 生成 IN 子句的占位符,如: (?, ?, ?)
+// n <= 0 时返回 (NULL),保证 SQL 合法且不匹配任何行
+func BuildInPlaceholders(n int) string {
+	if n <= 0 {
+		return "(NULL)"
+	}
+
+	return "(" + SqlPlaceholderWithArray(n) + ")"
+}
+
+// EscapeLike 转义 LIKE 中的通配符,配合 `LIKE ? ESCAPE '\\'` 使用(MySQL 默认即为反斜杠)
+// 如: "50%_off" => "50\%\_off"
+func EscapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
+
+// EncodeCursor 将分页游标(如最后一条记录的 id, created_at)编码成不透明的 token
+func EncodeCursor(cursor map[string]interface{}) (string, error) {
+	if len(cursor) == 0 {
+		return "", nil
+	}
+
+	buf, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal cursor: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeCursor 解析 EncodeCursor 生成的 token, 数字以 json.Number 返回避免精度丢失
+func DecodeCursor(token string) (map[string]interface{}, error) {
+	cursor := make(map[string]interface{})
+	if token == "" {
+		return cursor, nil
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor token: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	err = decoder.Decode(&cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor token: %v", err)
+	}
+
+	return cursor, nil
+}
+
+// NullString 空字符串视为 NULL
+func NullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func NullStringValue(ns sql.NullString) string {
+	if !ns.Valid {
+		return ""
+	}
+
+	return ns.String
+}
+
+// NullInt64 0 视为 NULL
+func NullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
+
+func NullInt64Value(n sql.NullInt64) int64 {
+	if !n.Valid {
+		return 0
+	}
+
+	return n.Int64
+}
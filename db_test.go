@@ -0,0 +1,40 @@
+package libtools
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildInPlaceholders(t *testing.T) {
+	if s := BuildInPlaceholders(3); s != "(?, ?, ?)" {
+		t.Errorf("BuildInPlaceholders(3) get: %s", s)
+	}
+	if s := BuildInPlaceholders(0); s != "(NULL)" {
+		t.Errorf("BuildInPlaceholders(0) get: %s", s)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if s := EscapeLike(`50%_off\`); s != `50\%\_off\\` {
+		t.Errorf("EscapeLike get unexpected result: %s", s)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	token, err := EncodeCursor(map[string]interface{}{"id": int64(1664182378999123), "name": "a"})
+	if err != nil {
+		t.Fatalf("EncodeCursor get err: %v", err)
+	}
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("DecodeCursor get err: %v", err)
+	}
+	if cursor["id"].(json.Number).String() != "1664182378999123" || cursor["name"] != "a" {
+		t.Errorf("DecodeCursor get unexpected result: %v", cursor)
+	}
+
+	if _, err = DecodeCursor("not-a-token!"); err == nil {
+		t.Errorf("DecodeCursor should reject invalid token")
+	}
+}
@@ -0,0 +1,153 @@
+package libtools
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// DebugTokenHeader MountDebug 接口的鉴权 header; 不支持 ?token= 传入, url 会被记录到访问日志
+// 使用 go tool pprof 时先用 curl -H 下载 profile 文件再分析
+const DebugTokenHeader = "X-Debug-Token"
+
+var debugStartTime = time.Now()
+
+// DebugOptions MountDebug 的可选参数
+type DebugOptions struct {
+	// AllowPrivateNetwork 为 true 时, token 为空的情况下允许内网直连的请求访问, 默认关闭
+	// 经过 nginx、ingress、负载均衡时所有请求的直连方都是内网 ip, 此时不要开启, 应设置 token
+	AllowPrivateNetwork bool
+}
+
+// MountDebug 在 mux 上挂载 /debug/pprof/、/debug/runtime 与 /debug/buildinfo, 请求需携带相同的 token
+// token 为空且未开启 AllowPrivateNetwork 时所有请求都返回 403; 不要在对外的端口上挂载
+func MountDebug(mux *http.ServeMux, token string, opts ...DebugOptions) {
+	var opt DebugOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if token == "" && !opt.AllowPrivateNetwork {
+		logs.Error("[MountDebug] token is empty, all debug requests will be rejected")
+	}
+
+	guard := func(h http.HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !debugAuthorized(r, token, opt.AllowPrivateNetwork) {
+				logs.Warning("[MountDebug] unauthorized access, ip: %s, path: %s", ClientIP(r), r.URL.Path)
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"code":403,"message":"forbidden"}`))
+				return
+			}
+			w.Header().Set("Cache-Control", "no-store")
+			h(w, r)
+		})
+	}
+
+	mux.Handle("/debug/pprof/", guard(pprof.Index))
+	mux.Handle("/debug/pprof/cmdline", guard(pprof.Cmdline))
+	mux.Handle("/debug/pprof/profile", guard(pprof.Profile))
+	mux.Handle("/debug/pprof/symbol", guard(pprof.Symbol))
+	mux.Handle("/debug/pprof/trace", guard(pprof.Trace))
+	mux.Handle("/debug/runtime", guard(debugRuntimeHandler))
+	mux.Handle("/debug/buildinfo", guard(debugBuildInfoHandler))
+}
+
+func debugAuthorized(r *http.Request, token string, allowPrivateNetwork bool) bool {
+	if token == "" {
+		if !allowPrivateNetwork {
+			return false
+		}
+		// 带有转发头说明经过了代理, 直连方的 ip 不能代表客户端
+		if r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("X-Real-IP") != "" || r.Header.Get("Forwarded") != "" {
+			return false
+		}
+		// 请求头可以伪造, 只看直连方
+		remote, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			remote = r.RemoteAddr
+		}
+		return IsPrivateIP(remote)
+	}
+
+	got := r.Header.Get(DebugTokenHeader)
+	// 比较摘要, 耗时与 token 内容及长度无关
+	return got != "" && hmac.Equal([]byte(Sha256(got)), []byte(Sha256(token)))
+}
+
+func debugWriteJSON(w http.ResponseWriter, v interface{}) {
+	buf, _ := json.MarshalIndent(v, "", "  ")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(buf)
+}
+
+func debugRuntimeHandler(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var gc debug.GCStats
+	gc.PauseQuantiles = make([]time.Duration, 5)
+	debug.ReadGCStats(&gc)
+
+	pauses := make([]string, 0, len(gc.PauseQuantiles))
+	for _, p := range gc.PauseQuantiles {
+		pauses = append(pauses, p.String())
+	}
+
+	debugWriteJSON(w, map[string]interface{}{
+		"uptime":     time.Since(debugStartTime).Round(time.Second).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"num_cpu":    runtime.NumCPU(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"go_version": runtime.Version(),
+		"memory": map[string]interface{}{
+			"heap_alloc":     mem.HeapAlloc,
+			"heap_inuse":     mem.HeapInuse,
+			"heap_objects":   mem.HeapObjects,
+			"stack_inuse":    mem.StackInuse,
+			"sys":            mem.Sys,
+			"total_alloc":    mem.TotalAlloc,
+			"next_gc":        mem.NextGC,
+			"gc_cpu_percent": mem.GCCPUFraction * 100,
+		},
+		"gc": map[string]interface{}{
+			"num_gc":      gc.NumGC,
+			"last_gc":     gc.LastGC,
+			"pause_total": gc.PauseTotal.String(),
+			// 最小值、25%、50%、75%、最大值
+			"pause_quantiles": pauses,
+		},
+	})
+}
+
+func debugBuildInfoHandler(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		debugWriteJSON(w, map[string]interface{}{"go_version": runtime.Version()})
+		return
+	}
+
+	settings := make(map[string]string, len(info.Settings))
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	deps := make(map[string]string, len(info.Deps))
+	for _, d := range info.Deps {
+		deps[d.Path] = d.Version
+	}
+
+	debugWriteJSON(w, map[string]interface{}{
+		"go_version": info.GoVersion,
+		"path":       info.Path,
+		"main":       info.Main.Path + "@" + info.Main.Version,
+		"settings":   settings, // 包含 vcs.revision、vcs.time 等
+		"deps":       deps,
+	})
+}
@@ -0,0 +1,62 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountDebugAuth(t *testing.T) {
+	get := func(mux *http.ServeMux, remote, target string, header map[string]string) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.RemoteAddr = remote
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// token 为空且未开启内网访问时全部拒绝
+	noToken := http.NewServeMux()
+	MountDebug(noToken, "")
+	for _, remote := range []string{"10.0.0.5:1234", "127.0.0.1:1234", "8.8.8.8:1234"} {
+		if got := get(noToken, remote, "/debug/buildinfo", nil); got != http.StatusForbidden {
+			t.Errorf("no token, remote %s: got %d, want 403", remote, got)
+		}
+	}
+
+	privateNet := http.NewServeMux()
+	MountDebug(privateNet, "", DebugOptions{AllowPrivateNetwork: true})
+	cases := []struct {
+		remote string
+		header map[string]string
+		want   int
+	}{
+		{"10.0.0.5:1234", nil, http.StatusOK},
+		{"8.8.8.8:1234", nil, http.StatusForbidden},
+		// 经过不追加 XFF 的代理时, 伪造的内网 XFF 不能通过
+		{"8.8.8.8:1234", map[string]string{"X-Forwarded-For": "10.0.0.1"}, http.StatusForbidden},
+		// 经过反向代理的公网请求, 直连方是内网 ip
+		{"10.0.0.5:1234", map[string]string{"X-Forwarded-For": "8.8.8.8"}, http.StatusForbidden},
+		{"10.0.0.5:1234", map[string]string{"X-Real-IP": "8.8.8.8"}, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if got := get(privateNet, c.remote, "/debug/buildinfo", c.header); got != c.want {
+			t.Errorf("private network, remote %s, header %v: got %d, want %d", c.remote, c.header, got, c.want)
+		}
+	}
+
+	withToken := http.NewServeMux()
+	MountDebug(withToken, "s3cret")
+	if got := get(withToken, "10.0.0.5:1234", "/debug/buildinfo", nil); got != http.StatusForbidden {
+		t.Errorf("token required even from private ip, got %d", got)
+	}
+	if got := get(withToken, "8.8.8.8:1234", "/debug/buildinfo?token=s3cret", nil); got != http.StatusForbidden {
+		t.Errorf("query token should not be accepted, got %d", got)
+	}
+	if got := get(withToken, "8.8.8.8:1234", "/debug/buildinfo", map[string]string{DebugTokenHeader: "s3cret"}); got != http.StatusOK {
+		t.Errorf("header token should be accepted, got %d", got)
+	}
+}
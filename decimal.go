@@ -0,0 +1,160 @@
+package libtools
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decMaxExponent 科学计数法的指数上限, big.Rat 会展开 1e1000000000 这类输入, 耗尽 cpu 与内存
+const decMaxExponent = 100
+
+// decPattern 十进制数, 可带指数; 不接受 big.Rat 额外支持的分数、0x 前缀与 p 指数
+var decPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE]([+-]?\d+))?$`)
+
+// DecRoundMode 舍入方式
+type DecRoundMode int
+
+const (
+	// DecRoundHalfUp 四舍五入, 默认方式
+	DecRoundHalfUp DecRoundMode = iota
+	// DecRoundHalfEven 银行家舍入, 四舍六入五成双, 大量累加时误差不会单向累积
+	DecRoundHalfEven
+	// DecRoundDown 直接截断(向零舍入)
+	DecRoundDown
+	// DecRoundUp 有余数就进位(远离零)
+	DecRoundUp
+)
+
+// DecAdd a + b, 结果保留 scale 位小数, 全程使用 math/big 精确计算, 避免 float64 带来的对账差异
+func DecAdd(a, b string, scale int, mode ...DecRoundMode) (string, error) {
+	return decCalc(a, b, scale, mode, func(x, y *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Add(x, y), nil
+	})
+}
+
+// DecSub a - b
+func DecSub(a, b string, scale int, mode ...DecRoundMode) (string, error) {
+	return decCalc(a, b, scale, mode, func(x, y *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Sub(x, y), nil
+	})
+}
+
+// DecMul a * b
+func DecMul(a, b string, scale int, mode ...DecRoundMode) (string, error) {
+	return decCalc(a, b, scale, mode, func(x, y *big.Rat) (*big.Rat, error) {
+		return new(big.Rat).Mul(x, y), nil
+	})
+}
+
+// DecDiv a / b, 除数为 0 时返回错误
+func DecDiv(a, b string, scale int, mode ...DecRoundMode) (string, error) {
+	return decCalc(a, b, scale, mode, func(x, y *big.Rat) (*big.Rat, error) {
+		if y.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return new(big.Rat).Quo(x, y), nil
+	})
+}
+
+// DecRound 将十进制字符串按 scale 位小数舍入, 如 DecRound("2.345", 2, DecRoundHalfEven) => "2.34"
+func DecRound(a string, scale int, mode ...DecRoundMode) (string, error) {
+	x, err := decParse(a)
+	if err != nil {
+		return "", err
+	}
+
+	return decFormat(x, scale, decMode(mode))
+}
+
+func decCalc(a, b string, scale int, mode []DecRoundMode, op func(x, y *big.Rat) (*big.Rat, error)) (string, error) {
+	x, err := decParse(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := decParse(b)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := op(x, y)
+	if err != nil {
+		return "", err
+	}
+
+	return decFormat(r, scale, decMode(mode))
+}
+
+func decMode(mode []DecRoundMode) DecRoundMode {
+	if len(mode) > 0 {
+		return mode[0]
+	}
+
+	return DecRoundHalfUp
+}
+
+func decParse(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	m := decPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+	if m[3] != "" {
+		if exp, err := strconv.Atoi(m[3]); err != nil || exp > decMaxExponent || exp < -decMaxExponent {
+			return nil, fmt.Errorf("decimal exponent out of range: %q", s)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+
+	return r, nil
+}
+
+// decFormat 将有理数按舍入方式保留 scale 位小数
+func decFormat(r *big.Rat, scale int, mode DecRoundMode) (string, error) {
+	if scale < 0 {
+		return "", fmt.Errorf("scale can not be negative: %d", scale)
+	}
+
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	num := new(big.Int).Mul(new(big.Int).Abs(r.Num()), pow)
+	den := r.Denom()
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		// 比较 2 * 余数 与 除数, 判断是否过半
+		half := new(big.Int).Lsh(rem, 1).Cmp(den)
+		roundUp := false
+		switch mode {
+		case DecRoundHalfEven:
+			roundUp = half > 0 || (half == 0 && q.Bit(0) == 1)
+		case DecRoundDown:
+		case DecRoundUp:
+			roundUp = true
+		default:
+			roundUp = half >= 0
+		}
+		if roundUp {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	digits := q.String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+
+	if r.Sign() < 0 && q.Sign() != 0 {
+		digits = "-" + digits
+	}
+
+	return digits, nil
+}
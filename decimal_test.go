@@ -0,0 +1,49 @@
+package libtools
+
+import "testing"
+
+func TestDecCalc(t *testing.T) {
+	cases := []struct {
+		fn     func(a, b string, scale int, mode ...DecRoundMode) (string, error)
+		a, b   string
+		scale  int
+		mode   DecRoundMode
+		expect string
+	}{
+		{DecAdd, "0.1", "0.2", 2, DecRoundHalfUp, "0.30"},
+		{DecSub, "1", "3.456", 2, DecRoundHalfUp, "-2.46"},
+		{DecMul, "1.005", "1", 2, DecRoundHalfUp, "1.01"},
+		{DecMul, "1.005", "1", 2, DecRoundHalfEven, "1.00"},
+		{DecMul, "1.015", "1", 2, DecRoundHalfEven, "1.02"},
+		{DecDiv, "10", "3", 4, DecRoundHalfUp, "3.3333"},
+		{DecDiv, "2", "3", 0, DecRoundDown, "0"},
+		{DecDiv, "-0.001", "1", 2, DecRoundHalfUp, "0.00"},
+		{DecDiv, "1", "3", 2, DecRoundUp, "0.34"},
+	}
+
+	for i, c := range cases {
+		got, err := c.fn(c.a, c.b, c.scale, c.mode)
+		if err != nil {
+			t.Errorf("case %d get err: %v", i, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d: got %s, expect %s", i, got, c.expect)
+		}
+	}
+
+	if _, err := DecDiv("1", "0", 2); err == nil {
+		t.Errorf("DecDiv by zero should fail")
+	}
+	if _, err := DecAdd("abc", "1", 2); err == nil {
+		t.Errorf("DecAdd with invalid input should fail")
+	}
+	for _, s := range []string{"1e1000000000", "1e-1000000000", "1e99999999999999999999", "0x10", "1p10", "1/3", "1.2.3"} {
+		if _, err := DecRound(s, 2); err == nil {
+			t.Errorf("DecRound(%s) should fail", s)
+		}
+	}
+	if got, err := DecAdd("1.5e2", ".5", 1); err != nil || got != "150.5" {
+		t.Errorf("DecAdd with exponent: %s, %v", got, err)
+	}
+}
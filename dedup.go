@@ -0,0 +1,230 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// DedupOptions 重复请求拦截的配置
+type DedupOptions struct {
+	// Window 相同请求的判重窗口, 默认 5 秒
+	Window time.Duration
+	// Store 默认使用进程内存储, 多实例部署时需使用 redis 实现
+	Store KVStore
+	// Methods 需要判重的方法, 默认 POST PUT PATCH DELETE
+	Methods []string
+	// MaxBodySize 参与计算摘要的最大请求体, 超过时不判重, 默认 1MB
+	MaxBodySize int64
+	// Identity 区分请求方, 默认使用 Authorization 头、Cookie 与 ClientIP
+	Identity func(r *http.Request) string
+	// Replay 为 true 时重复请求直接返回首次请求的响应, 否则返回 409
+	Replay bool
+	// MaxReplaySize Replay 模式下保存的最大响应体, 超过时不保存, 重复请求返回 409, 默认 64KB
+	MaxReplaySize int
+}
+
+// DedupStats 拦截统计
+type DedupStats struct {
+	Checked    int64 `json:"checked"`
+	Duplicates int64 `json:"duplicates"`
+	Replayed   int64 `json:"replayed"`
+}
+
+// Dedup 按 method+path+body 的摘要拦截窗口内的重复提交, 用于后台表单等重复点击的场景
+//
+//	dedup := NewDedup(DedupOptions{Window: 3 * time.Second})
+//	mux.Handle("/admin/loan/approve", dedup.Handler(approveHandler))
+type Dedup struct {
+	opts    DedupOptions
+	methods map[string]bool
+
+	checked    int64
+	duplicates int64
+	replayed   int64
+}
+
+// dedupRecord Replay 模式下保存的首次响应
+type dedupRecord struct {
+	Done        bool   `json:"done"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+func NewDedup(opts DedupOptions) *Dedup {
+	if opts.Window <= 0 {
+		opts.Window = 5 * time.Second
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryKV()
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	if opts.MaxReplaySize <= 0 {
+		opts.MaxReplaySize = 64 << 10
+	}
+	if opts.Identity == nil {
+		opts.Identity = dedupIdentity
+	}
+
+	d := &Dedup{opts: opts, methods: make(map[string]bool)}
+	for _, m := range opts.Methods {
+		d.methods[strings.ToUpper(m)] = true
+	}
+
+	return d
+}
+
+// Stats 返回拦截统计, 可输出到监控
+func (d *Dedup) Stats() DedupStats {
+	return DedupStats{
+		Checked:    atomic.LoadInt64(&d.checked),
+		Duplicates: atomic.LoadInt64(&d.duplicates),
+		Replayed:   atomic.LoadInt64(&d.replayed),
+	}
+}
+
+// Handler http 中间件
+func (d *Dedup) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.methods[r.Method] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := d.key(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		atomic.AddInt64(&d.checked, 1)
+
+		ctx := r.Context()
+		placeholder, _ := json.Marshal(dedupRecord{})
+		acquired, err := d.opts.Store.SetNX(ctx, key, placeholder, d.opts.Window)
+		if err != nil {
+			// 存储异常时放行, 不影响正常业务
+			logs.Error("[Dedup] could not check duplicate request, err: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !acquired {
+			atomic.AddInt64(&d.duplicates, 1)
+			d.duplicate(ctx, w, key)
+			return
+		}
+
+		rec := &dedupRecorder{ResponseWriter: w, statusCode: http.StatusOK, capture: d.opts.Replay, maxSize: d.opts.MaxReplaySize}
+		next.ServeHTTP(rec, r)
+
+		// 服务端错误时释放, 允许用户立即重试
+		if rec.statusCode >= http.StatusInternalServerError {
+			if err = d.opts.Store.Delete(ctx, key); err != nil {
+				logs.Error("[Dedup] could not release key, err: %v", err)
+			}
+			return
+		}
+		if !d.opts.Replay || rec.overflow {
+			return
+		}
+
+		record, _ := json.Marshal(dedupRecord{
+			Done:        true,
+			StatusCode:  rec.statusCode,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err = d.opts.Store.Set(ctx, key, record, d.opts.Window); err != nil {
+			logs.Error("[Dedup] could not save response, err: %v", err)
+		}
+	})
+}
+
+func (d *Dedup) duplicate(ctx context.Context, w http.ResponseWriter, key string) {
+	if d.opts.Replay {
+		if value, ok, err := d.opts.Store.Get(ctx, key); err == nil && ok {
+			var record dedupRecord
+			if json.Unmarshal(value, &record) == nil && record.Done {
+				atomic.AddInt64(&d.replayed, 1)
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.WriteHeader(record.StatusCode)
+				_, _ = w.Write(record.Body)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write([]byte(`{"code":409,"message":"duplicate request, please try again later"}`))
+}
+
+// key 计算请求摘要, 请求体过大时返回 false
+func (d *Dedup) key(r *http.Request) (string, bool) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, d.opts.MaxBodySize+1))
+		if err != nil {
+			return "", false
+		}
+		// 放回已读取的部分, 下游仍可完整读取
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		if int64(len(body)) > d.opts.MaxBodySize {
+			return "", false
+		}
+	}
+
+	digest := Sha256(r.Method + "\n" + r.URL.Path + "?" + r.URL.RawQuery + "\n" + d.opts.Identity(r) + "\n" + string(body))
+	return "dedup:" + digest, true
+}
+
+// dedupIdentity 经过反向代理时 RemoteAddr 都是代理的地址, 使用 cookie 登录的不同用户需按 Cookie 区分
+func dedupIdentity(r *http.Request) string {
+	return r.Header.Get("Authorization") + "|" + strings.Join(r.Header.Values("Cookie"), "; ") + "|" + ClientIP(r)
+}
+
+// dedupRecorder 记录状态码, capture 为 true 时在写出响应的同时保存一份, 超过 maxSize 时放弃保存
+type dedupRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	capture    bool
+	maxSize    int
+	overflow   bool
+	body       bytes.Buffer
+}
+
+func (r *dedupRecorder) WriteHeader(code int) {
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *dedupRecorder) Write(b []byte) (int, error) {
+	if r.capture && !r.overflow {
+		if r.body.Len()+len(b) > r.maxSize {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
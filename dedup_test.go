@@ -0,0 +1,51 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDedupReplay(t *testing.T) {
+	var calls int32
+	handler := NewDedup(DedupOptions{Replay: true, MaxReplaySize: 32}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		c, _ := r.Cookie("sid")
+		if r.URL.Path == "/large" {
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+			return
+		}
+		_, _ = w.Write([]byte("user:" + c.Value))
+	}))
+
+	post := func(path, sid string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"loan_id":1}`))
+		r.RemoteAddr = "10.0.0.2:5000" // 反向代理
+		r.Header.Set("X-Forwarded-For", "8.8.8.8")
+		r.AddCookie(&http.Cookie{Name: "sid", Value: sid})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/approve", "a"); w.Body.String() != "user:a" {
+		t.Fatalf("user a got: %s", w.Body.String())
+	}
+	// 同一出口 ip 的不同 cookie 用户不能拿到 a 的响应
+	if w := post("/approve", "b"); w.Body.String() != "user:b" {
+		t.Fatalf("user b got: %s", w.Body.String())
+	}
+	if w := post("/approve", "a"); w.Body.String() != "user:a" || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("user a should be replayed, got: %s, calls: %d", w.Body.String(), calls)
+	}
+
+	// 响应体超过 MaxReplaySize 时不保存, 重复请求返回 409
+	if w := post("/large", "a"); w.Body.Len() != 100 {
+		t.Fatalf("large response should be written in full, got %d bytes", w.Body.Len())
+	}
+	if w := post("/large", "a"); w.Code != http.StatusConflict {
+		t.Fatalf("large response should not be replayed, got: %d", w.Code)
+	}
+}
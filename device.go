@@ -0,0 +1,230 @@
+package libtools
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DeviceInfo 规范化后的设备信息, 各端 SDK 上报的字段名与格式并不统一, 统一在这里整理
+type DeviceInfo struct {
+	Platform     string `json:"platform"` // android, ios, 其他原样小写
+	OSVersion    string `json:"os_version"`
+	Brand        string `json:"brand"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	DeviceID     string `json:"device_id"` // android_id / idfv
+	IMEI         string `json:"imei"`
+	MAC          string `json:"mac"`
+	ScreenWidth  int    `json:"screen_width"`
+	ScreenHeight int    `json:"screen_height"`
+	CPUABI       string `json:"cpu_abi"`
+	Hardware     string `json:"hardware"`
+	Product      string `json:"product"`
+	BuildFinger  string `json:"build_fingerprint"`
+	Carrier      string `json:"carrier"`
+	Network      string `json:"network"`
+	Language     string `json:"language"`
+	Timezone     string `json:"timezone"`
+	AppVersion   string `json:"app_version"`
+	Rooted       bool   `json:"rooted"`
+	SensorCount  int    `json:"sensor_count"` // -1 表示未上报
+
+	// Fingerprint 由稳定字段计算出的设备指纹, 同一台设备在重装 App、切换网络后保持不变
+	Fingerprint string `json:"fingerprint"`
+}
+
+// deviceFieldAliases 各版本 SDK 上报的字段名, 按优先级排列, 匹配时忽略大小写与 _-
+var deviceFieldAliases = map[string][]string{
+	"platform":     {"platform", "os", "ostype", "system"},
+	"os_version":   {"osversion", "systemversion", "version", "release"},
+	"brand":        {"brand", "devicebrand"},
+	"manufacturer": {"manufacturer", "vendor"},
+	"model":        {"model", "devicemodel", "phonemodel"},
+	"device_id":    {"deviceid", "androidid", "idfv", "uuid"},
+	"imei":         {"imei", "meid"},
+	"mac":          {"mac", "macaddress", "wifimac"},
+	"screen":       {"screen", "resolution", "screensize"},
+	"cpu_abi":      {"cpuabi", "abi", "cpu"},
+	"hardware":     {"hardware", "board"},
+	"product":      {"product"},
+	"fingerprint":  {"fingerprint", "buildfingerprint"},
+	"carrier":      {"carrier", "operator", "simoperator"},
+	"network":      {"network", "networktype", "nettype"},
+	"language":     {"language", "lang", "locale"},
+	"timezone":     {"timezone", "tz"},
+	"app_version":  {"appversion", "versionname", "appver"},
+	"rooted":       {"root", "rooted", "isroot", "jailbreak", "jailbroken"},
+	"sensor_count": {"sensorcount", "sensors"},
+}
+
+func deviceKey(k string) string {
+	k = strings.ToLower(strings.TrimSpace(k))
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(k)
+}
+
+// NormalizeDeviceInfo 将上报的原始设备信息整理为 DeviceInfo 并计算设备指纹
+func NormalizeDeviceInfo(raw map[string]string) DeviceInfo {
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[deviceKey(k)] = strings.TrimSpace(v)
+	}
+
+	get := func(field string) string {
+		for _, alias := range deviceFieldAliases[field] {
+			if v, ok := values[alias]; ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	info := DeviceInfo{
+		Platform:     strings.ToLower(get("platform")),
+		OSVersion:    get("os_version"),
+		Brand:        strings.ToLower(get("brand")),
+		Manufacturer: strings.ToLower(get("manufacturer")),
+		Model:        get("model"),
+		DeviceID:     strings.ToLower(get("device_id")),
+		IMEI:         get("imei"),
+		MAC:          normalizeMAC(get("mac")),
+		CPUABI:       strings.ToLower(get("cpu_abi")),
+		Hardware:     strings.ToLower(get("hardware")),
+		Product:      strings.ToLower(get("product")),
+		BuildFinger:  get("fingerprint"),
+		Carrier:      get("carrier"),
+		Network:      strings.ToLower(get("network")),
+		Language:     get("language"),
+		Timezone:     get("timezone"),
+		AppVersion:   get("app_version"),
+		SensorCount:  -1,
+	}
+
+	switch {
+	case strings.Contains(info.Platform, "android"):
+		info.Platform = "android"
+	case strings.Contains(info.Platform, "ios"), strings.Contains(info.Platform, "iphone"):
+		info.Platform = "ios"
+	}
+
+	info.ScreenWidth, info.ScreenHeight = parseScreenSize(get("screen"))
+
+	switch strings.ToLower(get("rooted")) {
+	case "1", "true", "yes", "y":
+		info.Rooted = true
+	}
+
+	if n, err := strconv.Atoi(get("sensor_count")); err == nil {
+		info.SensorCount = n
+	}
+
+	info.Fingerprint = DeviceFingerprint(info)
+	return info
+}
+
+// DeviceFingerprint 只使用不会随网络、App 版本、系统升级变化的字段
+func DeviceFingerprint(info DeviceInfo) string {
+	return Sha256(strings.Join([]string{
+		info.Platform,
+		info.Brand,
+		strings.ToLower(info.Model),
+		info.DeviceID,
+		info.IMEI,
+		info.MAC,
+		strconv.Itoa(info.ScreenWidth) + "x" + strconv.Itoa(info.ScreenHeight),
+	}, "|"))
+}
+
+// normalizeMAC 统一为小写冒号分隔, 02:00:00:00:00:00 等系统返回的占位值视为空
+func normalizeMAC(mac string) string {
+	mac = strings.ToLower(strings.NewReplacer("-", ":", ".", "").Replace(mac))
+	if len(mac) == 12 && !strings.Contains(mac, ":") {
+		var parts []string
+		for i := 0; i < 12; i += 2 {
+			parts = append(parts, mac[i:i+2])
+		}
+		mac = strings.Join(parts, ":")
+	}
+
+	switch mac {
+	case "02:00:00:00:00:00", "00:00:00:00:00:00":
+		return ""
+	}
+
+	return mac
+}
+
+// parseScreenSize 解析 1080x2340 / 1080*2340 / 1080,2340, 宽高统一为 短边x长边
+func parseScreenSize(s string) (w, h int) {
+	s = strings.ToLower(s)
+	for _, sep := range []string{"x", "*", ",", " "} {
+		parts := strings.Split(s, sep)
+		if len(parts) != 2 {
+			continue
+		}
+		w, _ = strconv.Atoi(strings.TrimSpace(parts[0]))
+		h, _ = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if w > h {
+			w, h = h, w
+		}
+		return
+	}
+
+	return
+}
+
+// EmulatorHints 模拟器/改机的可疑特征, 返回命中的规则, 只作为风控打分的参考, 不能单独作为拦截依据
+func EmulatorHints(info DeviceInfo) []string {
+	var hints []string
+	contains := func(s string, subs ...string) bool {
+		s = strings.ToLower(s)
+		for _, sub := range subs {
+			if strings.Contains(s, sub) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if info.Platform == "ios" {
+		if contains(info.Model, "x86_64", "i386", "simulator") {
+			hints = append(hints, "ios_simulator_model")
+		}
+		if info.Rooted {
+			hints = append(hints, "jailbroken")
+		}
+		return hints
+	}
+
+	if contains(info.Hardware, "goldfish", "ranchu", "vbox86", "nox", "ttvm", "android_x86") {
+		hints = append(hints, "emulator_hardware")
+	}
+	if contains(info.Product, "sdk", "vbox", "nox", "emulator", "simulator") {
+		hints = append(hints, "emulator_product")
+	}
+	if contains(info.Model, "sdk", "emulator", "android sdk built for") {
+		hints = append(hints, "emulator_model")
+	}
+	if info.Brand == "generic" || contains(info.Manufacturer, "genymotion", "unknown") {
+		hints = append(hints, "generic_brand")
+	}
+	if strings.HasPrefix(strings.ToLower(info.BuildFinger), "generic") || contains(info.BuildFinger, "test-keys") {
+		hints = append(hints, "generic_build")
+	}
+	if strings.HasPrefix(info.CPUABI, "x86") {
+		hints = append(hints, "x86_abi")
+	}
+	if info.IMEI != "" && strings.Trim(info.IMEI, "0") == "" {
+		hints = append(hints, "zero_imei")
+	}
+	if info.SensorCount >= 0 && info.SensorCount < 3 {
+		hints = append(hints, "few_sensors")
+	}
+	if strings.EqualFold(info.Carrier, "android") {
+		hints = append(hints, "fake_carrier")
+	}
+	if info.Rooted {
+		hints = append(hints, "rooted")
+	}
+
+	return hints
+}
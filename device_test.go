@@ -0,0 +1,79 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDeviceInfo(t *testing.T) {
+	// 同一台设备, 新旧两个版本的 SDK 上报格式不同
+	v1 := NormalizeDeviceInfo(map[string]string{
+		"OS":          "Android",
+		"os_version":  "13",
+		"Brand":       "Xiaomi",
+		"model":       "22101316G",
+		"android_id":  "9F2C1A7B3E4D5C6A",
+		"wifi-mac":    "A4-5E-60-C1-22-3F",
+		"resolution":  "2400x1080",
+		"network":     "WIFI",
+		"app_version": "3.1.0",
+		"sensors":     "28",
+	})
+	v2 := NormalizeDeviceInfo(map[string]string{
+		"platform":   "android",
+		"version":    "14",
+		"brand":      "xiaomi",
+		"model":      "22101316G",
+		"deviceId":   "9f2c1a7b3e4d5c6a",
+		"mac":        "a45e60c1223f",
+		"screenSize": "1080*2400",
+		"netType":    "4g",
+		"appVer":     "3.2.0",
+	})
+
+	if v1.Platform != "android" || v1.Brand != "xiaomi" || v1.DeviceID != "9f2c1a7b3e4d5c6a" || v1.MAC != "a4:5e:60:c1:22:3f" {
+		t.Errorf("unexpected normalized info: %+v", v1)
+	}
+	if v1.ScreenWidth != 1080 || v1.ScreenHeight != 2400 || v1.SensorCount != 28 || v2.SensorCount != -1 {
+		t.Errorf("unexpected screen or sensors: %+v", v1)
+	}
+	// 系统、网络、App 版本变化不影响指纹
+	if v1.Fingerprint == "" || v1.Fingerprint != v2.Fingerprint {
+		t.Errorf("fingerprint should be stable: %s != %s", v1.Fingerprint, v2.Fingerprint)
+	}
+
+	other := NormalizeDeviceInfo(map[string]string{"platform": "android", "brand": "xiaomi", "model": "22101316G", "deviceId": "another"})
+	if other.Fingerprint == v1.Fingerprint {
+		t.Error("different devices should have different fingerprints")
+	}
+
+	if ios := NormalizeDeviceInfo(map[string]string{"os": "iPhone OS", "mac": "02:00:00:00:00:00"}); ios.Platform != "ios" || ios.MAC != "" {
+		t.Errorf("unexpected ios info: %+v", ios)
+	}
+}
+
+func TestEmulatorHints(t *testing.T) {
+	phone := NormalizeDeviceInfo(map[string]string{
+		"platform": "android", "brand": "samsung", "manufacturer": "samsung", "model": "SM-A536E",
+		"hardware": "s5e8825", "product": "a53xnsxx", "cpu_abi": "arm64-v8a", "imei": "356938035643809",
+		"sensors": "30", "carrier": "Telkomsel",
+	})
+	if hints := EmulatorHints(phone); len(hints) != 0 {
+		t.Errorf("real device should have no hints: %v", hints)
+	}
+
+	emulator := NormalizeDeviceInfo(map[string]string{
+		"platform": "android", "brand": "generic", "model": "Android SDK built for x86", "hardware": "ranchu",
+		"product": "sdk_gphone_x86", "cpu_abi": "x86", "imei": "000000000000000", "sensors": "1",
+		"carrier": "Android", "fingerprint": "generic/sdk_gphone_x86/generic_x86:11/RSR1/test-keys", "root": "1",
+	})
+	want := "emulator_hardware,emulator_product,emulator_model,generic_brand,generic_build,x86_abi,zero_imei,few_sensors,fake_carrier,rooted"
+	if hints := strings.Join(EmulatorHints(emulator), ","); hints != want {
+		t.Errorf("unexpected hints: %s", hints)
+	}
+
+	simulator := NormalizeDeviceInfo(map[string]string{"platform": "ios", "model": "x86_64", "jailbreak": "true", "cpu": "x86_64"})
+	if hints := strings.Join(EmulatorHints(simulator), ","); hints != "ios_simulator_model,jailbroken" {
+		t.Errorf("unexpected ios hints: %s", hints)
+	}
+}
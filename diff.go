@@ -0,0 +1,151 @@
+package libtools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldChange 单个字段的变更, Path 形如 "Applicant.Mobile"
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// 字段脱敏方式, 通过 tag 指定: `diff:"mobile,mask=mobile"`
+var diffMaskers = map[string]func(string) string{
+	"mobile":   MobileDesensitization,
+	"name":     RealNameMask,
+	"nickname": NicknameMask,
+	"secret":   SecretKeyMask,
+	"all": func(string) string {
+		return "***"
+	},
+}
+
+// DiffStructs 比较两个同类型结构体(或其指针), 返回有变化的字段
+// 字段名优先取 `diff` tag, 其次 `json` tag, 最后为字段名; `diff:"-"` 的字段不参与比较
+// tag 中的 mask=mobile|name|nickname|secret|all 会对新旧值脱敏后再输出, 便于直接写入审计日志
+// 嵌套结构体递归比较, slice/map 作为整体比较
+func DiffStructs(old, new interface{}) ([]FieldChange, error) {
+	oldV := reflect.ValueOf(old)
+	newV := reflect.ValueOf(new)
+
+	// 允许一侧为 nil, 如新建/删除
+	if !oldV.IsValid() && !newV.IsValid() {
+		return nil, nil
+	}
+	if !oldV.IsValid() {
+		oldV = reflect.Zero(newV.Type())
+	}
+	if !newV.IsValid() {
+		newV = reflect.Zero(oldV.Type())
+	}
+
+	if oldV.Type() != newV.Type() {
+		return nil, fmt.Errorf("DiffStructs need same type, get %s and %s", oldV.Type(), newV.Type())
+	}
+
+	oldV, newV = diffIndirect(oldV), diffIndirect(newV)
+	if oldV.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("DiffStructs need struct, get %s", oldV.Kind())
+	}
+
+	var changes []FieldChange
+	diffStruct("", oldV, newV, &changes)
+
+	return changes, nil
+}
+
+func diffIndirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Ptr {
+				return reflect.Zero(v.Type().Elem())
+			}
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func diffStruct(prefix string, oldV, newV reflect.Value, changes *[]FieldChange) {
+	rt := oldV.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			// 未导出字段
+			continue
+		}
+
+		name, mask := diffFieldName(f)
+		if name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		oldF := diffIndirect(oldV.Field(i))
+		newF := diffIndirect(newV.Field(i))
+
+		if oldF.IsValid() && newF.IsValid() && oldF.Type() == newF.Type() &&
+			oldF.Kind() == reflect.Struct && oldF.Type() != reflect.TypeOf(time.Time{}) {
+			diffStruct(path, oldF, newF, changes)
+			continue
+		}
+
+		oldI := diffInterface(oldF)
+		newI := diffInterface(newF)
+		if reflect.DeepEqual(oldI, newI) {
+			continue
+		}
+
+		if masker, ok := diffMaskers[mask]; ok {
+			oldI = masker(fmt.Sprintf("%v", oldI))
+			newI = masker(fmt.Sprintf("%v", newI))
+		}
+
+		*changes = append(*changes, FieldChange{Path: path, Old: oldI, New: newI})
+	}
+}
+
+func diffInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+func diffFieldName(f reflect.StructField) (name, mask string) {
+	tag := f.Tag.Get("diff")
+	if tag != "" {
+		parts := strings.Split(tag, ",")
+		name = parts[0]
+		for _, opt := range parts[1:] {
+			if strings.HasPrefix(opt, "mask=") {
+				mask = strings.TrimPrefix(opt, "mask=")
+			}
+		}
+	}
+
+	if name == "" {
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		if jsonName != "" && jsonName != "-" {
+			name = jsonName
+		}
+	}
+
+	if name == "" {
+		name = f.Name
+	}
+
+	return
+}
@@ -0,0 +1,43 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestDiffStructs(t *testing.T) {
+	type applicant struct {
+		Name   string `diff:"name,mask=name"`
+		Mobile string `json:"mobile" diff:",mask=mobile"`
+	}
+	type loan struct {
+		ID        int64
+		Amount    int64 `json:"amount"`
+		Status    string
+		Applicant applicant `json:"applicant"`
+		Internal  string    `diff:"-"`
+	}
+
+	before := loan{ID: 1, Amount: 100000, Status: "pending", Applicant: applicant{Name: "张三丰", Mobile: "08123456789"}, Internal: "a"}
+	after := before
+	after.Amount = 200000
+	after.Applicant.Mobile = "08123450000"
+	after.Internal = "b"
+
+	changes, err := DiffStructs(&before, &after)
+	if err != nil {
+		t.Fatalf("DiffStructs get err: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("DiffStructs want 2 changes, get: %v", changes)
+	}
+	if changes[0].Path != "amount" || changes[0].Old != int64(100000) || changes[0].New != int64(200000) {
+		t.Errorf("DiffStructs get unexpected change: %v", changes[0])
+	}
+	if changes[1].Path != "applicant.mobile" || changes[1].Old != "081****6789" || changes[1].New != "081****0000" {
+		t.Errorf("DiffStructs should mask mobile, get: %v", changes[1])
+	}
+
+	if _, err = DiffStructs(before, "x"); err == nil {
+		t.Errorf("DiffStructs should reject different types")
+	}
+}
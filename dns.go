@@ -0,0 +1,144 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DNSTimeout 单次解析的超时时间, ctx 没有 deadline 时生效
+var DNSTimeout = 5 * time.Second
+
+// DNSCacheTTL 解析结果在进程内的缓存时长, <= 0 表示不缓存
+var DNSCacheTTL = time.Minute
+
+var dnsCache = NewTTLCache(time.Minute)
+
+// dnsResolver resolverAddr 为空时使用系统解析, 否则走指定的 DNS 服务器,如: 8.8.8.8 或 8.8.8.8:53
+func dnsResolver(resolverAddr string) *net.Resolver {
+	if resolverAddr == "" {
+		return net.DefaultResolver
+	}
+
+	if _, _, err := net.SplitHostPort(resolverAddr); err != nil {
+		resolverAddr = net.JoinHostPort(resolverAddr, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{Timeout: DNSTimeout}
+			return d.DialContext(ctx, network, resolverAddr)
+		},
+	}
+}
+
+func dnsContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, DNSTimeout)
+}
+
+func dnsCacheGet(kind, host, resolverAddr string) (interface{}, bool) {
+	if DNSCacheTTL <= 0 {
+		return nil, false
+	}
+
+	return dnsCache.Get(fmt.Sprintf("%s|%s|%s", kind, resolverAddr, host))
+}
+
+func dnsCacheSet(kind, host, resolverAddr string, value interface{}) {
+	if DNSCacheTTL <= 0 {
+		return
+	}
+
+	dnsCache.Set(fmt.Sprintf("%s|%s|%s", kind, resolverAddr, host), value, DNSCacheTTL)
+}
+
+// ResolveIPs 解析域名对应的 ip 列表, host 本身是 ip 时直接返回
+func ResolveIPs(ctx context.Context, host string, resolverAddr string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{ip.String()}, nil
+	}
+
+	if v, ok := dnsCacheGet("ip", host, resolverAddr); ok {
+		return append([]string(nil), v.([]string)...), nil
+	}
+
+	ctx, cancel := dnsContext(ctx)
+	defer cancel()
+
+	addrs, err := dnsResolver(resolverAddr).LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s fail: %v", host, err)
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	// 缓存与返回值各用一份, 调用方修改返回的切片(如打乱顺序)不会影响缓存
+	dnsCacheSet("ip", host, resolverAddr, append([]string(nil), ips...))
+
+	return ips, nil
+}
+
+// LookupTXT 查询 TXT 记录
+func LookupTXT(ctx context.Context, host string, resolverAddr string) ([]string, error) {
+	if v, ok := dnsCacheGet("txt", host, resolverAddr); ok {
+		return append([]string(nil), v.([]string)...), nil
+	}
+
+	ctx, cancel := dnsContext(ctx)
+	defer cancel()
+
+	records, err := dnsResolver(resolverAddr).LookupTXT(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("lookup txt %s fail: %v", host, err)
+	}
+	dnsCacheSet("txt", host, resolverAddr, append([]string(nil), records...))
+
+	return records, nil
+}
+
+// LookupMX 查询 MX 记录,按优先级排好序
+func LookupMX(ctx context.Context, host string, resolverAddr string) ([]*net.MX, error) {
+	if v, ok := dnsCacheGet("mx", host, resolverAddr); ok {
+		return dnsCopyMX(v.([]*net.MX)), nil
+	}
+
+	ctx, cancel := dnsContext(ctx)
+	defer cancel()
+
+	records, err := dnsResolver(resolverAddr).LookupMX(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("lookup mx %s fail: %v", host, err)
+	}
+	dnsCacheSet("mx", host, resolverAddr, dnsCopyMX(records))
+
+	return records, nil
+}
+
+// dnsCopyMX 深拷贝, *net.MX 的字段同样可能被调用方修改
+func dnsCopyMX(records []*net.MX) []*net.MX {
+	copied := make([]*net.MX, len(records))
+	for i, mx := range records {
+		v := *mx
+		copied[i] = &v
+	}
+
+	return copied
+}
+
+// IsResolvable 域名能否通过系统 DNS 解析出至少一个 ip
+func IsResolvable(host string) bool {
+	ips, err := ResolveIPs(context.Background(), host, "")
+	return err == nil && len(ips) > 0
+}
@@ -0,0 +1,35 @@
+package libtools
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestDNSCacheCopy(t *testing.T) {
+	ctx := context.Background()
+	dnsCacheSet("ip", "cached.example", "", []string{"1.1.1.1", "8.8.8.8"})
+	dnsCacheSet("txt", "cached.example", "", []string{"v=spf1 -all"})
+	dnsCacheSet("mx", "cached.example", "", []*net.MX{{Host: "mx.cached.example.", Pref: 10}})
+
+	ips, err := ResolveIPs(ctx, "cached.example", "")
+	if err != nil || len(ips) != 2 {
+		t.Fatalf("ResolveIPs: %v, %v", ips, err)
+	}
+	ips[0] = "127.0.0.1"
+	txt, _ := LookupTXT(ctx, "cached.example", "")
+	txt[0] = "changed"
+	mx, _ := LookupMX(ctx, "cached.example", "")
+	mx[0].Host = "evil.example."
+
+	// 修改返回值不影响缓存
+	if ips, _ = ResolveIPs(ctx, "cached.example", ""); ips[0] != "1.1.1.1" {
+		t.Errorf("cached ips changed: %v", ips)
+	}
+	if txt, _ = LookupTXT(ctx, "cached.example", ""); txt[0] != "v=spf1 -all" {
+		t.Errorf("cached txt changed: %v", txt)
+	}
+	if mx, _ = LookupMX(ctx, "cached.example", ""); mx[0].Host != "mx.cached.example." {
+		t.Errorf("cached mx changed: %v", mx[0])
+	}
+}
@@ -0,0 +1,64 @@
+package libtools
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// AesGCMEncrypt 使用 AES-GCM 加密, key 长度需为 16/24/32 字节, 返回 nonce + 密文(含认证标签)
+// additionalData 为可选的附加认证数据, 解密时需传入相同的值
+func AesGCMEncrypt(plaintext, key []byte, additionalData ...[]byte) ([]byte, error) {
+	gcm, err := newAesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	var ad []byte
+	if len(additionalData) > 0 {
+		ad = additionalData[0]
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+// AesGCMDecrypt 解密 AesGCMEncrypt 的结果, 密文被篡改时返回错误
+func AesGCMDecrypt(ciphertext, key []byte, additionalData ...[]byte) ([]byte, error) {
+	gcm, err := newAesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("aes gcm ciphertext too short")
+	}
+
+	var ad []byte
+	if len(additionalData) > 0 {
+		ad = additionalData[0]
+	}
+
+	nonce, data := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, ad)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt aes gcm ciphertext: %v", err)
+	}
+
+	return plaintext, nil
+}
+
+func newAesGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,124 @@
+package libtools
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// 环境变量读取, 运行模式等配置仍以 app.conf 为准(见 GetCurrentEnv), 这里用于密钥、外部服务地址等部署时注入的值
+// 变量不存在或为空白时返回默认值, 格式错误时打日志并返回默认值
+
+func envValue(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+func EnvStr(key, def string) string {
+	if v, ok := envValue(key); ok {
+		return v
+	}
+
+	return def
+}
+
+func EnvInt(key string, def int) int {
+	v, ok := envValue(key)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		logs.Warning("[EnvInt] %s is not an integer: %s, use default: %d", key, v, def)
+		return def
+	}
+
+	return n
+}
+
+// EnvBool 支持 1/0、true/false、yes/no、on/off
+func EnvBool(key string, def bool) bool {
+	v, ok := envValue(key)
+	if !ok {
+		return def
+	}
+
+	switch strings.ToLower(v) {
+	case "1", "true", "yes", "on", "y":
+		return true
+	case "0", "false", "no", "off", "n":
+		return false
+	}
+
+	logs.Warning("[EnvBool] %s is not a bool: %s, use default: %v", key, v, def)
+	return def
+}
+
+// EnvDuration 使用 time.ParseDuration 的格式, 如 1m30s; 纯数字按秒处理
+func EnvDuration(key string, def time.Duration) time.Duration {
+	v, ok := envValue(key)
+	if !ok {
+		return def
+	}
+
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		logs.Warning("[EnvDuration] %s is not a duration: %s, use default: %v", key, v, def)
+		return def
+	}
+
+	return d
+}
+
+// EnvStrSlice 按 sep 切分, 去掉空白项, 如 EnvStrSlice("KAFKA_BROKERS", ",")
+func EnvStrSlice(key, sep string) []string {
+	v, ok := envValue(key)
+	if !ok {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// MustEnv 读取必填变量, 缺失时 panic, 只应在启动阶段调用; 启动时建议先用 CheckRequiredEnv 一次性报告所有缺失项
+func MustEnv(key string) string {
+	v, ok := envValue(key)
+	if !ok {
+		panic(fmt.Sprintf("required env %s is not set", key))
+	}
+
+	return v
+}
+
+// CheckRequiredEnv 检查必填变量, 返回包含所有缺失项的错误, 避免部署时缺一个改一个
+func CheckRequiredEnv(keys []string) error {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := envValue(key); !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("required env is not set: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
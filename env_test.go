@@ -0,0 +1,43 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("LIBTOOLS_TEST_INT", "42")
+	t.Setenv("LIBTOOLS_TEST_BAD_INT", "4x")
+	t.Setenv("LIBTOOLS_TEST_BOOL", "Yes")
+	t.Setenv("LIBTOOLS_TEST_DURATION", "1m30s")
+	t.Setenv("LIBTOOLS_TEST_SECONDS", "15")
+	t.Setenv("LIBTOOLS_TEST_SLICE", "a, b,,c ")
+	t.Setenv("LIBTOOLS_TEST_BLANK", "  ")
+
+	if v := EnvStr("LIBTOOLS_TEST_BLANK", "def"); v != "def" {
+		t.Errorf("EnvStr blank should use default, get %s", v)
+	}
+	if v := EnvInt("LIBTOOLS_TEST_INT", 1); v != 42 {
+		t.Errorf("EnvInt get %d", v)
+	}
+	if v := EnvInt("LIBTOOLS_TEST_BAD_INT", 1); v != 1 {
+		t.Errorf("EnvInt bad value should use default, get %d", v)
+	}
+	if !EnvBool("LIBTOOLS_TEST_BOOL", false) {
+		t.Errorf("EnvBool should be true")
+	}
+	if v := EnvDuration("LIBTOOLS_TEST_DURATION", 0); v != 90*time.Second {
+		t.Errorf("EnvDuration get %v", v)
+	}
+	if v := EnvDuration("LIBTOOLS_TEST_SECONDS", 0); v != 15*time.Second {
+		t.Errorf("EnvDuration seconds get %v", v)
+	}
+	if v := EnvStrSlice("LIBTOOLS_TEST_SLICE", ","); len(v) != 3 || v[2] != "c" {
+		t.Errorf("EnvStrSlice get %v", v)
+	}
+
+	err := CheckRequiredEnv([]string{"LIBTOOLS_TEST_INT", "LIBTOOLS_TEST_MISSING1", "LIBTOOLS_TEST_BLANK"})
+	if err == nil || err.Error() != "required env is not set: LIBTOOLS_TEST_MISSING1, LIBTOOLS_TEST_BLANK" {
+		t.Errorf("CheckRequiredEnv get unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ErrorReportOptions SetupErrorReport 的参数
+type ErrorReportOptions struct {
+	// DSN Sentry 格式的地址, 如 https://public_key@sentry.example.com/42, 兼容 Sentry 协议的服务均可使用
+	DSN         string
+	Environment string
+	Release     string
+	// SampleRate ReportError 的采样率, 取值 (0, 1], 默认 1; panic 不采样, 总是上报
+	SampleRate float64
+	// BatchSize 累计多少条立即发送, 默认 20; FlushInterval 最长等待时间, 默认 5 秒
+	BatchSize     int
+	FlushInterval time.Duration
+	// QueueSize 待发送事件的上限, 超出时丢弃新事件, 默认 1000
+	QueueSize int
+}
+
+type errorReporter struct {
+	opts       ErrorReportOptions
+	storeURL   string
+	authHeader string
+	serverName string
+
+	mu      sync.Mutex
+	pending []*sentryEvent
+	notify  chan struct{}
+	stop    chan struct{}
+}
+
+var (
+	errorReporterMu sync.RWMutex
+	reporter        *errorReporter
+)
+
+// SetupErrorReport 配置错误上报并启动后台发送, 重复调用时替换之前的配置; DSN 为空时关闭上报
+func SetupErrorReport(opts ErrorReportOptions) error {
+	var r *errorReporter
+	if opts.DSN != "" {
+		storeURL, authHeader, err := parseSentryDSN(opts.DSN)
+		if err != nil {
+			return err
+		}
+		if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+			opts.SampleRate = 1
+		}
+		if opts.BatchSize <= 0 {
+			opts.BatchSize = 20
+		}
+		if opts.FlushInterval <= 0 {
+			opts.FlushInterval = 5 * time.Second
+		}
+		if opts.QueueSize <= 0 {
+			opts.QueueSize = 1000
+		}
+
+		r = &errorReporter{
+			opts:       opts,
+			storeURL:   storeURL,
+			authHeader: authHeader,
+			serverName: Hostname(),
+			notify:     make(chan struct{}, 1),
+			stop:       make(chan struct{}),
+		}
+		go r.loop()
+	}
+
+	errorReporterMu.Lock()
+	old := reporter
+	reporter = r
+	errorReporterMu.Unlock()
+
+	if old != nil {
+		close(old.stop)
+	}
+	return nil
+}
+
+// parseSentryDSN 返回 store 接口地址与鉴权 header
+func parseSentryDSN(dsn string) (storeURL, authHeader string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid dsn: %v", err)
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || idx < 0 || idx == len(path)-1 {
+		return "", "", fmt.Errorf("invalid dsn, expect scheme://public_key@host/project_id")
+	}
+
+	storeURL = fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:idx], path[idx+1:])
+	authHeader = fmt.Sprintf("Sentry sentry_version=7, sentry_client=libtools/1.0, sentry_key=%s", u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		authHeader += ", sentry_secret=" + secret
+	}
+
+	return storeURL, authHeader, nil
+}
+
+func getErrorReporter() *errorReporter {
+	errorReporterMu.RLock()
+	defer errorReporterMu.RUnlock()
+	return reporter
+}
+
+// ReportError 异步上报错误, 未调用 SetupErrorReport 时不做任何事
+// 错误信息与 tags 会先经过 ScrubPII 脱敏; ctx 中的请求 ID 作为 request_id 标签, 便于关联日志
+func ReportError(ctx context.Context, err error, tags map[string]string) {
+	r := getErrorReporter()
+	if r == nil || err == nil {
+		return
+	}
+	if r.opts.SampleRate < 1 && rand.Float64() >= r.opts.SampleRate {
+		return
+	}
+
+	r.enqueue(r.newEvent(ctx, "error", reflect.TypeOf(err).String(), err.Error(), tags, 3))
+}
+
+// FlushErrorReport 立即发送待上报的事件, 一般在进程退出前调用
+func FlushErrorReport(ctx context.Context) {
+	if r := getErrorReporter(); r != nil {
+		r.flush(ctx)
+	}
+}
+
+// reportPanic 记录 panic 日志并上报, 需在 recover 所在的 defer 函数中直接调用, 以便取到 panic 时的调用栈
+func reportPanic(ctx context.Context, source string, p interface{}, tags map[string]string) {
+	logs.Error("[%s] panic: %v, stack: %s", source, p, debug.Stack())
+
+	r := getErrorReporter()
+	if r == nil {
+		return
+	}
+	if tags == nil {
+		tags = make(map[string]string, 1)
+	}
+	tags["panic.source"] = source
+
+	r.enqueue(r.newEvent(ctx, "fatal", "panic", fmt.Sprint(p), tags, 4))
+}
+
+// SafeGo 启动 goroutine, panic 时记录日志并上报, 不会导致进程退出
+func SafeGo(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				reportPanic(ctx, "SafeGo", p, nil)
+			}
+		}()
+
+		fn(ctx)
+	}()
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+func (r *errorReporter) newEvent(ctx context.Context, level, errType, message string, tags map[string]string, skip int) *sentryEvent {
+	event := &sentryEvent{
+		EventID:     GetGuid(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "libtools",
+		ServerName:  r.serverName,
+		Environment: r.opts.Environment,
+		Release:     r.opts.Release,
+		Tags:        make(map[string]string, len(tags)+1),
+	}
+	for k, v := range tags {
+		event.Tags[k] = ScrubPII(v)
+	}
+	if requestID := RequestIDFrom(ctx); requestID != "" {
+		event.Tags["request_id"] = requestID
+	}
+
+	exception := sentryException{Type: errType, Value: ScrubPII(message)}
+	exception.Stacktrace.Frames = sentryStackFrames(skip)
+	event.Exception.Values = []sentryException{exception}
+
+	return event
+}
+
+// sentryStackFrames Sentry 要求调用栈从最外层开始
+func sentryStackFrames(skip int) []sentryFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var result []sentryFrame
+	for {
+		frame, more := frames.Next()
+		result = append(result, sentryFrame{
+			Function: frame.Function,
+			Filename: frame.File,
+			Lineno:   frame.Line,
+			InApp:    !strings.HasPrefix(frame.Function, "runtime.") && !strings.HasPrefix(frame.Function, "net/http."),
+		})
+		if !more {
+			break
+		}
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+func (r *errorReporter) enqueue(event *sentryEvent) {
+	r.mu.Lock()
+	if len(r.pending) >= r.opts.QueueSize {
+		r.mu.Unlock()
+		logs.Warning("[ReportError] queue is full, drop event: %s", event.EventID)
+		return
+	}
+	r.pending = append(r.pending, event)
+	full := len(r.pending) >= r.opts.BatchSize
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (r *errorReporter) loop() {
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.notify:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		r.flush(ctx)
+		cancel()
+	}
+}
+
+func (r *errorReporter) flush(ctx context.Context) {
+	r.mu.Lock()
+	events := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	reqs := make([]HttpReq, 0, len(events))
+	for _, event := range events {
+		reqs = append(reqs, HttpReq{
+			Method:      HttpMethodPOST,
+			URL:         r.storeURL,
+			Headers:     map[string]string{"X-Sentry-Auth": r.authHeader},
+			ContentType: HttpApplicationJSON,
+			Body:        event,
+			Options:     HttpRequestOptions{Timeout: 10 * time.Second},
+		})
+	}
+
+	// 上报失败只记录日志, 不重试, 避免错误风暴时拖垮服务
+	for i, result := range HttpBatch(ctx, reqs, 4) {
+		if result.Err != nil || result.StatusCode >= 300 {
+			logs.Warning("[ReportError] send event fail, event_id: %s, status: %d, err: %v", events[i].EventID, result.StatusCode, result.Err)
+		}
+	}
+}
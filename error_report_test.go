@@ -0,0 +1,81 @@
+package libtools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScrubPII(t *testing.T) {
+	cases := map[string]string{
+		"user john.doe@mail.com mobile 081234567890":  "user j***@mail.com mobile 081*****7890",
+		"card 4111111111111111 nik 3174012345678901":  "card 4111********1111 nik 3174********8901",
+		"GET /pay?token=abc123&amount=1 password: x1": "GET /pay?token=***&amount=1 password: ***",
+		"Authorization: Bearer eyJhbGciOi.xx.yy":      "Authorization: Bearer ***",
+		"call +6281234567890 at 20240101":             "call +628******7890 at 20240101",
+	}
+	for in, want := range cases {
+		if got := ScrubPII(in); got != want {
+			t.Errorf("ScrubPII(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var mu sync.Mutex
+	var events []map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event map[string]interface{}
+		_ = json.Unmarshal(body, &event)
+
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("X-Sentry-Auth")
+		if r.URL.Path == "/api/42/store/" {
+			events = append(events, event)
+		}
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://pubkey@", 1) + "/42"
+	if err := SetupErrorReport(ErrorReportOptions{DSN: dsn, FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = SetupErrorReport(ErrorReportOptions{}) }()
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ReportError(ctx, errors.New("notify user a@b.com fail"), map[string]string{"mobile": "081234567890"})
+
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("recovery status: %d", w.Code)
+	}
+
+	FlushErrorReport(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || !strings.Contains(auth, "sentry_key=pubkey") {
+		t.Fatalf("events: %d, auth: %q", len(events), auth)
+	}
+
+	buf, _ := json.Marshal(events)
+	for _, want := range []string{`"request_id":"req-1"`, `"mobile":"081*****7890"`, `a***@b.com`, `"level":"fatal"`, `"http.path":"/orders"`} {
+		if !strings.Contains(string(buf), want) {
+			t.Errorf("events missing %s: %s", want, buf)
+		}
+	}
+}
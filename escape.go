@@ -0,0 +1,72 @@
+package libtools
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// 拼接模板片段时按输出位置选择编码函数:
+// 标签内容用 Escape, 属性值用 EscapeHTMLAttr, <script> 中的字符串用 EscapeJSString, url 参数用 EscapeURLParam
+
+func escapeIsAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// EscapeHTMLAttr 编码 html 属性值, 除字母数字与非 ASCII 字符外全部编码为 &#xHH;, 属性值没有引号时同样安全
+func EscapeHTMLAttr(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case escapeIsAlnum(r) || (r >= 0x80 && !unicode.IsControl(r)):
+			b.WriteRune(r)
+		case r == ',' || r == '.' || r == '-' || r == '_':
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "&#x%02X;", r)
+		}
+	}
+
+	return b.String()
+}
+
+// EscapeJSString 编码 js 字符串字面量的内容, 单双引号包裹均可, 结果中不含 < > 引号等, 可安全放在 <script> 中
+func EscapeJSString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case escapeIsAlnum(r) || r == ',' || r == '.' || r == '_' || r == ' ':
+			b.WriteRune(r)
+		case r < 0x80:
+			fmt.Fprintf(&b, `\x%02X`, r)
+		case r == 0x2028 || r == 0x2029 || unicode.IsControl(r):
+			// 行分隔符在 js 字符串中会被当作换行
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// EscapeURLParam 编码 url 的查询参数值
+func EscapeURLParam(s string) string {
+	return url.QueryEscape(s)
+}
+
+// StripControlChars 去掉控制字符、零宽字符与双向文本控制符(可伪造显示顺序), 保留 \t \n \r
+func StripControlChars(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case unicode.IsControl(r):
+			return -1
+		case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069, r == 0xFEFF:
+			return -1
+		}
+		return r
+	}, s)
+}
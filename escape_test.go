@@ -0,0 +1,26 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	if got := EscapeHTMLAttr(`x" onmouseover='alert(1)'`); got != "x&#x22;&#x20;onmouseover&#x3D;&#x27;alert&#x28;1&#x29;&#x27;" {
+		t.Errorf("EscapeHTMLAttr = %s", got)
+	}
+	if got := EscapeHTMLAttr("张三-01"); got != "张三-01" {
+		t.Errorf("EscapeHTMLAttr should keep unicode, got %s", got)
+	}
+	if got := EscapeJSString(`</script><script>alert('x')`); got != `\x3C\x2Fscript\x3E\x3Cscript\x3Ealert\x28\x27x\x27\x29` {
+		t.Errorf("EscapeJSString = %s", got)
+	}
+	if got := EscapeJSString("a\u2028b"); got != `a\u2028b` {
+		t.Errorf("EscapeJSString line separator = %s", got)
+	}
+	if got := EscapeURLParam("a b&c=中"); got != "a+b%26c%3D%E4%B8%AD" {
+		t.Errorf("EscapeURLParam = %s", got)
+	}
+	if got := StripControlChars("ab\x00c\u202ed\u200be\n"); got != "abcde\n" {
+		t.Errorf("StripControlChars = %q", got)
+	}
+}
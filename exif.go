@@ -0,0 +1,123 @@
+package libtools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// ErrNoEXIF 图片中没有 EXIF 信息, 截图、经过压缩或编辑的图片通常没有
+var ErrNoEXIF = errors.New("image has no exif")
+
+// EXIFInfo 风控关心的照片元数据, 缺失的字段为零值
+type EXIFInfo struct {
+	// CaptureTime 拍摄时间(DateTimeOriginal), 毫秒, 没有时为 0; 有 OffsetTimeOriginal 时按其时区解析, 否则按服务器本地时区解析
+	// 不使用 DateTime, 它是文件的修改时间, 经过编辑软件保存后会变化
+	CaptureTime int64 `json:"capture_time"`
+	// HasGPS 为 true 时 Latitude/Longitude 有效, WGS-84 坐标
+	HasGPS    bool    `json:"has_gps"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Make      string  `json:"make"`
+	Model     string  `json:"model"`
+	// Orientation 1~8, 0 表示未知; 6/8 表示图片需要旋转 90 度显示
+	Orientation int `json:"orientation"`
+}
+
+// DistanceMeters 拍摄位置到 (lat, lng) 的距离, 用于比对用户填写的位置; 没有 GPS 时返回 -1
+func (e *EXIFInfo) DistanceMeters(lat, lng float64) float64 {
+	if !e.HasGPS {
+		return -1
+	}
+	return LatLngDistanceMeters(e.Latitude, e.Longitude, lat, lng)
+}
+
+// ExtractEXIF 解析 jpeg/tiff 中的 EXIF, 取拍摄时间、GPS、设备型号与方向; 没有 EXIF 时返回 ErrNoEXIF
+// 个别字段损坏时忽略该字段, 不影响其他字段
+func ExtractEXIF(buf []byte) (*EXIFInfo, error) {
+	x, err := exif.Decode(bytes.NewReader(buf))
+	if err != nil && (x == nil || exif.IsCriticalError(err)) {
+		// jpeg 中找不到 APP1 时读到 EOF, APP1 是 XMP 等其他数据时没有 Exif 标记
+		if err == io.EOF || err == io.ErrUnexpectedEOF || strings.Contains(err.Error(), "exif intro marker") {
+			return nil, ErrNoEXIF
+		}
+		return nil, fmt.Errorf("could not decode exif: %v", err)
+	}
+
+	info := &EXIFInfo{
+		Make:  exifString(x, exif.Make),
+		Model: exifString(x, exif.Model),
+	}
+
+	if s := exifString(x, exif.DateTimeOriginal); s != "" {
+		if offset := exifOffsetTimeOriginal(x); offset != "" {
+			if t, parseErr := time.Parse("2006:01:02 15:04:05-07:00", s+offset); parseErr == nil {
+				info.CaptureTime = t.UnixMilli()
+			}
+		}
+		if info.CaptureTime == 0 {
+			info.CaptureTime = Str2TimeByLayout("2006:01:02 15:04:05", s)
+		}
+	}
+
+	if lat, lng, gpsErr := x.LatLong(); gpsErr == nil && IsValidLatLng(lat, lng) {
+		info.HasGPS, info.Latitude, info.Longitude = true, lat, lng
+	}
+
+	if tag, tagErr := x.Get(exif.Orientation); tagErr == nil && tag.Format() == tiff.IntVal {
+		if v, intErr := tag.Int(0); intErr == nil && v >= 1 && v <= 8 {
+			info.Orientation = v
+		}
+	}
+
+	return info, nil
+}
+
+// exifOffsetTimeOriginal 读取 EXIF 子 IFD 中的 OffsetTimeOriginal(0x9011, 如 +07:00), goexif 的字段表不包含该字段
+func exifOffsetTimeOriginal(x *exif.Exif) string {
+	tag, err := x.Get(exif.ExifIFDPointer)
+	if err != nil {
+		return ""
+	}
+	offset, err := tag.Int64(0)
+	if err != nil {
+		return ""
+	}
+
+	r := bytes.NewReader(x.Raw)
+	if _, err = r.Seek(offset, io.SeekStart); err != nil {
+		return ""
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		return ""
+	}
+	for _, t := range dir.Tags {
+		if t.Id == 0x9011 {
+			if s, strErr := t.StringVal(); strErr == nil {
+				return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+			}
+		}
+	}
+
+	return ""
+}
+
+func exifString(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	s, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	// 部分设备的字符串以多个 \0 或空格补齐
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
@@ -0,0 +1,155 @@
+package libtools
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+type exifEntryT struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+func exifASCIIT(tag uint16, s string) exifEntryT {
+	return exifEntryT{tag: tag, typ: 2, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func exifLongT(tag uint16, v uint32) exifEntryT {
+	return exifEntryT{tag: tag, typ: 4, count: 1, data: appendBE32T(nil, v)}
+}
+
+func exifRationalsT(tag uint16, values ...uint32) exifEntryT {
+	var data []byte
+	for i := 0; i < len(values); i += 2 {
+		data = appendBE32T(appendBE32T(data, values[i]), values[i+1])
+	}
+	return exifEntryT{tag: tag, typ: 5, count: uint32(len(values) / 2), data: data}
+}
+
+func appendBE16T(b []byte, v uint16) []byte { return append(b, byte(v>>8), byte(v)) }
+
+func appendBE32T(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// exifIFDT 生成大端序的 IFD, offset 为 IFD 在 TIFF 数据中的位置, 超过 4 字节的值放在 IFD 之后
+func exifIFDT(entries []exifEntryT, offset uint32) []byte {
+	var ifd, extra []byte
+	extraOffset := offset + 2 + uint32(len(entries))*12 + 4
+	ifd = appendBE16T(ifd, uint16(len(entries)))
+	for _, e := range entries {
+		ifd = appendBE16T(ifd, e.tag)
+		ifd = appendBE16T(ifd, e.typ)
+		ifd = appendBE32T(ifd, e.count)
+		if len(e.data) <= 4 {
+			ifd = append(ifd, append(e.data, make([]byte, 4-len(e.data))...)...)
+			continue
+		}
+		ifd = appendBE32T(ifd, extraOffset+uint32(len(extra)))
+		extra = append(extra, e.data...)
+	}
+	ifd = appendBE32T(ifd, 0)
+	return append(ifd, extra...)
+}
+
+// jpegWithEXIFT 生成带 EXIF 的 jpeg: 设备 Xiaomi/Redmi Note 12, 方向 6, 拍摄于 2024:03:15 10:20:30, GPS 6°10'30"S 106°49'30"E
+func jpegWithEXIFT(t *testing.T) []byte {
+	t.Helper()
+	return jpegWithEXIFEntriesT(t, nil, []exifEntryT{exifASCIIT(0x9003, "2024:03:15 10:20:30")})
+}
+
+// jpegWithEXIFEntriesT ifd0Extra 追加到 IFD0, exifEntries 为 EXIF 子 IFD 的全部内容
+func jpegWithEXIFEntriesT(t *testing.T, ifd0Extra, exifEntries []exifEntryT) []byte {
+	t.Helper()
+	ifd0 := func(exifOffset, gpsOffset uint32) []exifEntryT {
+		orientation := exifEntryT{tag: 0x0112, typ: 3, count: 1, data: []byte{0, 6}}
+		entries := []exifEntryT{
+			exifASCIIT(0x010F, "Xiaomi"), exifASCIIT(0x0110, "Redmi Note 12"), orientation,
+			exifLongT(0x8769, exifOffset), exifLongT(0x8825, gpsOffset),
+		}
+		return append(entries, ifd0Extra...)
+	}
+	exifOffset := 8 + uint32(len(exifIFDT(ifd0(0, 0), 8)))
+	exifIFD := exifIFDT(exifEntries, exifOffset)
+	gpsOffset := exifOffset + uint32(len(exifIFD))
+	gpsIFD := exifIFDT([]exifEntryT{
+		exifASCIIT(0x0001, "S"), exifRationalsT(0x0002, 6, 1, 10, 1, 30, 1),
+		exifASCIIT(0x0003, "E"), exifRationalsT(0x0004, 106, 1, 49, 1, 3000, 100),
+	}, gpsOffset)
+
+	tiffData := append([]byte("MM\x00\x2a\x00\x00\x00\x08"), exifIFDT(ifd0(exifOffset, gpsOffset), 8)...)
+	tiffData = append(append(tiffData, exifIFD...), gpsIFD...)
+
+	app1 := append([]byte("Exif\x00\x00"), tiffData...)
+	app1 = append([]byte{0xFF, 0xE1, byte((len(app1) + 2) >> 8), byte(len(app1) + 2)}, app1...)
+
+	return append(append([]byte{0xFF, 0xD8}, app1...), plainJPEGT(t)[2:]...)
+}
+
+func plainJPEGT(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractEXIF(t *testing.T) {
+	info, err := ExtractEXIF(jpegWithEXIFT(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Make != "Xiaomi" || info.Model != "Redmi Note 12" || info.Orientation != 6 {
+		t.Fatalf("unexpected device info: %+v", info)
+	}
+	want := time.Date(2024, 3, 15, 10, 20, 30, 0, time.Local).UnixMilli()
+	if info.CaptureTime != want {
+		t.Fatalf("capture time: got %d, want %d", info.CaptureTime, want)
+	}
+	if !info.HasGPS || math.Abs(info.Latitude+6.175) > 1e-9 || math.Abs(info.Longitude-106.825) > 1e-9 {
+		t.Fatalf("unexpected gps: %+v", info)
+	}
+	if d := info.DistanceMeters(-6.175, 106.835); d < 1000 || d > 1200 {
+		t.Fatalf("unexpected distance: %f", d)
+	}
+
+	if _, err = ExtractEXIF(plainJPEGT(t)); !errors.Is(err, ErrNoEXIF) {
+		t.Fatalf("expect no exif, got: %v", err)
+	}
+	if _, err = ExtractEXIF(pngBytesT(t, 8, 8)); !errors.Is(err, ErrNoEXIF) {
+		t.Fatalf("png expect no exif, got: %v", err)
+	}
+
+	noGPS := &EXIFInfo{}
+	if noGPS.DistanceMeters(1, 1) != -1 {
+		t.Fatal("distance without gps should be -1")
+	}
+}
+
+func TestExtractEXIFCaptureTime(t *testing.T) {
+	// 带时区偏移时按拍摄地时区解析
+	buf := jpegWithEXIFEntriesT(t, nil, []exifEntryT{
+		exifASCIIT(0x9003, "2024:03:15 10:20:30"), exifASCIIT(0x9011, "+07:00"),
+	})
+	info, err := ExtractEXIF(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 15, 3, 20, 30, 0, time.UTC).UnixMilli(); info.CaptureTime != want {
+		t.Errorf("capture time with offset: got %d, want %d", info.CaptureTime, want)
+	}
+
+	// 只有 DateTime(文件修改时间, 编辑软件会改写)时不作为拍摄时间
+	buf = jpegWithEXIFEntriesT(t, []exifEntryT{exifASCIIT(0x0132, "2024:03:16 08:00:00")}, []exifEntryT{exifASCIIT(0x9011, "+07:00")})
+	if info, err = ExtractEXIF(buf); err != nil || info.CaptureTime != 0 {
+		t.Errorf("DateTime should not be used as capture time: %+v, %v", info, err)
+	}
+}
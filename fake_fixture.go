@@ -0,0 +1,107 @@
+package libtools
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本文件的函数只用于生成测试与 QA 造数用的号码, 生成的号码能通过本库的校验, 但不对应真实的卡、手机号或身份证,
+// 不要在生产代码中使用
+
+// fakeIntn [0, n) 的随机数
+func fakeIntn(n int) int {
+	v, err := secureIntn(int64(n))
+	if err != nil {
+		return GenerateRandom(0, n)
+	}
+	return int(v)
+}
+
+func fakeDigits(intn func(int) int, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(byte('0' + intn(10)))
+	}
+	return b.String()
+}
+
+// FakeCardNumber 仅用于测试: 生成以 binPrefix 开头、通过 Luhn 校验的 16 位卡号(前缀不短于 16 位时为 19 位)
+// binPrefix 为空时从内置 BIN 表中随机选择, BankFromCard 可以识别出发卡行
+func FakeCardNumber(binPrefix string) string {
+	return fakeCardNumber(fakeIntn, binPrefix)
+}
+
+func fakeCardNumber(intn func(int) int, binPrefix string) string {
+	if binPrefix == "" {
+		bankBINMu.RLock()
+		bins := make([]string, 0, len(bankBINs))
+		for bin := range bankBINs {
+			bins = append(bins, bin)
+		}
+		bankBINMu.RUnlock()
+		// map 遍历顺序随机, 排序后 Faker 才能得到稳定的结果
+		sort.Strings(bins)
+		if len(bins) > 0 {
+			binPrefix = bins[intn(len(bins))]
+		}
+	}
+
+	length := 16
+	if len(binPrefix) >= length {
+		length = 19
+	}
+	if len(binPrefix) >= length {
+		binPrefix = binPrefix[:length-1]
+	}
+
+	number := binPrefix + fakeDigits(intn, length-1-len(binPrefix))
+	return number + strconv.Itoa(LuhnCheckDigit(number))
+}
+
+// FakePhone 仅用于测试: 生成手机号, region 为 CN(默认, 通过 VerifyMobile)或 ID(印尼, 通过 IsValidIndonesiaMobile)
+func FakePhone(region string) string {
+	return fakePhone(fakeIntn, region)
+}
+
+func fakePhone(intn func(int) int, region string) string {
+	switch strings.ToUpper(region) {
+	case "ID":
+		// 081x 开头, 共 11-13 位
+		return "081" + strconv.Itoa(1+intn(9)) + fakeDigits(intn, 7+intn(3))
+	default:
+		return "1" + strconv.Itoa(3+intn(7)) + fakeDigits(intn, 9)
+	}
+}
+
+// FakeIDCard 仅用于测试: 生成 18 位居民身份证号, 通过 VerifyIDCard
+// province 为省级行政区划代码的前两位, 如 "11"(北京), 为空时使用 11; birthday 为 YYYY-MM-DD 或 YYYYMMDD, 为空或无效时随机;
+// gender 为 1 男 2 女, 其他值随机
+func FakeIDCard(province, birthday string, gender int) string {
+	return fakeIDCard(fakeIntn, province, birthday, gender)
+}
+
+func fakeIDCard(intn func(int) int, province, birthday string, gender int) string {
+	if len(province) != 2 || !IsNumber(province) {
+		province = "11"
+	}
+
+	birth, err := time.Parse("20060102", strings.Replace(birthday, "-", "", -1))
+	if err != nil {
+		birth = time.Date(1970+intn(30), time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, intn(365))
+	}
+
+	// 顺序码的最后一位奇数为男, 偶数为女
+	sexDigit := intn(5) * 2
+	switch gender {
+	case 1:
+		sexDigit++
+	case 2:
+	default:
+		sexDigit += intn(2)
+	}
+
+	id := province + "0101" + birth.Format("20060102") + fakeDigits(intn, 2) + strconv.Itoa(sexDigit)
+	return id + string(idCardCheckDigit(id))
+}
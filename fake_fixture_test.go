@@ -0,0 +1,55 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFakeCardNumber(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		card := FakeCardNumber("")
+		if len(card) != 16 || !LuhnValid(card) {
+			t.Fatalf("invalid card: %s", card)
+		}
+		if info := BankFromCard(card); !info.Valid || info.BankCode == "" {
+			t.Fatalf("bank not found: %s", card)
+		}
+
+		card = FakeCardNumber("622848")
+		if !strings.HasPrefix(card, "622848") || !LuhnValid(card) {
+			t.Fatalf("invalid card: %s", card)
+		}
+	}
+}
+
+func TestFakePhone(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		if phone := FakePhone("CN"); !VerifyMobile(phone) {
+			t.Fatalf("invalid cn phone: %s", phone)
+		}
+		if phone := FakePhone("id"); !fakeIsIndonesiaMobile(phone) {
+			t.Fatalf("invalid id phone: %s", phone)
+		}
+	}
+}
+
+func TestFakeIDCard(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		id := FakeIDCard("44", "1990-02-28", 2)
+		if !VerifyIDCard(id) || id[:2] != "44" || id[6:14] != "19900228" || (id[16]-'0')%2 != 0 {
+			t.Fatalf("invalid id card: %s", id)
+		}
+		if id = FakeIDCard("", "", 1); !VerifyIDCard(id) || (id[16]-'0')%2 != 1 {
+			t.Fatalf("invalid id card: %s", id)
+		}
+	}
+
+	if !VerifyIDCard("11010519491231002x") || VerifyIDCard("110105194912310021") || VerifyIDCard("110105194913310021") {
+		t.Fatal("VerifyIDCard fail")
+	}
+}
+
+func fakeIsIndonesiaMobile(phone string) bool {
+	yes, _ := IsValidIndonesiaMobile(phone)
+	return yes
+}
@@ -0,0 +1,198 @@
+package libtools
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Faker 支持的语言, 未知语言按 FakerLocaleCN 处理
+const (
+	FakerLocaleCN = "zh_CN"
+	FakerLocaleEN = "en_US"
+	FakerLocaleID = "id_ID"
+)
+
+type fakerLocaleData struct {
+	lastNames  []string
+	firstNames []string
+	cities     []string
+	streets    []string
+	companies  []string // 公司名称后缀
+	nameFormat func(first, last string) string
+	address    func(f *Faker, city, street string) string
+	company    func(name, suffix string) string
+}
+
+var fakerLocales = map[string]fakerLocaleData{
+	FakerLocaleCN: {
+		lastNames:  []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭", "何", "林", "罗", "高"},
+		firstNames: []string{"伟", "芳", "娜", "敏", "静", "丽", "强", "磊", "军", "洋", "勇", "艳", "杰", "娟", "涛", "明", "超", "秀英", "子轩", "欣怡", "浩然", "梓涵"},
+		cities:     []string{"北京市", "上海市", "广州市", "深圳市", "杭州市", "成都市", "武汉市", "南京市", "西安市", "重庆市"},
+		streets:    []string{"人民路", "解放路", "中山路", "建设路", "和平路", "新华路", "文化路", "长江路", "胜利路", "青年路"},
+		companies:  []string{"科技有限公司", "贸易有限公司", "网络科技有限公司", "信息技术有限公司", "实业有限公司"},
+		nameFormat: func(first, last string) string { return last + first },
+		address: func(f *Faker, city, street string) string {
+			return fmt.Sprintf("%s%s%d号", city, street, 1+f.Intn(300))
+		},
+		company: func(name, suffix string) string { return name + suffix },
+	},
+	FakerLocaleEN: {
+		lastNames:  []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Miller", "Davis", "Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Martin", "Clark", "Lewis"},
+		firstNames: []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth", "David", "Susan", "Daniel", "Emma", "Olivia"},
+		cities:     []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix", "Seattle", "Boston", "Denver", "Austin", "Portland"},
+		streets:    []string{"Main St", "Oak Ave", "Maple Dr", "Park Ave", "Pine St", "Cedar Ln", "Elm St", "Lake Rd", "Hill St", "Washington Blvd"},
+		companies:  []string{"Inc", "LLC", "Group", "Holdings", "Corp"},
+		nameFormat: func(first, last string) string { return first + " " + last },
+		address: func(f *Faker, city, street string) string {
+			return fmt.Sprintf("%d %s, %s", 1+f.Intn(9999), street, city)
+		},
+		company: func(name, suffix string) string { return name + " " + suffix },
+	},
+	FakerLocaleID: {
+		lastNames:  []string{"Saputra", "Wijaya", "Santoso", "Hidayat", "Kurniawan", "Pratama", "Setiawan", "Nugroho", "Siregar", "Halim", "Gunawan", "Susanto"},
+		firstNames: []string{"Budi", "Siti", "Agus", "Dewi", "Andi", "Sri", "Rizky", "Putri", "Eko", "Ayu", "Fajar", "Indah", "Dimas", "Nur"},
+		cities:     []string{"Jakarta", "Surabaya", "Bandung", "Medan", "Semarang", "Makassar", "Palembang", "Denpasar", "Yogyakarta", "Bekasi"},
+		streets:    []string{"Jl. Sudirman", "Jl. Thamrin", "Jl. Gatot Subroto", "Jl. Diponegoro", "Jl. Merdeka", "Jl. Pahlawan", "Jl. Ahmad Yani", "Jl. Gajah Mada"},
+		companies:  []string{"PT", "CV"},
+		nameFormat: func(first, last string) string { return first + " " + last },
+		address: func(f *Faker, city, street string) string {
+			return fmt.Sprintf("%s No. %d, %s", street, 1+f.Intn(200), city)
+		},
+		company: func(name, suffix string) string { return suffix + " " + name },
+	},
+}
+
+var fakerEmailDomains = []string{"example.com", "example.net", "example.org"}
+
+// Faker 按种子生成的假数据, 相同的种子与调用顺序得到相同的结果, 用于压测请求体、测试造数
+// 邮箱只使用 example.* 域名, 手机号见 FakePhone 的规则; 可以在多个 goroutine 中共用
+type Faker struct {
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	locale string
+	data   fakerLocaleData
+}
+
+// NewFaker 创建 Faker, locale 为 FakerLocaleCN、FakerLocaleEN 或 FakerLocaleID
+func NewFaker(seed int64, locale string) *Faker {
+	data, ok := fakerLocales[locale]
+	if !ok {
+		locale = FakerLocaleCN
+		data = fakerLocales[locale]
+	}
+
+	return &Faker{rnd: rand.New(rand.NewSource(seed)), locale: locale, data: data}
+}
+
+// Locale 当前使用的语言
+func (f *Faker) Locale() string {
+	return f.locale
+}
+
+// Intn [0, n) 的随机整数, n <= 0 时返回 0
+func (f *Faker) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Intn(n)
+}
+
+// Int64Between [min, max] 的随机整数
+func (f *Faker) Int64Between(min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return min + f.rnd.Int63n(max-min+1)
+}
+
+func (f *Faker) pick(list []string) string {
+	return list[f.Intn(len(list))]
+}
+
+func (f *Faker) digits(n int) string {
+	return fakeDigits(f.Intn, n)
+}
+
+// FirstName 名
+func (f *Faker) FirstName() string {
+	return f.pick(f.data.firstNames)
+}
+
+// LastName 姓
+func (f *Faker) LastName() string {
+	return f.pick(f.data.lastNames)
+}
+
+// Name 姓名, 按语言习惯排列姓与名
+func (f *Faker) Name() string {
+	first := f.FirstName()
+	return f.data.nameFormat(first, f.LastName())
+}
+
+// City 城市
+func (f *Faker) City() string {
+	return f.pick(f.data.cities)
+}
+
+// Address 街道地址
+func (f *Faker) Address() string {
+	city := f.City()
+	return f.data.address(f, city, f.pick(f.data.streets))
+}
+
+// Company 公司名称
+func (f *Faker) Company() string {
+	var name string
+	if f.locale == FakerLocaleCN {
+		name = strings.TrimSuffix(f.City(), "市") + f.pick(f.data.firstNames) + f.pick(f.data.firstNames)
+	} else {
+		name = f.LastName()
+	}
+
+	return f.data.company(name, f.pick(f.data.companies))
+}
+
+// Email 邮箱, 本地部分为 ascii 字母数字
+func (f *Faker) Email() string {
+	var local string
+	if f.locale == FakerLocaleCN {
+		local = "user" + f.digits(6)
+	} else {
+		local = strings.ToLower(f.FirstName()+"."+f.LastName()) + strconv.Itoa(f.Intn(100))
+	}
+
+	return local + "@" + f.pick(fakerEmailDomains)
+}
+
+// Phone 手机号, 规则与 FakePhone 相同
+func (f *Faker) Phone() string {
+	if f.locale == FakerLocaleID {
+		return fakePhone(f.Intn, "ID")
+	}
+
+	return fakePhone(f.Intn, "CN")
+}
+
+// CardNumber 银行卡号, 规则与 FakeCardNumber 相同
+func (f *Faker) CardNumber(binPrefix string) string {
+	return fakeCardNumber(f.Intn, binPrefix)
+}
+
+// IDCard 身份证号, 参数与规则同 FakeIDCard
+func (f *Faker) IDCard(province, birthday string, gender int) string {
+	return fakeIDCard(f.Intn, province, birthday, gender)
+}
+
+// Amount [min, max] 的金额, 单位为分
+func (f *Faker) Amount(min, max int64) int64 {
+	return f.Int64Between(min, max)
+}
@@ -0,0 +1,41 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFakerDeterministic(t *testing.T) {
+	for _, locale := range []string{FakerLocaleCN, FakerLocaleEN, FakerLocaleID} {
+		a, b := NewFaker(42, locale), NewFaker(42, locale)
+		for i := 0; i < 20; i++ {
+			va := []string{a.Name(), a.Address(), a.Company(), a.Email(), a.Phone()}
+			vb := []string{b.Name(), b.Address(), b.Company(), b.Email(), b.Phone()}
+			if strings.Join(va, "|") != strings.Join(vb, "|") {
+				t.Fatalf("not deterministic, locale: %s, %v != %v", locale, va, vb)
+			}
+			if !VerifyEmail(va[3]) {
+				t.Fatalf("invalid email: %s", va[3])
+			}
+		}
+	}
+
+	if NewFaker(1, FakerLocaleCN).Name() == "" || NewFaker(1, "xx").Locale() != FakerLocaleCN {
+		t.Fatal("unknown locale should fallback to zh_CN")
+	}
+}
+
+func TestFakerAmount(t *testing.T) {
+	f := NewFaker(7, FakerLocaleEN)
+	for i := 0; i < 100; i++ {
+		if v := f.Amount(100, 200); v < 100 || v > 200 {
+			t.Fatalf("amount out of range: %d", v)
+		}
+	}
+	if f.Amount(5, 5) != 5 {
+		t.Fatal("amount should be min when max <= min")
+	}
+	if phone := NewFaker(3, FakerLocaleCN).Phone(); !VerifyMobile(phone) {
+		t.Fatalf("invalid phone: %s", phone)
+	}
+}
@@ -1,15 +1,18 @@
 package libtools
 
 import (
+	"bytes"
+	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/h2non/filetype"
@@ -19,23 +22,21 @@ const fileChunk = 8192 // we settle for 8KB
 
 // BuildFileHashName 创建本地文件的hash名
 func BuildFileHashName(localFile string) (hashDir, hashName, fileMd5 string, err error) {
+	return BuildFileHashNameCtx(context.Background(), localFile)
+}
+
+// BuildFileHashNameCtx 同 BuildFileHashName, 流式计算 md5, ctx 取消时中断并返回 ctx.Err(), 用于很大的文件
+func BuildFileHashNameCtx(ctx context.Context, localFile string) (hashDir, hashName, fileMd5 string, err error) {
 	file, err := os.Open(localFile)
 	if err != nil {
 		return
 	}
 	defer file.Close()
 
-	// calculate the file size
-	info, _ := file.Stat()
-	filesize := info.Size()
-	blocks := uint64(math.Ceil(float64(filesize) / float64(fileChunk)))
 	hash := md5.New()
-	for i := uint64(0); i < blocks; i++ {
-		blocksize := int(math.Min(fileChunk, float64(filesize-int64(i*fileChunk))))
-		buf := make([]byte, blocksize)
-
-		_, _ = file.Read(buf)
-		_, _ = io.WriteString(hash, string(buf)) // append into the hash
+	buf := make([]byte, fileChunk)
+	if _, err = io.CopyBuffer(hash, ctxReader{ctx: ctx, r: file}, buf); err != nil {
+		return
 	}
 
 	fileMd5 = fmt.Sprintf("%x", hash.Sum(nil)) // 文件md5值
@@ -47,6 +48,19 @@ func BuildFileHashName(localFile string) (hashDir, hashName, fileMd5 string, err
 	return
 }
 
+// ctxReader 每次读取前检查 ctx 是否已取消
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
 // 为app端上传文件生成hash文件名
 func BuildUploadFileHashName(buf []byte, suffix string) (hashDir, hashName, fileMd5 string) {
 	fileMd5 = Md5Bytes(buf)
@@ -163,6 +177,10 @@ func GitRevParseHead() string {
 }
 
 func FileDownload(fileName, url string) (realFileName string, err error) {
+	_, span := StartSpan(context.Background(), "FileDownload")
+	span.SetAttribute("url.full", httpSpanURL(url))
+	defer func() { endSpan(span, err) }()
+
 	realFileName = fmt.Sprintf("/tmp/%s", fileName)
 	res, err := http.Get(url)
 	if err != nil {
@@ -184,6 +202,110 @@ func FileDownload(fileName, url string) (realFileName string, err error) {
 	return
 }
 
+// SafeFileDownload 下载用户提交的 url, 拒绝指向内网/回环/metadata 等地址的请求
+func SafeFileDownload(fileName, url string, policy SSRFPolicy) (realFileName string, err error) {
+	return FileDownloadWithOptions(fileName, url, FileDownloadOptions{SSRF: &policy})
+}
+
+// FileDownloadOptions 下载限制,零值表示不限制
+type FileDownloadOptions struct {
+	// SSRF 不为 nil 时校验目标地址
+	SSRF *SSRFPolicy
+	// AllowedTypes 允许的文件类型,按文件头识别(DetectFileByteType),
+	// 可以写扩展名(pdf)、MIME(image/jpeg)或 MIME 大类(image/*)
+	AllowedTypes []string
+	// MaxBytes 文件大小上限,超过立即中断下载并删除已写入的部分
+	MaxBytes int64
+	// Timeout 为 0 时默认 10 分钟
+	Timeout time.Duration
+}
+
+func (o FileDownloadOptions) isAllowedType(extension, mime string) bool {
+	return isAllowedFileType(o.AllowedTypes, extension, mime)
+}
+
+// FileDownloadWithOptions 带类型白名单与大小限制的下载, 文件保存在 /tmp 下
+func FileDownloadWithOptions(fileName, url string, opts FileDownloadOptions) (realFileName string, err error) {
+	_, span := StartSpan(context.Background(), "FileDownloadWithOptions")
+	span.SetAttribute("url.full", httpSpanURL(url))
+	defer func() { endSpan(span, err) }()
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if opts.SSRF != nil {
+		_, err = SafeHTTPTarget(url, *opts.SSRF)
+		if err != nil {
+			logs.Warning("[FileDownloadWithOptions] url is rejected, url: %s, err: %v", url, err)
+			return
+		}
+		client = NewSSRFSafeClient(*opts.SSRF, timeout)
+	}
+
+	res, err := client.Get(url)
+	if err != nil {
+		logs.Error("[FileDownloadWithOptions] Get file failed, err:", err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("download %s get unexpected status code: %d", url, res.StatusCode)
+		return
+	}
+
+	if opts.MaxBytes > 0 && res.ContentLength > opts.MaxBytes {
+		err = fmt.Errorf("download %s exceeds size limit, content-length: %d, limit: %d", url, res.ContentLength, opts.MaxBytes)
+		return
+	}
+
+	// 先读文件头识别类型,不合规的文件不落盘
+	head := make([]byte, 512)
+	n, err := io.ReadFull(res.Body, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		logs.Error("[FileDownloadWithOptions] read file head failed, err:", err)
+		return
+	}
+	err = nil
+	head = head[:n]
+
+	if len(opts.AllowedTypes) > 0 {
+		extension, mime, _ := DetectFileByteType(head)
+		if !opts.isAllowedType(extension, mime) {
+			err = fmt.Errorf("download %s get not allowed file type, extension: %s, mime: %s", url, extension, mime)
+			return
+		}
+	}
+
+	realFileName = fmt.Sprintf("/tmp/%s", filepath.Base(fileName))
+	f, err := os.Create(realFileName)
+	if err != nil {
+		logs.Error("[FileDownloadWithOptions] Create file failed, err:", err)
+		return
+	}
+
+	var reader io.Reader = io.MultiReader(bytes.NewReader(head), res.Body)
+	if opts.MaxBytes > 0 {
+		// 多读 1 字节用于判断是否超限
+		reader = io.LimitReader(reader, opts.MaxBytes+1)
+	}
+
+	written, err := io.Copy(f, reader)
+	_ = f.Close()
+	if err == nil && opts.MaxBytes > 0 && written > opts.MaxBytes {
+		err = fmt.Errorf("download %s exceeds size limit: %d", url, opts.MaxBytes)
+	}
+	if err != nil {
+		_ = os.Remove(realFileName)
+		realFileName = ""
+	}
+
+	return
+}
+
 func GetFileContentType(out multipart.File) (string, error) {
 	// 只需要前 512 个字节就可以了
 	buffer := make([]byte, 512)
@@ -212,25 +334,52 @@ func GetFileType(out multipart.File) (string, error) {
 	return t.MIME.Value, err
 }
 
+// GetFileExtension 按文件头识别上传文件的扩展名, 识别为 zip(xlsx 等 office 文件也是 zip)或识别不了时取文件名后缀
+// jpeg 返回 "jpeg" 与旧版本一致; 读取后 f 回到文件开头
 func GetFileExtension(f multipart.File, h *multipart.FileHeader) (string, error) {
-	contentType := ""
-	fileContentType, err := GetFileContentType(f)
-	switch fileContentType {
-	case "image/jpeg":
-		contentType = "jpeg"
-	case "image/png":
-		contentType = "png"
-	case "image/gif":
-		contentType = "gif"
-	case "application/octet-stream":
-		sArr := strings.Split(h.Filename, ".")
-		contentType = sArr[len(sArr)-1]
-	case "application/zip":
-		sArr := strings.Split(h.Filename, ".")
-		contentType = sArr[len(sArr)-1]
-	case "application/pdf":
-		contentType = "pdf"
-	}
-
-	return contentType, err
+	buf := make([]byte, 512)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	extension, mime := DetectUploadType(buf[:n])
+	switch {
+	case mime == "image/jpeg":
+		return "jpeg", nil
+	case extension == "" || extension == "zip":
+		return strings.ToLower(GetFileExt(h.Filename)), nil
+	}
+
+	return extension, nil
+}
+
+// DirSize 统计目录下所有普通文件的大小之和(字节), 不跟随符号链接; 遍历中途被删除的文件忽略
+func DirSize(dir string) (int64, error) {
+	return DirSizeContext(context.Background(), dir)
+}
+
+// DirSizeContext 与 DirSize 相同, ctx 取消时停止遍历并返回 ctx.Err()
+func DirSizeContext(ctx context.Context, dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if os.IsNotExist(err) && path != dir {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
 }
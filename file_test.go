@@ -0,0 +1,86 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestBuildFileHashName(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 3000)
+	path := testutil.WriteTempFileT(t, "big.bin", data)
+
+	hashDir, hashName, fileMd5, err := BuildFileHashName(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileMd5 != Md5Bytes(data) || !strings.HasSuffix(hashName, fileMd5+".bin") || !strings.HasPrefix(hashName, hashDir+"/") {
+		t.Fatalf("unexpected result: %s %s %s", hashDir, hashName, fileMd5)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err = BuildFileHashNameCtx(ctx, path); err != context.Canceled {
+		t.Fatalf("expect context.Canceled, got: %v", err)
+	}
+}
+
+func TestFileDownloadWithOptions(t *testing.T) {
+	png := pngBytesT(t, 64, 64)
+	pdf := []byte("%PDF-1.4\n" + strings.Repeat("0", 2048))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png":
+			_, _ = w.Write(png)
+		case "/a.pdf":
+			_, _ = w.Write(pdf)
+		case "/chunked.pdf":
+			// 分块写出, 没有 Content-Length, 只能边下载边判断大小
+			_, _ = w.Write(pdf[:1024])
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(pdf[1024:])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fileName := "libtools-download-" + Md5Bytes([]byte(t.Name()))
+	opts := FileDownloadOptions{AllowedTypes: []string{"image/*"}, MaxBytes: 1024}
+
+	realFileName, err := FileDownloadWithOptions(fileName, srv.URL+"/a.png", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(realFileName)
+	if saved, _ := os.ReadFile(realFileName); !bytes.Equal(saved, png) {
+		t.Fatal("downloaded file content mismatch")
+	}
+
+	opts.MaxBytes = 0
+	if _, err = FileDownloadWithOptions(fileName, srv.URL+"/a.pdf", opts); err == nil {
+		t.Error("pdf should be rejected by image/* allowlist")
+	}
+
+	opts.AllowedTypes = []string{"pdf"}
+	opts.MaxBytes = 1024
+	for _, path := range []string{"/a.pdf", "/chunked.pdf"} {
+		realFileName, err = FileDownloadWithOptions(fileName, srv.URL+path, opts)
+		if err == nil || realFileName != "" {
+			t.Errorf("%s should exceed size limit, file: %s, err: %v", path, realFileName, err)
+		}
+	}
+	if _, statErr := os.Stat("/tmp/" + fileName); !os.IsNotExist(statErr) {
+		t.Errorf("partial download should be removed, stat err: %v", statErr)
+	}
+
+	if _, err = FileDownloadWithOptions(fileName, srv.URL+"/missing", FileDownloadOptions{}); err == nil {
+		t.Error("non-200 response should fail")
+	}
+}
@@ -0,0 +1,202 @@
+package libtools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FillOptions FillStruct 的参数, 零值可用
+type FillOptions struct {
+	// Faker 为空时使用 NewFaker(1, FakerLocaleCN), 即每次调用得到相同的数据
+	Faker *Faker
+	// SliceLen slice 与 map 填充的元素个数, 默认 2
+	SliceLen int
+	// MaxDepth 嵌套结构体的最大深度, 用于截断自引用的类型, 默认 5
+	MaxDepth int
+	// Overwrite 为 false 时只填充零值字段, 已经赋值的字段保持不变
+	Overwrite bool
+}
+
+// fillStructKinds `fake:"xxx"` 支持的取值, "-" 表示跳过该字段
+var fillStructKinds = map[string]func(f *Faker) string{
+	"name":       (*Faker).Name,
+	"first_name": (*Faker).FirstName,
+	"last_name":  (*Faker).LastName,
+	"email":      (*Faker).Email,
+	"phone":      (*Faker).Phone,
+	"address":    (*Faker).Address,
+	"city":       (*Faker).City,
+	"company":    (*Faker).Company,
+	"card":       func(f *Faker) string { return f.CardNumber("") },
+	"id_card":    func(f *Faker) string { return f.IDCard("", "", 0) },
+}
+
+// FillStruct 按字段类型与 `fake:"xxx"` 标签为 ptr 指向的结构体填充测试数据, 递归处理嵌套结构体、指针、slice 与 map
+// 标签可选 name、first_name、last_name、email、phone、address、city、company、card、id_card、amount(单位为分)、-
+// 没有标签的字符串字段按字段名猜测(如 Email、Mobile), 猜不出时填充随机字母; 未导出的字段与 interface 字段不处理
+//
+//	type User struct {
+//		Name    string `fake:"name"`
+//		Balance int64  `fake:"amount"`
+//	}
+//	var u User
+//	_ = FillStruct(&u, FillOptions{})
+func FillStruct(ptr interface{}, opts FillOptions) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FillStruct need a non-nil pointer to struct, got %T", ptr)
+	}
+
+	if opts.Faker == nil {
+		opts.Faker = NewFaker(1, FakerLocaleCN)
+	}
+	if opts.SliceLen <= 0 {
+		opts.SliceLen = 2
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 5
+	}
+
+	return fillValue(rv.Elem(), "", "", opts, 0)
+}
+
+var fillTimeType = reflect.TypeOf(time.Time{})
+
+func fillValue(v reflect.Value, name, tag string, opts FillOptions, depth int) error {
+	if tag == "-" {
+		return nil
+	}
+	if !opts.Overwrite && !v.IsZero() {
+		// 已赋值的结构体与指针继续填充其中的零值字段
+		switch {
+		case v.Kind() == reflect.Struct && v.Type() != fillTimeType:
+		case v.Kind() == reflect.Ptr && depth < opts.MaxDepth:
+			return fillValue(v.Elem(), name, tag, opts, depth+1)
+		default:
+			return nil
+		}
+	}
+
+	f := opts.Faker
+	if tag != "" && tag != "amount" {
+		gen, ok := fillStructKinds[tag]
+		if !ok {
+			return fmt.Errorf("FillStruct unknown fake tag: %s, field: %s", tag, name)
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("FillStruct fake tag %s need string field, field: %s is %s", tag, name, v.Type())
+		}
+		v.SetString(gen(f))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fillGuessString(f, name))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		max := int64(1000)
+		if tag == "amount" {
+			max = 100000
+		}
+		if bits := v.Type().Bits(); bits < 64 && max > int64(1)<<(bits-1)-1 {
+			max = int64(1)<<(bits-1) - 1
+		}
+		v.SetInt(f.Int64Between(1, max))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := int64(1000)
+		if bits := v.Type().Bits(); bits < 16 {
+			max = 255
+		}
+		v.SetUint(uint64(f.Int64Between(1, max)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(f.Int64Between(1, 100000)) / 100)
+	case reflect.Bool:
+		v.SetBool(f.Intn(2) == 1)
+	case reflect.Ptr:
+		if depth >= opts.MaxDepth {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := fillValue(elem.Elem(), name, tag, opts, depth+1); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		if v.Type() == fillTimeType {
+			// 2020-01-01 之后一年内的随机时间
+			v.Set(reflect.ValueOf(time.Unix(1577836800+f.Int64Between(0, 365*86400), 0)))
+			return nil
+		}
+		if depth >= opts.MaxDepth {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if err := fillValue(v.Field(i), field.Name, field.Tag.Get("fake"), opts, depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if depth >= opts.MaxDepth {
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), opts.SliceLen, opts.SliceLen)
+		for i := 0; i < opts.SliceLen; i++ {
+			if err := fillValue(s.Index(i), name, tag, opts, depth+1); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		if depth >= opts.MaxDepth {
+			return nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), opts.SliceLen)
+		for i := 0; i < opts.SliceLen; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := fillValue(key, "", "", opts, depth+1); err != nil {
+				return err
+			}
+			if err := fillValue(val, name, tag, opts, depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	}
+
+	return nil
+}
+
+// fillGuessString 按字段名猜测字符串内容
+func fillGuessString(f *Faker, name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "email"):
+		return f.Email()
+	case strings.Contains(lower, "mobile") || strings.Contains(lower, "phone"):
+		return f.Phone()
+	case strings.Contains(lower, "company"):
+		return f.Company()
+	case strings.Contains(lower, "address"):
+		return f.Address()
+	case strings.Contains(lower, "city"):
+		return f.City()
+	case strings.HasSuffix(lower, "name") && !strings.Contains(lower, "file") && !strings.Contains(lower, "user"):
+		return f.Name()
+	}
+
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = letters[f.Intn(len(letters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,68 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+)
+
+type fillTestAddress struct {
+	City   string `fake:"city"`
+	Street string `fake:"address"`
+}
+
+type fillTestUser struct {
+	Name      string `fake:"name"`
+	Mobile    string
+	Email     string
+	IDCard    string `fake:"id_card"`
+	Balance   int64  `fake:"amount"`
+	Age       int8
+	Vip       bool
+	Score     float64
+	Skip      string `fake:"-"`
+	CreatedAt time.Time
+	Address   *fillTestAddress
+	Tags      []string
+	Extra     map[string]int
+	Parent    *fillTestUser
+
+	secret string
+}
+
+func TestFillStruct(t *testing.T) {
+	var u fillTestUser
+	u.Email = "keep@example.com"
+	if err := FillStruct(&u, FillOptions{MaxDepth: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Name == "" || !VerifyMobile(u.Mobile) || !VerifyIDCard(u.IDCard) || u.Balance <= 0 || u.Age <= 0 {
+		t.Fatalf("fill fail: %+v", u)
+	}
+	if u.Email != "keep@example.com" || u.Skip != "" || u.secret != "" {
+		t.Fatalf("should keep preset, skipped and unexported fields: %+v", u)
+	}
+	if u.CreatedAt.IsZero() || u.Address == nil || u.Address.City == "" || len(u.Tags) != 2 || len(u.Extra) == 0 {
+		t.Fatalf("nested fill fail: %+v", u)
+	}
+	if u.Parent == nil || u.Parent.Parent != nil {
+		t.Fatalf("MaxDepth not applied: %+v", u.Parent)
+	}
+
+	var again fillTestUser
+	again.Email = "keep@example.com"
+	_ = FillStruct(&again, FillOptions{MaxDepth: 3})
+	if again.Name != u.Name || again.IDCard != u.IDCard {
+		t.Fatal("default faker should be deterministic")
+	}
+
+	if err := FillStruct(u, FillOptions{}); err == nil {
+		t.Fatal("non-pointer should fail")
+	}
+	var bad struct {
+		N int `fake:"phone"`
+	}
+	if err := FillStruct(&bad, FillOptions{}); err == nil {
+		t.Fatal("tag on non-string field should fail")
+	}
+}
@@ -0,0 +1,241 @@
+package libtools
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 金额统一使用分(int64), 与数据库中 DecimalMoneyMul100 之后的存储方式一致; 利率使用小数, 如年化 12% 传 0.12
+
+// RepayPeriod 还款计划中的一期
+type RepayPeriod struct {
+	Period    int   `json:"period"`    // 期数, 从 1 开始
+	DueDate   int64 `json:"due_date"`  // 应还日期, 毫秒, 未指定起息日时为 0
+	Payment   int64 `json:"payment"`   // 当期应还总额
+	Principal int64 `json:"principal"` // 当期本金
+	Interest  int64 `json:"interest"`  // 当期利息
+	Remaining int64 `json:"remaining"` // 还款后剩余本金
+}
+
+// EqualInstallment 等额本息, 按月还款, startMillis 为起息日(可选), 第 i 期的应还日为起息日后第 i 个月的同一天(月末自动对齐)
+// 每期金额四舍五入到分, 舍入误差在最后一期补齐
+func EqualInstallment(principal int64, annualRate float64, periods int, startMillis ...int64) ([]RepayPeriod, error) {
+	if err := checkRepayArgs(principal, annualRate, periods); err != nil {
+		return nil, err
+	}
+
+	p := decimal.NewFromInt(principal)
+	r := decimal.NewFromFloat(annualRate).Div(decimal.NewFromInt(12))
+	n := decimal.NewFromInt(int64(periods))
+
+	var payment int64
+	if r.IsZero() {
+		payment = p.Div(n).Round(0).IntPart()
+	} else {
+		// P * r * (1+r)^n / ((1+r)^n - 1)
+		pow := decimal.NewFromInt(1).Add(r).Pow(n)
+		payment = p.Mul(r).Mul(pow).Div(pow.Sub(decimal.NewFromInt(1))).Round(0).IntPart()
+	}
+
+	schedule := make([]RepayPeriod, 0, periods)
+	remaining := principal
+	for i := 1; i <= periods; i++ {
+		interest := decimal.NewFromInt(remaining).Mul(r).Round(0).IntPart()
+		principalPart := payment - interest
+		if i == periods || principalPart > remaining {
+			principalPart = remaining
+		}
+		remaining -= principalPart
+
+		schedule = append(schedule, RepayPeriod{
+			Period:    i,
+			DueDate:   repayDueDate(i, startMillis...),
+			Payment:   principalPart + interest,
+			Principal: principalPart,
+			Interest:  interest,
+			Remaining: remaining,
+		})
+	}
+
+	return schedule, nil
+}
+
+// EqualPrincipal 等额本金, 按月还款, 每期本金相同(除不尽的部分计入最后一期), 利息按剩余本金逐期递减
+func EqualPrincipal(principal int64, annualRate float64, periods int, startMillis ...int64) ([]RepayPeriod, error) {
+	if err := checkRepayArgs(principal, annualRate, periods); err != nil {
+		return nil, err
+	}
+
+	r := decimal.NewFromFloat(annualRate).Div(decimal.NewFromInt(12))
+	perPrincipal := principal / int64(periods)
+
+	schedule := make([]RepayPeriod, 0, periods)
+	remaining := principal
+	for i := 1; i <= periods; i++ {
+		interest := decimal.NewFromInt(remaining).Mul(r).Round(0).IntPart()
+		principalPart := perPrincipal
+		if i == periods {
+			principalPart = remaining
+		}
+		remaining -= principalPart
+
+		schedule = append(schedule, RepayPeriod{
+			Period:    i,
+			DueDate:   repayDueDate(i, startMillis...),
+			Payment:   principalPart + interest,
+			Principal: principalPart,
+			Interest:  interest,
+			Remaining: remaining,
+		})
+	}
+
+	return schedule, nil
+}
+
+// DailyInterest 按日计息, dailyRate 为日利率(如万分之五传 0.0005), 结果四舍五入到分
+func DailyInterest(principal int64, dailyRate float64, days int) int64 {
+	if principal <= 0 || dailyRate <= 0 || days <= 0 {
+		return 0
+	}
+
+	return decimal.NewFromInt(principal).
+		Mul(decimal.NewFromFloat(dailyRate)).
+		Mul(decimal.NewFromInt(int64(days))).
+		Round(0).IntPart()
+}
+
+// IRR 内部收益率(每期), cashFlows[0] 一般为负数(放款), 之后为每期回款
+// 先用牛顿法迭代, 不收敛时退化为二分法
+func IRR(cashFlows []float64) (float64, error) {
+	if len(cashFlows) < 2 {
+		return 0, fmt.Errorf("IRR need at least 2 cash flows")
+	}
+
+	var hasPositive, hasNegative bool
+	for _, cf := range cashFlows {
+		if cf > 0 {
+			hasPositive = true
+		} else if cf < 0 {
+			hasNegative = true
+		}
+	}
+	if !hasPositive || !hasNegative {
+		return 0, fmt.Errorf("IRR need both positive and negative cash flows")
+	}
+
+	npv := func(rate float64) (value, derivative float64) {
+		for t, cf := range cashFlows {
+			d := math.Pow(1+rate, float64(t))
+			value += cf / d
+			derivative -= float64(t) * cf / (d * (1 + rate))
+		}
+		return
+	}
+
+	rate := 0.1
+	for i := 0; i < 100; i++ {
+		value, derivative := npv(rate)
+		if math.Abs(value) < 1e-9 {
+			return rate, nil
+		}
+		if derivative == 0 {
+			break
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < 1e-12 {
+			return next, nil
+		}
+		rate = next
+	}
+
+	low, high := -0.999999, 10.0
+	lowValue, _ := npv(low)
+	highValue, _ := npv(high)
+	if lowValue*highValue > 0 {
+		return 0, fmt.Errorf("IRR does not converge")
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		midValue, _ := npv(mid)
+		if math.Abs(midValue) < 1e-9 || high-low < 1e-12 {
+			return mid, nil
+		}
+		if midValue*lowValue < 0 {
+			high = mid
+		} else {
+			low, lowValue = mid, midValue
+		}
+	}
+
+	return (low + high) / 2, nil
+}
+
+// APR 名义年化利率, 即到手本金(扣除砍头息/服务费后的实际放款金额)与每期还款之间的 IRR * 每年期数
+// 如按月还款: APR(received, payments, 12)
+func APR(received int64, payments []int64, periodsPerYear int) (float64, error) {
+	if received <= 0 {
+		return 0, fmt.Errorf("received amount must be positive")
+	}
+	if periodsPerYear <= 0 {
+		return 0, fmt.Errorf("periods per year must be positive")
+	}
+
+	cashFlows := make([]float64, 0, len(payments)+1)
+	cashFlows = append(cashFlows, -float64(received))
+	for _, p := range payments {
+		cashFlows = append(cashFlows, float64(p))
+	}
+
+	irr, err := IRR(cashFlows)
+	if err != nil {
+		return 0, err
+	}
+
+	return irr * float64(periodsPerYear), nil
+}
+
+// RepayPayments 从还款计划中取出每期应还金额, 便于计算 APR
+func RepayPayments(schedule []RepayPeriod) []int64 {
+	payments := make([]int64, 0, len(schedule))
+	for _, p := range schedule {
+		payments = append(payments, p.Payment)
+	}
+
+	return payments
+}
+
+func checkRepayArgs(principal int64, annualRate float64, periods int) error {
+	if principal <= 0 {
+		return fmt.Errorf("principal must be positive")
+	}
+	if annualRate < 0 || math.IsNaN(annualRate) {
+		return fmt.Errorf("invalid annual rate: %v", annualRate)
+	}
+	if periods <= 0 {
+		return fmt.Errorf("periods must be positive")
+	}
+
+	return nil
+}
+
+// repayDueDate 起息日后第 n 个月的同一天, 目标月份没有这一天时取该月最后一天
+func repayDueDate(n int, startMillis ...int64) int64 {
+	if len(startMillis) == 0 || startMillis[0] <= 0 {
+		return 0
+	}
+
+	start := time.Unix(startMillis[0]/1000, (startMillis[0]%1000)*int64(time.Millisecond))
+	first := time.Date(start.Year(), start.Month()+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	day := start.Day()
+	if last := GetMonthLastDay(first); day > last {
+		day = last
+	}
+
+	return GetUnixMillisByTime(first.AddDate(0, 0, day-1))
+}
@@ -0,0 +1,41 @@
+package libtools
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEqualInstallment(t *testing.T) {
+	schedule, err := EqualInstallment(1000000, 0.12, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var totalPrincipal int64
+	for _, p := range schedule {
+		totalPrincipal += p.Principal
+	}
+	if totalPrincipal != 1000000 || schedule[11].Remaining != 0 {
+		t.Errorf("principal not fully repaid: %d, remaining: %d", totalPrincipal, schedule[11].Remaining)
+	}
+	if schedule[0].Payment != 88849 || schedule[0].Interest != 10000 {
+		t.Errorf("unexpected first period: %+v", schedule[0])
+	}
+
+	apr, err := APR(1000000, RepayPayments(schedule), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(apr-0.12) > 1e-4 {
+		t.Errorf("APR = %v, expect 0.12", apr)
+	}
+}
+
+func TestRepayDueDate(t *testing.T) {
+	start := GetUnixMillisByTime(time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local))
+	due := repayDueDate(1, start)
+	if got := time.Unix(due/1000, 0).Format("2006-01-02"); got != "2024-02-29" {
+		t.Errorf("due date = %s, expect 2024-02-29", got)
+	}
+}
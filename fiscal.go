@@ -0,0 +1,69 @@
+package libtools
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// fiscalYearStartMonth 默认的财年起始月份, 见 SetFiscalYearStartMonth
+var fiscalYearStartMonth int32 = 1
+
+// SetFiscalYearStartMonth 设置默认的财年起始月份(1-12), 如 4 表示财年从 4 月 1 日开始; 影响 SameFiscalPeriod
+func SetFiscalYearStartMonth(month int) {
+	if month < 1 || month > 12 {
+		return
+	}
+	atomic.StoreInt32(&fiscalYearStartMonth, int32(month))
+}
+
+// FiscalYearRange 返回 ts 所在财年的起止时间, 毫秒, 左闭右开; startMonth 不在 1-12 时使用 SetFiscalYearStartMonth 的设置
+// 如 startMonth 为 4 时, 2024-02-10 所在财年为 [2023-04-01, 2024-04-01)
+func FiscalYearRange(ts int64, startMonth int) (begin, end int64) {
+	b := fiscalYearStart(time.UnixMilli(ts).In(time.Local), startMonth)
+	return GetUnixMillisByTime(b), GetUnixMillisByTime(b.AddDate(1, 0, 0))
+}
+
+// SameFiscalPeriod a、b(毫秒)是否属于同一财年
+func SameFiscalPeriod(a, b int64) bool {
+	beginA, _ := FiscalYearRange(a, 0)
+	beginB, _ := FiscalYearRange(b, 0)
+	return beginA == beginB
+}
+
+func fiscalYearStart(t time.Time, startMonth int) time.Time {
+	if startMonth < 1 || startMonth > 12 {
+		startMonth = int(atomic.LoadInt32(&fiscalYearStartMonth))
+	}
+
+	year := t.Year()
+	if int(t.Month()) < startMonth {
+		year--
+	}
+	return time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, t.Location())
+}
+
+// PeriodRange 返回 ts 所在账期的起止时间, 毫秒, 左闭右开; 账期从每月 anchorDay 日 0 点开始, 如账单日为 25 日
+// 当月没有 anchorDay 这一天时取当月最后一天, 如 anchorDay 为 31 时 2 月的账期从 2 月 28/29 日开始
+func PeriodRange(ts int64, anchorDay int) (begin, end int64) {
+	if anchorDay < 1 {
+		anchorDay = 1
+	}
+
+	t := time.UnixMilli(ts).In(time.Local)
+	b := periodAnchor(t.Year(), t.Month(), anchorDay, t.Location())
+	if t.Before(b) {
+		b = periodAnchor(t.Year(), t.Month()-1, anchorDay, t.Location())
+	}
+	e := periodAnchor(b.Year(), b.Month()+1, anchorDay, t.Location())
+
+	return GetUnixMillisByTime(b), GetUnixMillisByTime(e)
+}
+
+// periodAnchor month 超出 1-12 时由 time.Date 自动进位到相邻年份
+func periodAnchor(year int, month time.Month, anchorDay int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if last := GetMonthLastDay(first); anchorDay > last {
+		anchorDay = last
+	}
+	return first.AddDate(0, 0, anchorDay-1)
+}
@@ -0,0 +1,45 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiscalAndPeriodRange(t *testing.T) {
+	at := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 12, 0, 0, 0, time.Local).UnixMilli()
+	}
+	day := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local).UnixMilli()
+	}
+
+	if b, e := FiscalYearRange(at(2024, 2, 10), 4); b != day(2023, 4, 1) || e != day(2024, 4, 1) {
+		t.Errorf("FiscalYearRange: %d, %d", b, e)
+	}
+	if b, e := FiscalYearRange(at(2024, 4, 1), 4); b != day(2024, 4, 1) || e != day(2025, 4, 1) {
+		t.Errorf("FiscalYearRange: %d, %d", b, e)
+	}
+
+	SetFiscalYearStartMonth(7)
+	defer SetFiscalYearStartMonth(1)
+	if !SameFiscalPeriod(at(2023, 7, 1), at(2024, 6, 30)) || SameFiscalPeriod(at(2024, 6, 30), at(2024, 7, 1)) {
+		t.Error("SameFiscalPeriod with start month 7")
+	}
+
+	cases := []struct {
+		ts         int64
+		anchor     int
+		begin, end int64
+	}{
+		{at(2024, 5, 26), 25, day(2024, 5, 25), day(2024, 6, 25)},
+		{at(2024, 5, 24), 25, day(2024, 4, 25), day(2024, 5, 25)},
+		{at(2024, 1, 10), 25, day(2023, 12, 25), day(2024, 1, 25)},
+		{at(2024, 3, 1), 31, day(2024, 2, 29), day(2024, 3, 31)},
+		{at(2024, 2, 29), 31, day(2024, 2, 29), day(2024, 3, 31)},
+	}
+	for _, c := range cases {
+		if b, e := PeriodRange(c.ts, c.anchor); b != c.begin || e != c.end {
+			t.Errorf("PeriodRange(%s, %d) = %s, %s", time.UnixMilli(c.ts), c.anchor, time.UnixMilli(b), time.UnixMilli(e))
+		}
+	}
+}
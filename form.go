@@ -0,0 +1,184 @@
+package libtools
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormTimeLayout EncodeForm 中 time.Time 的默认格式, 格式符同 UnixMsec2Date, 可通过 form:"name,layout=Y-m-d" 单独指定
+const FormTimeLayout = "Y-m-d H:i:s"
+
+var formTimeType = reflect.TypeOf(time.Time{})
+
+// EncodeFormValues 将结构体编码为表单, 使用 form 标签, 未设置时使用字段名:
+//   - 嵌套结构体与 map 编码为 parent[child]=v, 匿名嵌入的结构体字段平铺
+//   - 切片与数组编码为重复的 a[]=1&a[]=2
+//   - time.Time 按 layout 格式化, 实现了 encoding.TextMarshaler 的类型(如 decimal.Decimal)使用 MarshalText
+//   - omitempty 时跳过零值, nil 指针总是跳过
+func EncodeFormValues(v interface{}) (url.Values, error) {
+	values := url.Values{}
+	err := formEncodeStruct(values, "", reflect.ValueOf(v), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// EncodeForm 与 EncodeFormValues 相同, 返回 HttpRequest 表单模式使用的 map[string]string;
+// map 不能有重复的 key, 切片编码为 a[0]=1&a[1]=2, 服务端需要 a[]= 形式时使用 EncodeFormValues
+func EncodeForm(v interface{}) (map[string]string, error) {
+	values := url.Values{}
+	if err := formEncodeStruct(values, "", reflect.ValueOf(v), true); err != nil {
+		return nil, err
+	}
+
+	form := make(map[string]string, len(values))
+	for key, list := range values {
+		form[key] = list[0]
+	}
+
+	return form, nil
+}
+
+func formEncodeStruct(values url.Values, prefix string, rv reflect.Value, indexed bool) error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("form value must be a struct, get %s", rv.Kind())
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		omitempty, layout := false, FormTimeLayout
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == "omitempty":
+				omitempty = true
+			case strings.HasPrefix(opt, "layout="):
+				layout = strings.TrimPrefix(opt, "layout=")
+			}
+		}
+
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != formTimeType {
+				if err := formEncodeStruct(values, prefix, fv, indexed); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+		if omitempty && fv.IsZero() {
+			continue
+		}
+
+		if err := formEncodeValue(values, name, fv, layout, indexed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formEncodeValue(values url.Values, name string, fv reflect.Value, layout string, indexed bool) error {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Type() == formTimeType {
+		t := fv.Interface().(time.Time)
+		if !t.IsZero() {
+			values.Add(name, UnixMsec2Date(GetUnixMillisByTime(t), layout))
+		}
+		return nil
+	}
+	if marshaler, ok := fv.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return fmt.Errorf("could not encode form field %s: %v", name, err)
+		}
+		values.Add(name, string(text))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		values.Add(name, fv.String())
+	case reflect.Bool:
+		values.Add(name, strconv.FormatBool(fv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values.Add(name, strconv.FormatInt(fv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values.Add(name, strconv.FormatUint(fv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		// 不使用科学计数法
+		values.Add(name, strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()))
+	case reflect.Struct:
+		return formEncodeStruct(values, name, fv, indexed)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			key := name + "[]"
+			if indexed {
+				key = name + "[" + strconv.Itoa(i) + "]"
+			}
+			if err := formEncodeValue(values, key, fv.Index(i), layout, indexed); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form field %s: map key must be string", name)
+		}
+		// 排序保证输出稳定, 便于签名
+		keys := fv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if err := formEncodeValue(values, name+"["+k.String()+"]", fv.MapIndex(k), layout, indexed); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("form field %s: unsupported type %s", name, fv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEncodeForm(t *testing.T) {
+	type Address struct {
+		City string `form:"city"`
+		Zip  string `form:"zip,omitempty"`
+	}
+	type Base struct {
+		AppID string `form:"app_id"`
+	}
+	type Req struct {
+		Base
+		Name     string            `form:"name"`
+		Age      int               `form:"age,omitempty"`
+		Amount   decimal.Decimal   `form:"amount"`
+		Rate     float64           `form:"rate"`
+		Tags     []string          `form:"tags"`
+		Address  Address           `form:"address"`
+		Extra    map[string]string `form:"extra"`
+		Birthday time.Time         `form:"birthday,layout=Y-m-d"`
+		Remark   *string           `form:"remark"`
+		Secret   string            `form:"-"`
+	}
+
+	req := Req{
+		Base:     Base{AppID: "app1"},
+		Name:     "张三",
+		Amount:   decimal.RequireFromString("100.50"),
+		Rate:     0.00001,
+		Tags:     []string{"a", "b"},
+		Address:  Address{City: "sz"},
+		Extra:    map[string]string{"k": "v"},
+		Birthday: time.Date(1990, 5, 1, 12, 0, 0, 0, time.Local),
+		Secret:   "x",
+	}
+
+	values, err := EncodeFormValues(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "address%5Bcity%5D=sz&amount=100.5&app_id=app1&birthday=1990-05-01&extra%5Bk%5D=v&name=%E5%BC%A0%E4%B8%89&rate=0.00001&tags%5B%5D=a&tags%5B%5D=b"
+	if got := values.Encode(); got != want {
+		t.Errorf("EncodeFormValues get:\n%s\nwant:\n%s", got, want)
+	}
+
+	form, err := EncodeForm(req)
+	if err != nil || form["tags[1]"] != "b" || form["address[city]"] != "sz" || len(form) != 9 {
+		t.Errorf("EncodeForm get unexpected result: %v, err: %v", form, err)
+	}
+}
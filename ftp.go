@@ -0,0 +1,188 @@
+package libtools
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// FTP 的加密方式
+const (
+	FTPPlain       = iota // 明文, 只用于内网或对方不支持 TLS 的情况
+	FTPExplicitTLS        // FTPES, 连接后通过 AUTH TLS 升级, 默认端口 21
+	FTPImplicitTLS        // FTPS, 连接即为 TLS, 默认端口 990
+)
+
+// FTPConfig FTP/FTPS 连接参数, 数据连接总是使用被动模式(EPSV, 不支持时为 PASV)
+type FTPConfig struct {
+	Addr     string // host:port, 不带端口时按 TLSMode 默认 21 或 990
+	User     string
+	Password string
+
+	TLSMode int
+	// TLSConfig 为空时校验服务端证书, ServerName 取 Addr 中的 host
+	TLSConfig *tls.Config
+	// DisableEPSV 部分老旧服务端或 NAT 环境下 EPSV 不可用, 强制使用 PASV
+	DisableEPSV bool
+
+	Timeout time.Duration // 连接与读写的超时时间, 默认 30 秒
+}
+
+func ftpDial(cfg FTPConfig) (*ftp.ServerConn, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	addr := cfg.Addr
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		port := "21"
+		if cfg.TLSMode == FTPImplicitTLS {
+			port = "990"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+
+	opts := []ftp.DialOption{ftp.DialWithTimeout(timeout), ftp.DialWithDisabledEPSV(cfg.DisableEPSV)}
+	if cfg.TLSMode != FTPPlain {
+		tlsConfig := cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		}
+		switch cfg.TLSMode {
+		case FTPExplicitTLS:
+			opts = append(opts, ftp.DialWithExplicitTLS(tlsConfig))
+		case FTPImplicitTLS:
+			opts = append(opts, ftp.DialWithTLS(tlsConfig))
+		default:
+			return nil, fmt.Errorf("ftp: unknown tls mode: %d", cfg.TLSMode)
+		}
+	}
+
+	conn, err := ftp.Dial(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ftp dial %s fail: %v", addr, err)
+	}
+	if err = conn.Login(cfg.User, cfg.Password); err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("ftp login %s fail: %v", addr, err)
+	}
+
+	return conn, nil
+}
+
+// FTPPut 上传文件, 先写入 remotePath.part, 完成后重命名为 remotePath(已存在时覆盖), 对方不会读到不完整的文件
+func FTPPut(cfg FTPConfig, localPath, remotePath string) error {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	conn, err := ftpDial(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+
+	part := remotePath + transferPartSuffix
+	if err = conn.Stor(part, src); err != nil {
+		return fmt.Errorf("ftp upload %s fail: %v", remotePath, err)
+	}
+
+	// 部分服务端的 RNTO 不能覆盖已有文件, 先删除, 文件不存在的错误忽略
+	_ = conn.Delete(remotePath)
+	return conn.Rename(part, remotePath)
+}
+
+// FTPGet 下载文件, 先写入 localPath.part, 完成后重命名为 localPath
+func FTPGet(cfg FTPConfig, remotePath, localPath string) (err error) {
+	conn, err := ftpDial(cfg)
+	if err != nil {
+		return
+	}
+	defer conn.Quit()
+
+	resp, err := conn.Retr(remotePath)
+	if err != nil {
+		return fmt.Errorf("ftp download %s fail: %v", remotePath, err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		_ = resp.Close()
+		return
+	}
+	part := localPath + transferPartSuffix
+	dst, err := os.Create(part)
+	if err != nil {
+		_ = resp.Close()
+		return
+	}
+	_, err = io.Copy(dst, resp)
+	// Close 时读取服务端的传输结果, 传输不完整时返回错误
+	if closeErr := resp.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(part)
+		return fmt.Errorf("ftp download %s fail: %v", remotePath, err)
+	}
+
+	return os.Rename(part, localPath)
+}
+
+// FTPList 列出远端目录下的文件, 不包含 . 与 ..
+func FTPList(cfg FTPConfig, remoteDir string) ([]os.FileInfo, error) {
+	conn, err := ftpDial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Quit()
+
+	entries, err := conn.List(remoteDir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		list = append(list, ftpFileInfo{entry})
+	}
+
+	return list, nil
+}
+
+// ftpFileInfo 把 ftp.Entry 转为 os.FileInfo, 权限信息 FTP 不一定提供, 统一为 0644/0755
+type ftpFileInfo struct {
+	entry *ftp.Entry
+}
+
+func (f ftpFileInfo) Name() string       { return f.entry.Name }
+func (f ftpFileInfo) Size() int64        { return int64(f.entry.Size) }
+func (f ftpFileInfo) ModTime() time.Time { return f.entry.Time }
+func (f ftpFileInfo) IsDir() bool        { return f.entry.Type == ftp.EntryTypeFolder }
+func (f ftpFileInfo) Sys() interface{}   { return f.entry }
+
+func (f ftpFileInfo) Mode() os.FileMode {
+	switch f.entry.Type {
+	case ftp.EntryTypeFolder:
+		return os.ModeDir | 0755
+	case ftp.EntryTypeLink:
+		return os.ModeSymlink | 0777
+	}
+	return 0644
+}
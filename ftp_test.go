@@ -0,0 +1,243 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+// startFTPServerT 启动明文 FTP 服务, 只实现客户端用到的命令, 用户名密码为 user/pass, 文件位于 root 下
+func startFTPServerT(t *testing.T, root string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fakeFTPServe(conn, root)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func fakeFTPServe(conn net.Conn, root string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		_, _ = fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	local := func(p string) string { return filepath.Join(root, filepath.FromSlash(p)) }
+
+	var data net.Listener
+	defer func() {
+		if data != nil {
+			_ = data.Close()
+		}
+	}()
+	// transfer 等待客户端建立数据连接, 执行 fn 后关闭
+	transfer := func(fn func(c net.Conn) error) {
+		if data == nil {
+			reply("425 use EPSV first")
+			return
+		}
+		reply("150 opening data connection")
+		c, err := data.Accept()
+		_ = data.Close()
+		data = nil
+		if err == nil {
+			err = fn(c)
+			_ = c.Close()
+		}
+		if err != nil {
+			reply("451 %v", err)
+			return
+		}
+		reply("226 transfer complete")
+	}
+
+	user, renameFrom, loggedIn := "", "", false
+	reply("220 fake ftp ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg := strings.TrimRight(line, "\r\n"), ""
+		if i := strings.IndexByte(cmd, ' '); i > 0 {
+			cmd, arg = cmd[:i], cmd[i+1:]
+		}
+
+		switch {
+		case cmd == "USER":
+			user = arg
+			reply("331 password required")
+			continue
+		case cmd == "PASS":
+			loggedIn = user == "user" && arg == "pass"
+			if loggedIn {
+				reply("230 logged in")
+			} else {
+				reply("530 login incorrect")
+			}
+			continue
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		case !loggedIn:
+			reply("530 not logged in")
+			continue
+		}
+
+		switch cmd {
+		case "FEAT":
+			reply("502 not implemented")
+		case "TYPE", "OPTS":
+			reply("200 ok")
+		case "EPSV":
+			if data, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+				reply("425 %v", err)
+				continue
+			}
+			reply("229 entering extended passive mode (|||%d|)", data.Addr().(*net.TCPAddr).Port)
+		case "STOR":
+			transfer(func(c net.Conn) error {
+				f, err := os.Create(local(arg))
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				_, err = io.Copy(f, c)
+				return err
+			})
+		case "RETR":
+			if _, err := os.Stat(local(arg)); err != nil {
+				reply("550 %v", err)
+				continue
+			}
+			transfer(func(c net.Conn) error {
+				f, err := os.Open(local(arg))
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				_, err = io.Copy(c, f)
+				return err
+			})
+		case "LIST":
+			list, err := os.ReadDir(local(arg))
+			if err != nil {
+				reply("550 %v", err)
+				continue
+			}
+			transfer(func(c net.Conn) error {
+				for _, entry := range list {
+					info, _ := entry.Info()
+					perm := "-rw-r--r--"
+					if info.IsDir() {
+						perm = "drwxr-xr-x"
+					}
+					_, _ = fmt.Fprintf(c, "%s 1 ftp ftp %d %s %s\r\n", perm, info.Size(), info.ModTime().Format("Jan _2 2006"), info.Name())
+				}
+				return nil
+			})
+		case "DELE":
+			if err := os.Remove(local(arg)); err != nil {
+				reply("550 %v", err)
+				continue
+			}
+			reply("250 deleted")
+		case "RNFR":
+			renameFrom = arg
+			reply("350 ready for RNTO")
+		case "RNTO":
+			if _, err := os.Stat(local(arg)); err == nil {
+				reply("553 file exists")
+				continue
+			}
+			if err := os.Rename(local(renameFrom), local(arg)); err != nil {
+				reply("550 %v", err)
+				continue
+			}
+			reply("250 renamed")
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestFTP(t *testing.T) {
+	root := filepath.Join(testutil.TempDirT(t), "remote")
+	_ = os.MkdirAll(filepath.Join(root, "drop"), 0755)
+	cfg := FTPConfig{Addr: startFTPServerT(t, root), User: "user", Password: "pass"}
+
+	data := bytes.Repeat([]byte("order_id,amount\n10001,1250\n"), 5000)
+	localFile := testutil.WriteTempFileT(t, "export.csv", data)
+
+	// 目标文件已存在时覆盖
+	_ = os.WriteFile(filepath.Join(root, "drop", "export.csv"), []byte("old"), 0644)
+	if err := FTPPut(cfg, localFile, "/drop/export.csv"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(filepath.Join(root, "drop", "export.csv"))
+	if !bytes.Equal(got, data) {
+		t.Fatal("upload content mismatch")
+	}
+	if _, err := os.Stat(filepath.Join(root, "drop", "export.csv.part")); !os.IsNotExist(err) {
+		t.Fatal(".part should be renamed")
+	}
+
+	download := filepath.Join(testutil.TempDirT(t), "download", "export.csv")
+	if err := FTPGet(cfg, "/drop/export.csv", download); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = os.ReadFile(download); !bytes.Equal(got, data) {
+		t.Fatal("download content mismatch")
+	}
+	if err := FTPGet(cfg, "/drop/missing.csv", download); err == nil {
+		t.Fatal("download missing file should fail")
+	}
+
+	_ = os.MkdirAll(filepath.Join(root, "drop", "archive"), 0755)
+	list, err := FTPList(cfg, "/drop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("unexpected list: %v", list)
+	}
+	for _, info := range list {
+		switch info.Name() {
+		case "archive":
+			if !info.IsDir() || !info.Mode().IsDir() {
+				t.Fatal("archive should be a dir")
+			}
+		case "export.csv":
+			if info.IsDir() || info.Size() != int64(len(data)) {
+				t.Fatalf("unexpected file info: %v %d", info.IsDir(), info.Size())
+			}
+		default:
+			t.Fatalf("unexpected name: %s", info.Name())
+		}
+	}
+
+	cfg.Password = "wrong"
+	if _, err = FTPList(cfg, "/drop"); err == nil {
+		t.Fatal("login with wrong password should fail")
+	}
+}
@@ -50,3 +50,127 @@ func CaculateTimeZone(lon float64) string {
 	}
 	return fmt.Sprintf("%.1f", timeZone)
 }
+
+// IsValidLatLng 坐标是否合法, 定位失败时 SDK 常返回的 (0, 0) 也视为非法
+func IsValidLatLng(lat, lng float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0) {
+		return false
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return false
+	}
+
+	return !(lat == 0 && lng == 0)
+}
+
+// LatLngDistanceMeters 两点间的球面距离(haversine), 单位米; 距离很近时比 GetDistance 的反余弦算法更稳定
+func LatLngDistanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const radius = 6371000.0
+	rad := math.Pi / 180.0
+
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * radius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// 坐标系说明: WGS-84 为 GPS 原始坐标; GCJ-02 为国测局坐标(高德、腾讯、谷歌中国); BD-09 为百度坐标
+const (
+	gcjA  = 6378245.0
+	gcjEE = 0.00669342162296594323
+	bdXPi = math.Pi * 3000.0 / 180.0
+)
+
+// outOfChina 国外坐标不做偏移
+func outOfChina(lat, lng float64) bool {
+	return lng < 72.004 || lng > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+func gcjTransformLat(x, y float64) float64 {
+	ret := -100.0 + 2.0*x + 3.0*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(y*math.Pi) + 40.0*math.Sin(y/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (160.0*math.Sin(y/12.0*math.Pi) + 320*math.Sin(y*math.Pi/30.0)) * 2.0 / 3.0
+	return ret
+}
+
+func gcjTransformLng(x, y float64) float64 {
+	ret := 300.0 + x + 2.0*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(x*math.Pi) + 40.0*math.Sin(x/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (150.0*math.Sin(x/12.0*math.Pi) + 300.0*math.Sin(x/30.0*math.Pi)) * 2.0 / 3.0
+	return ret
+}
+
+func gcjOffset(lat, lng float64) (dLat, dLng float64) {
+	dLat = gcjTransformLat(lng-105.0, lat-35.0)
+	dLng = gcjTransformLng(lng-105.0, lat-35.0)
+
+	radLat := lat / 180.0 * math.Pi
+	magic := math.Sin(radLat)
+	magic = 1 - gcjEE*magic*magic
+	sqrtMagic := math.Sqrt(magic)
+
+	dLat = (dLat * 180.0) / ((gcjA * (1 - gcjEE)) / (magic * sqrtMagic) * math.Pi)
+	dLng = (dLng * 180.0) / (gcjA / sqrtMagic * math.Cos(radLat) * math.Pi)
+	return
+}
+
+// WGS84ToGCJ02 GPS 坐标转国测局坐标
+func WGS84ToGCJ02(lat, lng float64) (float64, float64) {
+	if outOfChina(lat, lng) {
+		return lat, lng
+	}
+
+	dLat, dLng := gcjOffset(lat, lng)
+	return lat + dLat, lng + dLng
+}
+
+// GCJ02ToWGS84 国测局坐标转 GPS 坐标, 迭代逼近, 误差在厘米级
+func GCJ02ToWGS84(lat, lng float64) (float64, float64) {
+	if outOfChina(lat, lng) {
+		return lat, lng
+	}
+
+	wgsLat, wgsLng := lat, lng
+	for i := 0; i < 10; i++ {
+		gLat, gLng := WGS84ToGCJ02(wgsLat, wgsLng)
+		dLat, dLng := gLat-lat, gLng-lng
+		wgsLat -= dLat
+		wgsLng -= dLng
+		if math.Abs(dLat) < 1e-9 && math.Abs(dLng) < 1e-9 {
+			break
+		}
+	}
+
+	return wgsLat, wgsLng
+}
+
+// GCJ02ToBD09 国测局坐标转百度坐标
+func GCJ02ToBD09(lat, lng float64) (float64, float64) {
+	z := math.Sqrt(lng*lng+lat*lat) + 0.00002*math.Sin(lat*bdXPi)
+	theta := math.Atan2(lat, lng) + 0.000003*math.Cos(lng*bdXPi)
+
+	return z*math.Sin(theta) + 0.006, z*math.Cos(theta) + 0.0065
+}
+
+// BD09ToGCJ02 百度坐标转国测局坐标
+func BD09ToGCJ02(lat, lng float64) (float64, float64) {
+	x := lng - 0.0065
+	y := lat - 0.006
+	z := math.Sqrt(x*x+y*y) - 0.00002*math.Sin(y*bdXPi)
+	theta := math.Atan2(y, x) - 0.000003*math.Cos(x*bdXPi)
+
+	return z * math.Sin(theta), z * math.Cos(theta)
+}
+
+// WGS84ToBD09 GPS 坐标转百度坐标
+func WGS84ToBD09(lat, lng float64) (float64, float64) {
+	return GCJ02ToBD09(WGS84ToGCJ02(lat, lng))
+}
+
+// BD09ToWGS84 百度坐标转 GPS 坐标
+func BD09ToWGS84(lat, lng float64) (float64, float64) {
+	return GCJ02ToWGS84(BD09ToGCJ02(lat, lng))
+}
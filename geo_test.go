@@ -0,0 +1,68 @@
+package libtools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsValidLatLng(t *testing.T) {
+	valid := [][2]float64{{39.915, 116.404}, {-6.2, 106.8}, {90, 180}}
+	for _, p := range valid {
+		if !IsValidLatLng(p[0], p[1]) {
+			t.Errorf("%v should be valid", p)
+		}
+	}
+
+	invalid := [][2]float64{{0, 0}, {91, 0}, {0, -181}, {math.NaN(), 1}, {1, math.Inf(1)}}
+	for _, p := range invalid {
+		if IsValidLatLng(p[0], p[1]) {
+			t.Errorf("%v should be invalid", p)
+		}
+	}
+}
+
+func TestLatLngDistanceMeters(t *testing.T) {
+	// 经线上 1 度约为 111195 米
+	if d := LatLngDistanceMeters(0, 100, 1, 100); math.Abs(d-111194.93) > 0.01 {
+		t.Errorf("one degree distance: %f", d)
+	}
+	if d := LatLngDistanceMeters(39.915, 116.404, 39.915, 116.404); d != 0 {
+		t.Errorf("same point distance should be 0, got: %f", d)
+	}
+
+	// 北京 - 上海, 与 GetDistance 结果一致
+	d := LatLngDistanceMeters(39.9042, 116.4074, 31.2304, 121.4737)
+	if math.Abs(d-GetDistance(39.9042, 116.4074, 31.2304, 121.4737)) > 1 || d < 1060000 || d > 1075000 {
+		t.Errorf("beijing to shanghai: %f", d)
+	}
+}
+
+func TestCoordinateConvert(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+	// 与常用的 coordtransform 实现结果一致
+	if lat, lng := WGS84ToGCJ02(39.915, 116.404); !near(lat, 39.91640428150164) || !near(lng, 116.41024449916938) {
+		t.Errorf("WGS84ToGCJ02: %v, %v", lat, lng)
+	}
+	if lat, lng := GCJ02ToBD09(39.915, 116.404); !near(lat, 39.92133699351021) || !near(lng, 116.41036949371029) {
+		t.Errorf("GCJ02ToBD09: %v, %v", lat, lng)
+	}
+	if lat, lng := BD09ToGCJ02(39.915, 116.404); !near(lat, 39.90865673957631) || !near(lng, 116.39762729119315) {
+		t.Errorf("BD09ToGCJ02: %v, %v", lat, lng)
+	}
+
+	// GCJ-02 反算误差在厘米级, BD-09 反算为近似公式, 误差在米级以内
+	lat, lng := GCJ02ToWGS84(WGS84ToGCJ02(31.2304, 121.4737))
+	if LatLngDistanceMeters(lat, lng, 31.2304, 121.4737) > 0.05 {
+		t.Errorf("gcj02 round trip: %v, %v", lat, lng)
+	}
+	lat, lng = BD09ToWGS84(WGS84ToBD09(31.2304, 121.4737))
+	if LatLngDistanceMeters(lat, lng, 31.2304, 121.4737) > 1 {
+		t.Errorf("bd09 round trip: %v, %v", lat, lng)
+	}
+
+	// 国外坐标不偏移
+	if lat, lng := WGS84ToGCJ02(-6.2, 106.8); lat != -6.2 || lng != 106.8 {
+		t.Errorf("out of china should not change: %v, %v", lat, lng)
+	}
+}
@@ -0,0 +1,173 @@
+package libtools
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcRequestIDKey gRPC metadata 的 key 必须小写
+var grpcRequestIDKey = strings.ToLower(RequestIDHeader)
+
+// GRPCDialOptions DialGRPC 的可选参数, 零值即为常用的默认配置
+type GRPCDialOptions struct {
+	// Insecure 为 true 时使用明文连接, 仅用于内网或本地调试
+	Insecure bool
+	// TLSConfig 为 nil 时使用系统根证书
+	TLSConfig *tls.Config
+
+	// KeepaliveTime 空闲多久发送一次 ping, 默认 30 秒; KeepaliveTimeout ping 的超时, 默认 10 秒
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// MaxRetries 失败后的重试次数, 默认 2 次, 小于 0 时不重试; gRPC 最多允许 4 次重试
+	MaxRetries int
+	// RetryCodes 可重试的状态码, 默认 UNAVAILABLE
+	RetryCodes []string
+
+	// Block 为 true 时等待连接就绪或 ctx 结束才返回
+	Block bool
+
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+	// ExtraOptions 追加的原生参数, 可覆盖以上配置
+	ExtraOptions []grpc.DialOption
+}
+
+// DialGRPC 按统一的默认配置建立 gRPC 连接: keepalive、对 UNAVAILABLE 自动重试、TLS, 并将 ctx 中的请求 ID 透传给下游
+//
+//	conn, err := DialGRPC(ctx, "dns:///user-service:9090", GRPCDialOptions{Insecure: true})
+func DialGRPC(ctx context.Context, target string, opts ...GRPCDialOptions) (*grpc.ClientConn, error) {
+	var opt GRPCDialOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if opt.KeepaliveTime <= 0 {
+		opt.KeepaliveTime = 30 * time.Second
+	}
+	if opt.KeepaliveTimeout <= 0 {
+		opt.KeepaliveTimeout = 10 * time.Second
+	}
+
+	serviceConfig, err := grpcServiceConfig(opt.MaxRetries, opt.RetryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds credentials.TransportCredentials
+	if opt.Insecure {
+		creds = insecure.NewCredentials()
+	} else {
+		creds = credentials.NewTLS(opt.TLSConfig)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opt.KeepaliveTime,
+			Timeout:             opt.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(append([]grpc.UnaryClientInterceptor{GRPCRequestIDUnaryClientInterceptor}, opt.UnaryInterceptors...)...),
+		grpc.WithChainStreamInterceptor(append([]grpc.StreamClientInterceptor{GRPCRequestIDStreamClientInterceptor}, opt.StreamInterceptors...)...),
+	}
+	if opt.Block {
+		dialOpts = append(dialOpts, grpc.WithBlock())
+	}
+	dialOpts = append(dialOpts, opt.ExtraOptions...)
+
+	conn, err := grpc.DialContext(ctx, target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial grpc target %s: %v", target, err)
+	}
+
+	return conn, nil
+}
+
+// grpcServiceConfig 生成对所有方法生效的重试策略
+func grpcServiceConfig(maxRetries int, retryCodes []string) (string, error) {
+	if maxRetries == 0 {
+		maxRetries = 2
+	}
+	if maxRetries < 0 {
+		return `{}`, nil
+	}
+	if maxRetries > 4 {
+		maxRetries = 4
+	}
+	if len(retryCodes) == 0 {
+		retryCodes = []string{"UNAVAILABLE"}
+	}
+
+	config := map[string]interface{}{
+		"methodConfig": []interface{}{
+			map[string]interface{}{
+				"name": []interface{}{map[string]interface{}{}},
+				"retryPolicy": map[string]interface{}{
+					"maxAttempts":          maxRetries + 1,
+					"initialBackoff":       "0.1s",
+					"maxBackoff":           "1s",
+					"backoffMultiplier":    2,
+					"retryableStatusCodes": retryCodes,
+				},
+			},
+		},
+	}
+
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal grpc service config: %v", err)
+	}
+
+	return string(buf), nil
+}
+
+// grpcOutgoingContext 将 ctx 中的请求 ID 写入 outgoing metadata, 已显式设置的不覆盖
+func grpcOutgoingContext(ctx context.Context) context.Context {
+	requestID := RequestIDFrom(ctx)
+	if requestID == "" {
+		return ctx
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(grpcRequestIDKey)) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, grpcRequestIDKey, requestID)
+}
+
+// GRPCRequestIDUnaryClientInterceptor 透传请求 ID, DialGRPC 默认已添加
+func GRPCRequestIDUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(grpcOutgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+// GRPCRequestIDStreamClientInterceptor 透传请求 ID, DialGRPC 默认已添加
+func GRPCRequestIDStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(grpcOutgoingContext(ctx), desc, cc, method, opts...)
+}
+
+// GRPCRequestIDUnaryServerInterceptor 服务端从 metadata 中取出请求 ID 放入 ctx, 没有或格式不合法(过长、含空白等)时生成新的
+func GRPCRequestIDUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(grpcRequestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if !isValidRequestID(requestID) {
+		requestID = GetGuid()
+	}
+
+	return handler(ContextWithRequestID(ctx, requestID), req)
+}
@@ -0,0 +1,121 @@
+package libtools
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcRecorderT 记录服务端收到的请求 ID, 前 failFirst 次调用返回 UNAVAILABLE
+type grpcRecorderT struct {
+	mu         sync.Mutex
+	failFirst  int
+	attempts   int
+	requestIDs []string
+}
+
+func (r *grpcRecorderT) intercept(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	r.mu.Lock()
+	r.attempts++
+	r.requestIDs = append(r.requestIDs, RequestIDFrom(ctx))
+	fail := r.attempts <= r.failFirst
+	r.mu.Unlock()
+	if fail {
+		return nil, status.Error(codes.Unavailable, "warming up")
+	}
+
+	return handler(ctx, req)
+}
+
+func startGRPCServerT(t *testing.T, recorder *grpcRecorderT) *bufconn.Listener {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(GRPCRequestIDUnaryServerInterceptor, recorder.intercept))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis
+}
+
+func dialBufconnT(t *testing.T, lis *bufconn.Listener, opt GRPCDialOptions) healthpb.HealthClient {
+	t.Helper()
+	opt.Insecure = true
+	opt.ExtraOptions = append(opt.ExtraOptions, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	conn, err := DialGRPC(context.Background(), "passthrough:///bufnet", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestDialGRPCRetryAndRequestID(t *testing.T) {
+	recorder := &grpcRecorderT{failFirst: 2}
+	client := dialBufconnT(t, startGRPCServerT(t, recorder), GRPCDialOptions{})
+
+	// 默认重试 2 次, 每次重试都带上同一个请求 ID
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.attempts != 3 || strings.Join(recorder.requestIDs, ",") != "req-1,req-1,req-1" {
+		t.Fatalf("unexpected attempts: %d, request ids: %v", recorder.attempts, recorder.requestIDs)
+	}
+
+	// 显式设置的 metadata 不被覆盖, 没有请求 ID 时服务端生成新的
+	ctx = metadata.AppendToOutgoingContext(ctx, grpcRequestIDKey, "explicit")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.requestIDs[3] != "explicit" || recorder.requestIDs[4] == "" {
+		t.Errorf("unexpected request ids: %v", recorder.requestIDs)
+	}
+
+	// 不合法的请求 ID(过长、含空格)不能透传到日志与下游, 服务端重新生成
+	for _, invalid := range []string{strings.Repeat("a", requestIDMaxLen+1), "bad id"} {
+		ctx = metadata.AppendToOutgoingContext(context.Background(), grpcRequestIDKey, invalid)
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := recorder.requestIDs[len(recorder.requestIDs)-1]; got == invalid || !isValidRequestID(got) {
+			t.Errorf("invalid request id %q should be replaced, got: %q", invalid, got)
+		}
+	}
+}
+
+func TestDialGRPCNoRetry(t *testing.T) {
+	recorder := &grpcRecorderT{failFirst: 1}
+	client := dialBufconnT(t, startGRPCServerT(t, recorder), GRPCDialOptions{MaxRetries: -1})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unavailable || recorder.attempts != 1 {
+		t.Errorf("MaxRetries < 0 should not retry, attempts: %d, err: %v", recorder.attempts, err)
+	}
+}
+
+func TestGRPCServiceConfig(t *testing.T) {
+	config, err := grpcServiceConfig(10, []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"})
+	if err != nil || !strings.Contains(config, `"maxAttempts":5`) || !strings.Contains(config, `"RESOURCE_EXHAUSTED"`) {
+		t.Errorf("unexpected config: %s, %v", config, err)
+	}
+	if config, _ = grpcServiceConfig(-1, nil); config != `{}` {
+		t.Errorf("negative retries should disable retry policy: %s", config)
+	}
+}
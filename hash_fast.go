@@ -0,0 +1,47 @@
+package libtools
+
+import (
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/xxh3"
+)
+
+// 非加密哈希, 速度远高于 md5 且不分配内存, 用于分片、缓存 key、去重等场景; 不能用于签名、密码等安全相关的场景
+
+// XXH64 xxHash64, 种子为 0
+func XXH64(data []byte) uint64 {
+	return xxhash.Sum64(data)
+}
+
+// XXH64String 字符串的 xxHash64, 不复制 s
+func XXH64String(s string) uint64 {
+	return xxhash.Sum64String(s)
+}
+
+// XXH3 64 位的 XXH3, 短数据上比 XXH64 更快
+func XXH3(data []byte) uint64 {
+	return xxh3.Hash(data)
+}
+
+// XXH3String 字符串的 64 位 XXH3, 不复制 s
+func XXH3String(s string) uint64 {
+	return xxh3.HashString(s)
+}
+
+// XXH3Hex XXH3String 的 16 位十六进制表示, 可直接用作缓存 key
+func XXH3Hex(s string) string {
+	h := strconv.FormatUint(XXH3String(s), 16)
+	for len(h) < 16 {
+		h = "0" + h
+	}
+	return h
+}
+
+// ShardIndex 按 XXH3String 把 key 均匀分配到 [0, n) 的分片, n <= 0 时返回 0
+func ShardIndex(key string, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(XXH3String(key) % uint64(n))
+}
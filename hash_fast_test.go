@@ -0,0 +1,29 @@
+package libtools
+
+import "testing"
+
+func TestXXHash(t *testing.T) {
+	// 官方测试向量
+	if XXH64(nil) != 0xef46db3751d8e999 || XXH64String("abc") != 0x44bc2cf5ad770999 {
+		t.Fatalf("XXH64 mismatch: %x %x", XXH64(nil), XXH64String("abc"))
+	}
+	if XXH3(nil) != 0x2d06800538d394c2 || XXH3String("abc") != XXH3([]byte("abc")) {
+		t.Fatalf("XXH3 mismatch: %x", XXH3(nil))
+	}
+	if h := XXH3Hex(""); h != "2d06800538d394c2" || len(XXH3Hex("abc")) != 16 {
+		t.Fatalf("XXH3Hex mismatch: %s", h)
+	}
+
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		counts[ShardIndex(Int2Str(i), 4)]++
+	}
+	for _, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Fatalf("uneven shards: %v", counts)
+		}
+	}
+	if ShardIndex("a", 0) != 0 {
+		t.Fatal("ShardIndex with n <= 0 should be 0")
+	}
+}
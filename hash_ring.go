@@ -0,0 +1,133 @@
+package libtools
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// HashRing 一致性哈希环, 增删节点时只有相邻区间的 key 会迁移
+// 如按 BuildFileHashName 得到的 fileMd5 选择存储节点: ring.Get(fileMd5)
+type HashRing struct {
+	mu       sync.RWMutex
+	replicas int
+	hashes   []uint32
+	owners   map[uint32]string
+	nodes    map[string]bool
+}
+
+// NewHashRing replicas 为每个节点的虚拟节点数, <= 0 时默认 160
+func NewHashRing(nodes []string, replicas int) *HashRing {
+	if replicas <= 0 {
+		replicas = 160
+	}
+
+	r := &HashRing{
+		replicas: replicas,
+		owners:   make(map[uint32]string),
+		nodes:    make(map[string]bool),
+	}
+	for _, node := range nodes {
+		r.add(node)
+	}
+	r.sort()
+
+	return r
+}
+
+func hashRingKey(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// Add 增加节点, 已存在时忽略
+func (r *HashRing) Add(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.add(node) {
+		r.sort()
+	}
+}
+
+// Remove 移除节点
+func (r *HashRing) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.nodes[node] {
+		return
+	}
+	delete(r.nodes, node)
+
+	hashes := r.hashes[:0]
+	for _, h := range r.hashes {
+		if r.owners[h] == node {
+			delete(r.owners, h)
+			continue
+		}
+		hashes = append(hashes, h)
+	}
+	r.hashes = hashes
+}
+
+// Get 返回 key 所属的节点, 环为空时返回空字符串
+func (r *HashRing) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := hashRingKey(key)
+	idx := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+
+	return r.owners[r.hashes[idx]]
+}
+
+// Nodes 当前全部节点
+func (r *HashRing) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	return nodes
+}
+
+func (r *HashRing) add(node string) bool {
+	if node == "" || r.nodes[node] {
+		return false
+	}
+	r.nodes[node] = true
+
+	for i := 0; i < r.replicas; i++ {
+		h := hashRingKey(node + "#" + strconv.Itoa(i))
+		if _, ok := r.owners[h]; ok {
+			// 极少见的哈希冲突, 保留先加入的节点
+			continue
+		}
+		r.owners[h] = node
+		r.hashes = append(r.hashes, h)
+	}
+
+	return true
+}
+
+func (r *HashRing) sort() {
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+}
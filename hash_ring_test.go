@@ -0,0 +1,58 @@
+package libtools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashRing(t *testing.T) {
+	if node := NewHashRing(nil, 0).Get("a"); node != "" {
+		t.Errorf("empty ring should return empty node, got: %s", node)
+	}
+
+	ring := NewHashRing([]string{"node-a", "node-b", "node-c", "node-a", ""}, 0)
+	if nodes := ring.Nodes(); strings.Join(nodes, ",") != "node-a,node-b,node-c" {
+		t.Fatalf("unexpected nodes: %v", nodes)
+	}
+
+	const total = 30000
+	before := make(map[string]string, total)
+	counts := make(map[string]int)
+	for i := 0; i < total; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		before[key] = ring.Get(key)
+		counts[before[key]]++
+	}
+	// 虚拟节点使分布大致均匀
+	for node, count := range counts {
+		if count < total/3*7/10 || count > total/3*13/10 {
+			t.Errorf("unbalanced ring, node: %s, count: %d", node, count)
+		}
+	}
+
+	// 新增节点时 key 只会迁移到新节点
+	ring.Add("node-d")
+	moved := 0
+	for key, node := range before {
+		after := ring.Get(key)
+		if after != node {
+			moved++
+			if after != "node-d" {
+				t.Fatalf("key %s moved from %s to %s", key, node, after)
+			}
+		}
+	}
+	if moved < total/4*7/10 || moved > total/4*13/10 {
+		t.Errorf("about 1/4 keys should move, moved: %d", moved)
+	}
+
+	// 移除后恢复原来的分配
+	ring.Remove("node-d")
+	ring.Remove("node-x")
+	for key, node := range before {
+		if ring.Get(key) != node {
+			t.Fatalf("key %s should return to %s", key, node)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package libtools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// healthDefaultTimeout 单项检查的默认超时
+const healthDefaultTimeout = 3 * time.Second
+
+// diskUsageRefreshInterval DiskUsageCheck 缓存目录大小的时间, 避免每次探测都遍历大目录
+const diskUsageRefreshInterval = 5 * time.Minute
+
+// HealthCheck 一项健康检查, Optional 为 true 的检查失败时整体为 degraded 且仍返回 200, 否则返回 503 让负载均衡摘除实例
+type HealthCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Timeout  time.Duration
+	Optional bool
+}
+
+// HealthCheckResult 单项检查结果
+type HealthCheckResult struct {
+	Status    string `json:"status"` // ok, fail
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+}
+
+// HealthReport HealthHandler 的返回内容
+type HealthReport struct {
+	Status     string                       `json:"status"` // ok, degraded, fail
+	Checks     map[string]HealthCheckResult `json:"checks"`
+	ServerTime int64                        `json:"server_time"`
+}
+
+// DBPingCheck 数据库连通性
+func DBPingCheck(name string, db *sql.DB) HealthCheck {
+	return HealthCheck{Name: name, Check: db.PingContext}
+}
+
+// RedisPingCheck ping 由业务方使用自己的 redis 客户端实现, 如 func(ctx context.Context) error { return rdb.Ping(ctx).Err() }
+func RedisPingCheck(name string, ping func(ctx context.Context) error) HealthCheck {
+	return HealthCheck{Name: name, Check: ping}
+}
+
+// DiskUsageCheck 目录占用超过 maxBytes 时失败, 用于日志、上传等会持续增长的目录; 默认为 Optional
+// 目录大小缓存 diskUsageRefreshInterval, 并发的探测只遍历一次, 超时后停止遍历
+func DiskUsageCheck(name, dir string, maxBytes int64) HealthCheck {
+	var (
+		mu        sync.Mutex
+		size      int64
+		checkedAt time.Time
+	)
+
+	return HealthCheck{
+		Name:     name,
+		Optional: true,
+		Timeout:  10 * time.Second,
+		Check: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if checkedAt.IsZero() || time.Since(checkedAt) >= diskUsageRefreshInterval {
+				current, err := DirSizeContext(ctx, dir)
+				if err != nil {
+					return err
+				}
+				size, checkedAt = current, time.Now()
+			}
+			if size > maxBytes {
+				return fmt.Errorf("%s uses %d bytes, exceeds %d", dir, size, maxBytes)
+			}
+			return nil
+		},
+	}
+}
+
+// HTTPCheck 下游 http 服务, 5xx 与网络错误视为失败
+func HTTPCheck(name, url string) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			timeout := healthDefaultTimeout
+			if deadline, ok := ctx.Deadline(); ok {
+				timeout = time.Until(deadline)
+			}
+			result, err := ProbeHTTP(url, timeout)
+			if err != nil {
+				return err
+			}
+			if result.StatusCode >= 500 {
+				return fmt.Errorf("%s returns status %d", url, result.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// RunHealthChecks 并发执行所有检查
+func RunHealthChecks(ctx context.Context, checks ...HealthCheck) HealthReport {
+	report := HealthReport{Status: "ok", Checks: make(map[string]HealthCheckResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := runHealthCheck(ctx, check)
+
+			mu.Lock()
+			report.Checks[check.Name] = result
+			if result.Status != "ok" {
+				if !check.Optional {
+					report.Status = "fail"
+				} else if report.Status == "ok" {
+					report.Status = "degraded"
+				}
+			}
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	report.ServerTime = GetUnixMillis()
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) (result HealthCheckResult) {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = healthDefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+
+	// 检查函数不响应 ctx 时也按超时返回
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %v", timeout)
+	}
+
+	result = HealthCheckResult{Status: "ok", LatencyMs: time.Since(start).Milliseconds(), Optional: check.Optional}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// HealthHandler 健康检查接口, 如 mux.Handle("/healthz", HealthHandler(DBPingCheck("db", db)))
+// 必需的检查失败时返回 503, 否则返回 200; 接口通常无需认证, 失败原因只记录日志, 不在响应中返回
+func HealthHandler(checks ...HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := RunHealthChecks(r.Context(), checks...)
+		for name, result := range report.Checks {
+			if result.Error != "" {
+				logs.Warning("[HealthHandler] check %s fail, err: %s", name, result.Error)
+				result.Error = ""
+				report.Checks[name] = result
+			}
+		}
+
+		status := http.StatusOK
+		if report.Status == "fail" {
+			status = http.StatusServiceUnavailable
+		}
+
+		buf, _ := json.Marshal(report)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_, _ = w.Write(buf)
+	})
+}
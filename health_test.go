@@ -0,0 +1,66 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	ok := HealthCheck{Name: "db", Check: func(ctx context.Context) error { return nil }}
+	optionalFail := HealthCheck{Name: "cache", Optional: true, Check: func(ctx context.Context) error { return errors.New("down") }}
+	slow := HealthCheck{Name: "partner", Timeout: 50 * time.Millisecond, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	w := httptest.NewRecorder()
+	HealthHandler(ok, optionalFail).ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"status":"degraded"`) {
+		t.Errorf("optional failure should be degraded with 200, get %d %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "down") {
+		t.Errorf("handler should not expose error detail, get %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	HealthHandler(ok, slow).ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != 503 || !strings.Contains(w.Body.String(), `"partner":{"status":"fail"`) {
+		t.Errorf("required failure should return 503, get %d %s", w.Code, w.Body.String())
+	}
+
+	report := RunHealthChecks(context.Background(), DiskUsageCheck("tmp", t.TempDir(), 1))
+	if report.Status != "ok" {
+		t.Errorf("empty dir should pass disk usage check: %+v", report)
+	}
+}
+
+func TestDiskUsageCheck(t *testing.T) {
+	dir := t.TempDir()
+	check := DiskUsageCheck("upload", dir, 10)
+	if err := check.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新间隔内使用缓存的大小
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.bin"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := check.Check(context.Background()); err != nil {
+		t.Errorf("size should be cached, get %v", err)
+	}
+	if err := DiskUsageCheck("upload", dir, 10).Check(context.Background()); err == nil {
+		t.Error("new check should see the file")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DirSizeContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled walk should return ctx error, get %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package libtools
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 蜜罐数据: 混入导出文件中的假数据, 正常业务不会用到, 一旦在外部出现(被调用、被拨打、被访问)即可判定数据泄露
+// 蜜罐值自带签名, 检测时不需要查库; 生成后需要长期可识别, 务必通过 SetHoneytokenSecret 设置固定密钥
+
+type HoneytokenKind string
+
+const (
+	HoneytokenAPIKey HoneytokenKind = "api_key"
+	HoneytokenPhone  HoneytokenKind = "phone"
+	HoneytokenURL    HoneytokenKind = "url"
+)
+
+const (
+	honeytokenAPIKeyPrefix = "sk_live_"
+	// honeytokenPhonePrefix 170 号段为虚拟运营商号段, 业务中极少出现; 剩余 8 位中 3 位随机 5 位校验, 真实号码误判概率约 1/100000
+	honeytokenPhonePrefix = "170"
+	honeytokenURLParam    = "ht"
+)
+
+var (
+	honeytokenMu     sync.RWMutex
+	honeytokenBeacon = "https://example.com/assets/pixel.gif"
+	honeytokenSecret = func() []byte {
+		buf := make([]byte, 32)
+		_, _ = rand.Read(buf)
+		return buf
+	}()
+)
+
+// SetHoneytokenSecret 设置签名密钥, 默认每次启动随机生成, 重启后之前生成的蜜罐值将无法识别
+func SetHoneytokenSecret(secret []byte) {
+	honeytokenMu.Lock()
+	honeytokenSecret = append([]byte(nil), secret...)
+	honeytokenMu.Unlock()
+}
+
+// SetHoneytokenBeacon 设置蜜罐 url 的地址, 该地址被访问时由 beacon 服务调用 IsHoneytoken 识别并告警
+func SetHoneytokenBeacon(beaconURL string) {
+	honeytokenMu.Lock()
+	honeytokenBeacon = beaconURL
+	honeytokenMu.Unlock()
+}
+
+func honeytokenSign(kind HoneytokenKind, payload string) []byte {
+	honeytokenMu.RLock()
+	mac := hmac.New(sha256.New, honeytokenSecret)
+	honeytokenMu.RUnlock()
+	mac.Write([]byte(string(kind) + ":" + payload))
+
+	return mac.Sum(nil)
+}
+
+// honeytokenPhoneCheck 由签名得到 5 位数字校验码
+func honeytokenPhoneCheck(random string) string {
+	sum := honeytokenSign(HoneytokenPhone, random)
+	return fmt.Sprintf("%05d", binary.BigEndian.Uint32(sum)%100000)
+}
+
+// GenerateHoneytoken 生成指定类型的蜜罐值
+func GenerateHoneytoken(kind HoneytokenKind) (string, error) {
+	switch kind {
+	case HoneytokenAPIKey:
+		random := make([]byte, 12)
+		_, _ = rand.Read(random)
+		payload := hex.EncodeToString(random)
+		return honeytokenAPIKeyPrefix + payload + hex.EncodeToString(honeytokenSign(kind, payload)[:8]), nil
+
+	case HoneytokenPhone:
+		n, err := secureIntn(1000)
+		if err != nil {
+			return "", err
+		}
+		random := fmt.Sprintf("%03d", n)
+		return honeytokenPhonePrefix + random + honeytokenPhoneCheck(random), nil
+
+	case HoneytokenURL:
+		honeytokenMu.RLock()
+		beacon := honeytokenBeacon
+		honeytokenMu.RUnlock()
+
+		u, err := url.Parse(beacon)
+		if err != nil {
+			return "", fmt.Errorf("invalid honeytoken beacon url: %v", err)
+		}
+		random := make([]byte, 8)
+		_, _ = rand.Read(random)
+		payload := hex.EncodeToString(random)
+
+		query := u.Query()
+		query.Set(honeytokenURLParam, payload+hex.EncodeToString(honeytokenSign(kind, payload)[:8]))
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	return "", fmt.Errorf("unsupported honeytoken kind: %s", kind)
+}
+
+// IsHoneytoken 判断 value 是否为本服务生成的蜜罐值, 可用于接口鉴权失败、短信发送、beacon 访问等位置的检测
+func IsHoneytoken(value string) bool {
+	_, ok := HoneytokenKindOf(value)
+	return ok
+}
+
+// HoneytokenKindOf 识别蜜罐值的类型
+func HoneytokenKindOf(value string) (HoneytokenKind, bool) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, honeytokenAPIKeyPrefix) {
+		body := strings.TrimPrefix(value, honeytokenAPIKeyPrefix)
+		if len(body) == 40 && honeytokenVerifyHex(HoneytokenAPIKey, body[:24], body[24:]) {
+			return HoneytokenAPIKey, true
+		}
+		return "", false
+	}
+
+	phone := strings.TrimPrefix(strings.TrimPrefix(value, "+86"), "86")
+	if len(phone) == 11 && strings.HasPrefix(phone, honeytokenPhonePrefix) {
+		if hmac.Equal([]byte(phone[6:]), []byte(honeytokenPhoneCheck(phone[3:6]))) {
+			return HoneytokenPhone, true
+		}
+		return "", false
+	}
+
+	if u, err := url.Parse(value); err == nil && u.Host != "" {
+		token := u.Query().Get(honeytokenURLParam)
+		if len(token) == 32 && honeytokenVerifyHex(HoneytokenURL, token[:16], token[16:]) {
+			return HoneytokenURL, true
+		}
+	}
+
+	return "", false
+}
+
+func honeytokenVerifyHex(kind HoneytokenKind, payload, sig string) bool {
+	expected := hex.EncodeToString(honeytokenSign(kind, payload)[:8])
+	return hmac.Equal([]byte(strings.ToLower(sig)), []byte(expected))
+}
@@ -0,0 +1,31 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestHoneytoken(t *testing.T) {
+	SetHoneytokenSecret([]byte("honeytoken-test-secret"))
+	SetHoneytokenBeacon("https://beacon.example.com/p.gif?src=export")
+
+	for _, kind := range []HoneytokenKind{HoneytokenAPIKey, HoneytokenPhone, HoneytokenURL} {
+		value, err := GenerateHoneytoken(kind)
+		if err != nil {
+			t.Fatalf("GenerateHoneytoken(%s) err: %v", kind, err)
+		}
+		if got, ok := HoneytokenKindOf(value); !ok || got != kind {
+			t.Errorf("HoneytokenKindOf(%s) = %s, %v, want: %s", value, got, ok, kind)
+		}
+	}
+
+	phone, _ := GenerateHoneytoken(HoneytokenPhone)
+	if !VerifyMobile(phone) || !IsHoneytoken("+86"+phone) {
+		t.Errorf("honeytoken phone should look like a real mobile: %s", phone)
+	}
+
+	for _, value := range []string{"", "13800138000", "17000000000", "sk_live_abc", "https://beacon.example.com/p.gif?ht=0123456789abcdef0123456789abcdef"} {
+		if IsHoneytoken(value) {
+			t.Errorf("IsHoneytoken(%s) should be false", value)
+		}
+	}
+}
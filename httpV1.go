@@ -2,16 +2,24 @@ package libtools
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // ContentType 类型定义
@@ -21,114 +29,409 @@ const (
 	HttpApplicationJSON        ContentType = "application/json"
 	HttpMultipartForm          ContentType = "multipart/form-data"
 	HttpApplicationFormEncoded ContentType = "application/x-www-form-urlencoded"
+	// HttpApplicationProtobuf body 需为 proto.Message
+	HttpApplicationProtobuf ContentType = "application/x-protobuf"
 )
 
-// HttpRequest 封装的 HTTP 请求函数，带默认超时 10 秒，允许覆盖超时参数
+// HttpRequestOptions HttpRequest 的扩展参数,零值即默认行为
+type HttpRequestOptions struct {
+	// Timeout 为 0 时使用默认超时 15 秒
+	Timeout time.Duration
+
+	// Cache 不为 nil 时对 GET 请求启用客户端缓存,遵循 Cache-Control/ETag/Last-Modified
+	Cache HttpCacheStore
+	// CacheTTL 服务端未给出 Cache-Control/Expires 时的缓存时长
+	CacheTTL time.Duration
+	// ForceRefresh 忽略本地缓存直接请求服务端,结果依旧写回缓存
+	ForceRefresh bool
+
+	// Signer 不为 nil 时在请求发出前对请求签名, 如 AWSV4Signer
+	Signer HttpSigner
+
+	// SSRF 不为 nil 时,目标地址(含跳转)必须通过该策略校验,用于请求用户提交的 url
+	SSRF *SSRFPolicy
+
+	// Result 不为 nil 时,2xx 响应按响应的 Content-Type 解析到 Result:
+	// protobuf 响应需 Result 为 proto.Message; json 响应在 Result 为 proto.Message 时使用 protojson, 否则使用 encoding/json
+	Result interface{}
+
+	// ResponseHeader 不为 nil 时写入响应头, 如读取 ETag; 直接命中本地缓存时只有 Content-Type
+	ResponseHeader http.Header
+}
+
+// HttpRequest 封装的 HTTP 请求函数，带默认超时 15 秒，允许覆盖超时参数
 func HttpRequest(method, urlStr string, headers map[string]string, contentType ContentType, body interface{}, timeout ...time.Duration) ([]byte, int, error) {
-	var requestBody io.Reader
-	var contentTypeHeader string
+	var opts HttpRequestOptions
+	if len(timeout) > 0 {
+		opts.Timeout = timeout[0] // 使用传入的超时时间
+	}
+
+	return HttpRequestWithOptions(context.Background(), method, urlStr, headers, contentType, body, opts)
+}
+
+// HttpRequestWithOptions 与 HttpRequest 相同,额外支持 context 与缓存等扩展参数
+func HttpRequestWithOptions(ctx context.Context, method, urlStr string, headers map[string]string, contentType ContentType, body interface{}, opts HttpRequestOptions) ([]byte, int, error) {
+	respBody, httpStatusCode, respContentType, err := httpRequest(ctx, method, urlStr, headers, contentType, body, opts)
+	if err != nil || opts.Result == nil || httpStatusCode < 200 || httpStatusCode >= 300 {
+		return respBody, httpStatusCode, err
+	}
+
+	return respBody, httpStatusCode, decodeHttpResult(respBody, respContentType, opts.Result)
+}
+
+// httpRequest 发送请求, 额外返回响应的 Content-Type; 启用追踪(见 SetTracer)与指标(见 SetHttpClientMetrics)时同时记录
+func httpRequest(ctx context.Context, method, urlStr string, headers map[string]string, contentType ContentType, body interface{}, opts HttpRequestOptions) ([]byte, int, string, error) {
+	ctx, span := StartSpan(ctx, "HTTP "+strings.ToUpper(method))
+	span.SetAttribute("http.request.method", strings.ToUpper(method))
+	span.SetAttribute("url.full", httpSpanURL(urlStr))
+
+	start := time.Now()
+	respBody, statusCode, respContentType, err := doHttpRequest(ctx, method, urlStr, headers, contentType, body, opts)
+	observeHttpRequest(urlStr, statusCode, time.Since(start))
+	if statusCode > 0 {
+		span.SetAttribute("http.response.status_code", statusCode)
+	}
+	endSpan(span, err)
+
+	return respBody, statusCode, respContentType, err
+}
+
+// httpSpanURL span 中的 url 去掉 query 与账号密码, 避免 token 等敏感信息进入追踪系统
+func httpSpanURL(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// doHttpRequest httpRequest 的实际实现
+func doHttpRequest(ctx context.Context, method, urlStr string, headers map[string]string, contentType ContentType, body interface{}, opts HttpRequestOptions) ([]byte, int, string, error) {
 	var httpStatusCode int
 	var emptyBody []byte
 
-	// 如果用户没有传入超时参数，设置默认超时时间为 10 秒
-	var clientTimeout time.Duration
-	if len(timeout) > 0 {
-		clientTimeout = timeout[0] // 使用传入的超时时间
-	} else {
+	// 如果用户没有传入超时参数，设置默认超时时间为 15 秒
+	clientTimeout := opts.Timeout
+	if clientTimeout <= 0 {
 		clientTimeout = 15 * time.Second // 默认 15 秒超时
 	}
 
+	requestBody, contentTypeHeader, err := buildHttpRequestBody(contentType, body)
+	if err != nil {
+		return nil, httpStatusCode, "", err
+	}
+
+	// 创建 HTTP 请求
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, requestBody)
+	if err != nil {
+		return nil, httpStatusCode, "", fmt.Errorf("could not create http request: %v", err)
+	}
+
+	// 设置 Content-Type
+	req.Header.Set("Content-Type", contentTypeHeader)
+
+	// protobuf 请求默认优先接收 protobuf 响应, 服务端不支持时可退回 json
+	if _, ok := opts.Result.(proto.Message); ok || contentType == HttpApplicationProtobuf {
+		req.Header.Set("Accept", string(HttpApplicationProtobuf)+", application/json;q=0.9")
+	}
+
+	// 设置自定义的 headers
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// ctx 中有请求 ID 时(见 RequestIDMiddleware)透传 X-Request-ID 与 traceparent
+	setPropagationHeaders(ctx, req.Header)
+
+	// 为该 host 注册了 TokenSource 时自动附加 access token
+	tokenSource := httpTokenSourceFor(req.URL)
+	if tokenSource != nil && req.Header.Get("Authorization") == "" {
+		token, err := tokenSource.Token(ctx)
+		if err != nil {
+			return nil, httpStatusCode, "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		tokenSource = nil
+	}
+
+	// 只缓存 GET 请求, 按 Authorization/Cookie 区分用户, 需在 token 附加之后、签名之前查找
+	var cacheKey string
+	var cacheEntry *HttpCacheEntry
+	useCache := opts.Cache != nil && strings.ToUpper(method) == HttpMethodGet
+	if useCache {
+		cacheKey = httpCacheKey(method, urlStr, req.Header)
+		if entry, ok := opts.Cache.Get(cacheKey); ok && entry.MatchVary(req.Header) {
+			if entry.Fresh() && !opts.ForceRefresh {
+				if opts.ResponseHeader != nil {
+					opts.ResponseHeader.Set("Content-Type", entry.ContentType)
+				}
+				return entry.Body, entry.StatusCode, entry.ContentType, nil
+			}
+			cacheEntry = entry
+		}
+	}
+
+	// 本地有过期缓存时,做条件请求
+	if cacheEntry != nil && !opts.ForceRefresh {
+		if cacheEntry.ETag != "" {
+			req.Header.Set("If-None-Match", cacheEntry.ETag)
+		}
+		if cacheEntry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cacheEntry.LastModified)
+		}
+	}
+
+	// 签名需在全部 header 设置完成后进行, 未指定时使用按 host 注册的签名器
+	signer := opts.Signer
+	if signer == nil {
+		signer = httpSignerFor(req.URL)
+	}
+	if signer != nil {
+		if err = signer.Sign(req); err != nil {
+			return nil, httpStatusCode, "", fmt.Errorf("could not sign http request: %v", err)
+		}
+	}
+
+	// 创建 HTTP 客户端，并设置超时时间
+	client := &http.Client{
+		Timeout: clientTimeout, // 使用默认或用户提供的超时时间
+	}
+	if opts.SSRF != nil {
+		if _, err = SafeHTTPTarget(urlStr, *opts.SSRF); err != nil {
+			return nil, httpStatusCode, "", err
+		}
+		client = NewSSRFSafeClient(*opts.SSRF, clientTimeout)
+	}
+
+	// 发送 HTTP 请求
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, httpStatusCode, "", fmt.Errorf("could not send http request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if opts.ResponseHeader != nil {
+		for key, values := range resp.Header {
+			opts.ResponseHeader[key] = append([]string(nil), values...)
+		}
+	}
+
+	// token 被服务端提前吊销时, 丢弃缓存以便下次请求重新获取
+	if tokenSource != nil && resp.StatusCode == http.StatusUnauthorized {
+		tokenSource.Invalidate()
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return emptyBody, httpStatusCode, "", err
+	}
+
+	if useCache {
+		if resp.StatusCode == http.StatusNotModified && cacheEntry != nil {
+			// 缓存中的条目可能正被其他请求读取, 修改副本后再写回
+			entry := *cacheEntry
+			if httpCacheUpdate(&entry, req.Header, resp.Header, opts.CacheTTL) {
+				opts.Cache.Set(cacheKey, &entry)
+			}
+			if opts.ResponseHeader != nil {
+				opts.ResponseHeader.Set("Content-Type", entry.ContentType)
+			}
+			return entry.Body, entry.StatusCode, entry.ContentType, nil
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			entry := &HttpCacheEntry{Body: respBody, StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+			if httpCacheUpdate(entry, req.Header, resp.Header, opts.CacheTTL) {
+				opts.Cache.Set(cacheKey, entry)
+			} else {
+				opts.Cache.Delete(cacheKey)
+			}
+		}
+	}
+
+	return respBody, resp.StatusCode, resp.Header.Get("Content-Type"), err
+}
+
+// buildHttpRequestBody 按 contentType 编码请求体,返回 body 与 Content-Type 头
+func buildHttpRequestBody(contentType ContentType, body interface{}) (io.Reader, string, error) {
 	switch contentType {
 	case HttpApplicationJSON:
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
-			return nil, httpStatusCode, fmt.Errorf("could not marshal json: %v", err)
+			return nil, "", fmt.Errorf("could not marshal json: %v", err)
 		}
-		requestBody = bytes.NewBuffer(jsonBody)
-		contentTypeHeader = string(HttpApplicationJSON)
+		return bytes.NewBuffer(jsonBody), string(HttpApplicationJSON), nil
 
 	case HttpMultipartForm:
 		var buffer bytes.Buffer
 		writer := multipart.NewWriter(&buffer)
 
 		data := body.(map[string]interface{})
-		for key, val := range data {
-			switch v := val.(type) {
-			case string:
-				_ = writer.WriteField(key, v)
-			case *os.File:
-				file, err := os.Open(v.Name())
-				if err != nil {
-					return nil, httpStatusCode, fmt.Errorf("could not open file: %v", err)
-				}
-				defer file.Close()
+		// 按 key 排序, 保证请求体稳定
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 
-				part, err := writer.CreateFormFile(key, v.Name())
-				if err != nil {
-					return nil, httpStatusCode, fmt.Errorf("could not create form file: %v", err)
-				}
-				_, err = io.Copy(part, file)
-				if err != nil {
-					return nil, httpStatusCode, fmt.Errorf("could not copy file content: %v", err)
-				}
-			default:
-				return nil, httpStatusCode, fmt.Errorf("unsupported field type: %v", v)
+		for _, key := range keys {
+			if err := writeMultipartField(writer, key, data[key]); err != nil {
+				return nil, "", err
 			}
 		}
 
 		err := writer.Close()
 		if err != nil {
-			return nil, httpStatusCode, fmt.Errorf("could not close writer: %v", err)
+			return nil, "", fmt.Errorf("could not close writer: %v", err)
 		}
 
-		requestBody = &buffer
-		contentTypeHeader = writer.FormDataContentType()
+		return &buffer, writer.FormDataContentType(), nil
+
+	case HttpApplicationProtobuf:
+		msg, ok := body.(proto.Message)
+		if !ok {
+			return nil, "", fmt.Errorf("protobuf body must be proto.Message, get %T", body)
+		}
+		buf, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not marshal protobuf: %v", err)
+		}
+		return bytes.NewReader(buf), string(HttpApplicationProtobuf), nil
 
 	case HttpApplicationFormEncoded:
+		// url.Values 用于同名参数出现多次的场景, 如 EncodeFormValues 的结果
+		if values, ok := body.(url.Values); ok {
+			return strings.NewReader(values.Encode()), string(HttpApplicationFormEncoded), nil
+		}
+
 		formData := url.Values{}
 		data := body.(map[string]string)
 		for key, val := range data {
 			formData.Set(key, val)
 		}
-		requestBody = strings.NewReader(formData.Encode())
-		contentTypeHeader = string(HttpApplicationFormEncoded)
+		return strings.NewReader(formData.Encode()), string(HttpApplicationFormEncoded), nil
 
 	default:
-		return nil, httpStatusCode, fmt.Errorf("unsupported content type: %v", contentType)
+		return nil, "", fmt.Errorf("unsupported content type: %v", contentType)
 	}
+}
 
-	// 创建 HTTP 请求
-	req, err := http.NewRequest(method, urlStr, requestBody)
-	if err != nil {
-		return nil, httpStatusCode, fmt.Errorf("could not create http request: %v", err)
-	}
+// FormFile multipart 中的文件, 用于需要指定文件名与 MIME 类型的场景
+type FormFile struct {
+	// Name 表单字段名, 为空时使用 map 的 key
+	Name string
+	// Filename 为空时使用字段名
+	Filename string
+	// ContentType 为空时按文件名后缀推断, 推断不出时使用 application/octet-stream
+	ContentType string
+	Reader      io.Reader
+}
 
-	// 设置 Content-Type
-	req.Header.Set("Content-Type", contentTypeHeader)
+var multipartQuoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
 
-	// 设置自定义的 headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
+// writeMultipartField 支持 string、[]string、*os.File、[]*os.File、FormFile、*FormFile、[]FormFile, 切片写为同名的多个 part
+func writeMultipartField(writer *multipart.Writer, key string, val interface{}) error {
+	switch v := val.(type) {
+	case string:
+		return writer.WriteField(key, v)
+	case []string:
+		for _, item := range v {
+			if err := writer.WriteField(key, item); err != nil {
+				return err
+			}
+		}
+	case *os.File:
+		file, err := os.Open(v.Name())
+		if err != nil {
+			return fmt.Errorf("could not open file: %v", err)
+		}
+		defer file.Close()
 
-	// 创建 HTTP 客户端，并设置超时时间
-	client := &http.Client{
-		Timeout: clientTimeout, // 使用默认或用户提供的超时时间
-	}
+		part, err := writer.CreateFormFile(key, v.Name())
+		if err != nil {
+			return fmt.Errorf("could not create form file: %v", err)
+		}
+		_, err = io.Copy(part, file)
+		if err != nil {
+			return fmt.Errorf("could not copy file content: %v", err)
+		}
+	case []*os.File:
+		for _, item := range v {
+			if err := writeMultipartField(writer, key, item); err != nil {
+				return err
+			}
+		}
+	case *FormFile:
+		return writeMultipartField(writer, key, *v)
+	case []FormFile:
+		for _, item := range v {
+			if err := writeMultipartField(writer, key, item); err != nil {
+				return err
+			}
+		}
+	case FormFile:
+		if v.Reader == nil {
+			return fmt.Errorf("form file %s has no reader", key)
+		}
+		name := v.Name
+		if name == "" {
+			name = key
+		}
+		filename := v.Filename
+		if filename == "" {
+			filename = name
+		}
+		contentType := v.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(filename))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
 
-	// 发送 HTTP 请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, httpStatusCode, fmt.Errorf("could not send http request: %v", err)
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			multipartQuoteEscaper.Replace(name), multipartQuoteEscaper.Replace(filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return fmt.Errorf("could not create form file: %v", err)
+		}
+		if _, err = io.Copy(part, v.Reader); err != nil {
+			return fmt.Errorf("could not copy file content: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported field type: %v", v)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return emptyBody, httpStatusCode, err
+	return nil
+}
+
+// decodeHttpResult 按响应的 Content-Type 解析响应体
+func decodeHttpResult(body []byte, contentType string, result interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	msg, isProto := result.(proto.Message)
+
+	switch {
+	case isProto && (mediaType == "application/x-protobuf" || mediaType == "application/protobuf" || mediaType == "application/vnd.google.protobuf"):
+		if err := proto.Unmarshal(body, msg); err != nil {
+			return fmt.Errorf("could not unmarshal protobuf response: %v", err)
+		}
+	case isProto:
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, msg); err != nil {
+			return fmt.Errorf("could not unmarshal json response into proto message: %v", err)
+		}
+	default:
+		if err := json.Unmarshal(body, result); err != nil {
+			return fmt.Errorf("could not unmarshal json response: %v", err)
+		}
 	}
 
-	return respBody, resp.StatusCode, err
+	return nil
 }
 
 // 用法如下
@@ -0,0 +1,82 @@
+package libtools
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestHttpRequestProtobuf(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "world"
+		if r.Header.Get("Content-Type") == string(HttpApplicationProtobuf) {
+			body, _ := ioutil.ReadAll(r.Body)
+			var req wrapperspb.StringValue
+			if err := proto.Unmarshal(body, &req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			name = req.GetValue()
+		}
+
+		switch r.URL.Path {
+		case "/pb":
+			if !strings.HasPrefix(r.Header.Get("Accept"), string(HttpApplicationProtobuf)) {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			buf, _ := proto.Marshal(wrapperspb.String("hello " + name))
+			w.Header().Set("Content-Type", "application/x-protobuf; charset=binary")
+			_, _ = w.Write(buf)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`"hello ` + name + `"`))
+		case "/struct":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"greeting":"hello ` + name + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	var pbResult wrapperspb.StringValue
+	_, code, err := HttpRequestWithOptions(ctx, HttpMethodPOST, srv.URL+"/pb", nil, HttpApplicationProtobuf, wrapperspb.String("budi"), HttpRequestOptions{Result: &pbResult})
+	if err != nil || code != http.StatusOK || pbResult.GetValue() != "hello budi" {
+		t.Fatalf("protobuf response: %d, %v, %s", code, err, pbResult.GetValue())
+	}
+
+	// 服务端返回 json 时, proto.Message 使用 protojson 解析
+	var jsonResult wrapperspb.StringValue
+	if _, _, err = HttpRequestWithOptions(ctx, HttpMethodPOST, srv.URL+"/json", nil, HttpApplicationProtobuf, wrapperspb.String("budi"), HttpRequestOptions{Result: &jsonResult}); err != nil || jsonResult.GetValue() != "hello budi" {
+		t.Fatalf("json response into proto: %v, %s", err, jsonResult.GetValue())
+	}
+
+	var structResult struct {
+		Greeting string `json:"greeting"`
+	}
+	if _, _, err = HttpRequestWithOptions(ctx, HttpMethodGet, srv.URL+"/struct", nil, HttpApplicationJSON, nil, HttpRequestOptions{Result: &structResult}); err != nil || structResult.Greeting != "hello world" {
+		t.Fatalf("json response into struct: %v, %+v", err, structResult)
+	}
+
+	// 非 2xx 不解析, 由调用方根据状态码处理
+	body, code, err := HttpRequestWithOptions(ctx, HttpMethodGet, srv.URL+"/missing", nil, HttpApplicationJSON, nil, HttpRequestOptions{Result: &structResult})
+	if err != nil || code != http.StatusNotFound || string(body) != "not found" {
+		t.Errorf("non-2xx response: %d, %v, %s", code, err, body)
+	}
+
+	if _, _, err = HttpRequestWithOptions(ctx, HttpMethodPOST, srv.URL+"/pb", nil, HttpApplicationProtobuf, map[string]string{"name": "budi"}, HttpRequestOptions{}); err == nil {
+		t.Error("non proto body should fail")
+	}
+	if _, _, err = HttpRequestWithOptions(ctx, HttpMethodGet, srv.URL+"/struct", nil, HttpApplicationJSON, nil, HttpRequestOptions{Result: &pbResult}); err == nil {
+		t.Error("mismatched json should fail to decode")
+	}
+}
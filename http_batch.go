@@ -0,0 +1,91 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HttpReq HttpBatch 中的单个请求, 参数含义与 HttpRequestWithOptions 相同
+type HttpReq struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	// ContentType 为空时按表单发送, Body 为 nil 时不带请求体
+	ContentType ContentType
+	Body        interface{}
+	// Options.Timeout 为单个请求的超时
+	Options HttpRequestOptions
+}
+
+// HttpResult 与请求一一对应, 单个请求失败只影响自身的 Err
+type HttpResult struct {
+	Body       []byte
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// httpBatchDefaultConcurrency concurrency 不大于 0 时的并发数
+const httpBatchDefaultConcurrency = 8
+
+// HttpBatch 并发执行一组请求, 结果顺序与 reqs 一致; ctx 取消后尚未开始的请求直接返回 ctx.Err()
+func HttpBatch(ctx context.Context, reqs []HttpReq, concurrency int) []HttpResult {
+	if concurrency <= 0 {
+		concurrency = httpBatchDefaultConcurrency
+	}
+
+	results := make([]HttpResult, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(reqs); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = doHttpReq(ctx, reqs[i])
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func doHttpReq(ctx context.Context, req HttpReq) (result HttpResult) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result = HttpResult{Err: fmt.Errorf("http batch request panic: %v", r)}
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return HttpResult{Err: err}
+	}
+
+	contentType, body := req.ContentType, req.Body
+	if contentType == "" {
+		contentType = HttpApplicationFormEncoded
+	}
+	if body == nil && contentType == HttpApplicationFormEncoded {
+		body = map[string]string{}
+	}
+
+	result.Body, result.StatusCode, result.Err = HttpRequestWithOptions(ctx, req.Method, req.URL, req.Headers, contentType, body, req.Options)
+	return result
+}
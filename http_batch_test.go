@@ -0,0 +1,55 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHttpBatch(t *testing.T) {
+	var running, maxRunning int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		} else {
+			time.Sleep(20 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	reqs := []HttpReq{
+		{Method: HttpMethodGet, URL: server.URL + "/a"},
+		{Method: HttpMethodGet, URL: server.URL + "/slow", Options: HttpRequestOptions{Timeout: 50 * time.Millisecond}},
+		{Method: HttpMethodGet, URL: server.URL + "/b"},
+		{Method: HttpMethodPOST, URL: server.URL + "/c", ContentType: HttpApplicationJSON, Body: map[string]int{"x": 1}},
+	}
+	results := HttpBatch(context.Background(), reqs, 2)
+
+	for i, path := range []string{"/a", "", "/b", "/c"} {
+		if path == "" {
+			if results[i].Err == nil {
+				t.Errorf("request %d should time out", i)
+			}
+			continue
+		}
+		if results[i].Err != nil || string(results[i].Body) != path {
+			t.Errorf("request %d get unexpected result: %s, err: %v", i, results[i].Body, results[i].Err)
+		}
+	}
+	if maxRunning > 2 {
+		t.Errorf("concurrency exceeded: %d", maxRunning)
+	}
+}
@@ -0,0 +1,227 @@
+package libtools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// HttpCacheEntry 缓存的 GET 响应
+type HttpCacheEntry struct {
+	Body         []byte `json:"body"`
+	StatusCode   int    `json:"status_code"`
+	ContentType  string `json:"content_type,omitempty"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	ExpireAt     int64  `json:"expire_at"` // 毫秒, 超过之后需要向服务端重新验证
+	// Vary 响应 Vary 头中列出的请求头及缓存时请求中的值, 请求中这些头的值不同时不使用该缓存
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// Fresh 缓存是否仍在有效期内,无需请求服务端
+func (e *HttpCacheEntry) Fresh() bool {
+	return e.ExpireAt > GetUnixMillis()
+}
+
+// MatchVary 请求头是否与缓存时 Vary 中列出的请求头一致
+func (e *HttpCacheEntry) MatchVary(header http.Header) bool {
+	for name, value := range e.Vary {
+		if header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Revalidatable 是否可以带 If-None-Match/If-Modified-Since 做条件请求
+func (e *HttpCacheEntry) Revalidatable() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+// HttpCacheStore HttpRequest 的缓存存储
+type HttpCacheStore interface {
+	Get(key string) (*HttpCacheEntry, bool)
+	Set(key string, entry *HttpCacheEntry)
+	Delete(key string)
+}
+
+// httpMemoryCache 基于 TTLCache 的内存存储
+type httpMemoryCache struct {
+	cache *TTLCache
+}
+
+// NewHttpMemoryCache retention 为数据在内存中的保留时长,过期的条目在保留期内仍可用于条件请求
+func NewHttpMemoryCache(retention time.Duration) HttpCacheStore {
+	return &httpMemoryCache{cache: NewTTLCache(retention)}
+}
+
+func (m *httpMemoryCache) Get(key string) (*HttpCacheEntry, bool) {
+	v, ok := m.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry, ok := v.(*HttpCacheEntry)
+	return entry, ok
+}
+
+func (m *httpMemoryCache) Set(key string, entry *HttpCacheEntry) {
+	m.cache.Set(key, entry)
+}
+
+func (m *httpMemoryCache) Delete(key string) {
+	m.cache.Delete(key)
+}
+
+// httpDiskCache 每个 key 一个 json 文件
+type httpDiskCache struct {
+	dir string
+}
+
+// NewHttpDiskCache 缓存落盘,适合进程重启后仍需复用的配置类接口
+func NewHttpDiskCache(dir string) (HttpCacheStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache dir: %v", err)
+	}
+
+	return &httpDiskCache{dir: dir}, nil
+}
+
+func (d *httpDiskCache) filename(key string) string {
+	return filepath.Join(d.dir, Md5(key)+".json")
+}
+
+func (d *httpDiskCache) Get(key string) (*HttpCacheEntry, bool) {
+	buf, err := ioutil.ReadFile(d.filename(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry HttpCacheEntry
+	err = json.Unmarshal(buf, &entry)
+	if err != nil {
+		logs.Warning("[httpDiskCache] cache file is broken, key: %s, err: %v", key, err)
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (d *httpDiskCache) Set(key string, entry *HttpCacheEntry) {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		logs.Error("[httpDiskCache] marshal entry fail, key: %s, err: %v", key, err)
+		return
+	}
+
+	// 先写临时文件再改名,避免并发读到写了一半的文件
+	filename := d.filename(key)
+	tmpFile := fmt.Sprintf("%s.%d.tmp", filename, GetUnixMillis())
+	err = ioutil.WriteFile(tmpFile, buf, 0644)
+	if err != nil {
+		logs.Error("[httpDiskCache] write cache file fail, key: %s, err: %v", key, err)
+		return
+	}
+
+	err = os.Rename(tmpFile, filename)
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		logs.Error("[httpDiskCache] rename cache file fail, key: %s, err: %v", key, err)
+	}
+}
+
+func (d *httpDiskCache) Delete(key string) {
+	_ = os.Remove(d.filename(key))
+}
+
+// httpCacheKey 带 Authorization/Cookie 的请求按其摘要区分, 不同用户的响应不会互相命中
+func httpCacheKey(method, urlStr string, header http.Header) string {
+	key := strings.ToUpper(method) + " " + urlStr
+	if credential := header.Get("Authorization") + "\n" + header.Get("Cookie"); credential != "\n" {
+		key += " " + Sha256(credential)
+	}
+	return key
+}
+
+// httpCacheFreshness 根据 Cache-Control/Expires 计算缓存有效期
+// store 为 false 表示服务端要求不缓存 (no-store/private); 缓存在进程内被所有调用方共享, private 的响应同样不缓存
+func httpCacheFreshness(header http.Header, defaultTTL time.Duration) (ttl time.Duration, store bool) {
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+	if cacheControl != "" {
+		for _, directive := range strings.Split(cacheControl, ",") {
+			directive = strings.TrimSpace(directive)
+			switch {
+			case directive == "no-store" || directive == "private" || strings.HasPrefix(directive, "private="):
+				return 0, false
+			case directive == "no-cache":
+				// 可以存,但每次使用前都要重新验证
+				return 0, true
+			case strings.HasPrefix(directive, "max-age="):
+				secs, err := strconv.ParseInt(strings.TrimPrefix(directive, "max-age="), 10, 64)
+				if err == nil && secs >= 0 {
+					return time.Duration(secs) * time.Second, true
+				}
+			}
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// 非法的 Expires 按已过期处理
+			return 0, true
+		}
+		return time.Until(t), true
+	}
+
+	return defaultTTL, true
+}
+
+// httpCacheUpdate 用新响应的头信息刷新缓存条目的有效期、校验值与 Vary, 返回 false 表示不应缓存
+// entry 可能被并发读取, 调用方需传入副本
+func httpCacheUpdate(entry *HttpCacheEntry, reqHeader, header http.Header, defaultTTL time.Duration) bool {
+	ttl, store := httpCacheFreshness(header, defaultTTL)
+	if !store {
+		return false
+	}
+
+	entry.Vary = nil
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return false
+			}
+			if entry.Vary == nil {
+				entry.Vary = make(map[string]string)
+			}
+			entry.Vary[http.CanonicalHeaderKey(name)] = reqHeader.Get(name)
+		}
+	}
+
+	if etag := header.Get("ETag"); etag != "" {
+		entry.ETag = etag
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		entry.LastModified = lastModified
+	}
+
+	entry.ExpireAt = GetUnixMillis()
+	if ttl > 0 {
+		entry.ExpireAt += ttl.Milliseconds()
+	}
+
+	return ttl > 0 || entry.Revalidatable()
+}
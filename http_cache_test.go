@@ -0,0 +1,112 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHttpCacheAuthorizationAndVary(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	opts := HttpRequestOptions{Cache: NewHttpMemoryCache(time.Minute)}
+	get := func(headers map[string]string) string {
+		body, _, err := HttpRequestWithOptions(context.Background(), HttpMethodGet, srv.URL, headers, HttpApplicationJSON, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	if got := get(map[string]string{"Authorization": "Bearer a"}); got != "Bearer a|" {
+		t.Fatalf("user a got: %s", got)
+	}
+	if got := get(map[string]string{"Authorization": "Bearer b"}); got != "Bearer b|" {
+		t.Fatalf("user b should not get user a's response, got: %s", got)
+	}
+	if got := get(map[string]string{"Authorization": "Bearer a"}); got != "Bearer a|" || atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("user a should hit cache, got: %s, hits: %d", got, hits)
+	}
+	if got := get(map[string]string{"Authorization": "Bearer a", "Accept-Language": "id"}); got != "Bearer a|id" {
+		t.Fatalf("vary header mismatch should miss cache, got: %s", got)
+	}
+}
+
+func TestHttpCacheFreshness(t *testing.T) {
+	cases := map[string]bool{
+		"max-age=60":          true,
+		"no-cache":            true,
+		"no-store":            false,
+		"private, max-age=60": false,
+	}
+	for cacheControl, want := range cases {
+		if _, store := httpCacheFreshness(http.Header{"Cache-Control": {cacheControl}}, 0); store != want {
+			t.Errorf("%s: store = %v, want %v", cacheControl, store, want)
+		}
+	}
+
+	entry := &HttpCacheEntry{}
+	if httpCacheUpdate(entry, http.Header{}, http.Header{"Vary": {"*"}, "Cache-Control": {"max-age=60"}}, 0) {
+		t.Error("Vary: * should not be cached")
+	}
+}
+
+func TestHttpCacheRevalidateConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer srv.Close()
+
+	opts := HttpRequestOptions{Cache: NewHttpMemoryCache(time.Minute)}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, _, err := HttpRequestWithOptions(context.Background(), HttpMethodGet, srv.URL, nil, HttpApplicationJSON, nil, opts)
+			if err != nil || string(body) != "config" {
+				t.Errorf("got %s, %v", body, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTTLCacheExpireRace(t *testing.T) {
+	c := NewTTLCache(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		c.Set("k", "old", time.Millisecond)
+		time.Sleep(2 * time.Millisecond)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Get("k")
+		}()
+		go func() {
+			defer wg.Done()
+			c.Set("k", "new", time.Minute)
+		}()
+		wg.Wait()
+		if v, ok := c.Get("k"); !ok || v != "new" {
+			t.Fatalf("fresh value is deleted by expired Get, got: %v, %v", v, ok)
+		}
+	}
+}
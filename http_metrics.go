@@ -0,0 +1,72 @@
+package libtools
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// 熔断器状态, 用于 RecordHttpBreakerState
+const (
+	HttpBreakerClosed   = 0
+	HttpBreakerHalfOpen = 1
+	HttpBreakerOpen     = 2
+)
+
+// HttpClientMetrics HttpRequest 的指标接口, 库本身不依赖 Prometheus
+// 使用 Prometheus 时以 -tags prometheus 编译并调用 EnablePrometheusHttpMetrics(reg), 也可以自行实现后通过 SetHttpClientMetrics 接入
+type HttpClientMetrics interface {
+	// ObserveRequest 每次请求结束时调用, statusCode 为 0 表示没有收到响应
+	ObserveRequest(host string, statusCode int, duration time.Duration)
+	IncRetry(host string)
+	SetBreakerState(host string, state int)
+}
+
+var (
+	httpMetricsMu sync.RWMutex
+	httpMetrics   HttpClientMetrics
+)
+
+// SetHttpClientMetrics 设置全局的 HttpClientMetrics, 传入 nil 关闭
+func SetHttpClientMetrics(m HttpClientMetrics) {
+	httpMetricsMu.Lock()
+	defer httpMetricsMu.Unlock()
+	httpMetrics = m
+}
+
+func getHttpClientMetrics() HttpClientMetrics {
+	httpMetricsMu.RLock()
+	defer httpMetricsMu.RUnlock()
+	return httpMetrics
+}
+
+// RecordHttpRetry 调用方对 HttpRequest 重试(如配合 RetryWithBackoff)时上报, urlStr 可以是完整 url 或 host
+func RecordHttpRetry(urlStr string) {
+	if m := getHttpClientMetrics(); m != nil {
+		m.IncRetry(httpMetricsHost(urlStr))
+	}
+}
+
+// RecordHttpBreakerState 调用方的熔断器状态变化时上报, state 取 HttpBreakerClosed 等
+func RecordHttpBreakerState(urlStr string, state int) {
+	if m := getHttpClientMetrics(); m != nil {
+		m.SetBreakerState(httpMetricsHost(urlStr), state)
+	}
+}
+
+// observeHttpRequest 由 HttpRequest 在每次请求结束时调用
+func observeHttpRequest(urlStr string, statusCode int, duration time.Duration) {
+	if m := getHttpClientMetrics(); m != nil {
+		m.ObserveRequest(httpMetricsHost(urlStr), statusCode, duration)
+	}
+}
+
+// httpMetricsHost 指标只按 host 区分, 避免 path 中的 id 等导致标签数量失控
+func httpMetricsHost(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Host == "" {
+		return urlStr
+	}
+
+	return u.Host
+}
@@ -0,0 +1,78 @@
+//go:build prometheus
+// +build prometheus
+
+package libtools
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type prometheusHttpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	breaker  *prometheus.GaugeVec
+}
+
+// EnablePrometheusHttpMetrics 在 reg 上注册 HttpRequest 的指标并开始记录, reg 为 nil 时使用 prometheus.DefaultRegisterer
+// 指标: libtools_http_client_requests_total{host,status}、libtools_http_client_request_duration_seconds{host}、
+// libtools_http_client_retries_total{host}、libtools_http_client_breaker_state{host}
+// 仅在以 -tags prometheus 编译时可用
+func EnablePrometheusHttpMetrics(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	m := &prometheusHttpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "libtools",
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Total number of outgoing HTTP requests, status is 0 when no response was received.",
+		}, []string{"host", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "libtools",
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of outgoing HTTP requests.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"host"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "libtools",
+			Subsystem: "http_client",
+			Name:      "retries_total",
+			Help:      "Total number of retried outgoing HTTP requests.",
+		}, []string{"host"}),
+		breaker: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "libtools",
+			Subsystem: "http_client",
+			Name:      "breaker_state",
+			Help:      "Circuit breaker state per host: 0 closed, 1 half-open, 2 open.",
+		}, []string{"host"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.retries, m.breaker} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+
+	SetHttpClientMetrics(m)
+	return nil
+}
+
+func (m *prometheusHttpMetrics) ObserveRequest(host string, statusCode int, duration time.Duration) {
+	m.requests.WithLabelValues(host, strconv.Itoa(statusCode)).Inc()
+	m.duration.WithLabelValues(host).Observe(duration.Seconds())
+}
+
+func (m *prometheusHttpMetrics) IncRetry(host string) {
+	m.retries.WithLabelValues(host).Inc()
+}
+
+func (m *prometheusHttpMetrics) SetBreakerState(host string, state int) {
+	m.breaker.WithLabelValues(host).Set(float64(state))
+}
@@ -0,0 +1,59 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testHttpMetrics struct {
+	mu       sync.Mutex
+	requests map[string]int
+	retries  map[string]int
+	breaker  map[string]int
+}
+
+func (m *testHttpMetrics) ObserveRequest(host string, statusCode int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[host+" "+http.StatusText(statusCode)]++
+}
+
+func (m *testHttpMetrics) IncRetry(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[host]++
+}
+
+func (m *testHttpMetrics) SetBreakerState(host string, state int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breaker[host] = state
+}
+
+func TestHttpClientMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	m := &testHttpMetrics{requests: map[string]int{}, retries: map[string]int{}, breaker: map[string]int{}}
+	SetHttpClientMetrics(m)
+	defer SetHttpClientMetrics(nil)
+
+	host := server.Listener.Addr().String()
+	for i := 0; i < 2; i++ {
+		_, _, _ = HttpRequest(HttpMethodGet, server.URL+"/orders/"+GetGuid(), nil, HttpApplicationJSON, nil)
+	}
+	RecordHttpRetry(server.URL + "/orders/1")
+	RecordHttpBreakerState(host, HttpBreakerOpen)
+
+	if m.requests[host+" Bad Gateway"] != 2 || len(m.requests) != 1 {
+		t.Errorf("requests: %v", m.requests)
+	}
+	if m.retries[host] != 1 || m.breaker[host] != HttpBreakerOpen {
+		t.Errorf("retries: %v, breaker: %v", m.retries, m.breaker)
+	}
+}
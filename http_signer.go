@@ -0,0 +1,164 @@
+package libtools
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HttpSigner 请求签名, 在请求发出前调用, 可修改 header 与 url
+type HttpSigner interface {
+	Sign(req *http.Request) error
+}
+
+// HttpSignerFunc 函数形式的 HttpSigner
+type HttpSignerFunc func(req *http.Request) error
+
+func (f HttpSignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+var httpSigners sync.Map // host => HttpSigner
+
+// RegisterHttpSigner 为指定 host 注册签名器, HttpRequest 请求该 host 且未指定 HttpRequestOptions.Signer 时自动签名
+// signer 为 nil 时取消注册
+//
+//	RegisterHttpSigner("sms.tencentcloudapi.com", &TencentCloudSigner{SecretID: id, SecretKey: key, Region: "ap-guangzhou"})
+func RegisterHttpSigner(host string, signer HttpSigner) {
+	host = strings.ToLower(host)
+	if signer == nil {
+		httpSigners.Delete(host)
+		return
+	}
+	httpSigners.Store(host, signer)
+}
+
+// httpSignerFor 先按 host:port 查找, 再按 host 查找
+func httpSignerFor(u *url.URL) HttpSigner {
+	for _, key := range []string{strings.ToLower(u.Host), strings.ToLower(u.Hostname())} {
+		if v, ok := httpSigners.Load(key); ok {
+			return v.(HttpSigner)
+		}
+	}
+
+	return nil
+}
+
+// signReadBody 读取请求体用于计算摘要, 并保证请求仍可正常发送
+func signReadBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("could not get request body: %v", err)
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	payload, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read request body: %v", err)
+	}
+	_ = req.Body.Close()
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(payload)), nil
+	}
+
+	return payload, nil
+}
+
+func hmacSha256Raw(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signURIEncode 按 RFC 3986 编码, 只保留 A-Z a-z 0-9 - _ . ~
+func signURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// signCanonicalQuery 编码后的查询参数, 先按 key 排序, key 相同时按 value 排序
+func signCanonicalQuery(req *http.Request) string {
+	var pairs [][2]string
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{signURIEncode(key, true), signURIEncode(value, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	list := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		list = append(list, pair[0]+"="+pair[1])
+	}
+
+	return strings.Join(list, "&")
+}
+
+// signCanonicalHeaders 按名称排序拼接 "name:value\n", 返回 canonicalHeaders 与 signedHeaders
+// include 判断小写的 header 名称是否参与签名, host 始终参与
+func signCanonicalHeaders(req *http.Request, include func(name string) bool) (canonicalHeaders, signedHeaders string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		name := strings.ToLower(key)
+		if !include(name) {
+			continue
+		}
+		trimmed := make([]string, 0, len(values))
+		for _, v := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
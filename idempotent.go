@@ -0,0 +1,110 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ErrIdempotentInProgress 相同 key 的请求正在处理中, 调用方可稍后重试
+var ErrIdempotentInProgress = errors.New("idempotent request is in progress")
+
+// 存储的值以状态字节开头, 完成状态后面跟着响应内容
+const (
+	idempotentPending byte = 'P'
+	idempotentDone    byte = 'D'
+)
+
+const idempotentKeyPrefix = "idempotent:"
+
+var idempotentStore = NewMemoryKV()
+
+// idempotentLockTTL 处理中占位的有效期, 进程在 fn 执行期间崩溃时, 最多锁住这么久; fn 的执行时间需小于该值
+var idempotentLockTTL = time.Minute
+
+// SetIdempotentStore 设置 Idempotent 使用的存储, 多实例部署时需设置为 redis 实现
+func SetIdempotentStore(store KVStore) {
+	idempotentStore = store
+}
+
+// SetIdempotentLockTTL 设置处理中占位的有效期, 默认 1 分钟, 需大于 fn 的最长执行时间
+func SetIdempotentLockTTL(ttl time.Duration) {
+	if ttl > 0 {
+		idempotentLockTTL = ttl
+	}
+}
+
+// Idempotent 以 key 保证 fn 在 ttl 内只成功执行一次, 重复调用直接返回首次成功的响应
+// fn 返回错误时不缓存, 允许重试; 相同 key 正在处理时返回 ErrIdempotentInProgress
+// 处理中的占位只保留 SetIdempotentLockTTL 设置的时长, 进程崩溃或结果写入失败时不会长时间锁住 key
+//
+//	resp, err := Idempotent("wxpay:notify:"+transactionID, 24*time.Hour, func() ([]byte, error) {
+//		return handlePaid(order)
+//	})
+func Idempotent(key string, ttl time.Duration, fn func() (resp []byte, err error)) ([]byte, error) {
+	return IdempotentWithStore(context.Background(), idempotentStore, key, ttl, fn)
+}
+
+// IdempotentWithStore 与 Idempotent 相同, 使用指定的存储
+func IdempotentWithStore(ctx context.Context, store KVStore, key string, ttl time.Duration, fn func() (resp []byte, err error)) (resp []byte, err error) {
+	storeKey := idempotentKeyPrefix + key
+
+	// 抢占失败时重新读取一次, 防止恰好在两次操作之间完成
+	for i := 0; i < 2; i++ {
+		value, ok, err := store.Get(ctx, storeKey)
+		if err != nil {
+			return nil, err
+		}
+		if ok && len(value) > 0 {
+			if value[0] == idempotentDone {
+				return value[1:], nil
+			}
+			return nil, ErrIdempotentInProgress
+		}
+
+		lockTTL := idempotentLockTTL
+		if ttl > 0 && ttl < lockTTL {
+			lockTTL = ttl
+		}
+		acquired, err := store.SetNX(ctx, storeKey, []byte{idempotentPending}, lockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return idempotentRun(ctx, store, storeKey, ttl, fn)
+		}
+	}
+
+	return nil, ErrIdempotentInProgress
+}
+
+func idempotentRun(ctx context.Context, store KVStore, storeKey string, ttl time.Duration, fn func() ([]byte, error)) (resp []byte, err error) {
+	done := false
+	defer func() {
+		// 失败或 panic 时释放占位, 允许重试
+		if !done {
+			if delErr := store.Delete(ctx, storeKey); delErr != nil {
+				logs.Error("[Idempotent] could not release key: %s, err: %v", storeKey, delErr)
+			}
+		}
+	}()
+
+	resp, err = fn()
+	if err != nil {
+		return resp, err
+	}
+
+	value := make([]byte, 0, len(resp)+1)
+	value = append(value, idempotentDone)
+	value = append(value, resp...)
+	if err = store.Set(ctx, storeKey, value, ttl); err != nil {
+		// 业务已执行成功, 只记录日志, 不影响本次结果; 占位到期前重复请求仍返回 ErrIdempotentInProgress
+		logs.Error("[Idempotent] could not save response, key: %s, err: %v", storeKey, err)
+		err = nil
+	}
+	done = true
+
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotentConcurrent(t *testing.T) {
+	store := NewMemoryKV()
+	var runs int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := IdempotentWithStore(context.Background(), store, "notify:1", time.Hour, func() ([]byte, error) {
+				atomic.AddInt32(&runs, 1)
+				time.Sleep(10 * time.Millisecond)
+				return []byte("ok"), nil
+			})
+			if err != nil && !errors.Is(err, ErrIdempotentInProgress) {
+				t.Error(err)
+			}
+			if err == nil && string(resp) != "ok" {
+				t.Errorf("unexpected resp: %s", resp)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Fatalf("fn should run once, runs: %d", runs)
+	}
+
+	resp, err := IdempotentWithStore(context.Background(), store, "notify:1", time.Hour, func() ([]byte, error) {
+		t.Fatal("fn should not run again")
+		return nil, nil
+	})
+	if err != nil || string(resp) != "ok" {
+		t.Fatalf("expect cached resp, got: %s, %v", resp, err)
+	}
+}
+
+func TestIdempotentLockExpire(t *testing.T) {
+	defer SetIdempotentLockTTL(time.Minute)
+	SetIdempotentLockTTL(50 * time.Millisecond)
+
+	store := NewMemoryKV()
+	// 模拟 fn 执行期间进程崩溃, 只留下处理中的占位
+	_, _ = store.SetNX(context.Background(), idempotentKeyPrefix+"order:1", []byte{idempotentPending}, idempotentLockTTL)
+
+	fn := func() ([]byte, error) { return []byte("paid"), nil }
+	if _, err := IdempotentWithStore(context.Background(), store, "order:1", 24*time.Hour, fn); !errors.Is(err, ErrIdempotentInProgress) {
+		t.Fatalf("expect in progress, got: %v", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if resp, err := IdempotentWithStore(context.Background(), store, "order:1", 24*time.Hour, fn); err != nil || string(resp) != "paid" {
+		t.Fatalf("lock should expire, got: %s, %v", resp, err)
+	}
+	// 结果按完整的 ttl 保存
+	if ttl, ok := store.(*memoryKV).cache.TTL(idempotentKeyPrefix + "order:1"); !ok || ttl < time.Hour {
+		t.Fatalf("result ttl: %v, %v", ttl, ok)
+	}
+}
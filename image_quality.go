@@ -0,0 +1,174 @@
+package libtools
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ImageQualityOptions 图片质量阈值, 为 0 的项不检查; 不传时使用 DefaultImageQualityOptions
+type ImageQualityOptions struct {
+	MinWidth  int
+	MinHeight int
+	// MinSize/MaxSize 文件大小, 字节
+	MinSize int
+	MaxSize int
+	// MinBrightness/MaxBrightness 平均亮度, 取值 0~255
+	MinBrightness float64
+	MaxBrightness float64
+	// MinBlurScore 清晰度(拉普拉斯方差), 越小越模糊
+	MinBlurScore float64
+	// MaxPixels 解码前按图片头中的宽高拒绝过大的图片, 防止解压炸弹; 为 0 时使用 imageQualityMaxPixels
+	MaxPixels int
+}
+
+// imageQualityMaxPixels 默认的最大像素数, 约 4000 万像素, 高于常见手机相机
+const imageQualityMaxPixels = 40 * 1000 * 1000
+
+// DefaultImageQualityOptions 适用于 KYC 自拍/证件照的默认阈值
+func DefaultImageQualityOptions() ImageQualityOptions {
+	return ImageQualityOptions{
+		MinWidth:      480,
+		MinHeight:     480,
+		MinSize:       20 * 1024,
+		MaxSize:       5 * 1024 * 1024,
+		MinBrightness: 60,
+		MaxBrightness: 220,
+		MinBlurScore:  100,
+	}
+}
+
+// ImageQualityReport 检查结果, Reasons 为未通过的原因
+type ImageQualityReport struct {
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	Size       int      `json:"size"`
+	Brightness float64  `json:"brightness"`
+	BlurScore  float64  `json:"blur_score"`
+	Passed     bool     `json:"passed"`
+	Reasons    []string `json:"reasons,omitempty"`
+}
+
+// imageQualitySampleSize 计算亮度与清晰度前先把长边缩小到这个尺寸, 兼顾速度与准确度
+const imageQualitySampleSize = 512
+
+// CheckImageQuality 检查图片的分辨率、文件大小、亮度与清晰度, 在调用收费的第三方 KYC 接口前拦截明显不可用的照片
+// 图片无法解码或像素数超过 MaxPixels 时返回 error, 质量不达标时 err 为 nil, 通过 report.Passed 判断
+func CheckImageQuality(buf []byte, opts ...ImageQualityOptions) (report ImageQualityReport, err error) {
+	opt := DefaultImageQualityOptions()
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	// 先只读图片头, 几十 KB 的文件可能声明数万像素的宽高, 直接解码会耗尽内存
+	config, _, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		err = fmt.Errorf("could not decode image: %v", err)
+		return
+	}
+	maxPixels := opt.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = imageQualityMaxPixels
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxPixels/config.Height {
+		err = fmt.Errorf("image %dx%d exceeds max pixels %d", config.Width, config.Height, maxPixels)
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		err = fmt.Errorf("could not decode image: %v", err)
+		return
+	}
+
+	bounds := img.Bounds()
+	report.Width = bounds.Dx()
+	report.Height = bounds.Dy()
+	report.Size = len(buf)
+
+	gray, w, h := imageQualityGray(img)
+	report.Brightness = imageBrightness(gray)
+	report.BlurScore = imageBlurScore(gray, w, h)
+
+	if opt.MinWidth > 0 && report.Width < opt.MinWidth {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("width %d less than %d", report.Width, opt.MinWidth))
+	}
+	if opt.MinHeight > 0 && report.Height < opt.MinHeight {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("height %d less than %d", report.Height, opt.MinHeight))
+	}
+	if opt.MinSize > 0 && report.Size < opt.MinSize {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("file size %d less than %d", report.Size, opt.MinSize))
+	}
+	if opt.MaxSize > 0 && report.Size > opt.MaxSize {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("file size %d greater than %d", report.Size, opt.MaxSize))
+	}
+	if opt.MinBrightness > 0 && report.Brightness < opt.MinBrightness {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("too dark, brightness %.1f", report.Brightness))
+	}
+	if opt.MaxBrightness > 0 && report.Brightness > opt.MaxBrightness {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("too bright, brightness %.1f", report.Brightness))
+	}
+	if opt.MinBlurScore > 0 && report.BlurScore < opt.MinBlurScore {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("too blurry, blur score %.1f", report.BlurScore))
+	}
+
+	report.Passed = len(report.Reasons) == 0
+	return
+}
+
+// imageQualityGray 按最近邻缩放并转为灰度, 返回行优先的亮度数组
+func imageQualityGray(img image.Image) (gray []float64, w, h int) {
+	bounds := img.Bounds()
+	w, h = bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, 0, 0
+	}
+
+	scale := 1.0
+	if longer := int(MaxInt64(int64(w), int64(h))); longer > imageQualitySampleSize {
+		scale = float64(longer) / imageQualitySampleSize
+		w = int(float64(w) / scale)
+		h = int(float64(h) / scale)
+		if w == 0 {
+			w = 1
+		}
+		if h == 0 {
+			h = 1
+		}
+	}
+
+	gray = make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+int(float64(x)*scale), bounds.Min.Y+int(float64(y)*scale)).RGBA()
+			// ITU-R BT.601, RGBA() 返回 16 位
+			gray[y*w+x] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+		}
+	}
+
+	return
+}
+
+func imageBrightness(gray []float64) float64 {
+	return Mean(gray)
+}
+
+// imageBlurScore 拉普拉斯算子响应的方差, 图像越清晰边缘越多, 方差越大
+func imageBlurScore(gray []float64, w, h int) float64 {
+	if w < 3 || h < 3 {
+		return 0
+	}
+
+	lap := make([]float64, 0, (w-2)*(h-2))
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			lap = append(lap, gray[i-w]+gray[i+w]+gray[i-1]+gray[i+1]-4*gray[i])
+		}
+	}
+
+	sd := StdDev(lap)
+	return sd * sd
+}
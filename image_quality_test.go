@@ -0,0 +1,86 @@
+package libtools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestCheckImageQuality(t *testing.T) {
+	encode := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	opt := ImageQualityOptions{MinWidth: 64, MinHeight: 64, MinBrightness: 60, MaxBrightness: 220, MinBlurScore: 100}
+
+	// 棋盘格: 亮度适中且边缘清晰
+	board := image.NewGray(image.Rect(0, 0, 128, 128))
+	for y := 0; y < 128; y++ {
+		for x := 0; x < 128; x++ {
+			if (x/4+y/4)%2 == 0 {
+				board.SetGray(x, y, color.Gray{Y: 220})
+			} else {
+				board.SetGray(x, y, color.Gray{Y: 30})
+			}
+		}
+	}
+	report, err := CheckImageQuality(encode(board), opt)
+	if err != nil || !report.Passed {
+		t.Fatalf("checkerboard should pass, report: %+v, err: %v", report, err)
+	}
+
+	dark := image.NewGray(image.Rect(0, 0, 128, 128))
+	report, err = CheckImageQuality(encode(dark), opt)
+	if err != nil || report.Passed || !strings.Contains(strings.Join(report.Reasons, ";"), "too dark") ||
+		!strings.Contains(strings.Join(report.Reasons, ";"), "too blurry") {
+		t.Fatalf("black image should fail, report: %+v, err: %v", report, err)
+	}
+
+	report, _ = CheckImageQuality(encode(image.NewGray(image.Rect(0, 0, 32, 32))), opt)
+	if report.Passed || report.Width != 32 {
+		t.Fatalf("small image should fail, report: %+v", report)
+	}
+
+	if _, err = CheckImageQuality([]byte("not an image"), opt); err == nil {
+		t.Fatal("invalid image should return error")
+	}
+}
+
+func TestCheckImageQualityMaxPixels(t *testing.T) {
+	// 只有文件头的 png, 声明 100000x100000, 解码会申请约 10GB 内存
+	chunk := func(typ string, data []byte) []byte {
+		var buf bytes.Buffer
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.WriteString(typ)
+		buf.Write(data)
+		_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+		return buf.Bytes()
+	}
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	ihdr[8] = 8 // 8 位灰度
+	bomb := append([]byte("\x89PNG\r\n\x1a\n"), chunk("IHDR", ihdr)...)
+
+	_, err := CheckImageQuality(bomb)
+	if err == nil || !strings.Contains(err.Error(), "exceeds max pixels") {
+		t.Fatalf("expect max pixels error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 100)))
+	if _, err = CheckImageQuality(buf.Bytes(), ImageQualityOptions{MaxPixels: 9999}); err == nil {
+		t.Fatal("expect custom max pixels error")
+	}
+	if _, err = CheckImageQuality(buf.Bytes(), ImageQualityOptions{MaxPixels: 10000}); err != nil {
+		t.Fatal(err)
+	}
+}
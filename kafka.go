@@ -0,0 +1,312 @@
+package libtools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// 为了不让 libtools 强依赖某个 kafka 客户端,底层收发由业务方注入(sarama/franz-go/kafka-go 均可适配),
+// 这里统一消息信封、重试与优雅退出的逻辑
+
+// KafkaMessage 与具体客户端无关的消息结构
+type KafkaMessage struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Partition int32
+	Offset    int64
+}
+
+// KafkaWriter 生产端驱动
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...KafkaMessage) error
+	Close() error
+}
+
+// KafkaReader 消费组驱动, FetchMessage 阻塞直到有消息或 ctx 结束
+type KafkaReader interface {
+	FetchMessage(ctx context.Context) (KafkaMessage, error)
+	CommitMessages(ctx context.Context, msgs ...KafkaMessage) error
+	Close() error
+}
+
+// KafkaEnvelope 统一的 json 消息信封
+type KafkaEnvelope struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Source    string          `json:"source,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Time      int64           `json:"time"` // 毫秒
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewKafkaEnvelope 将业务数据包装成信封
+func NewKafkaEnvelope(eventType string, data interface{}) (*KafkaEnvelope, error) {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal envelope data: %v", err)
+	}
+
+	return &KafkaEnvelope{
+		ID:   GetGuid(),
+		Type: eventType,
+		Time: GetUnixMillis(),
+		Data: buf,
+	}, nil
+}
+
+// DecodeKafkaEnvelope 解析消息信封
+func DecodeKafkaEnvelope(value []byte) (*KafkaEnvelope, error) {
+	var env KafkaEnvelope
+	err := json.Unmarshal(value, &env)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal envelope: %v", err)
+	}
+
+	return &env, nil
+}
+
+// Bind 将信封中的 data 解析到 v
+func (e *KafkaEnvelope) Bind(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// KafkaProducerConfig 生产者配置
+type KafkaProducerConfig struct {
+	Writer KafkaWriter
+	// Source 写入信封的来源服务名
+	Source string
+	// Retries 发送失败后的重试次数, 0 表示不重试
+	Retries int
+	// RetryBackoff 重试基础间隔, 默认 200ms
+	RetryBackoff time.Duration
+}
+
+// KafkaProducer 在 KafkaWriter 之上增加信封与重试, Close 会等待发送中的消息结束
+type KafkaProducer struct {
+	cfg    KafkaProducerConfig
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+var ErrKafkaClosed = errors.New("kafka client is closed")
+
+func NewProducer(cfg KafkaProducerConfig) (*KafkaProducer, error) {
+	if cfg.Writer == nil {
+		return nil, fmt.Errorf("kafka producer need a writer")
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+
+	return &KafkaProducer{cfg: cfg}, nil
+}
+
+// Publish 发送原始消息
+func (p *KafkaProducer) Publish(ctx context.Context, msgs ...KafkaMessage) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrKafkaClosed
+	}
+	p.wg.Add(1)
+	p.mu.RUnlock()
+	defer p.wg.Done()
+
+	return RetryWithBackoff(ctx, p.cfg.Retries+1, p.cfg.RetryBackoff, func() error {
+		err := p.cfg.Writer.WriteMessages(ctx, msgs...)
+		if err != nil {
+			logs.Warning("[KafkaProducer] write messages fail, count: %d, err: %v", len(msgs), err)
+		}
+		return err
+	})
+}
+
+// PublishJSON 将 data 包装成信封后发送, key 用于分区
+func (p *KafkaProducer) PublishJSON(ctx context.Context, topic, key, eventType string, data interface{}) error {
+	env, err := NewKafkaEnvelope(eventType, data)
+	if err != nil {
+		return err
+	}
+	env.Source = p.cfg.Source
+
+	value, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(ctx, KafkaMessage{
+		Topic:   topic,
+		Key:     []byte(key),
+		Value:   value,
+		Headers: map[string]string{"type": eventType, "id": env.ID},
+	})
+}
+
+// Close 拒绝新的发送,等待已发出的请求结束后关闭底层 writer
+func (p *KafkaProducer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return p.cfg.Writer.Close()
+}
+
+// KafkaHandler 消费处理函数, 返回 error 会按配置重试
+type KafkaHandler func(ctx context.Context, msg KafkaMessage) error
+
+// KafkaConsumerConfig 消费组配置
+type KafkaConsumerConfig struct {
+	Reader KafkaReader
+	// Retries 处理失败后的重试次数, 0 表示不重试, 失败后直接交给 OnFailure
+	Retries int
+	// RetryBackoff 重试基础间隔, 默认 500ms
+	RetryBackoff time.Duration
+	// OnFailure 重试耗尽后的回调(如写死信队列), 为 nil 时仅记录日志; 无论如何消息都会被提交
+	OnFailure func(msg KafkaMessage, err error)
+}
+
+// KafkaConsumerGroup 单协程顺序消费, Close/ctx 取消后处理完当前消息再退出
+type KafkaConsumerGroup struct {
+	cfg     KafkaConsumerConfig
+	handler KafkaHandler
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func NewConsumerGroup(cfg KafkaConsumerConfig, handler KafkaHandler) (*KafkaConsumerGroup, error) {
+	if cfg.Reader == nil {
+		return nil, fmt.Errorf("kafka consumer need a reader")
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("kafka consumer need a handler")
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	return &KafkaConsumerGroup{
+		cfg:     cfg,
+		handler: handler,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// JSONHandler 将信封解析后交给 fn 处理
+func JSONHandler(fn func(ctx context.Context, env *KafkaEnvelope) error) KafkaHandler {
+	return func(ctx context.Context, msg KafkaMessage) error {
+		env, err := DecodeKafkaEnvelope(msg.Value)
+		if err != nil {
+			// 格式错误的消息重试也没有意义
+			logs.Error("[JSONHandler] drop invalid message, topic: %s, offset: %d, err: %v", msg.Topic, msg.Offset, err)
+			return nil
+		}
+		return fn(ctx, env)
+	}
+}
+
+// Run 阻塞消费, 直到 ctx 取消或调用 Close
+func (c *KafkaConsumerGroup) Run(ctx context.Context) error {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return fmt.Errorf("kafka consumer is already running")
+	}
+	c.running = true
+	c.mu.Unlock()
+	defer close(c.done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		msg, err := c.cfg.Reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logs.Error("[KafkaConsumerGroup] fetch message fail, err: %v", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(c.cfg.RetryBackoff):
+			}
+			continue
+		}
+
+		// 处理过程不受 ctx 取消影响,保证当前消息处理完再退出
+		handleCtx := context.Background()
+		err = RetryWithBackoff(handleCtx, c.cfg.Retries+1, c.cfg.RetryBackoff, func() error {
+			return c.handler(handleCtx, msg)
+		})
+		if err != nil {
+			logs.Error("[KafkaConsumerGroup] handle message fail, topic: %s, partition: %d, offset: %d, err: %v",
+				msg.Topic, msg.Partition, msg.Offset, err)
+			if c.cfg.OnFailure != nil {
+				c.cfg.OnFailure(msg, err)
+			}
+		}
+
+		err = c.cfg.Reader.CommitMessages(handleCtx, msg)
+		if err != nil {
+			logs.Error("[KafkaConsumerGroup] commit message fail, topic: %s, offset: %d, err: %v", msg.Topic, msg.Offset, err)
+		}
+	}
+}
+
+// Close 停止拉取新消息,等待 Run 退出后关闭底层 reader, 可配合 ClearOnSignal 使用
+func (c *KafkaConsumerGroup) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.stop)
+
+		c.mu.Lock()
+		running := c.running
+		c.mu.Unlock()
+		if running {
+			<-c.done
+		}
+
+		err = c.cfg.Reader.Close()
+	})
+
+	return err
+}
+
+// NewKafkaAuditSink 审计事件写入 kafka, 同一对象的事件使用相同的分区 key 以保证顺序
+func NewKafkaAuditSink(producer *KafkaProducer, topic string) AuditSink {
+	return AuditSinkFunc(func(event *AuditEvent) error {
+		key := fmt.Sprintf("%s:%s", event.Object, event.ObjectID)
+		return producer.PublishJSON(context.Background(), topic, key, "audit."+event.Action, event)
+	})
+}
@@ -0,0 +1,142 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKafkaWriterT struct {
+	mu     sync.Mutex
+	calls  int
+	fail   int // 前 fail 次写入失败
+	msgs   []KafkaMessage
+	closed bool
+}
+
+func (w *fakeKafkaWriterT) WriteMessages(ctx context.Context, msgs ...KafkaMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	if w.calls <= w.fail {
+		return errors.New("broker not available")
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriterT) Close() error {
+	w.closed = true
+	return nil
+}
+
+type fakeKafkaReaderT struct {
+	mu        sync.Mutex
+	msgs      chan KafkaMessage
+	committed []int64
+}
+
+func (r *fakeKafkaReaderT) FetchMessage(ctx context.Context) (KafkaMessage, error) {
+	select {
+	case msg := <-r.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return KafkaMessage{}, ctx.Err()
+	}
+}
+
+func (r *fakeKafkaReaderT) CommitMessages(ctx context.Context, msgs ...KafkaMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+func (r *fakeKafkaReaderT) Close() error { return nil }
+
+func TestKafkaProducerRetries(t *testing.T) {
+	for _, c := range []struct {
+		retries, fail, calls int
+		ok                   bool
+	}{
+		{0, 1, 1, false}, // 0 表示不重试
+		{2, 2, 3, true},
+		{2, 3, 3, false},
+	} {
+		w := &fakeKafkaWriterT{fail: c.fail}
+		p, _ := NewProducer(KafkaProducerConfig{Writer: w, Source: "loan", Retries: c.retries, RetryBackoff: time.Millisecond})
+		err := p.PublishJSON(context.Background(), "orders", "1", "order.created", map[string]int{"id": 1})
+		if (err == nil) != c.ok || w.calls != c.calls {
+			t.Errorf("retries %d, fail %d: calls %d, err %v", c.retries, c.fail, w.calls, err)
+		}
+	}
+
+	w := &fakeKafkaWriterT{}
+	p, _ := NewProducer(KafkaProducerConfig{Writer: w, Source: "loan"})
+	if err := p.PublishJSON(context.Background(), "orders", "1", "order.created", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	env, err := DecodeKafkaEnvelope(w.msgs[0].Value)
+	if err != nil || env.Type != "order.created" || env.Source != "loan" || w.msgs[0].Headers["id"] != env.ID {
+		t.Fatalf("unexpected envelope: %+v, %v", env, err)
+	}
+	_ = p.Close()
+	if !w.closed || !errors.Is(p.Publish(context.Background(), KafkaMessage{}), ErrKafkaClosed) {
+		t.Error("closed producer should reject publish")
+	}
+}
+
+func TestKafkaConsumerGroup(t *testing.T) {
+	r := &fakeKafkaReaderT{msgs: make(chan KafkaMessage, 2)}
+	var mu sync.Mutex
+	var handled int
+	var failed []int64
+	c, _ := NewConsumerGroup(KafkaConsumerConfig{
+		Reader:       r,
+		RetryBackoff: time.Millisecond,
+		OnFailure: func(msg KafkaMessage, err error) {
+			mu.Lock()
+			failed = append(failed, msg.Offset)
+			mu.Unlock()
+		},
+	}, func(ctx context.Context, msg KafkaMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		if msg.Offset == 1 {
+			return errors.New("handle fail")
+		}
+		return nil
+	})
+
+	r.msgs <- KafkaMessage{Topic: "orders", Offset: 1}
+	r.msgs <- KafkaMessage{Topic: "orders", Offset: 2}
+	done := make(chan error)
+	go func() { done <- c.Run(context.Background()) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		n := len(r.committed)
+		r.mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_ = c.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Retries 为 0 时失败的消息只处理一次, 交给 OnFailure 后仍然提交
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 2 || len(failed) != 1 || failed[0] != 1 || len(r.committed) != 2 {
+		t.Errorf("handled %d, failed %v, committed %v", handled, failed, r.committed)
+	}
+}
@@ -0,0 +1,174 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// 为了不让 libtools 强依赖某个 redis 客户端, 需要跨进程共享状态的功能(幂等、登录限制等)都通过 KVStore 存取,
+// 业务方用 go-redis/redigo 等实现该接口即可, 单机场景可直接使用 NewMemoryKV
+
+// KVStore 带过期时间的 kv 存储, ttl <= 0 表示永不过期
+type KVStore interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX key 不存在时写入并返回 true, 对应 redis 的 SET key value NX PX ttl
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// KVIncrementer KVStore 的可选接口, 原子自增并返回新值, key 不存在时从 0 开始
+// 对应 redis 的 INCR, 新建 key 时再设置 EXPIRE; 用于 DailySeq 等计数场景
+type KVIncrementer interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// KVGetDeleter KVStore 的可选接口, 原子地读取并删除 key, 对应 redis 的 GETDEL; 用于验证码等只能使用一次的值
+type KVGetDeleter interface {
+	GetDel(ctx context.Context, key string) ([]byte, bool, error)
+}
+
+// kvGetDel 存储实现了 KVGetDeleter 时使用 GetDel, 否则读取后用 SetNX 占用 key:taken, 并发读取同一个 key 时只有一方成功
+// claimTTL 为占用标记的保留时间, 不短于 key 本身的有效期即可
+func kvGetDel(ctx context.Context, store KVStore, key string, claimTTL time.Duration) ([]byte, bool, error) {
+	if getDel, ok := store.(KVGetDeleter); ok {
+		return getDel.GetDel(ctx, key)
+	}
+
+	value, ok, err := store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	claimed, err := store.SetNX(ctx, key+":taken", []byte{'1'}, claimTTL)
+	if err != nil || !claimed {
+		return nil, false, err
+	}
+	if err = store.Delete(ctx, key); err != nil {
+		logs.Warning("[kvGetDel] delete fail, key: %s, err: %v", key, err)
+	}
+
+	return value, true, nil
+}
+
+// kvIncr 存储实现了 KVIncrementer 时使用 Incr, 否则通过 kvClaimIncr 逐个占号, 两者都不会返回重复的值
+func kvIncr(ctx context.Context, store KVStore, key string, ttl time.Duration) (int64, error) {
+	if incr, ok := store.(KVIncrementer); ok {
+		return incr.Incr(ctx, key, ttl)
+	}
+
+	return kvClaimIncr(ctx, store, key, ttl)
+}
+
+// kvClaimIncr 不支持自增的存储: 以 key 中记录的最近值为起点, 用 SetNX 占用 key:N, 成功即得到 N
+// 记录的值可能落后于实际值, 只会多尝试几次, 不会返回重复的值
+func kvClaimIncr(ctx context.Context, store KVStore, key string, ttl time.Duration) (int64, error) {
+	var last int64
+	if v, ok, err := store.Get(ctx, key); err != nil {
+		return 0, err
+	} else if ok {
+		last, _ = strconv.ParseInt(string(v), 10, 64)
+	}
+
+	for n := last + 1; n <= last+10000; n++ {
+		claimed, err := store.SetNX(ctx, fmt.Sprintf("%s:%d", key, n), []byte{'1'}, ttl)
+		if err != nil {
+			return 0, err
+		}
+		if claimed {
+			if err = store.Set(ctx, key, []byte(strconv.FormatInt(n, 10)), ttl); err != nil {
+				logs.Warning("[kvClaimIncr] save last value fail, key: %s, err: %v", key, err)
+			}
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not claim next value for %s", key)
+}
+
+// memoryKV 基于 TTLCache 的进程内实现
+type memoryKV struct {
+	mu    sync.Mutex // 保证 SetNX/Incr 等读后写操作的原子性
+	cache *TTLCache
+}
+
+// NewMemoryKV 进程内的 KVStore, 重启后数据丢失, 多实例部署时请使用 redis 实现
+func NewMemoryKV() KVStore {
+	return &memoryKV{cache: NewTTLCache(0)}
+}
+
+func (m *memoryKV) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	v, ok := m.cache.Get(key)
+	m.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	return v.([]byte), true, nil
+}
+
+func (m *memoryKV) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	m.cache.Set(key, value, ttl)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryKV) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.cache.Get(key); ok {
+		return false, nil
+	}
+	m.cache.Set(key, value, ttl)
+
+	return true, nil
+}
+
+// Incr 值按十进制字符串存储, 与 redis 一致
+func (m *memoryKV) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	if v, ok := m.cache.Get(key); ok {
+		var err error
+		if n, err = strconv.ParseInt(string(v.([]byte)), 10, 64); err != nil {
+			return 0, fmt.Errorf("value of %s is not an integer", key)
+		}
+		// 已存在的 key 保持原有的过期时间
+		if remaining, ok := m.cache.TTL(key); ok {
+			ttl = remaining
+		}
+	}
+	n++
+	m.cache.Set(key, []byte(strconv.FormatInt(n, 10)), ttl)
+
+	return n, nil
+}
+
+func (m *memoryKV) GetDel(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	m.cache.Delete(key)
+
+	return v.([]byte), true, nil
+}
+
+func (m *memoryKV) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	m.cache.Delete(key)
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,62 @@
+package libtools
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginGuard(t *testing.T) {
+	guard := NewLoginGuard(LoginGuardOptions{MaxFailures: 2, BaseLockout: 20 * time.Millisecond})
+
+	if d := guard.RecordFailure("13800000000", "1.2.3.4"); d != 0 {
+		t.Fatalf("first failure should not lock, got %s", d)
+	}
+	if d := guard.RecordFailure("13800000000", "1.2.3.4"); d != 20*time.Millisecond {
+		t.Fatalf("first lockout = %s", d)
+	}
+	if locked, _ := guard.IsLocked("13800000000", "1.2.3.4"); !locked {
+		t.Fatal("should be locked")
+	}
+	if locked, _ := guard.IsLocked("13800000000", "5.6.7.8"); locked {
+		t.Fatal("other ip should not be locked")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	guard.RecordFailure("13800000000", "1.2.3.4")
+	if d := guard.RecordFailure("13800000000", "1.2.3.4"); d != 40*time.Millisecond {
+		t.Fatalf("second lockout should double, got %s", d)
+	}
+
+	guard.Reset("13800000000", "1.2.3.4")
+	if locked, _ := guard.IsLocked("13800000000", "1.2.3.4"); locked {
+		t.Fatal("should be unlocked after reset")
+	}
+}
+
+func TestLoginGuardConcurrent(t *testing.T) {
+	for name, store := range map[string]KVStore{"incr": NewMemoryKV(), "claim": claimOnlyKV{NewMemoryKV()}} {
+		guard := NewLoginGuard(LoginGuardOptions{Store: store, MaxFailures: 5, BaseLockout: time.Minute})
+
+		var lockouts int32
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if guard.RecordFailure("13800000000", "1.2.3.4") > 0 {
+					atomic.AddInt32(&lockouts, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if lockouts != 1 {
+			t.Errorf("%s: parallel failures should lock exactly once, got %d", name, lockouts)
+		}
+		if locked, _ := guard.IsLocked("13800000000", "1.2.3.4"); !locked {
+			t.Errorf("%s: should be locked", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package libtools
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// secureIntn 返回 [0, n) 内均匀分布的随机数, 使用 crypto/rand, 不存在取模偏差
+func secureIntn(n int64) (int64, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(n))
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Int64(), nil
+}
+
+// WeightedPick 按整数权重随机选取一项, 第 i 项被选中的概率严格等于 weights[i] / sum(weights)
+// 权重为 0 的项不会被选中, 权重不能为负
+func WeightedPick[T any](items []T, weights []int) (picked T, err error) {
+	if len(items) != len(weights) {
+		err = fmt.Errorf("items and weights length mismatch: %d != %d", len(items), len(weights))
+		return
+	}
+
+	idx, err := weightedIndex(weights)
+	if err != nil {
+		return
+	}
+
+	return items[idx], nil
+}
+
+func weightedIndex(weights []int) (int, error) {
+	var total int64
+	for i, w := range weights {
+		if w < 0 {
+			return 0, fmt.Errorf("weight can not be negative, index: %d, weight: %d", i, w)
+		}
+		total += int64(w)
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("total weight is zero")
+	}
+
+	n, err := secureIntn(total)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, w := range weights {
+		n -= int64(w)
+		if n < 0 {
+			return i, nil
+		}
+	}
+
+	// 不会走到这里
+	return len(weights) - 1, nil
+}
+
+// Prize 抽奖奖品, 谢谢参与也应作为一个奖品配置, 这样所有奖品的中奖概率之和为 1
+type Prize struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// LotteryDraw 按奖品权重抽取一个奖品, 库存等业务校验由调用方在配置奖品列表时处理
+func LotteryDraw(prizes []Prize) (prize Prize, err error) {
+	weights := make([]int, len(prizes))
+	for i, p := range prizes {
+		weights[i] = p.Weight
+	}
+
+	idx, err := weightedIndex(weights)
+	if err != nil {
+		return
+	}
+
+	return prizes[idx], nil
+}
+
+// LotteryProbabilities 各奖品的中奖概率, 以最简分数字符串表示(如 "1/50"), 便于活动页公示
+func LotteryProbabilities(prizes []Prize) map[string]string {
+	var total int64
+	for _, p := range prizes {
+		if p.Weight > 0 {
+			total += int64(p.Weight)
+		}
+	}
+
+	result := make(map[string]string, len(prizes))
+	for _, p := range prizes {
+		if p.Weight <= 0 || total == 0 {
+			result[p.ID] = "0"
+			continue
+		}
+		result[p.ID] = big.NewRat(int64(p.Weight), total).RatString()
+	}
+
+	return result
+}
@@ -0,0 +1,56 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestWeightedPick(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		picked, err := WeightedPick(items, []int{1, 0, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[picked]++
+	}
+	if counts["b"] != 0 {
+		t.Errorf("zero weight should never be picked: %v", counts)
+	}
+	if counts["c"] < 2700 || counts["c"] > 3300 {
+		t.Errorf("weight 3/4 is picked %d/4000 times", counts["c"])
+	}
+
+	bad := [][]int{{1, 2}, {0, 0, 0}, {1, -1, 1}}
+	for _, weights := range bad {
+		if _, err := WeightedPick(items, weights); err == nil {
+			t.Errorf("weights %v should fail", weights)
+		}
+	}
+}
+
+func TestLotteryDraw(t *testing.T) {
+	prizes := []Prize{
+		{ID: "phone", Weight: 1},
+		{ID: "coupon", Weight: 9},
+		{ID: "thanks", Weight: 40},
+		{ID: "offline", Weight: 0},
+	}
+	for i := 0; i < 200; i++ {
+		prize, err := LotteryDraw(prizes)
+		if err != nil || prize.ID == "offline" {
+			t.Fatalf("unexpected prize: %+v, %v", prize, err)
+		}
+	}
+	if _, err := LotteryDraw(nil); err == nil {
+		t.Error("empty prizes should fail")
+	}
+
+	probabilities := LotteryProbabilities(prizes)
+	want := map[string]string{"phone": "1/50", "coupon": "9/50", "thanks": "4/5", "offline": "0"}
+	for id, p := range want {
+		if probabilities[id] != p {
+			t.Errorf("%s: got %s, want %s", id, probabilities[id], p)
+		}
+	}
+}
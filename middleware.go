@@ -0,0 +1,165 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// writeJSONError 中间件统一的错误响应, 如 {"code":504,"message":"request timeout"}
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"code":` + strconv.Itoa(status) + `,"message":"` + message + `"}`))
+}
+
+// WithTimeout 请求处理超过 d 时返回 504, handler 需通过 r.Context() 感知超时并尽早退出
+// 响应在 handler 返回前缓存在内存中, 不适用于流式输出与大文件下载
+func WithTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicChan := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					writeJSONError(w, http.StatusGatewayTimeout, "request timeout")
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter 超时后 handler 的写入返回 http.ErrHandlerTimeout
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// WithMaxBody 限制请求体大小, Content-Length 已超出时直接返回 413, 否则读取超过 n 字节时读取方会得到错误
+func WithMaxBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithIPPolicy 按客户端 ip(见 ClientIP) 放行请求, deny 优先; allow 为空时放行 deny 以外的所有 ip
+// 如只允许内网访问: WithIPPolicy([]string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.1"}, nil)
+// 网段配置错误时 panic, 避免错误的配置在运行时静默放行
+func WithIPPolicy(allow, deny []string) func(http.Handler) http.Handler {
+	allowMatcher, err := NewIPMatcher(allow)
+	if err != nil {
+		panic("WithIPPolicy: allow list " + err.Error())
+	}
+	denyMatcher, err := NewIPMatcher(deny)
+	if err != nil {
+		panic("WithIPPolicy: deny list " + err.Error())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if denyMatcher.Contains(ip) || (allowMatcher.Len() > 0 && !allowMatcher.Contains(ip)) {
+				logs.Warning("[WithIPPolicy] reject ip: %s, path: %s", ip, r.URL.Path)
+				writeJSONError(w, http.StatusForbidden, "ip not allowed")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RecoveryMiddleware handler panic 时返回 500, 并记录日志与上报(见 ReportError)
+// 客户端断开等原因产生的 http.ErrAbortHandler 按原样抛出, 由 net/http 处理
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				reportPanic(r.Context(), "RecoveryMiddleware", p, map[string]string{"http.method": r.Method, "http.path": r.URL.Path})
+				writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
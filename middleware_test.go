@@ -0,0 +1,90 @@
+package libtools
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	handler := WithTimeout(50 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		w.Header().Set("X-Handler", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "ok" || w.Header().Get("X-Handler") != "1" {
+		t.Errorf("fast request get unexpected result: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), `"code":504`) || w.Header().Get("X-Handler") != "" {
+		t.Errorf("slow request should time out, get: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWithMaxBody(t *testing.T) {
+	handler := WithMaxBody(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("small")))
+	if w.Code != http.StatusOK {
+		t.Errorf("small body should pass, get %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 100))))
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), `"code":413`) {
+		t.Errorf("large body should be rejected early, get %d %s", w.Code, w.Body.String())
+	}
+
+	// 未声明 Content-Length 时在读取时拦截
+	r := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 100)))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked large body should be rejected, get %d", w.Code)
+	}
+}
+
+func TestWithIPPolicy(t *testing.T) {
+	handler := WithIPPolicy([]string{"10.0.0.0/8", "203.0.113.7"}, []string{"10.0.0.66"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	td := map[string]int{
+		"10.1.2.3:80":    http.StatusOK,
+		"10.0.0.66:80":   http.StatusForbidden,
+		"203.0.113.7:80": http.StatusOK,
+		"8.8.8.8:80":     http.StatusForbidden,
+	}
+	for remote, want := range td {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("WithIPPolicy(%s) get %d, want: %d", remote, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package libtools
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// MigrateOptions Migrate 的参数, 零值可用
+type MigrateOptions struct {
+	// Dir 迁移文件在 fs 中的目录, 默认为根目录
+	Dir string
+	// Table 记录已执行版本的表名, 默认 schema_migrations
+	Table string
+	// Dialect 为 postgres 时使用 $1 占位符, 其他情况使用 ?
+	Dialect string
+	// DryRun 只输出待执行的 SQL, 不做任何修改
+	DryRun bool
+	// Output DryRun 以及执行进度的输出, 默认丢弃
+	Output io.Writer
+}
+
+type migrationFile struct {
+	version  string
+	name     string
+	content  string
+	checksum string
+}
+
+// Migrate 按文件名顺序执行 fsys 中尚未执行过的 .sql 文件, 如 0001_init.sql, 0002_add_index.sql
+// 已执行的文件被修改过(校验和不一致)时报错退出, 返回本次执行(或 DryRun 时将要执行)的版本
+// 文件内的多条语句按行尾的 `;` 拆分, 不支持存储过程等语句体内带分号的写法
+func Migrate(db *sql.DB, fsys fs.FS, opts ...MigrateOptions) (applied []string, err error) {
+	var opt MigrateOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Dir == "" {
+		opt.Dir = "."
+	}
+	if opt.Table == "" {
+		opt.Table = "schema_migrations"
+	}
+	if opt.Output == nil {
+		opt.Output = ioutil.Discard
+	}
+
+	files, err := loadMigrationFiles(fsys, opt.Dir)
+	if err != nil {
+		return
+	}
+
+	if !opt.DryRun {
+		_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version VARCHAR(255) NOT NULL PRIMARY KEY,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at BIGINT NOT NULL
+)`, opt.Table))
+		if err != nil {
+			err = fmt.Errorf("could not create migration table: %v", err)
+			return
+		}
+	}
+
+	done, err := loadAppliedMigrations(db, opt.Table)
+	if err != nil {
+		if !opt.DryRun {
+			return
+		}
+		// DryRun 时表可能还不存在
+		done = map[string]string{}
+		err = nil
+	}
+
+	for _, file := range files {
+		checksum, ok := done[file.version]
+		if ok {
+			if checksum != file.checksum {
+				err = fmt.Errorf("migration %s has been modified after applied, checksum: %s, expect: %s", file.name, file.checksum, checksum)
+				return
+			}
+			continue
+		}
+
+		if opt.DryRun {
+			_, _ = fmt.Fprintf(opt.Output, "-- migration: %s (checksum: %s)\n%s\n\n", file.name, file.checksum, strings.TrimSpace(file.content))
+			applied = append(applied, file.version)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(opt.Output, "applying migration: %s\n", file.name)
+		err = applyMigration(db, opt, file)
+		if err != nil {
+			err = fmt.Errorf("apply migration %s fail: %v", file.name, err)
+			return
+		}
+		logs.Info("[Migrate] migration applied: %s", file.name)
+		applied = append(applied, file.version)
+	}
+
+	return
+}
+
+func loadMigrationFiles(fsys fs.FS, dir string) ([]migrationFile, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read migration dir: %v", err)
+	}
+
+	var files []migrationFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		buf, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		content := string(buf)
+		files = append(files, migrationFile{
+			version:  strings.TrimSuffix(entry.Name(), ".sql"),
+			name:     entry.Name(),
+			content:  content,
+			checksum: Sha256(content),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].version < files[j].version
+	})
+
+	return files, nil
+}
+
+func loadAppliedMigrations(db *sql.DB, table string) (map[string]string, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version, checksum FROM %s", table))
+	if err != nil {
+		return nil, fmt.Errorf("could not query applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	done := make(map[string]string)
+	for rows.Next() {
+		var version, checksum string
+		if err = rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+		done[version] = checksum
+	}
+
+	return done, rows.Err()
+}
+
+func applyMigration(db *sql.DB, opt MigrateOptions, file migrationFile) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range splitSQLStatements(file.content) {
+		if _, err = tx.Exec(stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (version, checksum, applied_at) VALUES (?, ?, ?)", opt.Table)
+	if opt.Dialect == "postgres" {
+		insert = fmt.Sprintf("INSERT INTO %s (version, checksum, applied_at) VALUES ($1, $2, $3)", opt.Table)
+	}
+	if _, err = tx.Exec(insert, file.version, file.checksum, GetUnixMillis()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// splitSQLStatements 按行尾分号拆分语句, 跳过 -- 注释行
+func splitSQLStatements(content string) []string {
+	var stmts []string
+	var current []string
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(strings.Join(current, "\n")), ";"))
+			current = nil
+		}
+	}
+
+	if rest := strings.TrimSpace(strings.Join(current, "\n")); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
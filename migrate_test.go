@@ -0,0 +1,211 @@
+package libtools
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeMigrateDBT 模拟数据库: 只认识迁移表的建表/查询/插入, 其他语句记录下来, 包含 FAIL 的语句执行失败
+type fakeMigrateDBT struct {
+	mu          sync.Mutex
+	tableExists bool
+	applied     map[string]string
+	execs       []string
+}
+
+var (
+	fakeMigrateOnce sync.Once
+	fakeMigrateDBs  sync.Map
+)
+
+type fakeMigrateDriverT struct{}
+
+func (fakeMigrateDriverT) Open(name string) (driver.Conn, error) {
+	db, _ := fakeMigrateDBs.Load(name)
+	return &fakeMigrateConnT{db: db.(*fakeMigrateDBT)}, nil
+}
+
+type fakeMigrateConnT struct {
+	db      *fakeMigrateDBT
+	inTx    bool
+	pending []func()
+}
+
+func (c *fakeMigrateConnT) Prepare(query string) (driver.Stmt, error) {
+	return &fakeMigrateStmtT{conn: c, query: query}, nil
+}
+
+func (c *fakeMigrateConnT) Close() error { return nil }
+
+func (c *fakeMigrateConnT) Begin() (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return c, nil
+}
+
+func (c *fakeMigrateConnT) Commit() error {
+	c.db.mu.Lock()
+	for _, apply := range c.pending {
+		apply()
+	}
+	c.db.mu.Unlock()
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeMigrateConnT) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+type fakeMigrateStmtT struct {
+	conn  *fakeMigrateConnT
+	query string
+}
+
+func (s *fakeMigrateStmtT) Close() error  { return nil }
+func (s *fakeMigrateStmtT) NumInput() int { return -1 }
+
+func (s *fakeMigrateStmtT) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "FAIL") {
+		return nil, errors.New("syntax error")
+	}
+
+	db, query := s.conn.db, s.query
+	var apply func()
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		apply = func() { db.tableExists = true }
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		apply = func() {
+			db.applied[args[0].(string)] = args[1].(string)
+			db.execs = append(db.execs, query)
+		}
+	default:
+		apply = func() { db.execs = append(db.execs, query) }
+	}
+
+	if s.conn.inTx {
+		s.conn.pending = append(s.conn.pending, apply)
+	} else {
+		db.mu.Lock()
+		apply()
+		db.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeMigrateStmtT) Query(_ []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !db.tableExists {
+		return nil, errors.New("table schema_migrations does not exist")
+	}
+
+	rows := &fakeMigrateRowsT{}
+	for version, checksum := range db.applied {
+		rows.values = append(rows.values, []driver.Value{version, checksum})
+	}
+	return rows, nil
+}
+
+type fakeMigrateRowsT struct {
+	values [][]driver.Value
+}
+
+func (r *fakeMigrateRowsT) Columns() []string { return []string{"version", "checksum"} }
+func (r *fakeMigrateRowsT) Close() error      { return nil }
+
+func (r *fakeMigrateRowsT) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func openFakeMigrateDBT(t *testing.T) (*sql.DB, *fakeMigrateDBT) {
+	t.Helper()
+	fakeMigrateOnce.Do(func() { sql.Register("libtools-fake-migrate", fakeMigrateDriverT{}) })
+
+	state := &fakeMigrateDBT{applied: map[string]string{}}
+	fakeMigrateDBs.Store(t.Name(), state)
+	db, err := sql.Open("libtools-fake-migrate", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	db.SetMaxOpenConns(1)
+
+	return db, state
+}
+
+func TestMigrate(t *testing.T) {
+	db, state := openFakeMigrateDBT(t)
+	fsys := fstest.MapFS{
+		"migrations/0002_add_index.sql": {Data: []byte("-- 查询用\nCREATE INDEX idx_user ON loan(user_id);\n")},
+		"migrations/0001_init.sql":      {Data: []byte("CREATE TABLE loan (\n  id BIGINT,\n  user_id BIGINT\n);\nINSERT INTO loan VALUES (1, 1);\n")},
+		"migrations/README.md":          {Data: []byte("not a migration")},
+	}
+	opt := MigrateOptions{Dir: "migrations"}
+
+	var out bytes.Buffer
+	applied, err := Migrate(db, fsys, MigrateOptions{Dir: "migrations", DryRun: true, Output: &out})
+	if err != nil || strings.Join(applied, ",") != "0001_init,0002_add_index" {
+		t.Fatalf("dry run: %v, %v", applied, err)
+	}
+	if !strings.Contains(out.String(), "-- migration: 0001_init.sql") || state.tableExists || len(state.execs) != 0 {
+		t.Fatalf("dry run should only print sql, output: %s, execs: %v", out.String(), state.execs)
+	}
+
+	if applied, err = Migrate(db, fsys, opt); err != nil || len(applied) != 2 {
+		t.Fatalf("migrate: %v, %v", applied, err)
+	}
+	want := []string{"CREATE TABLE loan (\n  id BIGINT,\n  user_id BIGINT\n)", "INSERT INTO loan VALUES (1, 1)"}
+	if len(state.execs) != 5 || state.execs[0] != want[0] || state.execs[1] != want[1] || state.execs[3] != "CREATE INDEX idx_user ON loan(user_id)" {
+		t.Fatalf("unexpected statements: %q", state.execs)
+	}
+	if state.applied["0001_init"] != Sha256(string(fsys["migrations/0001_init.sql"].Data)) {
+		t.Errorf("unexpected checksum: %v", state.applied)
+	}
+
+	if applied, err = Migrate(db, fsys, opt); err != nil || len(applied) != 0 {
+		t.Errorf("applied migrations should be skipped: %v, %v", applied, err)
+	}
+
+	// 执行失败时整个文件回滚, 不记录版本
+	fsys["migrations/0003_bad.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE loan ADD amount BIGINT;\nFAIL;\n")}
+	execs := len(state.execs)
+	if _, err = Migrate(db, fsys, opt); err == nil || len(state.execs) != execs || state.applied["0003_bad"] != "" {
+		t.Errorf("failed migration should roll back, err: %v, execs: %q", err, state.execs[execs:])
+	}
+
+	fsys["migrations/0003_bad.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE loan ADD amount BIGINT;\n")}
+	if applied, err = Migrate(db, fsys, MigrateOptions{Dir: "migrations", Dialect: "postgres"}); err != nil || len(applied) != 1 {
+		t.Fatalf("migrate: %v, %v", applied, err)
+	}
+	if !strings.Contains(state.execs[len(state.execs)-1], "VALUES ($1, $2, $3)") {
+		t.Errorf("postgres should use $n placeholder: %s", state.execs[len(state.execs)-1])
+	}
+
+	fsys["migrations/0001_init.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE loan (id BIGINT);\n")}
+	if _, err = Migrate(db, fsys, opt); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("modified migration should fail, got: %v", err)
+	}
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	stmts := splitSQLStatements("-- comment\nCREATE TABLE a (id INT);\n\nUPDATE a\nSET id = 1;\nSELECT 1")
+	if len(stmts) != 3 || stmts[0] != "CREATE TABLE a (id INT)" || stmts[1] != "UPDATE a\nSET id = 1" || stmts[2] != "SELECT 1" {
+		t.Errorf("unexpected statements: %q", stmts)
+	}
+}
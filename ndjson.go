@@ -0,0 +1,121 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// NDJSONResult ReadNDJSON 的统计
+type NDJSONResult struct {
+	Lines   int `json:"lines"`   // 成功解析并处理的行数
+	Skipped int `json:"skipped"` // 无法解析而跳过的行数
+}
+
+// WriteNDJSON 写入 NDJSON(每行一个 json), v 为 slice/array 时每个元素一行, 否则写一行
+func WriteNDJSON(w io.Writer, v interface{}) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			// Encoder 会在每个值后追加换行
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return fmt.Errorf("ndjson encode item %d fail: %v", i, err)
+			}
+		}
+	} else if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// WriteNDJSONFile 写入文件, 文件名以 .gz 结尾时使用 gzip 压缩
+func WriteNDJSONFile(filename string, v interface{}) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if !strings.HasSuffix(filename, ".gz") {
+		return WriteNDJSON(f, v)
+	}
+
+	gz := gzip.NewWriter(f)
+	if err = WriteNDJSON(gz, v); err != nil {
+		return
+	}
+	return gz.Close()
+}
+
+// ReadNDJSON 逐行解析为 T 并交给 fn 处理, 输入为 gzip 时自动解压
+// 无法解析的行记录日志后跳过, 空行忽略; fn 返回错误时立即停止并返回该错误
+func ReadNDJSON[T any](r io.Reader, fn func(T) error) (result NDJSONResult, err error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, gzErr := gzip.NewReader(br)
+		if gzErr != nil {
+			err = fmt.Errorf("could not open gzip ndjson: %v", gzErr)
+			return
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	lineNo := 0
+	for {
+		// 不使用 bufio.Scanner, 避免超长行被截断
+		line, readErr := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				var item T
+				if jsonErr := json.Unmarshal(line, &item); jsonErr != nil {
+					logs.Warning("[ReadNDJSON] skip invalid line %d, err: %v", lineNo, jsonErr)
+					result.Skipped++
+				} else {
+					if err = fn(item); err != nil {
+						return
+					}
+					result.Lines++
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return
+		}
+		if readErr != nil {
+			err = readErr
+			return
+		}
+	}
+}
+
+// ReadNDJSONFile 读取 NDJSON 文件, 支持 gzip 压缩的文件
+func ReadNDJSONFile[T any](filename string, fn func(T) error) (NDJSONResult, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return NDJSONResult{}, err
+	}
+	defer f.Close()
+
+	return ReadNDJSON(f, fn)
+}
@@ -0,0 +1,36 @@
+package libtools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+func TestNDJSON(t *testing.T) {
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := WriteNDJSON(gz, []row{{1, "a"}, {2, "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = gz.Write([]byte("{broken\n\n"))
+	_ = WriteNDJSON(gz, row{3, "c"})
+	_ = gz.Close()
+
+	var names []string
+	result, err := ReadNDJSON(&buf, func(r row) error {
+		names = append(names, r.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "a,b,c" || result.Lines != 3 || result.Skipped != 1 {
+		t.Errorf("unexpected result: %v, %+v", names, result)
+	}
+}
@@ -3,8 +3,11 @@ package libtools
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"net"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/beego/beego/v2/core/logs"
 )
@@ -29,3 +32,223 @@ func Long2IP4(ipLong int64) string {
 
 	return b0 + "." + b1 + "." + b2 + "." + b3
 }
+
+// ExpandCIDRMax ExpandCIDR 最多展开的 ip 数量,防止误传 /8 之类的大网段撑爆内存
+const ExpandCIDRMax = 65536
+
+// IPToUint32 仅支持 ipv4
+func IPToUint32(ip string) (uint32, error) {
+	ipv4 := net.ParseIP(ip).To4()
+	if ipv4 == nil {
+		return 0, fmt.Errorf("invalid ipv4 address: %s", ip)
+	}
+
+	return binary.BigEndian.Uint32(ipv4), nil
+}
+
+func Uint32ToIP(n uint32) string {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, n)
+
+	return ip.String()
+}
+
+// GetFreePort 向系统申请一个当前可用的 tcp 端口
+func GetFreePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// IsValidHostPort 校验 host:port 格式, host 可以是域名或 ip, ipv6 需要用 [] 包裹
+func IsValidHostPort(s string) bool {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || host == "" {
+		return false
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum <= 0 || portNum > 65535 {
+		return false
+	}
+
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	return isValidHostname(host)
+}
+
+func isValidHostname(host string) bool {
+	if len(host) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CIDRContains 判断 ip 是否在网段内, cidr 也可以直接是单个 ip
+func CIDRContains(cidr, ip string) bool {
+	target := net.ParseIP(ip)
+	if target == nil {
+		return false
+	}
+
+	if !strings.Contains(cidr, "/") {
+		single := net.ParseIP(cidr)
+		return single != nil && single.Equal(target)
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		logs.Warning("[CIDRContains] invalid cidr: %s, err: %v", cidr, err)
+		return false
+	}
+
+	return ipNet.Contains(target)
+}
+
+// ExpandCIDR 展开 ipv4 网段内的所有地址(包含网络地址与广播地址), 超过 ExpandCIDRMax 时返回错误
+func ExpandCIDR(cidr string) ([]string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	if ipNet.IP.To4() == nil {
+		return nil, fmt.Errorf("only ipv4 cidr can be expanded: %s", cidr)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	total := uint64(1) << uint(bits-ones)
+	if total > ExpandCIDRMax {
+		return nil, fmt.Errorf("cidr %s contains %d addresses, exceeds limit %d", cidr, total, ExpandCIDRMax)
+	}
+
+	start := binary.BigEndian.Uint32(ipNet.IP.To4())
+	ips := make([]string, 0, total)
+	for i := uint64(0); i < total; i++ {
+		ips = append(ips, Uint32ToIP(start+uint32(i)))
+	}
+
+	return ips, nil
+}
+
+// ClientIP 取请求的客户端 ip
+// 只有直连方是内网地址(即经过自己的反向代理)时才信任 X-Forwarded-For 与 X-Real-IP, 防止外部直接伪造请求头;
+// X-Forwarded-For 从右往左取第一个非内网地址, 左侧的值可能由客户端随意填写;
+// 全部为内网地址(内网客户端经过代理)时取最右侧的一个, 即自己的代理看到的地址, 不取可能被伪造的最左侧
+func ClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !IsPrivateIP(remote) {
+		return remote
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		nearest := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				continue
+			}
+			if !IsPrivateIP(hop) {
+				return hop
+			}
+			if nearest == "" {
+				nearest = hop
+			}
+		}
+		if nearest != "" {
+			return nearest
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return remote
+}
+
+// IPMatcher 预先解析的一组网段, 用于高频匹配的场景, 如按 ip 放行的中间件
+type IPMatcher struct {
+	nets []*net.IPNet
+}
+
+// NewIPMatcher cidrs 中可以混合单个 ip 与网段, 支持 ipv6
+func NewIPMatcher(cidrs []string) (*IPMatcher, error) {
+	m := &IPMatcher{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip: %s", cidr)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			m.nets = append(m.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr: %s, err: %v", cidr, err)
+		}
+		m.nets = append(m.nets, ipNet)
+	}
+
+	return m, nil
+}
+
+// Contains ip 是否在任一网段内, ipv4-mapped ipv6 地址按 ipv4 匹配
+func (m *IPMatcher) Contains(ip string) bool {
+	target := net.ParseIP(ip)
+	if target == nil {
+		return false
+	}
+	if v4 := target.To4(); v4 != nil {
+		target = v4
+	}
+
+	for _, ipNet := range m.nets {
+		if ipNet.Contains(target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Len 网段数量
+func (m *IPMatcher) Len() int {
+	return len(m.nets)
+}
@@ -0,0 +1,97 @@
+package libtools
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPToUint32(t *testing.T) {
+	n, err := IPToUint32("192.168.1.10")
+	if err != nil || n != 3232235786 {
+		t.Errorf("IPToUint32 get unexpected result: %d, err: %v", n, err)
+	}
+
+	if ip := Uint32ToIP(n); ip != "192.168.1.10" {
+		t.Errorf("Uint32ToIP get unexpected result: %s", ip)
+	}
+
+	if _, err = IPToUint32("::1"); err == nil {
+		t.Errorf("IPToUint32 should reject ipv6")
+	}
+}
+
+func TestCIDR(t *testing.T) {
+	td := []struct {
+		cidr string
+		ip   string
+		in   bool
+	}{
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/8", "11.1.2.3", false},
+		{"172.16.0.0/12", "172.31.255.255", true},
+		{"127.0.0.1", "127.0.0.1", true},
+		{"fd00::/8", "fd00::1", true},
+		{"bad", "10.1.2.3", false},
+	}
+	for _, d := range td {
+		if CIDRContains(d.cidr, d.ip) != d.in {
+			t.Errorf("CIDRContains(%s, %s) want: %v", d.cidr, d.ip, d.in)
+		}
+	}
+
+	ips, err := ExpandCIDR("192.168.0.0/30")
+	if err != nil || len(ips) != 4 || ips[3] != "192.168.0.3" {
+		t.Errorf("ExpandCIDR get unexpected result: %v, err: %v", ips, err)
+	}
+
+	if _, err = ExpandCIDR("10.0.0.0/8"); err == nil {
+		t.Errorf("ExpandCIDR should refuse too large cidr")
+	}
+}
+
+func TestIsValidHostPort(t *testing.T) {
+	td := map[string]bool{
+		"example.com:443": true,
+		"127.0.0.1:80":    true,
+		"[::1]:8080":      true,
+		"example.com":     false,
+		"example.com:0":   false,
+		"-bad.com:80":     false,
+		":80":             false,
+	}
+	for s, valid := range td {
+		if IsValidHostPort(s) != valid {
+			t.Errorf("IsValidHostPort(%s) want: %v", s, valid)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	td := []struct {
+		remote string
+		xff    string
+		realIP string
+		want   string
+	}{
+		{"8.8.8.8:1234", "1.1.1.1", "", "8.8.8.8"},
+		{"10.0.0.2:1234", "6.6.6.6, 1.1.1.1, 10.0.0.3", "", "1.1.1.1"},
+		{"10.0.0.2:1234", "10.0.0.5", "", "10.0.0.5"},
+		// 全部为内网地址时不取客户端可伪造的最左侧
+		{"10.0.0.2:1234", "127.0.0.1, 10.0.0.5, 10.0.0.3", "", "10.0.0.3"},
+		{"10.0.0.2:1234", "", "2.2.2.2", "2.2.2.2"},
+		{"10.0.0.2:1234", "bad", "", "10.0.0.2"},
+	}
+	for _, d := range td {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = d.remote
+		if d.xff != "" {
+			r.Header.Set("X-Forwarded-For", d.xff)
+		}
+		if d.realIP != "" {
+			r.Header.Set("X-Real-IP", d.realIP)
+		}
+		if got := ClientIP(r); got != d.want {
+			t.Errorf("ClientIP(%s, %s) = %s, want: %s", d.remote, d.xff, got, d.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package libtools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta 提前刷新的时间, 避免 token 在请求途中过期
+const oauth2ExpiryDelta = 60 * time.Second
+
+// TokenSource OAuth2 client credentials 模式的 access token 管理, 自动缓存与刷新, 并发获取时只请求一次
+type TokenSource struct {
+	tokenURL string
+	clientID string
+	secret   string
+	scopes   []string
+
+	mu       sync.Mutex
+	token    string
+	expiry   time.Time
+	inflight *oauth2Call
+}
+
+type oauth2Call struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// NewTokenSource 如: NewTokenSource("https://auth.partner.com/oauth/token", "client_id", "secret", []string{"loan.read"})
+func NewTokenSource(tokenURL, clientID, secret string, scopes []string) *TokenSource {
+	return &TokenSource{
+		tokenURL: tokenURL,
+		clientID: clientID,
+		secret:   secret,
+		scopes:   scopes,
+	}
+}
+
+// Token 返回有效的 access token, 即将过期时重新获取
+func (ts *TokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	if ts.token != "" && time.Now().Add(oauth2ExpiryDelta).Before(ts.expiry) {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+
+	call := ts.inflight
+	if call == nil {
+		call = &oauth2Call{done: make(chan struct{})}
+		ts.inflight = call
+		// 使用独立的 context, 发起请求的调用方取消时不影响其他等待者
+		go ts.refresh(call)
+	}
+	ts.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Invalidate 丢弃缓存的 token, 如服务端返回 401 时
+func (ts *TokenSource) Invalidate() {
+	ts.mu.Lock()
+	ts.token = ""
+	ts.expiry = time.Time{}
+	ts.mu.Unlock()
+}
+
+func (ts *TokenSource) refresh(call *oauth2Call) {
+	token, expiresIn, err := ts.fetch()
+
+	ts.mu.Lock()
+	if err == nil {
+		ts.token = token
+		ts.expiry = time.Now().Add(expiresIn)
+	}
+	ts.inflight = nil
+	ts.mu.Unlock()
+
+	call.token, call.err = token, err
+	close(call.done)
+}
+
+func (ts *TokenSource) fetch() (string, time.Duration, error) {
+	form := map[string]string{"grant_type": "client_credentials"}
+	if len(ts.scopes) > 0 {
+		form["scope"] = strings.Join(ts.scopes, " ")
+	}
+
+	headers := map[string]string{
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(ts.clientID)+":"+url.QueryEscape(ts.secret))),
+		"Accept":        "application/json",
+	}
+
+	body, statusCode, err := HttpRequestWithOptions(context.Background(), HttpMethodPOST, ts.tokenURL, headers, HttpApplicationFormEncoded, form, HttpRequestOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("could not request oauth2 token: %v", err)
+	}
+
+	var resp struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return "", 0, fmt.Errorf("could not unmarshal oauth2 token response, status: %d, err: %v", statusCode, err)
+	}
+	if statusCode != 200 || resp.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 token request failed, status: %d, error: %s %s", statusCode, resp.Error, resp.ErrorDescription)
+	}
+
+	// 部分服务端不返回 expires_in, 按 1 小时处理
+	expiresIn := time.Hour
+	if seconds, err := resp.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		expiresIn = time.Duration(seconds) * time.Second
+	}
+
+	return resp.AccessToken, expiresIn, nil
+}
+
+var httpTokenSources sync.Map // host => *TokenSource
+
+// SetHttpTokenSource 为指定 host 注册 TokenSource, 之后 HttpRequest 请求该 host 时自动添加 Authorization: Bearer 头
+// 调用方已显式传入 Authorization 头时不会覆盖; ts 为 nil 时取消注册
+func SetHttpTokenSource(host string, ts *TokenSource) {
+	host = strings.ToLower(host)
+	if ts == nil {
+		httpTokenSources.Delete(host)
+		return
+	}
+	httpTokenSources.Store(host, ts)
+}
+
+// httpTokenSourceFor 先按 host:port 查找, 再按 host 查找
+func httpTokenSourceFor(u *url.URL) *TokenSource {
+	for _, key := range []string{strings.ToLower(u.Host), strings.ToLower(u.Hostname())} {
+		if v, ok := httpTokenSources.Load(key); ok {
+			return v.(*TokenSource)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startTokenServerT 模拟 client credentials 的 token 接口, 第 n 次请求返回 token-n
+func startTokenServerT(t *testing.T, expiresIn int, fetches *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client" || pass != "s%3Acret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "loan.read loan.write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		n := atomic.AddInt32(fetches, 1)
+		// 模拟较慢的 token 接口, 让并发请求在刷新期间到达
+		time.Sleep(20 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTokenSourceSingleFlight(t *testing.T) {
+	var fetches int32
+	srv := startTokenServerT(t, 3600, &fetches)
+	ts := NewTokenSource(srv.URL, "client", "s:cret", []string{"loan.read", "loan.write"})
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 20)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := ts.Token(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("concurrent Token should fetch once, get %d", fetches)
+	}
+	for _, token := range tokens {
+		if token != "token-1" {
+			t.Fatalf("unexpected token: %v", tokens)
+		}
+	}
+
+	ts.Invalidate()
+	if token, _ := ts.Token(context.Background()); token != "token-2" || fetches != 2 {
+		t.Errorf("Invalidate should force refresh, get %s, fetches %d", token, fetches)
+	}
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	// 有效期短于提前刷新时间, 每次都重新获取
+	var fetches int32
+	srv := startTokenServerT(t, 30, &fetches)
+	ts := NewTokenSource(srv.URL, "client", "s:cret", []string{"loan.read", "loan.write"})
+	for i := 1; i <= 2; i++ {
+		if token, err := ts.Token(context.Background()); err != nil || token != fmt.Sprintf("token-%d", i) {
+			t.Fatalf("Token: %s, %v", token, err)
+		}
+	}
+
+	// 调用方取消时立即返回, 不影响进行中的刷新
+	ts.Invalidate()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ts.Token(ctx); err != context.Canceled {
+		t.Errorf("canceled ctx should return ctx error, get %v", err)
+	}
+
+	bad := NewTokenSource(srv.URL, "client", "wrong", nil)
+	if _, err := bad.Token(context.Background()); err == nil {
+		t.Error("invalid client should fail")
+	}
+}
+
+func TestHttpTokenSourceInvalidateOn401(t *testing.T) {
+	var fetches int32
+	tokenSrv := startTokenServerT(t, 3600, &fetches)
+	ts := NewTokenSource(tokenSrv.URL, "client", "s:cret", []string{"loan.read", "loan.write"})
+
+	// token-1 被服务端提前吊销
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer api.Close()
+
+	u, _ := url.Parse(api.URL)
+	SetHttpTokenSource(u.Host, ts)
+	defer SetHttpTokenSource(u.Host, nil)
+
+	if _, status, err := HttpRequest(HttpMethodGet, api.URL, nil, HttpApplicationJSON, nil); err != nil || status != 401 {
+		t.Fatalf("first request: %d, %v", status, err)
+	}
+	body, status, err := HttpRequest(HttpMethodGet, api.URL, nil, HttpApplicationJSON, nil)
+	if err != nil || status != 200 || string(body) != "ok" || fetches != 2 {
+		t.Errorf("401 should invalidate token: %d %s, fetches %d, err %v", status, body, fetches, err)
+	}
+
+	// 显式传入的 Authorization 不被覆盖
+	if _, status, _ = HttpRequest(HttpMethodGet, api.URL, map[string]string{"Authorization": "Bearer mine"}, HttpApplicationJSON, nil); status != 401 {
+		t.Errorf("explicit authorization should be kept, get %d", status)
+	}
+}
@@ -0,0 +1,394 @@
+package libtools
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+var (
+	ErrJWTInvalid = errors.New("invalid jwt")
+	ErrJWTExpired = errors.New("jwt expired")
+)
+
+const (
+	// jwksCacheTTL 公钥缓存时长, 身份服务轮换密钥时会提前发布新公钥
+	jwksCacheTTL = 10 * time.Minute
+	// jwksMinRefreshInterval 遇到未知 kid 时强制刷新的最小间隔, 防止伪造 kid 的请求打爆身份服务
+	jwksMinRefreshInterval = time.Minute
+	// jwtDefaultLeeway 校验时间时允许的时钟偏差
+	jwtDefaultLeeway = time.Minute
+)
+
+// jwtAudience aud 可以是字符串或字符串数组
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*a = multi
+	return nil
+}
+
+// TokenIntrospection RFC 7662 token 内省结果, Active 为 false 时其余字段无意义
+type TokenIntrospection struct {
+	Active    bool        `json:"active"`
+	Scope     string      `json:"scope"`
+	ClientID  string      `json:"client_id"`
+	Username  string      `json:"username"`
+	TokenType string      `json:"token_type"`
+	Subject   string      `json:"sub"`
+	Audience  jwtAudience `json:"aud"`
+	Issuer    string      `json:"iss"`
+	ExpiresAt int64       `json:"exp"`
+	IssuedAt  int64       `json:"iat"`
+	ID        string      `json:"jti"`
+}
+
+// IntrospectToken 向身份服务查询 token 状态, 适用于不透明 token 或需要感知吊销的场景
+func IntrospectToken(ctx context.Context, introspectionURL, clientID, secret, token string) (*TokenIntrospection, error) {
+	form := map[string]string{
+		"token":           token,
+		"token_type_hint": "access_token",
+	}
+	headers := map[string]string{
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(clientID)+":"+url.QueryEscape(secret))),
+		"Accept":        "application/json",
+	}
+
+	body, statusCode, err := HttpRequestWithOptions(ctx, HttpMethodPOST, introspectionURL, headers, HttpApplicationFormEncoded, form, HttpRequestOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not request token introspection: %v", err)
+	}
+	if statusCode != 200 {
+		return nil, fmt.Errorf("token introspection failed, status: %d, body: %s", statusCode, body)
+	}
+
+	var result TokenIntrospection
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("could not unmarshal token introspection response: %v", err)
+	}
+
+	return &result, nil
+}
+
+// JWK 单个公钥, 支持 RSA 与 EC
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key 按 kid 查找公钥
+func (s *JWKS) Key(kid string) (*JWK, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// PublicKey 转换为 *rsa.PublicKey 或 *ecdsa.PublicKey
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(buf), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk n: %v", err)
+		}
+		e, err := decode(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid jwk e: %s", k.E)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported jwk curve: %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk x: %v", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk y: %v", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("jwk point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported jwk kty: %s", k.Kty)
+}
+
+type jwksEntry struct {
+	mu        sync.Mutex
+	keys      *JWKS
+	fetchedAt time.Time
+}
+
+var jwksCache sync.Map // url => *jwksEntry
+
+// FetchJWKS 获取公钥集合, 结果缓存 10 分钟
+func FetchJWKS(jwksURL string) (*JWKS, error) {
+	return fetchJWKS(jwksURL, false)
+}
+
+func fetchJWKS(jwksURL string, force bool) (*JWKS, error) {
+	v, _ := jwksCache.LoadOrStore(jwksURL, &jwksEntry{})
+	entry := v.(*jwksEntry)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	age := time.Since(entry.fetchedAt)
+	if entry.keys != nil && (age < jwksCacheTTL && !force || age < jwksMinRefreshInterval) {
+		return entry.keys, nil
+	}
+
+	headers := map[string]string{"Accept": "application/json"}
+	body, statusCode, err := HttpRequestWithOptions(context.Background(), HttpMethodGet, jwksURL, headers, HttpApplicationFormEncoded, map[string]string{}, HttpRequestOptions{})
+	if err == nil && statusCode != 200 {
+		err = fmt.Errorf("status: %d", statusCode)
+	}
+	var keys JWKS
+	if err == nil {
+		err = json.Unmarshal(body, &keys)
+	}
+	if err != nil {
+		// 身份服务短暂不可用时继续使用旧公钥
+		if entry.keys != nil {
+			logs.Warning("[FetchJWKS] refresh %s failed, use cached keys, err: %v", jwksURL, err)
+			return entry.keys, nil
+		}
+		return nil, fmt.Errorf("could not fetch jwks from %s: %v", jwksURL, err)
+	}
+
+	entry.keys = &keys
+	entry.fetchedAt = time.Now()
+	return entry.keys, nil
+}
+
+// JWTClaims 常用的标准声明, 全部声明保存在 Raw 中
+type JWTClaims struct {
+	Issuer    string                 `json:"iss"`
+	Subject   string                 `json:"sub"`
+	Audience  jwtAudience            `json:"aud"`
+	ExpiresAt int64                  `json:"exp"`
+	NotBefore int64                  `json:"nbf"`
+	IssuedAt  int64                  `json:"iat"`
+	ID        string                 `json:"jti"`
+	Scope     string                 `json:"scope"`
+	Raw       map[string]interface{} `json:"-"`
+}
+
+// JWTVerifyOptions 额外校验项, 为空时不校验
+type JWTVerifyOptions struct {
+	Issuer   string
+	Audience string
+	// Leeway 默认 1 分钟
+	Leeway time.Duration
+}
+
+// VerifyJWTWithJWKS 使用 jwks 公钥校验 jwt 的签名与有效期, 支持 RS256/384/512、PS256/384/512、ES256/384/512
+func VerifyJWTWithJWKS(token, jwksURL string, opts ...JWTVerifyOptions) (*JWTClaims, error) {
+	var opt JWTVerifyOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Leeway <= 0 {
+		opt.Leeway = jwtDefaultLeeway
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTInvalid
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := jwtDecodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	keys, err := FetchJWKS(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	jwk, ok := keys.Key(header.Kid)
+	if !ok {
+		// 密钥可能刚轮换
+		if keys, err = fetchJWKS(jwksURL, true); err != nil {
+			return nil, err
+		}
+		if jwk, ok = keys.Key(header.Kid); !ok {
+			return nil, fmt.Errorf("%w: unknown kid %s", ErrJWTInvalid, header.Kid)
+		}
+	}
+	if jwk.Alg != "" && jwk.Alg != header.Alg {
+		return nil, fmt.Errorf("%w: alg %s does not match key alg %s", ErrJWTInvalid, header.Alg, jwk.Alg)
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrJWTInvalid)
+	}
+	if err = jwtVerifySignature(header.Alg, pub, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims JWTClaims
+	if err = jwtDecodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err = jwtDecodeSegment(parts[1], &claims.Raw); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt > 0 && now.Add(-opt.Leeway).Unix() >= claims.ExpiresAt {
+		return nil, ErrJWTExpired
+	}
+	if claims.NotBefore > 0 && now.Add(opt.Leeway).Unix() < claims.NotBefore {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrJWTInvalid)
+	}
+	if opt.Issuer != "" && claims.Issuer != opt.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrJWTInvalid, claims.Issuer)
+	}
+	if opt.Audience != "" && !InSlice(opt.Audience, []string(claims.Audience)) {
+		return nil, fmt.Errorf("%w: unexpected audience %v", ErrJWTInvalid, claims.Audience)
+	}
+
+	return &claims, nil
+}
+
+func jwtDecodeSegment(seg string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: bad segment encoding", ErrJWTInvalid)
+	}
+	if err = json.Unmarshal(buf, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrJWTInvalid, err)
+	}
+
+	return nil
+}
+
+// jwtVerifySignature 只接受非对称算法, 拒绝 none 与 HS*, 防止算法替换攻击
+func jwtVerifySignature(alg string, pub crypto.PublicKey, signingInput string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("%w: unsupported alg %s", ErrJWTInvalid, alg)
+	}
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported alg %s", ErrJWTInvalid, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	var err error
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: alg %s requires rsa key", ErrJWTInvalid, alg)
+		}
+		err = rsa.VerifyPKCS1v15(key, hash, digest, signature)
+
+	case strings.HasPrefix(alg, "PS"):
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: alg %s requires rsa key", ErrJWTInvalid, alg)
+		}
+		err = rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+
+	case strings.HasPrefix(alg, "ES"):
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: alg %s requires ec key", ErrJWTInvalid, alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: bad ecdsa signature length", ErrJWTInvalid)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			err = errors.New("ecdsa verification failed")
+		}
+
+	default:
+		return fmt.Errorf("%w: unsupported alg %s", ErrJWTInvalid, alg)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: signature mismatch", ErrJWTInvalid)
+	}
+	return nil
+}
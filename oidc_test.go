@@ -0,0 +1,72 @@
+package libtools
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifyJWTWithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := JWKS{Keys: []JWK{{
+		Kty: "RSA",
+		Kid: "k1",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	defer server.Close()
+
+	sign := func(header, claims map[string]interface{}) string {
+		h, _ := json.Marshal(header)
+		c, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+		digest := sha256.Sum256([]byte(input))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	header := map[string]interface{}{"alg": "RS256", "kid": "k1"}
+	now := time.Now().Unix()
+	token := sign(header, map[string]interface{}{"iss": "https://idp", "sub": "u1", "aud": []string{"loan-api"}, "exp": now + 300})
+
+	claims, err := VerifyJWTWithJWKS(token, server.URL, JWTVerifyOptions{Issuer: "https://idp", Audience: "loan-api"})
+	if err != nil || claims.Subject != "u1" || claims.Raw["iss"] != "https://idp" {
+		t.Fatalf("VerifyJWTWithJWKS get unexpected result: %+v, err: %v", claims, err)
+	}
+
+	if _, err = VerifyJWTWithJWKS(token, server.URL, JWTVerifyOptions{Audience: "other"}); !errors.Is(err, ErrJWTInvalid) {
+		t.Errorf("audience mismatch should fail, err: %v", err)
+	}
+
+	expired := sign(header, map[string]interface{}{"sub": "u1", "exp": now - 3600})
+	if _, err = VerifyJWTWithJWKS(expired, server.URL); !errors.Is(err, ErrJWTExpired) {
+		t.Errorf("expired token should fail, err: %v", err)
+	}
+
+	tampered := token[:len(token)-4] + "AAAA"
+	if _, err = VerifyJWTWithJWKS(tampered, server.URL); !errors.Is(err, ErrJWTInvalid) {
+		t.Errorf("tampered token should fail, err: %v", err)
+	}
+
+	hs := sign(map[string]interface{}{"alg": "HS256", "kid": "k1"}, map[string]interface{}{"sub": "u1"})
+	if _, err = VerifyJWTWithJWKS(hs, server.URL); !errors.Is(err, ErrJWTInvalid) {
+		t.Errorf("HS256 token should be rejected, err: %v", err)
+	}
+}
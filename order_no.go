@@ -0,0 +1,80 @@
+package libtools
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// orderNoShard 当前实例的分片号, 见 SetOrderNoShard
+var orderNoShard int32
+
+// SetOrderNoShard 设置写入单号的分片号(0-99), 如库表分片或机房编号, 默认 0
+func SetOrderNoShard(shard int) {
+	if shard < 0 || shard > 99 {
+		return
+	}
+	atomic.StoreInt32(&orderNoShard, int32(shard))
+}
+
+// OrderNo ParseOrderNo 的结果
+type OrderNo struct {
+	Prefix string
+	Date   string // YYYYMMDD
+	Shard  int
+	Seq    int64
+}
+
+// NewOrderNo 生成单号, 格式为 前缀 + 日期(YYYYMMDD) + 分片号(2 位) + 序号(至少 6 位) + Luhn 校验位
+// prefix 只能由字母组成, seq 必须大于 0, 如 NewOrderNo("LN", GetUnixMillis(), DailySeq("loan")) => LN20240512030001236(分片号为 3 时)
+func NewOrderNo(prefix string, ts int64, seq int64) (string, error) {
+	if seq <= 0 {
+		return "", fmt.Errorf("invalid order no seq: %d", seq)
+	}
+	for i := 0; i < len(prefix); i++ {
+		if !isOrderNoLetter(prefix[i]) {
+			return "", fmt.Errorf("invalid order no prefix: %s", prefix)
+		}
+	}
+
+	digits := fmt.Sprintf("%s%02d%06d", time.UnixMilli(ts).In(time.Local).Format("20060102"), atomic.LoadInt32(&orderNoShard), seq)
+	return prefix + digits + strconv.Itoa(LuhnCheckDigit(digits)), nil
+}
+
+func isOrderNoLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// ParseOrderNo 解析 NewOrderNo 生成的单号, 校验位不符(如抄错一位)时返回错误
+func ParseOrderNo(s string) (OrderNo, error) {
+	var no OrderNo
+
+	i := 0
+	for i < len(s) && isOrderNoLetter(s[i]) {
+		i++
+	}
+	no.Prefix = s[:i]
+	digits := s[i:]
+
+	// 日期 8 位 + 分片 2 位 + 序号至少 6 位 + 校验位
+	if len(digits) < 17 {
+		return no, fmt.Errorf("invalid order no: %s", s)
+	}
+	if !LuhnValid(digits) {
+		return no, fmt.Errorf("order no checksum mismatch: %s", s)
+	}
+
+	if _, err := time.ParseInLocation("20060102", digits[:8], time.Local); err != nil {
+		return no, fmt.Errorf("invalid date in order no: %s", s)
+	}
+	no.Date = digits[:8]
+	no.Shard, _ = strconv.Atoi(digits[8:10])
+	seq, err := strconv.ParseInt(digits[10:len(digits)-1], 10, 64)
+	if err != nil {
+		return no, fmt.Errorf("invalid seq in order no: %s", s)
+	}
+	no.Seq = seq
+
+	return no, nil
+}
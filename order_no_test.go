@@ -0,0 +1,48 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrderNo(t *testing.T) {
+	ts := time.Date(2024, 5, 12, 10, 0, 0, 0, time.Local).UnixMilli()
+
+	SetOrderNoShard(3)
+	defer SetOrderNoShard(0)
+
+	no, err := NewOrderNo("LN", ts, 123)
+	if err != nil || len(no) != 19 || no[:18] != "LN2024051203000123" || !LuhnValid(no[2:]) {
+		t.Fatalf("NewOrderNo: %s", no)
+	}
+
+	parsed, err := ParseOrderNo(no)
+	if err != nil || parsed != (OrderNo{Prefix: "LN", Date: "20240512", Shard: 3, Seq: 123}) {
+		t.Errorf("ParseOrderNo: %+v, %v", parsed, err)
+	}
+
+	// 序号超过 6 位时自动变长
+	long, _ := NewOrderNo("", ts, 12345678)
+	if parsed, err = ParseOrderNo(long); err != nil || parsed.Seq != 12345678 || parsed.Prefix != "" {
+		t.Errorf("ParseOrderNo long seq: %+v, %v", parsed, err)
+	}
+
+	// 抄错一位
+	typo := []byte(no)
+	typo[10]++
+	if _, err = ParseOrderNo(string(typo)); err == nil {
+		t.Error("typo should fail checksum")
+	}
+	if _, err = ParseOrderNo("LN123"); err == nil {
+		t.Error("short order no should fail")
+	}
+
+	for _, c := range []struct {
+		prefix string
+		seq    int64
+	}{{"LN", 0}, {"LN", -1}, {"L1", 1}, {"LN-", 1}, {"借款", 1}} {
+		if _, err = NewOrderNo(c.prefix, ts, c.seq); err == nil {
+			t.Errorf("NewOrderNo(%q, %d) should fail", c.prefix, c.seq)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const (
+	outboxJournalFile = "outbox.journal"
+	outboxOffsetFile  = "outbox.offset"
+)
+
+// OutboxEvent 落盘后再投递的事件, 投递语义为至少一次, 下游按 ID 去重
+type OutboxEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Key       string          `json:"key,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt int64           `json:"created_at"` // 毫秒
+}
+
+// NewOutboxEvent 构造事件, key 可为空, 投递 kafka 时作为分区 key
+func NewOutboxEvent(eventType, key string, data interface{}) (OutboxEvent, error) {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return OutboxEvent{}, fmt.Errorf("could not marshal outbox data: %v", err)
+	}
+
+	return OutboxEvent{
+		ID:        GetGuid(),
+		Type:      eventType,
+		Key:       key,
+		Data:      buf,
+		CreatedAt: GetUnixMillis(),
+	}, nil
+}
+
+// OutboxSink 事件的投递目标, 一批全部成功才返回 nil
+type OutboxSink interface {
+	Send(ctx context.Context, events []OutboxEvent) error
+}
+
+type OutboxSinkFunc func(ctx context.Context, events []OutboxEvent) error
+
+func (f OutboxSinkFunc) Send(ctx context.Context, events []OutboxEvent) error {
+	return f(ctx, events)
+}
+
+// NewHttpOutboxSink 以 json 数组 POST 一批事件, 非 2xx 视为失败
+func NewHttpOutboxSink(url string, headers map[string]string, timeout time.Duration) OutboxSink {
+	return OutboxSinkFunc(func(ctx context.Context, events []OutboxEvent) error {
+		_, code, err := HttpRequestWithOptions(ctx, HttpMethodPOST, url, headers, HttpApplicationJSON, events, HttpRequestOptions{Timeout: timeout})
+		if err != nil {
+			return err
+		}
+		if code < 200 || code >= 300 {
+			return fmt.Errorf("outbox http sink get unexpected status code: %d", code)
+		}
+		return nil
+	})
+}
+
+// NewKafkaOutboxSink 每个事件一条消息, 值为 KafkaEnvelope
+func NewKafkaOutboxSink(producer *KafkaProducer, topic string) OutboxSink {
+	return OutboxSinkFunc(func(ctx context.Context, events []OutboxEvent) error {
+		msgs := make([]KafkaMessage, 0, len(events))
+		for _, event := range events {
+			value, err := json.Marshal(KafkaEnvelope{
+				ID:   event.ID,
+				Type: event.Type,
+				Time: event.CreatedAt,
+				Data: event.Data,
+			})
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, KafkaMessage{
+				Topic:   topic,
+				Key:     []byte(event.Key),
+				Value:   value,
+				Headers: map[string]string{"type": event.Type, "id": event.ID},
+			})
+		}
+		return producer.Publish(ctx, msgs...)
+	})
+}
+
+// OutboxConfig 配置
+type OutboxConfig struct {
+	// Dir 日志目录, 同一目录只能被一个进程使用
+	Dir  string
+	Sink OutboxSink
+	// FlushInterval 默认 1 秒
+	FlushInterval time.Duration
+	// BatchSize 单次投递的最大事件数, 默认 100
+	BatchSize int
+	// Retries 单批次投递失败的重试次数, 默认 3, 耗尽后等下个周期再试
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// Outbox 先把事件追加写入本地日志再由后台协程投递,进程崩溃后重启可继续投递未完成的事件
+type Outbox struct {
+	cfg     OutboxConfig
+	mu      sync.Mutex
+	journal *os.File
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewOutbox 打开(或创建)日志并启动后台投递
+func NewOutbox(cfg OutboxConfig) (*Outbox, error) {
+	if cfg.Sink == nil {
+		return nil, fmt.Errorf("outbox need a sink")
+	}
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("outbox need a journal dir")
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	err := os.MkdirAll(cfg.Dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(cfg.Dir, outboxJournalFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Outbox{
+		cfg:     cfg,
+		journal: journal,
+		notify:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go o.loop()
+
+	return o, nil
+}
+
+// Emit 事件写入本地日志并 fsync 后返回, 随后异步投递
+func (o *Outbox) Emit(ctx context.Context, event OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if event.ID == "" {
+		event.ID = GetGuid()
+	}
+	if event.CreatedAt == 0 {
+		event.CreatedAt = GetUnixMillis()
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	o.mu.Lock()
+	// 整行一次写入, O_APPEND 保证追加在文件末尾
+	_, err = o.journal.Write(line)
+	if err == nil {
+		err = o.journal.Sync()
+	}
+	o.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("outbox write journal fail: %v", err)
+	}
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Close 停止后台协程, 退出前尽力投递一次, 未投递的事件保留在日志中下次启动继续
+func (o *Outbox) Close() error {
+	var err error
+	o.once.Do(func() {
+		close(o.stop)
+		<-o.done
+
+		o.mu.Lock()
+		err = o.journal.Close()
+		o.mu.Unlock()
+	})
+
+	return err
+}
+
+func (o *Outbox) loop() {
+	defer close(o.done)
+
+	ticker := time.NewTicker(o.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.stop:
+			o.flush()
+			return
+		case <-ticker.C:
+		case <-o.notify:
+		}
+
+		o.flush()
+	}
+}
+
+// flush 投递日志中尚未投递的事件, 直到全部完成或出错
+func (o *Outbox) flush() {
+	for {
+		events, next, err := o.readBatch()
+		if err != nil {
+			logs.Error("[Outbox] read journal fail, err: %v", err)
+			return
+		}
+
+		if len(events) > 0 {
+			ctx := context.Background()
+			err = RetryWithBackoff(ctx, o.cfg.Retries+1, o.cfg.RetryBackoff, func() error {
+				return o.cfg.Sink.Send(ctx, events)
+			})
+			if err != nil {
+				logs.Error("[Outbox] send events fail, will retry later, count: %d, err: %v", len(events), err)
+				return
+			}
+		}
+
+		done, err := o.commit(next)
+		if err != nil {
+			logs.Error("[Outbox] commit offset fail, err: %v", err)
+			return
+		}
+		if done || len(events) < o.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// readBatch 从已提交的 offset 开始读取一批完整的行, 返回读完之后的 offset
+func (o *Outbox) readBatch() (events []OutboxEvent, next int64, err error) {
+	offset := o.readOffset()
+
+	f, err := os.Open(o.journal.Name())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	if offset > info.Size() {
+		// 日志被截断过,从头开始
+		offset = 0
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return
+	}
+
+	next = offset
+	reader := bufio.NewReader(f)
+	for len(events) < o.cfg.BatchSize {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil {
+			// 没有换行的残缺行可能还在写入中, 留到下一次
+			break
+		}
+		next += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var event OutboxEvent
+		if jsonErr := json.Unmarshal(line, &event); jsonErr != nil {
+			logs.Error("[Outbox] skip broken journal line, err: %v, line: %s", jsonErr, string(line))
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return
+}
+
+// commit 记录新的 offset, 日志全部投递完成时截断日志, done 表示已没有待投递事件
+func (o *Outbox) commit(next int64) (done bool, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.journal.Stat()
+	if err != nil {
+		return
+	}
+
+	if next >= info.Size() {
+		// 先把 offset 归零再截断, 中途崩溃最多重复投递, 不会丢事件
+		if err = o.writeOffset(0); err != nil {
+			return
+		}
+		err = o.journal.Truncate(0)
+		return true, err
+	}
+
+	err = o.writeOffset(next)
+	return false, err
+}
+
+func (o *Outbox) readOffset() int64 {
+	buf, err := ioutil.ReadFile(filepath.Join(o.cfg.Dir, outboxOffsetFile))
+	if err != nil {
+		return 0
+	}
+
+	offset, _ := strconv.ParseInt(strings.TrimSpace(string(buf)), 10, 64)
+	return offset
+}
+
+func (o *Outbox) writeOffset(offset int64) error {
+	filename := filepath.Join(o.cfg.Dir, outboxOffsetFile)
+	tmpFile := filename + ".tmp"
+	err := ioutil.WriteFile(tmpFile, []byte(strconv.FormatInt(offset, 10)), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, filename)
+}
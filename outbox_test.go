@@ -0,0 +1,137 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+// recordOutboxSinkT 记录投递成功的事件, fail 为 true 时投递失败
+type recordOutboxSinkT struct {
+	mu      sync.Mutex
+	fail    bool
+	batches [][]OutboxEvent
+}
+
+func (s *recordOutboxSinkT) Send(_ context.Context, events []OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink down")
+	}
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func (s *recordOutboxSinkT) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, batch := range s.batches {
+		for _, event := range batch {
+			ids = append(ids, event.Key)
+		}
+	}
+	return ids
+}
+
+func waitOutboxT(t *testing.T, sink *recordOutboxSinkT, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if ids := sink.ids(); len(ids) >= count {
+			return ids
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("outbox delivered %d events, want %d", len(sink.ids()), count)
+	return nil
+}
+
+func TestOutboxDeliver(t *testing.T) {
+	dir := testutil.TempDirT(t)
+	sink := &recordOutboxSinkT{}
+	o, err := NewOutbox(OutboxConfig{Dir: dir, Sink: sink, BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		event, err := NewOutboxEvent("loan.created", key, map[string]string{"key": key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = o.Emit(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := waitOutboxT(t, sink, 5)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		if ids[i] != key {
+			t.Fatalf("events should be delivered in order: %v", ids)
+		}
+	}
+	sink.mu.Lock()
+	for _, batch := range sink.batches {
+		if len(batch) > 2 {
+			t.Errorf("batch size exceeds limit: %d", len(batch))
+		}
+	}
+	sink.mu.Unlock()
+
+	// 全部投递后日志被截断
+	_ = o.Close()
+	if info, err := os.Stat(filepath.Join(dir, outboxJournalFile)); err != nil || info.Size() != 0 {
+		t.Errorf("journal should be truncated, info: %v, err: %v", info, err)
+	}
+}
+
+func TestOutboxRedeliverAfterRestart(t *testing.T) {
+	dir := testutil.TempDirT(t)
+	sink := &recordOutboxSinkT{fail: true}
+	cfg := OutboxConfig{Dir: dir, Sink: sink, FlushInterval: time.Hour, Retries: 1, RetryBackoff: time.Millisecond}
+	o, err := NewOutbox(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"a", "b"} {
+		event, _ := NewOutboxEvent("loan.created", key, nil)
+		if err = o.Emit(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := sink.ids(); len(ids) != 0 {
+		t.Fatalf("failing sink should receive nothing: %v", ids)
+	}
+
+	// 重启后继续投递残留在日志中的事件
+	sink.fail = false
+	if o, err = NewOutbox(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	if err = o.Emit(context.Background(), OutboxEvent{Type: "loan.created", Key: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := waitOutboxT(t, sink, 3); ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Errorf("unexpected redelivery: %v", ids)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = o.Emit(ctx, OutboxEvent{Type: "loan.created"}); err != context.Canceled {
+		t.Errorf("canceled ctx should fail, got: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package libtools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RotateUnit PartitionedWriter 的切分周期
+type RotateUnit int
+
+const (
+	RotateHourly RotateUnit = iota
+	RotateDaily
+)
+
+// PartitionedWriter 按时间分区写文件, 如 /data/events/2024/05/12/13.log, 跨小时/天时自动切换到新文件
+// 与 RotatingWriter 不同, 文件路径本身就是时间分区, 不做重命名与清理, 适合采集原始事件后按目录批量处理
+type PartitionedWriter struct {
+	dirPattern string
+	unit       RotateUnit
+
+	mu        sync.Mutex
+	file      *os.File
+	path      string
+	partition string
+	nowFunc   func() time.Time
+}
+
+// NewPartitionedWriter dirPattern 为目录模板, 支持 ExpandPattern 的日期占位符与 {env}、{host}
+// 按小时切分时文件名为 {H}.log, 按天切分时为 {d}.log, 如:
+// NewPartitionedWriter("/data/events/{Y}/{m}/{d}", RotateHourly) => /data/events/2024/05/12/13.log
+// NewPartitionedWriter("/data/events/{Y}/{m}", RotateDaily) => /data/events/2024/05/12.log
+func NewPartitionedWriter(dirPattern string, rotate RotateUnit) (*PartitionedWriter, error) {
+	if dirPattern == "" {
+		return nil, fmt.Errorf("partitioned writer need a dir pattern")
+	}
+	if rotate != RotateHourly && rotate != RotateDaily {
+		return nil, fmt.Errorf("unsupported rotate unit: %d", rotate)
+	}
+
+	return &PartitionedWriter{dirPattern: dirPattern, unit: rotate, nowFunc: time.Now}, nil
+}
+
+// Write 实现 io.Writer, 单次写入不会被拆分到两个文件
+func (w *PartitionedWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.nowFunc()
+	if partition := w.partitionOf(now); w.file == nil || partition != w.partition {
+		if err = w.open(now, partition); err != nil {
+			return
+		}
+	}
+
+	return w.file.Write(p)
+}
+
+// Path 当前正在写入的文件, 尚未写入时为空
+func (w *PartitionedWriter) Path() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.path
+}
+
+// Sync 刷盘
+func (w *PartitionedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *PartitionedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	w.partition = ""
+	return err
+}
+
+func (w *PartitionedWriter) partitionOf(t time.Time) string {
+	if w.unit == RotateDaily {
+		return t.Format("20060102")
+	}
+	return t.Format("2006010215")
+}
+
+// open 关闭旧文件并以追加方式打开新分区, 进程重启后继续写入同一分区
+func (w *PartitionedWriter) open(now time.Time, partition string) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	fileName := "{H}.log"
+	if w.unit == RotateDaily {
+		fileName = "{d}.log"
+	}
+	path := filepath.Join(ExpandPattern(w.dirPattern, now.UnixMilli()), ExpandPattern(fileName, now.UnixMilli()))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.path = path
+	w.partition = partition
+	return nil
+}
@@ -0,0 +1,37 @@
+package libtools
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPartitionedWriter(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewPartitionedWriter(filepath.Join(dir, "{Y}", "{m}", "{d}"), RotateHourly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	now := time.Date(2024, 5, 12, 13, 59, 59, 0, time.Local)
+	w.nowFunc = func() time.Time { return now }
+	_, _ = w.Write([]byte("a\n"))
+	_, _ = w.Write([]byte("b\n"))
+
+	now = now.Add(time.Second)
+	_, _ = w.Write([]byte("c\n"))
+
+	for path, want := range map[string]string{
+		filepath.Join(dir, "2024", "05", "12", "13.log"): "a\nb\n",
+		filepath.Join(dir, "2024", "05", "12", "14.log"): "c\n",
+	} {
+		if buf, err := ioutil.ReadFile(path); err != nil || string(buf) != want {
+			t.Errorf("%s: %q, %v", path, buf, err)
+		}
+	}
+	if w.Path() != filepath.Join(dir, "2024", "05", "12", "14.log") {
+		t.Errorf("Path: %s", w.Path())
+	}
+}
@@ -0,0 +1,82 @@
+package libtools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	expandPatternRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+	// 只由 Y/y/m/d/H/i/s 与分隔符组成的 token 按日期格式处理, 如 {Ymd}、{Y-m-d}、{His}
+	// 其他日期字母需写成 {date:D, d M Y}, 避免 {date}、{name} 这类普通单词被当作日期格式
+	expandDateTokenRegexp = regexp.MustCompile(`^[YymdHis_.:\- ]+$`)
+)
+
+// expandRandCharset {rand} 使用小写字母与数字, 在大小写不敏感的文件系统上也不会冲突
+const expandRandCharset = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// ExpandPattern 按 ts(毫秒, 不大于 0 时取当前时间)展开文件名或路径模板, 用于批处理输出与 S3 key 等
+// 支持的占位符:
+// 日期: Y/y/m/d/H/i/s 及分隔符的组合, 如 {Y}、{Ymd}、{Y-m-d}、{His}
+// {date:格式}: 与 UnixMsec2Date 相同的完整格式, 如 {date:D-M-Y}
+// {env}: 当前运行环境(runmode), 未配置时为 dev
+// {host}: 主机名
+// {rand}: 8 位随机字符, {randN} 为 N 位, 如 {rand6}
+// 不认识的占位符原样保留
+// 如 ExpandPattern("reports/{Y}/{m}/{d}/report-{Ymd}-{rand6}.csv", ts) => reports/2024/01/02/report-20240102-k3x9qa.csv
+func ExpandPattern(pattern string, ts int64) string {
+	tm := time.Now()
+	if ts > 0 {
+		tm = time.UnixMilli(ts)
+	}
+	tm = tm.In(time.Local)
+
+	return expandPatternRegexp.ReplaceAllStringFunc(pattern, func(token string) string {
+		name := token[1 : len(token)-1]
+
+		switch {
+		case name == "env":
+			if env := GetCurrentEnv(); env != "" {
+				return env
+			}
+			return "dev"
+
+		case name == "host":
+			return Hostname()
+
+		case strings.HasPrefix(name, "rand"):
+			n := 8
+			if name != "rand" {
+				var err error
+				if n, err = strconv.Atoi(name[len("rand"):]); err != nil || n <= 0 || n > 64 {
+					return token
+				}
+			}
+			return expandRandString(n)
+
+		case strings.HasPrefix(name, "date:") && len(name) > len("date:"):
+			return formatPHPDate(tm, name[len("date:"):])
+
+		case expandDateTokenRegexp.MatchString(name):
+			return formatPHPDate(tm, name)
+		}
+
+		return token
+	})
+}
+
+func expandRandString(n int) string {
+	buf := make([]byte, n)
+	for i := range buf {
+		idx, err := secureIntn(int64(len(expandRandCharset)))
+		if err != nil {
+			// crypto/rand 不可用时退回 math/rand, 文件名不要求不可预测
+			idx = int64(GenerateRandom(0, len(expandRandCharset)))
+		}
+		buf[i] = expandRandCharset[idx]
+	}
+
+	return string(buf)
+}
@@ -0,0 +1,33 @@
+package libtools
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestExpandPattern(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local).UnixMilli()
+
+	got := ExpandPattern("reports/{Y}/{m}/{d}/report-{Ymd}-{rand6}.csv", ts)
+	if !regexp.MustCompile(`^reports/2024/01/02/report-20240102-[a-z0-9]{6}\.csv$`).MatchString(got) {
+		t.Errorf("ExpandPattern: %s", got)
+	}
+
+	if got = ExpandPattern("{Y-m-d}_{His}/{host}/{unknown}/{rand}", ts); !regexp.MustCompile(`^2024-01-02_150405/` + regexp.QuoteMeta(Hostname()) + `/\{unknown\}/[a-z0-9]{8}$`).MatchString(got) {
+		t.Errorf("ExpandPattern: %s", got)
+	}
+
+	for _, p := range []string{"{date}", "{name}", "{time}", "{line}", "{mode}"} {
+		if got = ExpandPattern(p, ts); got != p {
+			t.Errorf("ExpandPattern should keep %s, got: %s", p, got)
+		}
+	}
+	if got = ExpandPattern("{date:D-M-Y}", ts); got != "Tue-Jan-2024" {
+		t.Errorf("ExpandPattern date prefix: %s", got)
+	}
+
+	if got = ExpandPattern("{env}", ts); got == "" || got == "{env}" {
+		t.Errorf("ExpandPattern env: %s", got)
+	}
+}
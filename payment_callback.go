@@ -0,0 +1,231 @@
+package libtools
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支付宝异步通知的应答, 返回 success 以外的内容支付宝会持续重试
+const (
+	AlipayCallbackSuccess = "success"
+	AlipayCallbackFail    = "fail"
+)
+
+// wechatPayV3MaxSkew 回调时间戳允许的最大偏差, 防止重放
+const wechatPayV3MaxSkew = 5 * time.Minute
+
+// VerifyWeChatPayV3Callback 校验微信支付 V3 回调的签名
+// platformCert 为微信支付平台证书或微信支付公钥(PEM), body 需为原始请求体, 不能经过 json 重新序列化
+func VerifyWeChatPayV3Callback(headers http.Header, body []byte, platformCert []byte) error {
+	timestamp := headers.Get("Wechatpay-Timestamp")
+	nonce := headers.Get("Wechatpay-Nonce")
+	signature := headers.Get("Wechatpay-Signature")
+	if timestamp == "" || nonce == "" || signature == "" {
+		return fmt.Errorf("wechatpay signature headers are missing")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid wechatpay timestamp: %s", timestamp)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > wechatPayV3MaxSkew || skew < -wechatPayV3MaxSkew {
+		return fmt.Errorf("wechatpay timestamp is expired: %s", timestamp)
+	}
+
+	pub, err := paymentParsePublicKey(platformCert)
+	if err != nil {
+		return err
+	}
+
+	sign, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("could not decode wechatpay signature: %v", err)
+	}
+
+	message := timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	hashed := sha256.Sum256([]byte(message))
+	if err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sign); err != nil {
+		return fmt.Errorf("wechatpay signature mismatch: %v", err)
+	}
+
+	return nil
+}
+
+// WeChatPayV3Notify 微信支付 V3 回调通知
+type WeChatPayV3Notify struct {
+	ID           string `json:"id"`
+	CreateTime   string `json:"create_time"`
+	EventType    string `json:"event_type"` // 如 TRANSACTION.SUCCESS
+	ResourceType string `json:"resource_type"`
+	Summary      string `json:"summary"`
+	Resource     struct {
+		Algorithm      string `json:"algorithm"`
+		Ciphertext     string `json:"ciphertext"`
+		AssociatedData string `json:"associated_data"`
+		Nonce          string `json:"nonce"`
+		OriginalType   string `json:"original_type"`
+	} `json:"resource"`
+}
+
+// DecryptWeChatPayV3Notify 解析回调并使用 APIv3 密钥解密 resource, 返回通知结构与解密后的业务数据(json)
+// 需先调用 VerifyWeChatPayV3Callback 校验签名
+func DecryptWeChatPayV3Notify(body []byte, apiV3Key string) (*WeChatPayV3Notify, []byte, error) {
+	var notify WeChatPayV3Notify
+	if err := json.Unmarshal(body, &notify); err != nil {
+		return nil, nil, fmt.Errorf("could not unmarshal wechatpay notify: %v", err)
+	}
+
+	if notify.Resource.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, nil, fmt.Errorf("unsupported wechatpay resource algorithm: %s", notify.Resource.Algorithm)
+	}
+	if len(apiV3Key) != 32 {
+		return nil, nil, fmt.Errorf("wechatpay apiv3 key must be 32 bytes")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(notify.Resource.Ciphertext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decode wechatpay ciphertext: %v", err)
+	}
+
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(notify.Resource.Nonce))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, []byte(notify.Resource.Nonce), ciphertext, []byte(notify.Resource.AssociatedData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decrypt wechatpay resource: %v", err)
+	}
+
+	return &notify, plaintext, nil
+}
+
+// WeChatPayV3CallbackResponse 生成回调的应答, err 为 nil 时应答成功(204 无内容), 否则返回 500 与失败原因, 微信支付会重试
+func WeChatPayV3CallbackResponse(err error) (statusCode int, body []byte) {
+	if err == nil {
+		return http.StatusNoContent, nil
+	}
+
+	body, _ = json.Marshal(map[string]string{
+		"code":    "FAIL",
+		"message": err.Error(),
+	})
+	return http.StatusInternalServerError, body
+}
+
+// VerifyAlipayCallback 校验支付宝异步通知的签名, params 为通知的全部表单参数
+// publicKey 为支付宝公钥, 支持 PEM 或支付宝后台展示的不带头尾的 base64 字符串
+func VerifyAlipayCallback(params url.Values, publicKey string) error {
+	signature := params.Get("sign")
+	if signature == "" {
+		return fmt.Errorf("alipay sign is missing")
+	}
+
+	pub, err := paymentParsePublicKey([]byte(publicKey))
+	if err != nil {
+		return err
+	}
+
+	sign, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("could not decode alipay sign: %v", err)
+	}
+
+	content := AlipaySignContent(params)
+	switch strings.ToUpper(params.Get("sign_type")) {
+	case "RSA":
+		hashed := sha1.Sum([]byte(content))
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA1, hashed[:], sign)
+	case "RSA2", "":
+		hashed := sha256.Sum256([]byte(content))
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sign)
+	default:
+		return fmt.Errorf("unsupported alipay sign_type: %s", params.Get("sign_type"))
+	}
+	if err != nil {
+		return fmt.Errorf("alipay sign mismatch: %v", err)
+	}
+
+	return nil
+}
+
+// AlipaySignContent 生成支付宝异步通知的待签名字符串: 去掉 sign、sign_type 与空值, 按参数名排序后以 & 连接
+func AlipaySignContent(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key == "sign" || key == "sign_type" || params.Get(key) == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+params.Get(key))
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// paymentParsePublicKey 支持 PEM 格式的证书、PKIX/PKCS1 公钥, 以及不带头尾的 base64 公钥
+func paymentParsePublicKey(key []byte) (*rsa.PublicKey, error) {
+	der := key
+	if block, _ := pem.Decode(key); block != nil {
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse certificate: %v", err)
+			}
+			if cert.NotAfter.Before(time.Now()) {
+				return nil, fmt.Errorf("certificate %s is expired", cert.SerialNumber.Text(16))
+			}
+			pub, ok := cert.PublicKey.(*rsa.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("certificate public key is not rsa")
+			}
+			return pub, nil
+		}
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(key)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key")
+		}
+		der = decoded
+	}
+
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is not rsa")
+		}
+		return rsaPub, nil
+	}
+
+	pub, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse public key: %v", err)
+	}
+
+	return pub, nil
+}
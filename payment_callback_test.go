@@ -0,0 +1,64 @@
+package libtools
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPaymentCallbackVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	sign := func(content string) string {
+		hashed := sha256.Sum256([]byte(content))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	// 微信支付 V3
+	body := []byte(`{"id":"EV-2018022511223320873"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := http.Header{}
+	headers.Set("Wechatpay-Timestamp", timestamp)
+	headers.Set("Wechatpay-Nonce", "fdasflkja484w")
+	headers.Set("Wechatpay-Signature", sign(timestamp+"\nfdasflkja484w\n"+string(body)+"\n"))
+	if err = VerifyWeChatPayV3Callback(headers, body, pubPEM); err != nil {
+		t.Errorf("wechatpay verify: %v", err)
+	}
+	if err = VerifyWeChatPayV3Callback(headers, []byte(`{"id":"x"}`), pubPEM); err == nil {
+		t.Errorf("wechatpay verify should fail for tampered body")
+	}
+
+	// 支付宝, 公钥使用不带头尾的 base64
+	params := url.Values{}
+	params.Set("out_trade_no", "20240512001")
+	params.Set("total_amount", "88.88")
+	params.Set("trade_status", "TRADE_SUCCESS")
+	params.Set("passback_params", "")
+	params.Set("sign_type", "RSA2")
+	if content := AlipaySignContent(params); content != "out_trade_no=20240512001&total_amount=88.88&trade_status=TRADE_SUCCESS" {
+		t.Errorf("AlipaySignContent = %s", content)
+	}
+	params.Set("sign", sign(AlipaySignContent(params)))
+	if err = VerifyAlipayCallback(params, base64.StdEncoding.EncodeToString(der)); err != nil {
+		t.Errorf("alipay verify: %v", err)
+	}
+	params.Set("total_amount", "0.01")
+	if err = VerifyAlipayCallback(params, string(pubPEM)); err == nil {
+		t.Errorf("alipay verify should fail for tampered params")
+	}
+}
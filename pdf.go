@@ -0,0 +1,709 @@
+package libtools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFOptions 页面设置, 单位为 pt(1/72 英寸), 零值使用 A4 纵向
+type PDFOptions struct {
+	PageWidth  float64
+	PageHeight float64
+	Margin     float64
+	// FontSize 正文默认字号
+	FontSize float64
+}
+
+// PDF 简单文档(合同、收据等)的生成器, 按添加顺序自上而下排版, 内容超出一页时自动分页
+// 默认字体为 Helvetica, 只能显示西文; 中文等字符需先通过 SetFont 嵌入 TrueType 字体
+type PDF struct {
+	opt    PDFOptions
+	font   *pdfTTF
+	header string
+	footer string
+	blocks []interface{}
+}
+
+type pdfTextBlock struct {
+	text string
+	size float64
+}
+
+type pdfTableBlock struct {
+	headers []string
+	rows    [][]string
+	widths  []float64
+}
+
+type pdfImageBlock struct {
+	data       []byte
+	colorSpace string
+	pixelW     int
+	pixelH     int
+	width      float64
+}
+
+type pdfSpaceBlock float64
+
+type pdfPageBreak struct{}
+
+// Helvetica 字体 32~126 字符的宽度(1000 单位)
+var pdfHelveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// NewPDF 创建文档
+//
+//	doc := NewPDF()
+//	_ = doc.SetFontFile("/usr/share/fonts/NotoSansSC-Regular.ttf")
+//	doc.SetFooter("第 {page} 页 / 共 {pages} 页")
+//	doc.AddText("借款合同", 18)
+//	doc.AddTable([]string{"期数", "应还金额"}, rows)
+//	err := doc.WriteFile("contract.pdf")
+func NewPDF(opts ...PDFOptions) *PDF {
+	var opt PDFOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.PageWidth <= 0 || opt.PageHeight <= 0 {
+		opt.PageWidth, opt.PageHeight = 595.28, 841.89
+	}
+	if opt.Margin <= 0 {
+		opt.Margin = 50
+	}
+	if opt.FontSize <= 0 {
+		opt.FontSize = 11
+	}
+
+	return &PDF{opt: opt}
+}
+
+// SetFont 嵌入 TrueType 字体(如思源黑体/Noto Sans SC 的 ttf 版本), 设置后全文使用该字体
+// 字体文件整体嵌入, 不做子集化, 生成的文件大小约等于字体大小
+func (p *PDF) SetFont(ttf []byte) error {
+	font, err := parsePdfTTF(ttf)
+	if err != nil {
+		return err
+	}
+
+	p.font = font
+	return nil
+}
+
+func (p *PDF) SetFontFile(filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return p.SetFont(buf)
+}
+
+// SetHeader 每页顶部居中显示的页眉
+func (p *PDF) SetHeader(text string) {
+	p.header = text
+}
+
+// SetFooter 每页底部居中显示的页脚, {page} 与 {pages} 会被替换为当前页码与总页数
+func (p *PDF) SetFooter(text string) {
+	p.footer = text
+}
+
+// AddText 添加一段文字, 按页面宽度自动换行, 文字中的 \n 强制换行
+func (p *PDF) AddText(text string, size ...float64) {
+	fontSize := p.opt.FontSize
+	if len(size) > 0 && size[0] > 0 {
+		fontSize = size[0]
+	}
+
+	p.blocks = append(p.blocks, pdfTextBlock{text: text, size: fontSize})
+}
+
+// AddSpace 添加垂直空白
+func (p *PDF) AddSpace(height float64) {
+	p.blocks = append(p.blocks, pdfSpaceBlock(height))
+}
+
+// AddPage 之后的内容从新的一页开始
+func (p *PDF) AddPage() {
+	p.blocks = append(p.blocks, pdfPageBreak{})
+}
+
+// AddTable 添加表格, widths 为各列的相对宽度, 不传时等宽; 跨页时在新页重复表头
+func (p *PDF) AddTable(headers []string, rows [][]string, widths ...float64) {
+	p.blocks = append(p.blocks, pdfTableBlock{headers: headers, rows: rows, widths: widths})
+}
+
+// AddImage 添加图片, 支持 jpeg/png/gif, width 为显示宽度(pt), 不传时按原始尺寸且不超过页面宽度
+func (p *PDF) AddImage(buf []byte, width ...float64) error {
+	config, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("could not decode image: %v", err)
+	}
+
+	block := pdfImageBlock{
+		data:   buf,
+		pixelW: config.Width,
+		pixelH: config.Height,
+	}
+	if len(width) > 0 {
+		block.width = width[0]
+	}
+
+	if format != "jpeg" || (config.ColorModel != color.GrayModel && config.ColorModel != color.YCbCrModel) {
+		// 其他格式统一转为 jpeg 后以 DCTDecode 嵌入, 透明通道会丢失; 灰度图编码后为单通道 jpeg
+		img, _, err := image.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("could not decode image: %v", err)
+		}
+		var out bytes.Buffer
+		if err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90}); err != nil {
+			return err
+		}
+		block.data = out.Bytes()
+		if config, err = jpeg.DecodeConfig(bytes.NewReader(block.data)); err != nil {
+			return err
+		}
+	}
+
+	// 颜色空间需与 jpeg 的通道数一致, 否则阅读器无法显示
+	block.colorSpace = "DeviceRGB"
+	if config.ColorModel == color.GrayModel {
+		block.colorSpace = "DeviceGray"
+	}
+
+	p.blocks = append(p.blocks, block)
+	return nil
+}
+
+// WriteFile 生成文档并写入文件
+func (p *PDF) WriteFile(filename string) error {
+	buf, err := p.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, buf, 0644)
+}
+
+// pdfLayout 一次排版过程的状态
+type pdfLayout struct {
+	doc        *PDF
+	pages      []*bytes.Buffer
+	y          float64
+	images     []pdfImageBlock
+	usedGlyphs map[uint16]rune
+}
+
+// Bytes 排版并生成 PDF 文件内容
+func (p *PDF) Bytes() ([]byte, error) {
+	l := &pdfLayout{doc: p, usedGlyphs: make(map[uint16]rune)}
+	l.newPage()
+
+	for _, block := range p.blocks {
+		switch b := block.(type) {
+		case pdfTextBlock:
+			l.text(b)
+		case pdfTableBlock:
+			l.table(b)
+		case pdfImageBlock:
+			l.image(b)
+		case pdfSpaceBlock:
+			l.ensure(float64(b))
+			l.y -= float64(b)
+		case pdfPageBreak:
+			l.newPage()
+		}
+	}
+
+	l.headerFooter()
+
+	return l.output()
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &bytes.Buffer{})
+	l.y = l.doc.opt.PageHeight - l.doc.opt.Margin
+}
+
+func (l *pdfLayout) page() *bytes.Buffer {
+	return l.pages[len(l.pages)-1]
+}
+
+func (l *pdfLayout) contentWidth() float64 {
+	return l.doc.opt.PageWidth - 2*l.doc.opt.Margin
+}
+
+// ensure 剩余空间不足 h 时换页, 已在页首时不再换页, 避免超大内容导致死循环
+func (l *pdfLayout) ensure(h float64) {
+	top := l.doc.opt.PageHeight - l.doc.opt.Margin
+	if l.y-h < l.doc.opt.Margin && l.y < top {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) text(b pdfTextBlock) {
+	lineHeight := b.size * 1.5
+	for _, line := range l.wrap(b.text, b.size, l.contentWidth()) {
+		l.ensure(lineHeight)
+		l.y -= lineHeight
+		l.drawText(l.doc.opt.Margin, l.y+lineHeight*0.3, b.size, line)
+	}
+}
+
+func (l *pdfLayout) table(b pdfTableBlock) {
+	cols := len(b.headers)
+	for _, row := range b.rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return
+	}
+
+	widths := make([]float64, cols)
+	var total float64
+	for i := range widths {
+		widths[i] = 1
+		if i < len(b.widths) && b.widths[i] > 0 {
+			widths[i] = b.widths[i]
+		}
+		total += widths[i]
+	}
+	for i := range widths {
+		widths[i] = widths[i] / total * l.contentWidth()
+	}
+
+	size := l.doc.opt.FontSize
+	lineHeight := size * 1.4
+	padding := 4.0
+
+	drawRow := func(cells []string, isHeader bool) {
+		wrapped, rowHeight := l.wrapRow(cells, widths, size, lineHeight, padding)
+		if l.y-rowHeight < l.doc.opt.Margin && l.y < l.doc.opt.PageHeight-l.doc.opt.Margin {
+			l.newPage()
+			if !isHeader && len(b.headers) > 0 {
+				headerWrapped, headerHeight := l.wrapRow(b.headers, widths, size, lineHeight, padding)
+				l.tableRow(headerWrapped, widths, true, headerHeight, lineHeight, padding, size)
+			}
+		}
+		l.tableRow(wrapped, widths, isHeader, rowHeight, lineHeight, padding, size)
+	}
+
+	if len(b.headers) > 0 {
+		drawRow(b.headers, true)
+	}
+	for _, row := range b.rows {
+		drawRow(row, false)
+	}
+}
+
+// wrapRow 计算每个单元格折行后的内容与整行高度
+func (l *pdfLayout) wrapRow(cells []string, widths []float64, size, lineHeight, padding float64) ([][]string, float64) {
+	wrapped := make([][]string, len(widths))
+	maxLines := 1
+	for i := range widths {
+		if i < len(cells) {
+			wrapped[i] = l.wrap(cells[i], size, widths[i]-2*padding)
+		}
+		if len(wrapped[i]) > maxLines {
+			maxLines = len(wrapped[i])
+		}
+	}
+
+	return wrapped, float64(maxLines)*lineHeight + 2*padding
+}
+
+func (l *pdfLayout) tableRow(wrapped [][]string, widths []float64, isHeader bool, rowHeight, lineHeight, padding, size float64) {
+	w := l.page()
+	x := l.doc.opt.Margin
+	bottom := l.y - rowHeight
+
+	for i, width := range widths {
+		if isHeader {
+			_, _ = fmt.Fprintf(w, "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", x, bottom, width, rowHeight)
+		}
+		_, _ = fmt.Fprintf(w, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, bottom, width, rowHeight)
+
+		for n, line := range wrapped[i] {
+			baseline := l.y - padding - float64(n+1)*lineHeight + lineHeight*0.3
+			l.drawText(x+padding, baseline, size, line)
+		}
+		x += width
+	}
+
+	l.y = bottom
+}
+
+func (l *pdfLayout) image(b pdfImageBlock) {
+	width := b.width
+	if width <= 0 {
+		// 按 96dpi 换算
+		width = float64(b.pixelW) * 0.75
+	}
+	if width > l.contentWidth() {
+		width = l.contentWidth()
+	}
+	height := width * float64(b.pixelH) / float64(b.pixelW)
+
+	l.ensure(height)
+	l.y -= height
+
+	l.images = append(l.images, b)
+	_, _ = fmt.Fprintf(l.page(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", width, height, l.doc.opt.Margin, l.y, len(l.images))
+}
+
+func (l *pdfLayout) headerFooter() {
+	size := 9.0
+	total := len(l.pages)
+	for i, page := range l.pages {
+		if l.doc.header != "" {
+			x := (l.doc.opt.PageWidth - l.textWidth(l.doc.header, size)) / 2
+			l.drawTextTo(page, x, l.doc.opt.PageHeight-l.doc.opt.Margin/2-size/2, size, l.doc.header)
+		}
+		if l.doc.footer != "" {
+			text := strings.NewReplacer("{page}", fmt.Sprint(i+1), "{pages}", fmt.Sprint(total)).Replace(l.doc.footer)
+			x := (l.doc.opt.PageWidth - l.textWidth(text, size)) / 2
+			l.drawTextTo(page, x, l.doc.opt.Margin/2, size, text)
+		}
+	}
+}
+
+func (l *pdfLayout) drawText(x, y, size float64, text string) {
+	l.drawTextTo(l.page(), x, y, size, text)
+}
+
+func (l *pdfLayout) drawTextTo(w *bytes.Buffer, x, y, size float64, text string) {
+	if text == "" {
+		return
+	}
+
+	font := "F1"
+	if l.doc.font != nil {
+		font = "F2"
+	}
+	_, _ = fmt.Fprintf(w, "BT /%s %.2f Tf 1 0 0 1 %.2f %.2f Tm %s Tj ET\n", font, size, x, y, l.encode(text))
+}
+
+// encode 嵌入字体时输出字形编号(Identity-H), 否则输出 WinAnsi 字符串, 无法表示的字符替换为 ?
+func (l *pdfLayout) encode(text string) string {
+	var sb strings.Builder
+	if l.doc.font != nil {
+		sb.WriteString("<")
+		for _, r := range text {
+			glyph := l.doc.font.glyph(r)
+			l.usedGlyphs[glyph] = r
+			_, _ = fmt.Fprintf(&sb, "%04X", glyph)
+		}
+		sb.WriteString(">")
+		return sb.String()
+	}
+
+	sb.WriteString("(")
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(byte(r))
+		case r >= 32 && r <= 126:
+			sb.WriteByte(byte(r))
+		case r >= 160 && r <= 255:
+			_, _ = fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+func (l *pdfLayout) runeWidth(r rune, size float64) float64 {
+	if l.doc.font != nil {
+		return float64(l.doc.font.advance(l.doc.font.glyph(r))) * size / 1000
+	}
+	if r >= 32 && r <= 126 {
+		return float64(pdfHelveticaWidths[r-32]) * size / 1000
+	}
+
+	return 556 * size / 1000
+}
+
+func (l *pdfLayout) textWidth(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		width += l.runeWidth(r, size)
+	}
+
+	return width
+}
+
+// wrap 按宽度折行, 西文优先在空格处断开, 中文可在任意字符处断开
+func (l *pdfLayout) wrap(text string, size, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		runes := []rune(paragraph)
+		if len(runes) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		start, lastSpace := 0, -1
+		var width float64
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			if r == ' ' {
+				lastSpace = i
+			}
+			width += l.runeWidth(r, size)
+			if width <= maxWidth || i == start {
+				continue
+			}
+
+			end := i
+			if lastSpace > start && r < 0x2E80 {
+				end = lastSpace
+			}
+			lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+
+			start = end
+			for start < len(runes) && runes[start] == ' ' {
+				start++
+			}
+			i = start - 1
+			lastSpace = -1
+			width = 0
+		}
+		if start < len(runes) {
+			lines = append(lines, string(runes[start:]))
+		}
+	}
+
+	return lines
+}
+
+// output 组装 PDF 对象并生成 xref
+func (l *pdfLayout) output() ([]byte, error) {
+	var objects [][]byte
+	add := func(obj []byte) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+	stream := func(dict string, data []byte, compress bool) ([]byte, error) {
+		if compress {
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			dict += " /Filter /FlateDecode"
+			data = buf.Bytes()
+		}
+		out := []byte(fmt.Sprintf("<< %s /Length %d >>\nstream\n", dict, len(data)))
+		out = append(out, data...)
+		return append(out, []byte("\nendstream")...), nil
+	}
+
+	catalogID := add(nil)
+	pagesID := add(nil)
+	objects[catalogID-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	var fontRes string
+	if font := l.doc.font; font != nil {
+		fontFile, err := stream(fmt.Sprintf("/Length1 %d", len(font.data)), font.data, true)
+		if err != nil {
+			return nil, err
+		}
+		fontFileID := add(fontFile)
+
+		descriptorID := add([]byte(fmt.Sprintf("<< /Type /FontDescriptor /FontName /EmbeddedFont /Flags 4 /FontBBox [%d %d %d %d] "+
+			"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+			font.scale(font.ascent), font.scale(font.descent), font.scale(font.ascent), fontFileID)))
+
+		glyphs := make([]int, 0, len(l.usedGlyphs))
+		for g := range l.usedGlyphs {
+			glyphs = append(glyphs, int(g))
+		}
+		sort.Ints(glyphs)
+
+		var widths strings.Builder
+		for _, g := range glyphs {
+			_, _ = fmt.Fprintf(&widths, "%d [%d] ", g, font.advance(uint16(g)))
+		}
+		cidFontID := add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /EmbeddedFont "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>", descriptorID, widths.String())))
+
+		toUnicode, err := stream("", pdfToUnicodeCMap(glyphs, l.usedGlyphs), true)
+		if err != nil {
+			return nil, err
+		}
+		toUnicodeID := add(toUnicode)
+
+		fontID := add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /EmbeddedFont /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFontID, toUnicodeID)))
+		fontRes = fmt.Sprintf("/F2 %d 0 R", fontID)
+	} else {
+		fontID := add([]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"))
+		fontRes = fmt.Sprintf("/F1 %d 0 R", fontID)
+	}
+
+	var imageRes strings.Builder
+	for i, img := range l.images {
+		obj, err := stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /DCTDecode",
+			img.pixelW, img.pixelH, img.colorSpace), img.data, false)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = fmt.Fprintf(&imageRes, "/Im%d %d 0 R ", i+1, add(obj))
+	}
+
+	resources := fmt.Sprintf("<< /Font << %s >> /XObject << %s>> >>", fontRes, imageRes.String())
+	var kids []string
+	for _, page := range l.pages {
+		content, err := stream("", page.Bytes(), true)
+		if err != nil {
+			return nil, err
+		}
+		contentID := add(content)
+		pageID := add([]byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			pagesID, l.doc.opt.PageWidth, l.doc.opt.PageHeight, resources, contentID)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	objects[pagesID-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		_, _ = fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	_, _ = fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		_, _ = fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	_, _ = fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalogID, xref)
+
+	return out.Bytes(), nil
+}
+
+// pdfToUnicodeCMap 字形到 unicode 的映射, 使生成的 PDF 中的文字可以复制与搜索
+func pdfToUnicodeCMap(glyphs []int, used map[uint16]rune) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		_, _ = fmt.Fprintf(&buf, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			_, _ = fmt.Fprintf(&buf, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{used[uint16(g)]}) {
+				_, _ = fmt.Fprintf(&buf, "%04X", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buf.Bytes()
+}
+
+// HTMLToPDFRenderer HTML 转 PDF 的后端, 如 wkhtmltopdf、headless chrome 或独立的渲染服务
+type HTMLToPDFRenderer interface {
+	Render(ctx context.Context, html string) ([]byte, error)
+}
+
+type HTMLToPDFRendererFunc func(ctx context.Context, html string) ([]byte, error)
+
+func (f HTMLToPDFRendererFunc) Render(ctx context.Context, html string) ([]byte, error) {
+	return f(ctx, html)
+}
+
+var (
+	htmlToPDFMu       sync.RWMutex
+	htmlToPDFRenderer HTMLToPDFRenderer
+)
+
+// SetHTMLToPDFRenderer 设置 RenderHTMLToPDF 使用的后端, 一般在程序启动时调用
+func SetHTMLToPDFRenderer(r HTMLToPDFRenderer) {
+	htmlToPDFMu.Lock()
+	htmlToPDFRenderer = r
+	htmlToPDFMu.Unlock()
+}
+
+// RenderHTMLToPDF 使用已设置的后端将 html 渲染为 PDF, 默认超时 30 秒
+func RenderHTMLToPDF(html string, timeout ...time.Duration) ([]byte, error) {
+	htmlToPDFMu.RLock()
+	r := htmlToPDFRenderer
+	htmlToPDFMu.RUnlock()
+	if r == nil {
+		return nil, fmt.Errorf("html to pdf renderer is not set, call SetHTMLToPDFRenderer first")
+	}
+
+	d := 30 * time.Second
+	if len(timeout) > 0 && timeout[0] > 0 {
+		d = timeout[0]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return r.Render(ctx, html)
+}
+
+// NewWkhtmltopdfRenderer 调用本机的 wkhtmltopdf, binPath 为空时从 PATH 查找, args 为额外参数(如 "--encoding", "utf-8")
+func NewWkhtmltopdfRenderer(binPath string, args ...string) HTMLToPDFRenderer {
+	if binPath == "" {
+		binPath = "wkhtmltopdf"
+	}
+
+	return HTMLToPDFRendererFunc(func(ctx context.Context, html string) ([]byte, error) {
+		cmdArgs := append(append([]string{"--quiet"}, args...), "-", "-")
+		cmd := exec.CommandContext(ctx, binPath, cmdArgs...)
+		cmd.Stdin = strings.NewReader(html)
+
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("wkhtmltopdf fail: %v, stderr: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		return stdout.Bytes(), nil
+	})
+}
@@ -0,0 +1,150 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var pdfImageStreamRegexp = regexp.MustCompile(`/Subtype /Image /Width (\d+) /Height (\d+) /ColorSpace /(\w+) [^>]*/Length (\d+) >>\nstream\n`)
+
+// pdfImagesT 取出 PDF 中嵌入的图片, 返回颜色空间与解码后的 jpeg 配置
+func pdfImagesT(t *testing.T, doc []byte) (spaces []string, configs []image.Config) {
+	t.Helper()
+	for _, m := range pdfImageStreamRegexp.FindAllSubmatchIndex(doc, -1) {
+		length, _ := strconv.Atoi(string(doc[m[8]:m[9]]))
+		data := doc[m[1] : m[1]+length]
+		config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("embedded image is not jpeg: %v", err)
+		}
+		if _, err = jpeg.Decode(bytes.NewReader(data)); err != nil {
+			t.Fatalf("embedded image is broken: %v", err)
+		}
+		spaces = append(spaces, string(doc[m[6]:m[7]]))
+		configs = append(configs, config)
+	}
+	return
+}
+
+func TestPDFAddImage(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 20, 10))
+	rgba := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	rgba.Set(1, 1, color.RGBA{R: 255, A: 255})
+	paletted := image.NewPaletted(image.Rect(0, 0, 20, 10), palette.Plan9)
+
+	encode := func(f func(*bytes.Buffer) error) []byte {
+		var buf bytes.Buffer
+		if err := f(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	cases := []struct {
+		name  string
+		buf   []byte
+		space string
+	}{
+		{"gray jpeg", encode(func(b *bytes.Buffer) error { return jpeg.Encode(b, gray, nil) }), "DeviceGray"},
+		{"color jpeg", encode(func(b *bytes.Buffer) error { return jpeg.Encode(b, rgba, nil) }), "DeviceRGB"},
+		{"gray png", encode(func(b *bytes.Buffer) error { return png.Encode(b, gray) }), "DeviceGray"},
+		{"color png", encode(func(b *bytes.Buffer) error { return png.Encode(b, rgba) }), "DeviceRGB"},
+		{"gif", encode(func(b *bytes.Buffer) error { return gif.Encode(b, paletted, nil) }), "DeviceRGB"},
+	}
+	for _, c := range cases {
+		doc := NewPDF()
+		if err := doc.AddImage(c.buf, 100); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		out, err := doc.Bytes()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		spaces, configs := pdfImagesT(t, out)
+		if len(spaces) != 1 || spaces[0] != c.space {
+			t.Errorf("%s: color space %v, want %s", c.name, spaces, c.space)
+			continue
+		}
+		if gotGray := configs[0].ColorModel == color.GrayModel; gotGray != (c.space == "DeviceGray") {
+			t.Errorf("%s: color space %s does not match jpeg color model", c.name, spaces[0])
+		}
+		if configs[0].Width != 20 || configs[0].Height != 10 {
+			t.Errorf("%s: unexpected size %dx%d", c.name, configs[0].Width, configs[0].Height)
+		}
+	}
+
+	if err := NewPDF().AddImage([]byte("not an image")); err == nil {
+		t.Error("invalid image should return error")
+	}
+}
+
+func TestPDFBytes(t *testing.T) {
+	doc := NewPDF()
+	doc.SetFooter("Page {page} / {pages}")
+	doc.AddText("Loan Agreement", 18)
+	rows := make([][]string, 0, 80)
+	for i := 1; i <= 80; i++ {
+		rows = append(rows, []string{strconv.Itoa(i), "150000"})
+	}
+	doc.AddTable([]string{"Term", "Amount"}, rows)
+	doc.AddPage()
+	doc.AddText("Signature")
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatal("invalid pdf header or trailer")
+	}
+
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindStringSubmatch(s)
+	if pages == nil {
+		t.Fatal("pages object not found")
+	}
+	if n, _ := strconv.Atoi(pages[1]); n < 3 {
+		t.Errorf("table should span pages and AddPage should break, pages: %d", n)
+	}
+
+	// xref 中的偏移需指向对应的对象
+	xref := strings.LastIndex(s, "\nxref\n") + 1
+	lines := strings.Split(s[xref:], "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < size; i++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+i])[0])
+		if !strings.HasPrefix(s[offset:], fmt.Sprintf("%d 0 obj\n", i)) {
+			t.Fatalf("xref offset of object %d is wrong", i)
+		}
+	}
+}
+
+func TestRenderHTMLToPDF(t *testing.T) {
+	defer SetHTMLToPDFRenderer(nil)
+
+	SetHTMLToPDFRenderer(nil)
+	if _, err := RenderHTMLToPDF("<p>hi</p>"); err == nil {
+		t.Fatal("expect error without renderer")
+	}
+
+	SetHTMLToPDFRenderer(HTMLToPDFRendererFunc(func(ctx context.Context, html string) ([]byte, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("render ctx should have deadline")
+		}
+		return []byte("%PDF " + html), nil
+	}))
+	if out, err := RenderHTMLToPDF("<p>hi</p>"); err != nil || string(out) != "%PDF <p>hi</p>" {
+		t.Fatalf("got %s, %v", out, err)
+	}
+}
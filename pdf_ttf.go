@@ -0,0 +1,213 @@
+package libtools
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// pdfTTF 从 TrueType 字体中解析出嵌入 PDF 所需的度量信息, 字体文件整体嵌入, 不做子集化
+type pdfTTF struct {
+	data        []byte
+	unitsPerEm  uint16
+	ascent      int16
+	descent     int16
+	bbox        [4]int16
+	advances    []uint16
+	runeToGlyph map[rune]uint16
+}
+
+func parsePdfTTF(data []byte) (*pdfTTF, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("invalid font file")
+	}
+
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, fmt.Errorf("CFF based OpenType font is not supported, please use a TrueType font")
+	case "ttcf":
+		return nil, fmt.Errorf("font collection (ttc) is not supported, please extract a single ttf")
+	default:
+		return nil, fmt.Errorf("unknown font format")
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		if rec+16 > len(data) {
+			return nil, fmt.Errorf("font table directory is truncated")
+		}
+		tag := string(data[rec : rec+4])
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("font table %s is truncated", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("font table %s not found", tag)
+		}
+	}
+
+	f := &pdfTTF{data: data}
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("invalid head table")
+	}
+	f.unitsPerEm = binary.BigEndian.Uint16(head[18:])
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int16(binary.BigEndian.Uint16(head[36+i*2:]))
+	}
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("invalid units per em")
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("invalid hhea table")
+	}
+	f.ascent = int16(binary.BigEndian.Uint16(hhea[4:]))
+	f.descent = int16(binary.BigEndian.Uint16(hhea[6:]))
+	numberOfHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	hmtx := tables["hmtx"]
+	if len(hmtx) < numberOfHMetrics*4 {
+		return nil, fmt.Errorf("invalid hmtx table")
+	}
+	f.advances = make([]uint16, numberOfHMetrics)
+	for i := 0; i < numberOfHMetrics; i++ {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[i*4:])
+	}
+
+	cmap, err := parsePdfCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.runeToGlyph = cmap
+
+	return f, nil
+}
+
+// parsePdfCmap 优先使用 format 12(支持 BMP 以外的字符), 其次 format 4
+func parsePdfCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("invalid cmap table")
+	}
+
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		rec := 4 + i*8
+		if rec+8 > len(cmap) {
+			break
+		}
+		platformID := binary.BigEndian.Uint16(cmap[rec:])
+		encodingID := binary.BigEndian.Uint16(cmap[rec+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if offset+4 > len(cmap) {
+			continue
+		}
+		// 只接受 Unicode 编码的子表
+		if platformID != 0 && !(platformID == 3 && (encodingID == 1 || encodingID == 10)) {
+			continue
+		}
+
+		sub := cmap[offset:]
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if format4 == nil {
+				format4 = sub
+			}
+		case 12:
+			if format12 == nil {
+				format12 = sub
+			}
+		}
+	}
+
+	m := make(map[rune]uint16)
+	if format12 != nil && len(format12) >= 16 {
+		nGroups := int(binary.BigEndian.Uint32(format12[12:]))
+		for i := 0; i < nGroups; i++ {
+			g := 16 + i*12
+			if g+12 > len(format12) {
+				break
+			}
+			start := binary.BigEndian.Uint32(format12[g:])
+			end := binary.BigEndian.Uint32(format12[g+4:])
+			glyph := binary.BigEndian.Uint32(format12[g+8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				m[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+		return m, nil
+	}
+
+	if format4 == nil || len(format4) < 14 {
+		return nil, fmt.Errorf("no unicode cmap found in font")
+	}
+
+	segCount := int(binary.BigEndian.Uint16(format4[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(format4) {
+		return nil, fmt.Errorf("invalid cmap format 4")
+	}
+
+	for i := 0; i < segCount; i++ {
+		end := binary.BigEndian.Uint16(format4[endCodes+i*2:])
+		start := binary.BigEndian.Uint16(format4[startCodes+i*2:])
+		delta := binary.BigEndian.Uint16(format4[idDeltas+i*2:])
+		rangeOffset := int(binary.BigEndian.Uint16(format4[idRangeOffsets+i*2:]))
+
+		for c := uint32(start); c <= uint32(end) && c != 0xFFFF; c++ {
+			var glyph uint16
+			if rangeOffset == 0 {
+				glyph = uint16(c) + delta
+			} else {
+				pos := idRangeOffsets + i*2 + rangeOffset + int(c-uint32(start))*2
+				if pos+2 > len(format4) {
+					continue
+				}
+				glyph = binary.BigEndian.Uint16(format4[pos:])
+				if glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 {
+				m[rune(c)] = glyph
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (f *pdfTTF) glyph(r rune) uint16 {
+	return f.runeToGlyph[r]
+}
+
+// advance 字形宽度, 换算为 1000 单位
+func (f *pdfTTF) advance(glyph uint16) int {
+	if len(f.advances) == 0 {
+		return 1000
+	}
+
+	idx := int(glyph)
+	if idx >= len(f.advances) {
+		idx = len(f.advances) - 1
+	}
+
+	return int(f.advances[idx]) * 1000 / int(f.unitsPerEm)
+}
+
+func (f *pdfTTF) scale(v int16) int {
+	return int(v) * 1000 / int(f.unitsPerEm)
+}
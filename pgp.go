@@ -0,0 +1,205 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// PGP 文件加解密与签名, 用于银行文件交换; 密钥支持 ASCII armor 与二进制两种格式
+
+const pgpArmorHeader = "-----BEGIN PGP"
+
+// pgpReadKeyRing 解析公钥或私钥, 自动识别 armor 格式
+func pgpReadKeyRing(key []byte) (openpgp.EntityList, error) {
+	var (
+		list openpgp.EntityList
+		err  error
+	)
+	if bytes.Contains(key, []byte(pgpArmorHeader)) {
+		list, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	} else {
+		list, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read pgp key: %v", err)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("pgp: no key found")
+	}
+	return list, nil
+}
+
+// pgpReadPrivateKey 解析私钥, 私钥有密码保护时用 passphrase 解密
+func pgpReadPrivateKey(key []byte, passphrase string) (openpgp.EntityList, error) {
+	list, err := pgpReadKeyRing(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range list {
+		if entity.PrivateKey == nil {
+			return nil, errors.New("pgp: private key is required")
+		}
+		if entity.PrivateKey.Encrypted {
+			if err = entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("could not decrypt pgp private key: %v", err)
+			}
+		}
+	}
+	return list, nil
+}
+
+// pgpWriteFile 写入 out.part, fn 成功后重命名为 out, 失败时删除, 不会留下不完整的文件
+func pgpWriteFile(out string, fn func(w io.Writer) error) error {
+	part := out + transferPartSuffix
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	err = fn(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(part)
+		return err
+	}
+	return os.Rename(part, out)
+}
+
+// PGPEncryptFile 用接收方公钥加密文件, recipientPubKey 可包含多个公钥; out 以 .asc 结尾时输出 ASCII armor 格式, 否则为二进制
+func PGPEncryptFile(in, out string, recipientPubKey []byte) error {
+	recipients, err := pgpReadKeyRing(recipientPubKey)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return pgpWriteFile(out, func(w io.Writer) error {
+		var armored io.WriteCloser
+		if strings.HasSuffix(strings.ToLower(out), ".asc") {
+			a, err := armor.Encode(w, "PGP MESSAGE", nil)
+			if err != nil {
+				return err
+			}
+			armored, w = a, a
+		}
+
+		plain, err := openpgp.Encrypt(w, recipients, nil, &openpgp.FileHints{IsBinary: true}, nil)
+		if err != nil {
+			return fmt.Errorf("pgp encrypt fail: %v", err)
+		}
+		if _, err = io.Copy(plain, src); err != nil {
+			return err
+		}
+		if err = plain.Close(); err != nil {
+			return err
+		}
+		if armored != nil {
+			return armored.Close()
+		}
+		return nil
+	})
+}
+
+// PGPDecryptFile 用私钥解密文件, 自动识别 armor 格式; 完整性校验失败时不会生成 out
+func PGPDecryptFile(in, out string, privateKey []byte, passphrase string) error {
+	keyring, err := pgpReadPrivateKey(privateKey, passphrase)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	r, err := pgpDearmor(src)
+	if err != nil {
+		return err
+	}
+	md, err := openpgp.ReadMessage(r, keyring, nil, nil)
+	if err != nil {
+		return fmt.Errorf("pgp decrypt fail: %v", err)
+	}
+
+	return pgpWriteFile(out, func(w io.Writer) error {
+		// 读到结尾时才校验 MDC
+		if _, err := io.Copy(w, md.UnverifiedBody); err != nil {
+			return fmt.Errorf("pgp decrypt fail: %v", err)
+		}
+		return nil
+	})
+}
+
+// PGPSignDetached 用私钥生成分离签名, 签名为 ASCII armor 格式, 一般保存为 in + ".asc" 或 ".sig"
+func PGPSignDetached(in, sigOut string, privateKey []byte, passphrase string) error {
+	keyring, err := pgpReadPrivateKey(privateKey, passphrase)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return pgpWriteFile(sigOut, func(w io.Writer) error {
+		if err := openpgp.ArmoredDetachSign(w, keyring[0], src, nil); err != nil {
+			return fmt.Errorf("pgp sign fail: %v", err)
+		}
+		return nil
+	})
+}
+
+// PGPVerifyDetached 用签名方公钥校验分离签名, 签名支持 armor 与二进制格式, 校验通过返回 nil
+func PGPVerifyDetached(in, sigFile string, signerPubKey []byte) error {
+	keyring, err := pgpReadKeyRing(signerPubKey)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	sig, err := os.Open(sigFile)
+	if err != nil {
+		return err
+	}
+	defer sig.Close()
+
+	sigReader, err := pgpDearmor(sig)
+	if err != nil {
+		return err
+	}
+	if _, err = openpgp.CheckDetachedSignature(keyring, src, sigReader, nil); err != nil {
+		return fmt.Errorf("pgp verify fail: %v", err)
+	}
+	return nil
+}
+
+// pgpDearmor armor 格式时返回解码后的内容, 否则原样返回
+func pgpDearmor(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(pgpArmorHeader))
+	if string(head) != pgpArmorHeader {
+		return br, nil
+	}
+	block, err := armor.Decode(br)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode pgp armor: %v", err)
+	}
+	return block.Body, nil
+}
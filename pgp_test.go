@@ -0,0 +1,133 @@
+package libtools
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/chester84/libtools/testutil"
+)
+
+// newPGPKeyT 生成 ed25519/x25519 密钥, 返回 armor 格式的公钥与用 passphrase 加密的私钥
+func newPGPKeyT(t *testing.T, name, passphrase string) (pub, priv []byte) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pubBuf, privBuf bytes.Buffer
+	w, _ := armor.Encode(&pubBuf, openpgp.PublicKeyType, nil)
+	if err = entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	if err = entity.EncryptPrivateKeys([]byte(passphrase), nil); err != nil {
+		t.Fatal(err)
+	}
+	w, _ = armor.Encode(&privBuf, openpgp.PrivateKeyType, nil)
+	if err = entity.SerializePrivateWithoutSigning(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	return pubBuf.Bytes(), privBuf.Bytes()
+}
+
+func TestPGPEncryptDecrypt(t *testing.T) {
+	bankPub, bankPriv := newPGPKeyT(t, "bank", "bank-pass")
+	_, otherPriv := newPGPKeyT(t, "other", "other-pass")
+
+	data := bytes.Repeat([]byte("H|20240101|BATCH001\nD|6222020200001234|1250.00\n"), 2000)
+	in := testutil.WriteTempFileT(t, "batch.txt", data)
+	dir := testutil.TempDirT(t)
+
+	for _, name := range []string{"batch.txt.pgp", "batch.txt.asc"} {
+		out := filepath.Join(dir, name)
+		if err := PGPEncryptFile(in, out, bankPub); err != nil {
+			t.Fatal(err)
+		}
+		encrypted, _ := os.ReadFile(out)
+		if isArmor := bytes.HasPrefix(encrypted, []byte(pgpArmorHeader)); isArmor != (filepath.Ext(name) == ".asc") {
+			t.Fatalf("%s armor: %v", name, isArmor)
+		}
+
+		decrypted := filepath.Join(dir, name+".out")
+		if err := PGPDecryptFile(out, decrypted, bankPriv, "bank-pass"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(decrypted); !bytes.Equal(got, data) {
+			t.Fatalf("%s decrypt content mismatch", name)
+		}
+	}
+
+	out := filepath.Join(dir, "batch.txt.pgp")
+	if err := PGPDecryptFile(out, filepath.Join(dir, "wrong-pass"), bankPriv, "bad"); err == nil {
+		t.Fatal("decrypt with wrong passphrase should fail")
+	}
+	if err := PGPDecryptFile(out, filepath.Join(dir, "wrong-key"), otherPriv, "other-pass"); err == nil {
+		t.Fatal("decrypt with other key should fail")
+	}
+	if err := PGPDecryptFile(out, filepath.Join(dir, "pub-key"), bankPub, ""); err == nil {
+		t.Fatal("decrypt with public key should fail")
+	}
+
+	// 篡改密文后完整性校验失败, 不生成输出文件
+	encrypted, _ := os.ReadFile(out)
+	encrypted[len(encrypted)-100] ^= 0xff
+	tampered := testutil.WriteTempFileT(t, "tampered.pgp", encrypted)
+	tamperedOut := filepath.Join(dir, "tampered.out")
+	if err := PGPDecryptFile(tampered, tamperedOut, bankPriv, "bank-pass"); err == nil {
+		t.Fatal("decrypt tampered file should fail")
+	}
+	if _, err := os.Stat(tamperedOut); !os.IsNotExist(err) {
+		t.Fatal("tampered output should not exist")
+	}
+	if _, err := os.Stat(tamperedOut + transferPartSuffix); !os.IsNotExist(err) {
+		t.Fatal("tampered .part should be removed")
+	}
+}
+
+func TestPGPSignDetached(t *testing.T) {
+	pub, priv := newPGPKeyT(t, "merchant", "pass")
+	otherPub, _ := newPGPKeyT(t, "other", "pass")
+
+	in := testutil.WriteTempFileT(t, "settle.csv", []byte("order_id,amount\n10001,1250\n"))
+	sig := in + ".asc"
+	if err := PGPSignDetached(in, sig, priv, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PGPVerifyDetached(in, sig, pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := PGPVerifyDetached(in, sig, otherPub); err == nil {
+		t.Fatal("verify with other key should fail")
+	}
+
+	// 二进制签名
+	block, _ := os.Open(sig)
+	decoded, err := armor.Decode(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw bytes.Buffer
+	_, _ = raw.ReadFrom(decoded.Body)
+	_ = block.Close()
+	binarySig := testutil.WriteTempFileT(t, "settle.csv.sig", raw.Bytes())
+	if err = PGPVerifyDetached(in, binarySig, pub); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := testutil.WriteTempFileT(t, "settle_modified.csv", []byte("order_id,amount\n10001,9250\n"))
+	if err = PGPVerifyDetached(modified, sig, pub); err == nil {
+		t.Fatal("verify modified file should fail")
+	}
+	if err = PGPSignDetached(in, sig, priv, "bad"); err == nil {
+		t.Fatal("sign with wrong passphrase should fail")
+	}
+}
@@ -0,0 +1,61 @@
+package libtools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WritePIDFile 写入当前进程的 pid, 文件中记录的进程仍在运行时返回错误; 进程退出前应删除该文件
+func WritePIDFile(path string) error {
+	if pid, ok := readPIDFile(path); ok && pid != os.Getpid() && processAlive(pid) {
+		return fmt.Errorf("process %d from pid file %s is still running", pid, path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create pid file dir: %v", err)
+	}
+
+	// 先写临时文件再改名, 避免其他进程读到空文件
+	tmpFile := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := ioutil.WriteFile(tmpFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("could not write pid file: %v", err)
+	}
+
+	return os.Rename(tmpFile, path)
+}
+
+// IsRunning pid 文件中记录的进程是否仍在运行, 文件不存在或内容无效时返回 false
+func IsRunning(pidFile string) bool {
+	pid, ok := readPIDFile(pidFile)
+	return ok && processAlive(pid)
+}
+
+func readPIDFile(path string) (int, bool) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+// EnsureSingleInstance 保证同一台机器上同名任务只有一个在运行, 如 EnsureSingleInstance("daily-settle")
+// 锁文件位于系统临时目录, name 为绝对路径时直接作为锁文件; 基于 flock, 进程异常退出时锁自动释放
+// 成功时返回释放函数, 任务结束时调用, 或直接随进程退出
+func EnsureSingleInstance(name string) (func(), error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(os.TempDir(), strings.NewReplacer("/", "_", "\\", "_").Replace(name)+".lock")
+	}
+
+	return lockInstanceFile(path)
+}
@@ -0,0 +1,54 @@
+package libtools
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPIDFile(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "job.pid")
+
+	if IsRunning(pidFile) {
+		t.Errorf("IsRunning should be false when pid file does not exist")
+	}
+	if err := WritePIDFile(pidFile); err != nil {
+		t.Fatal(err)
+	}
+	if !IsRunning(pidFile) {
+		t.Errorf("IsRunning should be true for current process")
+	}
+
+	release, err := EnsureSingleInstance(filepath.Join(dir, "job.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = EnsureSingleInstance(filepath.Join(dir, "job.lock")); err == nil {
+		t.Errorf("EnsureSingleInstance should fail when lock is held")
+	}
+	release()
+
+	release, err = EnsureSingleInstance(filepath.Join(dir, "job.lock"))
+	if err != nil {
+		t.Errorf("EnsureSingleInstance should succeed after release, err: %v", err)
+	} else {
+		release()
+	}
+
+	_ = os.Remove(pidFile)
+}
+
+func TestEnsureSingleInstanceWithoutRelease(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "daily-settle.lock")
+	// 调用方不保留释放函数, 锁需持续到进程退出
+	if _, err := EnsureSingleInstance(lock); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	runtime.GC()
+	if _, err := EnsureSingleInstance(lock); err == nil {
+		t.Fatal("lock should be held after GC")
+	}
+}
@@ -0,0 +1,90 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// pollDefaultInterval Poll 的 interval <= 0 时使用的间隔
+const pollDefaultInterval = 5 * time.Second
+
+// PollOptions Poll 的扩展参数, 零值即默认行为
+type PollOptions struct {
+	Headers map[string]string
+	// Timeout 单次请求超时, 默认 15 秒
+	Timeout time.Duration
+	// Signer、SSRF 与 HttpRequestOptions 中的含义相同; 未指定 Signer 时同样使用按 host 注册的签名器与 TokenSource
+	Signer HttpSigner
+	SSRF   *SSRFPolicy
+}
+
+// Poll 每隔 interval(<= 0 时为 5 秒)请求一次 url, 直到 until 返回 true 或 ctx 结束, 返回最后一次的响应体
+// 请求通过 HttpRequestWithOptions 发出, 签名、TokenSource、SSRF 校验与追踪都与普通请求一致;
+// 通过 ETag/Last-Modified 做条件请求, 内容未变化(304)时不会重复下载, 也不会调用 until;
+// 非 200 响应(如结果文件尚未生成的 404)与网络错误只记录日志, 继续等待
+func Poll(ctx context.Context, url string, interval time.Duration, until func(resp []byte) bool, opts ...PollOptions) ([]byte, error) {
+	var opt PollOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if interval <= 0 {
+		interval = pollDefaultInterval
+	}
+
+	var etag, lastModified string
+	var last []byte
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		body, statusCode, header, err := pollOnce(ctx, url, opt, etag, lastModified)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				logs.Warning("[Poll] request %s fail, err: %v", url, err)
+			}
+		case statusCode == http.StatusNotModified:
+		case statusCode == http.StatusOK:
+			etag, lastModified = header.Get("ETag"), header.Get("Last-Modified")
+			last = body
+			if until(body) {
+				return body, nil
+			}
+		default:
+			logs.Warning("[Poll] request %s get status: %d", url, statusCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func pollOnce(ctx context.Context, url string, opt PollOptions, etag, lastModified string) ([]byte, int, http.Header, error) {
+	headers := make(map[string]string, len(opt.Headers)+2)
+	for key, value := range opt.Headers {
+		headers[key] = value
+	}
+	if etag != "" {
+		headers["If-None-Match"] = etag
+	}
+	if lastModified != "" {
+		headers["If-Modified-Since"] = lastModified
+	}
+
+	header := make(http.Header)
+	body, statusCode, err := HttpRequestWithOptions(ctx, HttpMethodGet, url, headers, HttpApplicationFormEncoded, map[string]string{}, HttpRequestOptions{
+		Timeout:        opt.Timeout,
+		Signer:         opt.Signer,
+		SSRF:           opt.SSRF,
+		ResponseHeader: header,
+	})
+
+	return body, statusCode, header, err
+}
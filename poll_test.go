@@ -0,0 +1,78 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	var hits, fullBodies int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		switch {
+		case n == 1:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case n < 5:
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			atomic.AddInt32(&fullBodies, 1)
+			_, _ = w.Write([]byte("processing"))
+		default:
+			w.Header().Set("ETag", `"v2"`)
+			atomic.AddInt32(&fullBodies, 1)
+			_, _ = w.Write([]byte("done"))
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	body, err := Poll(ctx, server.URL, 10*time.Millisecond, func(resp []byte) bool {
+		return string(resp) == "done"
+	})
+	if err != nil || string(body) != "done" {
+		t.Fatalf("Poll get unexpected result: %s, err: %v", body, err)
+	}
+	if fullBodies != 2 {
+		t.Errorf("unchanged payload should not be refetched, full bodies: %d", fullBodies)
+	}
+}
+
+func TestPollOptions(t *testing.T) {
+	var signed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test-Sign") == "ok" {
+			atomic.AddInt32(&signed, 1)
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	// interval 为 0 时使用默认间隔, 首次请求即完成, 请求经过签名器
+	signer := HttpSignerFunc(func(req *http.Request) error {
+		req.Header.Set("X-Test-Sign", "ok")
+		return nil
+	})
+	body, err := Poll(context.Background(), server.URL, 0, func(resp []byte) bool { return true }, PollOptions{Signer: signer})
+	if err != nil || string(body) != "done" || signed != 1 {
+		t.Fatalf("Poll get unexpected result: %s, signed: %d, err: %v", body, signed, err)
+	}
+
+	// SSRF 策略拒绝本地地址, 网络错误只记录日志直到 ctx 结束
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	policy := DefaultSSRFPolicy()
+	_, err = Poll(ctx, server.URL, 10*time.Millisecond, func(resp []byte) bool { return true }, PollOptions{SSRF: &policy})
+	if err != context.DeadlineExceeded || signed != 1 {
+		t.Errorf("ssrf policy should block the request, signed: %d, err: %v", signed, err)
+	}
+}
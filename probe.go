@@ -0,0 +1,167 @@
+package libtools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+const probeDefaultTimeout = 5 * time.Second
+
+// TLSCertInfo 证书链中单张证书的摘要信息, 时间均为毫秒
+type TLSCertInfo struct {
+	Subject      string   `json:"subject"`
+	Issuer       string   `json:"issuer"`
+	DNSNames     []string `json:"dns_names"`
+	SerialNumber string   `json:"serial_number"`
+	NotBefore    int64    `json:"not_before"`
+	NotAfter     int64    `json:"not_after"`
+}
+
+// TLSProbeResult ProbeTLS 的结果
+type TLSProbeResult struct {
+	Addr       string        `json:"addr"`
+	Latency    time.Duration `json:"latency"`
+	Version    string        `json:"version"`
+	Chain      []TLSCertInfo `json:"chain"`
+	ExpireAt   int64         `json:"expire_at"` // 叶子证书过期时间,毫秒
+	DaysLeft   int64         `json:"days_left"`
+	Verified   bool          `json:"verified"`
+	VerifyErr  string        `json:"verify_err"`
+	ServerName string        `json:"server_name"`
+}
+
+// HTTPProbeResult ProbeHTTP 的结果
+type HTTPProbeResult struct {
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	Latency    time.Duration `json:"latency"`
+}
+
+func probeTimeout(timeout []time.Duration) time.Duration {
+	if len(timeout) > 0 && timeout[0] > 0 {
+		return timeout[0]
+	}
+
+	return probeDefaultTimeout
+}
+
+// ProbeTCP 探测 tcp 端口是否可连接,返回建连耗时
+func ProbeTCP(addr string, timeout time.Duration) (time.Duration, error) {
+	if timeout <= 0 {
+		timeout = probeDefaultTimeout
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return 0, fmt.Errorf("tcp probe %s fail: %v", addr, err)
+	}
+	latency := time.Since(start)
+	_ = conn.Close()
+
+	return latency, nil
+}
+
+// ProbeTLS 握手并返回证书链与过期信息, addr 不带端口时默认 443
+// 证书校验失败不算探测失败,结果记录在 Verified/VerifyErr 中,便于对已失效的证书告警
+func ProbeTLS(addr string, timeout ...time.Duration) (result TLSProbeResult, err error) {
+	host, _, splitErr := net.SplitHostPort(addr)
+	if splitErr != nil {
+		host = addr
+		addr = net.JoinHostPort(addr, "443")
+	}
+	result.Addr = addr
+	result.ServerName = host
+
+	dialer := &net.Dialer{Timeout: probeTimeout(timeout)}
+	start := time.Now()
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		err = fmt.Errorf("tls probe %s fail: %v", addr, err)
+		return
+	}
+	defer conn.Close()
+	result.Latency = time.Since(start)
+
+	state := conn.ConnectionState()
+	result.Version = tlsVersionName(state.Version)
+	if len(state.PeerCertificates) == 0 {
+		err = fmt.Errorf("tls probe %s get no peer certificate", addr)
+		return
+	}
+
+	for _, cert := range state.PeerCertificates {
+		result.Chain = append(result.Chain, TLSCertInfo{
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			DNSNames:     cert.DNSNames,
+			SerialNumber: cert.SerialNumber.String(),
+			NotBefore:    GetUnixMillisByTime(cert.NotBefore),
+			NotAfter:     GetUnixMillisByTime(cert.NotAfter),
+		})
+	}
+
+	leaf := state.PeerCertificates[0]
+	result.ExpireAt = GetUnixMillisByTime(leaf.NotAfter)
+	result.DaysLeft = (result.ExpireAt - GetUnixMillis()) / MillsSecondADay
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+	if verifyErr != nil {
+		result.VerifyErr = verifyErr.Error()
+	} else {
+		result.Verified = true
+	}
+
+	return
+}
+
+// ProbeHTTP 发起 GET 请求,返回状态码与耗时,响应体最多读取 64KB 后丢弃
+func ProbeHTTP(url string, timeout ...time.Duration) (result HTTPProbeResult, err error) {
+	result.URL = url
+
+	client := &http.Client{Timeout: probeTimeout(timeout)}
+	start := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		err = fmt.Errorf("http probe %s fail: %v", url, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	result.Latency = time.Since(start)
+	result.StatusCode = resp.StatusCode
+
+	return
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
@@ -0,0 +1,70 @@
+package libtools
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if _, err = ProbeTCP(addr, time.Second); err != nil {
+		t.Errorf("ProbeTCP should succeed: %v", err)
+	}
+
+	_ = ln.Close()
+	if _, err = ProbeTCP(addr, time.Second); err == nil {
+		t.Error("ProbeTCP should fail on closed port")
+	}
+}
+
+func TestProbeTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	addr := strings.TrimPrefix(srv.URL, "https://")
+	result, err := ProbeTLS(addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// httptest 的证书不受系统信任, 探测成功但校验失败
+	if result.Verified || result.VerifyErr == "" {
+		t.Errorf("self-signed certificate should not be verified: %+v", result)
+	}
+	if len(result.Chain) == 0 || result.ExpireAt <= GetUnixMillis() || result.DaysLeft <= 0 {
+		t.Errorf("unexpected chain info: %+v", result)
+	}
+	if result.ServerName != "127.0.0.1" || !strings.HasPrefix(result.Version, "TLS1.") {
+		t.Errorf("unexpected server name or version: %+v", result)
+	}
+
+	srv.Close()
+	if _, err = ProbeTLS(addr, time.Second); err == nil {
+		t.Error("ProbeTLS should fail on closed server")
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// 非 2xx 也算探测成功, 由调用方根据状态码判断
+	result, err := ProbeHTTP(srv.URL+"/health", time.Second)
+	if err != nil || result.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("ProbeHTTP: %+v, %v", result, err)
+	}
+
+	srv.Close()
+	if _, err = ProbeHTTP(srv.URL, time.Second); err == nil {
+		t.Error("ProbeHTTP should fail on closed server")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package libtools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// instanceLockFiles 持有加锁的文件, 调用方不保留释放函数时, 文件也不会被 GC 关闭而丢失 flock
+var (
+	instanceLockMu    sync.Mutex
+	instanceLockFiles = make(map[string]*os.File)
+)
+
+// processAlive 发送 0 号信号探测, 无权限(EPERM)说明进程存在但属于其他用户
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func lockInstanceFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %v", err)
+	}
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		buf, _ := ioutil.ReadAll(f)
+		_ = f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("another instance is running, lock: %s, pid: %s", path, strings.TrimSpace(string(buf)))
+		}
+		return nil, fmt.Errorf("could not lock %s: %v", path, err)
+	}
+
+	// 记录持有者的 pid 便于排查, 锁文件本身不删除, 避免与正在打开它的进程产生竞争
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	instanceLockMu.Lock()
+	instanceLockFiles[path] = f
+	instanceLockMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			instanceLockMu.Lock()
+			delete(instanceLockFiles, path)
+			instanceLockMu.Unlock()
+
+			_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+			_ = f.Close()
+		})
+	}, nil
+}
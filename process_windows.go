@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package libtools
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+
+	return true
+}
+
+// lockInstanceFile windows 下没有 flock, 以独占创建锁文件代替, 锁文件中记录的进程已退出时视为过期锁
+func lockInstanceFile(path string) (func(), error) {
+	for i := 0; i < 2; i++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			_ = f.Close()
+			return func() {
+				_ = os.Remove(path)
+			}, nil
+		}
+
+		if pid, ok := readPIDFile(path); ok && processAlive(pid) {
+			return nil, fmt.Errorf("another instance is running, lock: %s, pid: %d", path, pid)
+		}
+		_ = os.Remove(path)
+	}
+
+	return nil, fmt.Errorf("could not create lock file: %s", path)
+}
@@ -0,0 +1,174 @@
+package libtools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ProgressOptions 进度条配置, 零值使用默认值
+type ProgressOptions struct {
+	// Name 显示在进度条与日志前面, 如 "zip uploads"
+	Name string
+	// Writer 进度条输出位置, 默认 os.Stderr
+	Writer io.Writer
+	// Quiet 为 true 时不画进度条, 只在每达到 MilestoneStep 时打一条日志, 适合 crontab/容器等没有终端的环境
+	Quiet bool
+	// MilestoneStep 日志的百分比间隔, 默认 10
+	MilestoneStep int
+	// Width 进度条宽度, 默认 30
+	Width int
+}
+
+// Progress 批处理任务的进度, 并发安全
+type Progress struct {
+	opts  ProgressOptions
+	total int64
+	done  int64
+	start time.Time
+
+	mu            sync.Mutex
+	lastRender    time.Time
+	lastMilestone int
+	finished      bool
+}
+
+// progressRenderInterval 进度条最短刷新间隔, 避免大量小步 Add 时刷屏
+const progressRenderInterval = 200 * time.Millisecond
+
+// NewProgress total 未知时传 0, 此时只显示已完成数量与速度
+func NewProgress(total int64, opts ...ProgressOptions) *Progress {
+	var opt ProgressOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Writer == nil {
+		opt.Writer = os.Stderr
+	}
+	if opt.MilestoneStep <= 0 {
+		opt.MilestoneStep = 10
+	}
+	if opt.Width <= 0 {
+		opt.Width = 30
+	}
+
+	return &Progress{opts: opt, total: total, start: time.Now()}
+}
+
+// Add 完成 n 个单位(文件数、字节数等)
+func (p *Progress) Add(n int64) {
+	done := atomic.AddInt64(&p.done, n)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+
+	if p.opts.Quiet {
+		if p.total > 0 {
+			percent := int(done * 100 / p.total)
+			milestone := percent / p.opts.MilestoneStep * p.opts.MilestoneStep
+			if milestone > p.lastMilestone {
+				p.lastMilestone = milestone
+				logs.Info("[Progress] %s %d%% (%d/%d), eta: %s", p.opts.Name, milestone, done, p.total, p.etaDisplay(done))
+			}
+		}
+		return
+	}
+
+	if time.Since(p.lastRender) >= progressRenderInterval || (p.total > 0 && done >= p.total) {
+		p.lastRender = time.Now()
+		fmt.Fprint(p.opts.Writer, "\r"+p.line(done))
+	}
+}
+
+// Step 报告进入新的步骤, 如 Step("uploading"), 进度条模式下另起一行输出
+func (p *Progress) Step(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.opts.Quiet {
+		logs.Info("[Progress] %s step: %s", p.opts.Name, name)
+		return
+	}
+	fmt.Fprintf(p.opts.Writer, "\n==> %s\n", name)
+}
+
+// Done 结束进度显示, 输出总耗时
+func (p *Progress) Done() {
+	done := atomic.LoadInt64(&p.done)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	p.finished = true
+
+	elapsed := HumanUnixMillisV2(time.Since(p.start).Milliseconds())
+	if p.opts.Quiet {
+		logs.Info("[Progress] %s done, %d items, elapsed: %s", p.opts.Name, done, elapsed)
+		return
+	}
+	fmt.Fprintf(p.opts.Writer, "\r%s, elapsed: %s\n", p.line(done), elapsed)
+}
+
+// ETA 按当前平均速度估算的剩余时间, total 未知或尚无进度时返回 -1
+func (p *Progress) ETA() time.Duration {
+	return p.eta(atomic.LoadInt64(&p.done))
+}
+
+func (p *Progress) eta(done int64) time.Duration {
+	if p.total <= 0 || done <= 0 {
+		return -1
+	}
+	if done >= p.total {
+		return 0
+	}
+
+	elapsed := time.Since(p.start)
+	return time.Duration(float64(elapsed) / float64(done) * float64(p.total-done))
+}
+
+func (p *Progress) etaDisplay(done int64) string {
+	eta := p.eta(done)
+	if eta < 0 {
+		return "--:--:--"
+	}
+
+	return HumanUnixMillisV2(eta.Milliseconds())
+}
+
+// line 如: zip uploads [=========>          ]  33.3% 100/300 12.5/s eta 00:00:16
+func (p *Progress) line(done int64) string {
+	var b strings.Builder
+	if p.opts.Name != "" {
+		b.WriteString(p.opts.Name + " ")
+	}
+
+	rate := float64(done) / time.Since(p.start).Seconds()
+	if p.total <= 0 {
+		fmt.Fprintf(&b, "%d %.1f/s", done, rate)
+		return b.String()
+	}
+
+	ratio := float64(done) / float64(p.total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio * float64(p.opts.Width))
+	bar := strings.Repeat("=", filled)
+	if filled < p.opts.Width {
+		bar += ">" + strings.Repeat(" ", p.opts.Width-filled-1)
+	}
+	fmt.Fprintf(&b, "[%s] %5.1f%% %d/%d %.1f/s eta %s", bar, ratio*100, done, p.total, rate, p.etaDisplay(done))
+
+	return b.String()
+}
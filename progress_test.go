@@ -0,0 +1,70 @@
+package libtools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressBar(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProgress(40, ProgressOptions{Name: "upload", Writer: &out, Width: 10})
+
+	p.Add(10)
+	if !strings.Contains(out.String(), "upload [==>       ]  25.0% 10/40") {
+		t.Fatalf("unexpected progress line: %q", out.String())
+	}
+
+	// 刷新间隔内不重复输出, 完成时总是输出
+	out.Reset()
+	p.Add(10)
+	if out.Len() != 0 {
+		t.Errorf("should not render within interval: %q", out.String())
+	}
+	p.Add(20)
+	if !strings.Contains(out.String(), "[==========] 100.0% 40/40") {
+		t.Errorf("should render on completion: %q", out.String())
+	}
+
+	p.Step("verify")
+	out.Reset()
+	p.Done()
+	p.Done()
+	p.Add(1)
+	if s := out.String(); strings.Count(s, "elapsed") != 1 || !strings.HasSuffix(s, "\n") {
+		t.Errorf("Done should print once: %q", s)
+	}
+}
+
+func TestProgressETA(t *testing.T) {
+	if eta := NewProgress(0).ETA(); eta != -1 {
+		t.Errorf("unknown total should return -1, got: %v", eta)
+	}
+
+	p := NewProgress(40, ProgressOptions{Quiet: true})
+	if eta := p.ETA(); eta != -1 {
+		t.Errorf("no progress should return -1, got: %v", eta)
+	}
+
+	p.start = time.Now().Add(-10 * time.Second)
+	p.Add(10)
+	if eta := p.ETA(); eta < 29*time.Second || eta > 31*time.Second {
+		t.Errorf("eta should be about 30s, got: %v", eta)
+	}
+	if p.lastMilestone != 20 {
+		t.Errorf("quiet mode should log at 10%% steps, last milestone: %d", p.lastMilestone)
+	}
+
+	p.Add(30)
+	if eta := p.ETA(); eta != 0 || p.lastMilestone != 100 {
+		t.Errorf("finished progress, eta: %v, milestone: %d", eta, p.lastMilestone)
+	}
+
+	var out bytes.Buffer
+	unknown := NewProgress(0, ProgressOptions{Writer: &out})
+	unknown.Add(5)
+	if !strings.HasPrefix(out.String(), "\r5 ") || !strings.HasSuffix(out.String(), "/s") {
+		t.Errorf("unknown total should only show count and rate: %q", out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package libtools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RBAC 角色与权限的映射, 权限使用 . 分隔的层级名称, 如 loan.apply.approve
+// 支持通配符: "*" 拥有全部权限; "loan.*" 匹配 loan 下的任意权限; "*.read" 匹配任意模块的 read
+type RBAC struct {
+	mu    sync.RWMutex
+	roles map[string][]string
+}
+
+// NewRBAC mapping 为 角色 => 权限列表
+func NewRBAC(mapping map[string][]string) *RBAC {
+	r := &RBAC{roles: make(map[string][]string)}
+	r.Set(mapping)
+	return r
+}
+
+// Set 替换全部映射, 可用于配置热更新
+func (r *RBAC) Set(mapping map[string][]string) {
+	roles := make(map[string][]string, len(mapping))
+	for role, perms := range mapping {
+		roles[role] = append([]string(nil), perms...)
+	}
+
+	r.mu.Lock()
+	r.roles = roles
+	r.mu.Unlock()
+}
+
+// Load 从 json 配置加载, 如 {"admin": ["*"], "auditor": ["loan.read", "user.*"]}
+func (r *RBAC) Load(reader io.Reader) error {
+	var mapping map[string][]string
+	if err := json.NewDecoder(reader).Decode(&mapping); err != nil {
+		return fmt.Errorf("could not decode rbac config: %v", err)
+	}
+
+	r.Set(mapping)
+	return nil
+}
+
+// Can 任一角色拥有 perm 权限即返回 true
+func (r *RBAC) Can(roles []string, perm string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, role := range roles {
+		for _, pattern := range r.roles[role] {
+			if rbacMatch(pattern, perm) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Require 返回校验权限的 http 中间件, rolesOf 从请求中取出当前用户的角色(如从会话中读取)
+func (r *RBAC) Require(perm string, rolesOf func(req *http.Request) []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !r.Can(rolesOf(req), perm) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"code":403,"message":"permission denied"}`))
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// rbacMatch 按 . 分段匹配, 中间的 * 匹配一段, 末尾的 * 匹配剩余的一段或多段
+func rbacMatch(pattern, perm string) bool {
+	if pattern == "*" || pattern == perm {
+		return true
+	}
+
+	ps, qs := strings.Split(pattern, "."), strings.Split(perm, ".")
+	for i, p := range ps {
+		if i >= len(qs) {
+			return false
+		}
+		if p == "*" && i == len(ps)-1 {
+			return true
+		}
+		if p != "*" && p != qs[i] {
+			return false
+		}
+	}
+
+	return len(ps) == len(qs)
+}
+
+var defaultRBAC = NewRBAC(nil)
+
+// LoadRBAC 加载全局的角色权限配置
+func LoadRBAC(reader io.Reader) error {
+	return defaultRBAC.Load(reader)
+}
+
+// Can 使用全局配置判断权限
+func Can(roles []string, perm string) bool {
+	return defaultRBAC.Can(roles, perm)
+}
+
+// RequirePermission 使用全局配置的权限中间件
+//
+//	mux.Handle("/admin/loan/approve", RequirePermission("loan.approve", rolesFromSession)(approveHandler))
+func RequirePermission(perm string, rolesOf func(req *http.Request) []string) func(http.Handler) http.Handler {
+	return defaultRBAC.Require(perm, rolesOf)
+}
@@ -0,0 +1,35 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRBAC(t *testing.T) {
+	r := NewRBAC(nil)
+	err := r.Load(strings.NewReader(`{"admin": ["*"], "auditor": ["loan.read", "user.*"], "viewer": ["*.read"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		roles []string
+		perm  string
+		want  bool
+	}{
+		{[]string{"admin"}, "loan.apply.approve", true},
+		{[]string{"auditor"}, "loan.read", true},
+		{[]string{"auditor"}, "loan.write", false},
+		{[]string{"auditor"}, "user.profile.edit", true},
+		{[]string{"auditor"}, "user", false},
+		{[]string{"viewer"}, "order.read", true},
+		{[]string{"viewer"}, "order.detail.read", false},
+		{[]string{"viewer", "auditor"}, "user.delete", true},
+		{[]string{"unknown"}, "loan.read", false},
+	}
+	for _, c := range cases {
+		if got := r.Can(c.roles, c.perm); got != c.want {
+			t.Errorf("Can(%v, %s) = %v, want %v", c.roles, c.perm, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package libtools
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Region 行政区划
+type Region struct {
+	Code string `json:"code"` // 6 位行政区划代码, 如 440305
+	Name string `json:"name"`
+}
+
+// CNAddress ParseCNAddress 的结果, 无法识别的层级为空
+type CNAddress struct {
+	Province     string `json:"province"`
+	City         string `json:"city"`
+	District     string `json:"district"`
+	Detail       string `json:"detail"`
+	ProvinceCode string `json:"province_code,omitempty"`
+	CityCode     string `json:"city_code,omitempty"`
+	DistrictCode string `json:"district_code,omitempty"`
+}
+
+// regionCodesData 内置的行政区划代码表(GB/T 2260), 包含省、地级市、区县三级
+//
+//go:embed region_codes.txt
+var regionCodesData string
+
+// regionProvinces 省级行政区划, 由内置表生成
+var regionProvinces []Region
+
+var (
+	regionMu     sync.RWMutex
+	regionByCode = map[string]string{}
+)
+
+func init() {
+	codes, err := parseRegionCodes(strings.NewReader(regionCodesData))
+	if err != nil {
+		panic(err)
+	}
+
+	regionByCode = codes
+	for code, name := range codes {
+		if strings.HasSuffix(code, "0000") {
+			regionProvinces = append(regionProvinces, Region{Code: code, Name: name})
+		}
+	}
+	sort.Slice(regionProvinces, func(i, j int) bool {
+		return regionProvinces[i].Code < regionProvinces[j].Code
+	})
+}
+
+// parseRegionCodes 解析 "代码,名称" 或 "代码<tab>名称" 格式的区划代码表, 忽略空行与 # 开头的注释
+func parseRegionCodes(r io.Reader) (map[string]string, error) {
+	loaded := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == '\t'
+		})
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid region line %d: %s", line, text)
+		}
+
+		code, name := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if len(code) != 6 || !IsNumber(code) {
+			return nil, fmt.Errorf("invalid region code at line %d: %s", line, code)
+		}
+		loaded[code] = name
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
+
+// LoadRegionCodes 加载行政区划代码表覆盖内置表, 每行 "代码,名称" 或 "代码<tab>名称", 如 440305,南山区
+// 用于民政部调整区划后更新数据, 可多次调用, 相同代码以后加载的为准
+func LoadRegionCodes(r io.Reader) error {
+	loaded, err := parseRegionCodes(r)
+	if err != nil {
+		return err
+	}
+
+	regionMu.Lock()
+	for code, name := range loaded {
+		regionByCode[code] = name
+	}
+	regionMu.Unlock()
+
+	return nil
+}
+
+// RegionCodeLookup 根据 6 位代码返回完整的区划名称链, 如 440305 => [广东省 深圳市 南山区]
+// 直辖市的 "市辖区" 层级会被省略
+func RegionCodeLookup(code string) ([]Region, bool) {
+	if len(code) != 6 {
+		return nil, false
+	}
+
+	regionMu.RLock()
+	defer regionMu.RUnlock()
+
+	if _, ok := regionByCode[code]; !ok {
+		return nil, false
+	}
+
+	var chain []Region
+	for _, c := range []string{code[:2] + "0000", code[:4] + "00", code} {
+		if len(chain) > 0 && chain[len(chain)-1].Code == c {
+			continue
+		}
+		name, ok := regionByCode[c]
+		if !ok || name == "市辖区" || name == "县" {
+			continue
+		}
+		chain = append(chain, Region{Code: c, Name: name})
+	}
+
+	return chain, len(chain) > 0
+}
+
+// regionShortName 去掉行政区划的通名, 如 广西壮族自治区 => 广西, 深圳市 => 深圳
+func regionShortName(name string) string {
+	suffixes := []string{"维吾尔自治区", "壮族自治区", "回族自治区", "特别行政区", "自治区", "自治州", "地区", "省", "市", "盟", "区", "县", "旗"}
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			short := strings.TrimSuffix(name, suffix)
+			// 只剩一个字的简称容易误匹配, 保留全称, 如 "城区"
+			if utf8.RuneCountInString(short) >= 2 {
+				return short
+			}
+			return name
+		}
+	}
+
+	return name
+}
+
+// regionMatch 在 addr 开头匹配候选名称(全称或简称), 返回最长匹配的候选及消耗的长度
+func regionMatch(addr string, candidates []Region) (matched Region, length int) {
+	for _, r := range candidates {
+		for _, name := range []string{r.Name, regionShortName(r.Name)} {
+			if len(name) > length && strings.HasPrefix(addr, name) {
+				matched, length = r, len(name)
+			}
+		}
+	}
+
+	return
+}
+
+// regionChildren 返回 parent 的下一级区划, parent 为省级时返回地级市(直辖市返回区县)
+func regionChildren(parent string) []Region {
+	regionMu.RLock()
+	defer regionMu.RUnlock()
+
+	var children []Region
+	for code, name := range regionByCode {
+		if code == parent || name == "市辖区" || name == "县" {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(parent, "0000"):
+			if code[:2] == parent[:2] && strings.HasSuffix(code, "00") {
+				children = append(children, Region{Code: code, Name: name})
+			}
+		case strings.HasSuffix(parent, "00"):
+			if code[:4] == parent[:4] {
+				children = append(children, Region{Code: code, Name: name})
+			}
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Code < children[j].Code
+	})
+	return children
+}
+
+// regionMunicipalities 直辖市, 省与市同名
+var regionMunicipalities = map[string]bool{"110000": true, "120000": true, "310000": true, "500000": true}
+
+// ParseCNAddress 将用户填写的地址拆分为 省/市/区县/详细地址, 支持省略 "省" "市" 等通名以及省略省份的写法
+//
+//	ParseCNAddress("广东深圳市南山区科技园xx号") => 广东省(440000) 深圳市(440300) 南山区(440305) 科技园xx号
+//	ParseCNAddress("北京朝阳区建国路1号") => 北京市(110000) 北京市(110100) 朝阳区(110105) 建国路1号
+func ParseCNAddress(addr string) CNAddress {
+	var result CNAddress
+	rest := strings.Join(strings.Fields(addr), "")
+
+	province, n := regionMatch(rest, regionProvinces)
+	if n > 0 {
+		result.Province, result.ProvinceCode = province.Name, province.Code
+		rest = rest[n:]
+	}
+
+	// 省略了省份时, 通过城市名推断
+	if n == 0 {
+		var candidates []Region
+		for cityName := range GetCityProvinceMap() {
+			candidates = append(candidates, Region{Name: cityName})
+		}
+		if city, cn := regionMatch(rest, candidates); cn > 0 {
+			province, _ = regionMatch(GetProvinceOfCity(city.Name), regionProvinces)
+			result.Province, result.ProvinceCode = province.Name, province.Code
+		}
+	}
+
+	// 直辖市: 北京市朝阳区, 省与市相同, 下一级直接是区县
+	if regionMunicipalities[province.Code] {
+		result.City = province.Name
+		rest = strings.TrimPrefix(rest, "市辖区")
+		districts := append(regionChildren(province.Code[:2]+"0100"), regionChildren(province.Code[:2]+"0200")...)
+		if district, n := regionMatch(rest, districts); n > 0 {
+			result.District, result.DistrictCode = district.Name, district.Code
+			result.CityCode = district.Code[:4] + "00"
+			rest = rest[n:]
+		}
+		result.Detail = rest
+		return result
+	}
+
+	// 地级市: 优先使用区划代码表, 其次使用城市名称表
+	var city Region
+	n = 0
+	if province.Code != "" {
+		city, n = regionMatch(rest, regionChildren(province.Code))
+	}
+	if n == 0 {
+		var candidates []Region
+		for cityName, provinceName := range GetCityProvinceMap() {
+			if result.Province == "" || provinceName == result.Province {
+				candidates = append(candidates, Region{Name: cityName})
+			}
+		}
+		city, n = regionMatch(rest, candidates)
+	}
+	if n > 0 {
+		result.City, result.CityCode = city.Name, city.Code
+		rest = rest[n:]
+	}
+
+	if result.CityCode != "" {
+		if district, n := regionMatch(rest, regionChildren(result.CityCode)); n > 0 {
+			result.District, result.DistrictCode = district.Name, district.Code
+			rest = rest[n:]
+		}
+	}
+
+	result.Detail = rest
+	return result
+}
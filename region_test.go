@@ -0,0 +1,100 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+)
+
+// withRegionCodesT 加载测试用的区划代码, 测试结束后恢复内置表
+func withRegionCodesT(t *testing.T, codes string) {
+	t.Helper()
+	regionMu.Lock()
+	saved := make(map[string]string, len(regionByCode))
+	for code, name := range regionByCode {
+		saved[code] = name
+	}
+	regionMu.Unlock()
+	t.Cleanup(func() {
+		regionMu.Lock()
+		regionByCode = saved
+		regionMu.Unlock()
+	})
+
+	if err := LoadRegionCodes(strings.NewReader(codes)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseCNAddressBuiltin(t *testing.T) {
+	cases := map[string]CNAddress{
+		"广东深圳市南山区科技园xx号": {
+			Province: "广东省", City: "深圳市", District: "南山区", Detail: "科技园xx号",
+			ProvinceCode: "440000", CityCode: "440300", DistrictCode: "440305",
+		},
+		"深圳南山科技园xx号": {
+			Province: "广东省", City: "深圳市", District: "南山区", Detail: "科技园xx号",
+			ProvinceCode: "440000", CityCode: "440300", DistrictCode: "440305",
+		},
+		"广西壮族自治区 南宁市 青秀区": {
+			Province: "广西壮族自治区", City: "南宁市", District: "青秀区",
+			ProvinceCode: "450000", CityCode: "450100", DistrictCode: "450103",
+		},
+		"北京朝阳区建国路1号": {
+			Province: "北京市", City: "北京市", District: "朝阳区", Detail: "建国路1号",
+			ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110105",
+		},
+		"重庆市城口县葛城街道": {
+			Province: "重庆市", City: "重庆市", District: "城口县", Detail: "葛城街道",
+			ProvinceCode: "500000", CityCode: "500200", DistrictCode: "500229",
+		},
+		"火星基地1号": {Detail: "火星基地1号"},
+	}
+	for addr, want := range cases {
+		if got := ParseCNAddress(addr); got != want {
+			t.Errorf("ParseCNAddress(%s): got %+v, want %+v", addr, got, want)
+		}
+	}
+
+	if len(regionProvinces) != 34 {
+		t.Errorf("provinces: got %d, want 34", len(regionProvinces))
+	}
+	chain, ok := RegionCodeLookup("440305")
+	if !ok || len(chain) != 3 || chain[0].Name != "广东省" || chain[1].Name != "深圳市" || chain[2].Name != "南山区" {
+		t.Errorf("RegionCodeLookup: %+v, %v", chain, ok)
+	}
+}
+
+func TestParseCNAddressWithCodes(t *testing.T) {
+	withRegionCodesT(t, `# 测试数据, 覆盖内置表
+440000,广东省
+440300,深圳市
+440305,南山新区
+110000,北京市
+110100,市辖区
+110105,朝阳区
+`)
+
+	cases := map[string]CNAddress{
+		"广东深圳市南山新区科技园xx号": {
+			Province: "广东省", City: "深圳市", District: "南山新区", Detail: "科技园xx号",
+			ProvinceCode: "440000", CityCode: "440300", DistrictCode: "440305",
+		},
+		"北京朝阳区建国路1号": {
+			Province: "北京市", City: "北京市", District: "朝阳区", Detail: "建国路1号",
+			ProvinceCode: "110000", CityCode: "110100", DistrictCode: "110105",
+		},
+	}
+	for addr, want := range cases {
+		if got := ParseCNAddress(addr); got != want {
+			t.Errorf("ParseCNAddress(%s): got %+v, want %+v", addr, got, want)
+		}
+	}
+
+	chain, ok := RegionCodeLookup("110105")
+	if !ok || len(chain) != 2 || chain[0].Name != "北京市" || chain[1].Name != "朝阳区" {
+		t.Errorf("RegionCodeLookup: %+v, %v", chain, ok)
+	}
+	if err := LoadRegionCodes(strings.NewReader("4403,深圳市")); err == nil {
+		t.Error("invalid code should fail")
+	}
+}
@@ -0,0 +1,95 @@
+package libtools
+
+import (
+	"fmt"
+	"time"
+)
+
+// WindowKind 报表统计周期
+type WindowKind int
+
+const (
+	WindowDaily WindowKind = iota
+	WindowWeekly
+	WindowMonthly
+	WindowQuarterly
+)
+
+// Window 一个统计周期, 毫秒, 左闭右开 [Begin, End)
+// Label 如 2024-05-12、2024-W19(ISO 周)、2024-05、2024-Q2
+type Window struct {
+	Begin int64  `json:"begin"`
+	End   int64  `json:"end"`
+	Label string `json:"label"`
+}
+
+// ReportWindows 按本地时区的自然日/周(周一开始)/月/季度, 返回与 [from, to) 有交集的所有完整周期, from、to 为毫秒
+// 首尾周期不做裁剪, 如 from 为 5 月 10 日时按月统计的第一个周期仍是 5 月 1 日至 6 月 1 日
+func ReportWindows(kind WindowKind, from, to int64) []Window {
+	if from >= to {
+		return nil
+	}
+
+	begin := windowStart(kind, time.UnixMilli(from).In(time.Local))
+	end := time.UnixMilli(to)
+
+	var windows []Window
+	for begin.Before(end) {
+		next := windowNext(kind, begin)
+		windows = append(windows, Window{
+			Begin: GetUnixMillisByTime(begin),
+			End:   GetUnixMillisByTime(next),
+			Label: windowLabel(kind, begin),
+		})
+		begin = next
+	}
+
+	return windows
+}
+
+// windowStart t 所在周期的起点
+func windowStart(kind WindowKind, t time.Time) time.Time {
+	day := GetZeroTime(t)
+
+	switch kind {
+	case WindowWeekly:
+		// 周日的 Weekday 为 0, 按周一开始时属于上一周
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case WindowMonthly:
+		return GetFirstDateOfMonth(day)
+	case WindowQuarterly:
+		month := (int(day.Month())-1)/3*3 + 1
+		return time.Date(day.Year(), time.Month(month), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day
+	}
+}
+
+// windowNext 使用 AddDate 而不是加固定时长, 夏令时切换的那天也能对齐到 0 点
+func windowNext(kind WindowKind, begin time.Time) time.Time {
+	switch kind {
+	case WindowWeekly:
+		return begin.AddDate(0, 0, 7)
+	case WindowMonthly:
+		return begin.AddDate(0, 1, 0)
+	case WindowQuarterly:
+		return begin.AddDate(0, 3, 0)
+	default:
+		return begin.AddDate(0, 0, 1)
+	}
+}
+
+func windowLabel(kind WindowKind, begin time.Time) string {
+	switch kind {
+	case WindowWeekly:
+		year, week := begin.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case WindowMonthly:
+		return begin.Format("2006-01")
+	case WindowQuarterly:
+		return fmt.Sprintf("%d-Q%d", begin.Year(), (int(begin.Month())-1)/3+1)
+	default:
+		return begin.Format("2006-01-02")
+	}
+}
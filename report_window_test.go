@@ -0,0 +1,44 @@
+package libtools
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportWindows(t *testing.T) {
+	at := func(y int, m time.Month, d, h int) int64 {
+		return time.Date(y, m, d, h, 0, 0, 0, time.Local).UnixMilli()
+	}
+	labels := func(windows []Window) []string {
+		var list []string
+		for _, w := range windows {
+			list = append(list, w.Label)
+		}
+		return list
+	}
+
+	cases := []struct {
+		kind     WindowKind
+		from, to int64
+		want     []string
+	}{
+		{WindowDaily, at(2024, 2, 28, 10), at(2024, 3, 1, 0), []string{"2024-02-28", "2024-02-29"}},
+		{WindowWeekly, at(2024, 5, 12, 10), at(2024, 5, 14, 0), []string{"2024-W19", "2024-W20"}},
+		{WindowMonthly, at(2023, 12, 10, 0), at(2024, 2, 1, 1), []string{"2023-12", "2024-01", "2024-02"}},
+		{WindowQuarterly, at(2024, 2, 1, 0), at(2024, 7, 1, 0), []string{"2024-Q1", "2024-Q2"}},
+		{WindowDaily, at(2024, 1, 2, 0), at(2024, 1, 1, 0), nil},
+	}
+	for _, c := range cases {
+		got := ReportWindows(c.kind, c.from, c.to)
+		if strings.Join(labels(got), ",") != strings.Join(c.want, ",") {
+			t.Errorf("ReportWindows(%d) = %v, want %v", c.kind, labels(got), c.want)
+		}
+	}
+
+	// 周一 0 点开始, 与下一个周期首尾相接
+	weeks := ReportWindows(WindowWeekly, at(2024, 5, 12, 10), at(2024, 5, 14, 0))
+	if weeks[0].Begin != at(2024, 5, 6, 0) || weeks[0].End != weeks[1].Begin || weeks[1].End != at(2024, 5, 20, 0) {
+		t.Errorf("weekly windows: %+v", weeks)
+	}
+}
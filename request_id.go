@@ -0,0 +1,126 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader 服务间传递请求 ID 使用的 header
+const RequestIDHeader = "X-Request-ID"
+
+// TraceParentHeader W3C Trace Context 的 header, 格式为 00-{trace-id}-{parent-id}-{flags}
+const TraceParentHeader = "traceparent"
+
+// requestIDMaxLen 上游传入的请求 ID 超过该长度时重新生成, 避免日志被超长 header 污染
+const requestIDMaxLen = 128
+
+type requestIDCtxKey struct{}
+
+type traceParentCtxKey struct{}
+
+// ContextWithRequestID 将请求 ID 放入 context, 下游的 gRPC/HTTP 调用会自动透传
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, requestID)
+}
+
+// RequestIDFrom 取出 context 中的请求 ID, 不存在时返回空字符串
+func RequestIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestID, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return requestID
+}
+
+// ContextWithTraceParent 将上游传入的 traceparent 放入 context, 格式不合法时忽略
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if !isValidTraceParent(traceParent) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, traceParentCtxKey{}, strings.ToLower(traceParent))
+}
+
+// TraceParentFrom 取出 context 中的 traceparent, 不存在时返回空字符串
+func TraceParentFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	traceParent, _ := ctx.Value(traceParentCtxKey{}).(string)
+	return traceParent
+}
+
+// RequestIDMiddleware 读取上游的请求 ID 与 traceparent 放入 r.Context(), 没有时生成新的请求 ID
+// 请求 ID 同时写入响应头, OKJSON/FailJSON 会带上它, 用于跨服务关联日志
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = GetGuid()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := ContextWithRequestID(r.Context(), requestID)
+		ctx = ContextWithTraceParent(ctx, r.Header.Get(TraceParentHeader))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setPropagationHeaders 将 ctx 中的请求 ID 与 trace 信息写入请求头, 已显式设置的不覆盖
+func setPropagationHeaders(ctx context.Context, header http.Header) {
+	requestID := RequestIDFrom(ctx)
+	if requestID == "" {
+		return
+	}
+
+	if header.Get(RequestIDHeader) == "" {
+		header.Set(RequestIDHeader, requestID)
+	}
+	if header.Get(TraceParentHeader) == "" {
+		header.Set(TraceParentHeader, childTraceParent(ctx, requestID))
+	}
+}
+
+// childTraceParent 沿用上游的 trace-id 并生成新的 parent-id
+// 上游没有 traceparent 时由请求 ID 派生 trace-id, 同一请求发出的多个调用属于同一条链路
+func childTraceParent(ctx context.Context, requestID string) string {
+	traceID, flags := Md5(requestID), "01"
+	if parent := TraceParentFrom(ctx); parent != "" {
+		traceID, flags = parent[3:35], parent[53:55]
+	}
+
+	return "00-" + traceID + "-" + GetGuid()[:16] + "-" + flags
+}
+
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > requestIDMaxLen {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isValidTraceParent 只接受 version 00, trace-id 与 parent-id 不能全为 0
+func isValidTraceParent(s string) bool {
+	if len(s) != 55 || s[:3] != "00-" || s[35] != '-' || s[52] != '-' {
+		return false
+	}
+	for i, c := range strings.ToLower(s) {
+		if i == 2 || i == 35 || i == 52 {
+			continue
+		}
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return strings.Trim(s[3:35], "0") != "" && strings.Trim(s[36:52], "0") != ""
+}
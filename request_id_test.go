@@ -0,0 +1,51 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDPropagation(t *testing.T) {
+	var gotRequestID, gotTraceParent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID = r.Header.Get(RequestIDHeader)
+		gotTraceParent = r.Header.Get(TraceParentHeader)
+	}))
+	defer downstream.Close()
+
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := HttpRequestWithOptions(r.Context(), HttpMethodGet, downstream.URL, nil, HttpApplicationJSON, nil, HttpRequestOptions{})
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	r.Header.Set(TraceParentHeader, parent)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if gotRequestID != "req-1" || w.Header().Get(RequestIDHeader) != "req-1" {
+		t.Errorf("request id: %q, response: %q", gotRequestID, w.Header().Get(RequestIDHeader))
+	}
+	if !strings.HasPrefix(gotTraceParent, parent[:36]) || gotTraceParent == parent || !isValidTraceParent(gotTraceParent) {
+		t.Errorf("traceparent: %q", gotTraceParent)
+	}
+
+	// 上游没有请求 ID 时生成新的, 非法的 traceparent 被忽略
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceParentHeader, "00-"+strings.Repeat("0", 32)+"-00f067aa0ba902b7-01")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if len(gotRequestID) != 32 || w.Header().Get(RequestIDHeader) != gotRequestID {
+		t.Errorf("generated request id: %q", gotRequestID)
+	}
+	if !strings.HasPrefix(gotTraceParent, "00-"+Md5(gotRequestID)+"-") {
+		t.Errorf("derived traceparent: %q", gotTraceParent)
+	}
+}
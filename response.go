@@ -0,0 +1,78 @@
+package libtools
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// APIResponse 统一的接口返回结构, Code 为 0 表示成功
+type APIResponse struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	RequestID  string      `json:"request_id,omitempty"`
+	ServerTime int64       `json:"server_time"` // 毫秒, 客户端可用于校准本地时间
+}
+
+// PageData 分页数据, 如 OKJSON(w, NewPage(list, total).WithPage(page, pageSize))
+type PageData struct {
+	List     interface{} `json:"list"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page,omitempty"`
+	PageSize int         `json:"page_size,omitempty"`
+	HasMore  bool        `json:"has_more"`
+}
+
+// NewPage list 为 nil 或值为 nil 的切片(如未赋值的 []Order)时输出空数组, 避免客户端处理 null
+func NewPage(list interface{}, total int64) *PageData {
+	if list == nil {
+		list = []interface{}{}
+	} else if v := reflect.ValueOf(list); v.Kind() == reflect.Slice && v.IsNil() {
+		list = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+
+	return &PageData{List: list, Total: total}
+}
+
+// WithPage 设置页码(从 1 开始)与每页条数, 并据此计算 HasMore
+func (p *PageData) WithPage(page, pageSize int) *PageData {
+	p.Page = page
+	p.PageSize = pageSize
+	p.HasMore = page > 0 && pageSize > 0 && int64(page)*int64(pageSize) < p.Total
+	return p
+}
+
+// OKJSON 返回成功结果, HTTP 状态码 200
+func OKJSON(w http.ResponseWriter, data interface{}) {
+	writeAPIResponse(w, http.StatusOK, APIResponse{Code: 0, Message: "ok", Data: data})
+}
+
+// FailJSON 返回失败结果, code 为 400-599 时同时作为 HTTP 状态码, 其他业务错误码使用 200
+func FailJSON(w http.ResponseWriter, code int, msg string) {
+	status := http.StatusOK
+	if code >= 400 && code <= 599 {
+		status = code
+	}
+
+	writeAPIResponse(w, status, APIResponse{Code: code, Message: msg})
+}
+
+// writeAPIResponse 请求 ID 取自响应头, 由 RequestIDMiddleware 等中间件设置
+func writeAPIResponse(w http.ResponseWriter, status int, resp APIResponse) {
+	resp.RequestID = w.Header().Get(RequestIDHeader)
+	resp.ServerTime = GetUnixMillis()
+
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		logs.Error("[writeAPIResponse] marshal response fail, err: %v", err)
+		status = http.StatusInternalServerError
+		buf, _ = json.Marshal(APIResponse{Code: status, Message: "internal server error", RequestID: resp.RequestID, ServerTime: resp.ServerTime})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf)
+}
@@ -0,0 +1,49 @@
+package libtools
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-1")
+	OKJSON(w, NewPage([]int{1, 2}, 5).WithPage(2, 2))
+
+	var resp struct {
+		Code      int    `json:"code"`
+		RequestID string `json:"request_id"`
+		Data      struct {
+			List    []int `json:"list"`
+			HasMore bool  `json:"has_more"`
+		} `json:"data"`
+		ServerTime int64 `json:"server_time"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != 200 || resp.Code != 0 || resp.RequestID != "req-1" ||
+		len(resp.Data.List) != 2 || !resp.Data.HasMore || resp.ServerTime == 0 {
+		t.Errorf("OKJSON get unexpected result: %s, err: %v", w.Body.String(), err)
+	}
+
+	w = httptest.NewRecorder()
+	FailJSON(w, 404, "not found")
+	if w.Code != 404 {
+		t.Errorf("FailJSON should use http status 404, get %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	FailJSON(w, 10001, "balance not enough")
+	if w.Code != 200 {
+		t.Errorf("FailJSON business code should use http status 200, get %d", w.Code)
+	}
+
+	if p := NewPage(nil, 4).WithPage(2, 2); p.HasMore {
+		t.Errorf("last page should not have more")
+	}
+
+	var orders []struct{ ID int }
+	if buf, _ := json.Marshal(NewPage(orders, 0)); !strings.Contains(string(buf), `"list":[]`) {
+		t.Errorf("typed nil slice should be encoded as empty array, get %s", buf)
+	}
+}
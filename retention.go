@@ -0,0 +1,171 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// RetentionPolicy 上传文件的保留策略
+type RetentionPolicy struct {
+	// MaxAge 按修改时间计算, 超过的文件会被删除
+	MaxAge time.Duration
+	// Patterns 文件名匹配规则(filepath.Match 语法, 如 "*.jpg"), 为空时匹配所有文件
+	Patterns []string
+	// DryRun 只统计不删除
+	DryRun bool
+}
+
+// RetentionReport 一次清理的结果
+type RetentionReport struct {
+	Scanned    int           `json:"scanned"`
+	Deleted    int           `json:"deleted"`
+	BytesFreed int64         `json:"bytes_freed"`
+	Errors     int           `json:"errors"`
+	Duration   time.Duration `json:"duration"`
+}
+
+func (r RetentionReport) String() string {
+	return fmt.Sprintf("scanned: %d, deleted: %d, freed: %d bytes, errors: %d, duration: %s",
+		r.Scanned, r.Deleted, r.BytesFreed, r.Errors, r.Duration)
+}
+
+func (p RetentionPolicy) match(name string) bool {
+	if len(p.Patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.Patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunRetention 遍历 baseDir(为空时使用 upload_prefix, 即 LocalHashDir 的根目录), 删除过期文件以及因本次清理而变空的目录
+// 原本就为空的目录、修改时间在保留期内的目录(如刚创建的上传目录)不删除
+// 单个文件删除失败只记录日志并计入 Errors, 不中断清理
+func RunRetention(baseDir string, policy RetentionPolicy) (report RetentionReport, err error) {
+	if policy.MaxAge <= 0 {
+		err = fmt.Errorf("retention policy need a positive max age")
+		return
+	}
+	if baseDir == "" {
+		baseDir = GetLocalUploadPrefix()
+	}
+	if baseDir == "" || baseDir == "/" {
+		err = fmt.Errorf("refuse to run retention on dir: %q", baseDir)
+		return
+	}
+
+	start := time.Now()
+	deadline := start.Add(-policy.MaxAge)
+	var dirs []string
+	dirModTimes := make(map[string]time.Time)
+	// touched 本次删除过文件或子目录的目录
+	touched := make(map[string]bool)
+
+	err = filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			logs.Warning("[RunRetention] walk fail, path: %s, err: %v", path, walkErr)
+			report.Errors++
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != baseDir {
+				if info, infoErr := d.Info(); infoErr == nil {
+					dirs = append(dirs, path)
+					dirModTimes[path] = info.ModTime()
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !policy.match(d.Name()) {
+			return nil
+		}
+
+		report.Scanned++
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			report.Errors++
+			return nil
+		}
+		if info.ModTime().After(deadline) {
+			return nil
+		}
+
+		if !policy.DryRun {
+			if rmErr := os.Remove(path); rmErr != nil {
+				logs.Warning("[RunRetention] remove file fail, path: %s, err: %v", path, rmErr)
+				report.Errors++
+				return nil
+			}
+			touched[filepath.Dir(path)] = true
+		}
+		report.Deleted++
+		report.BytesFreed += info.Size()
+
+		return nil
+	})
+
+	if !policy.DryRun {
+		// 由深到浅删除本次清理变空的目录, 子目录删除后父目录也可能变空; 非空目录删除失败是正常的
+		sort.Slice(dirs, func(i, j int) bool {
+			return len(dirs[i]) > len(dirs[j])
+		})
+		for _, dir := range dirs {
+			if !touched[dir] || dirModTimes[dir].After(deadline) {
+				continue
+			}
+			if os.Remove(dir) == nil {
+				touched[filepath.Dir(dir)] = true
+			}
+		}
+	}
+
+	report.Duration = time.Since(start)
+	return
+}
+
+// StartRetention 每隔 interval 执行一次 RunRetention, 直到 ctx 结束; 启动时先执行一次
+func StartRetention(ctx context.Context, baseDir string, policy RetentionPolicy, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	run := func() {
+		report, err := RunRetention(baseDir, policy)
+		if err != nil {
+			logs.Error("[StartRetention] run retention fail, dir: %s, err: %v", baseDir, err)
+			return
+		}
+		logs.Info("[StartRetention] retention done, dir: %s, %s", baseDir, report)
+	}
+
+	go func() {
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
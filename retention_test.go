@@ -0,0 +1,80 @@
+package libtools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunRetention(t *testing.T) {
+	base := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(rel string, mtime time.Time) string {
+		path := filepath.Join(base, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Chtimes(path, mtime, mtime)
+		return path
+	}
+	chtimes := func(rel string, mtime time.Time) {
+		_ = os.Chtimes(filepath.Join(base, rel), mtime, mtime)
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(base, rel))
+		return err == nil
+	}
+
+	write("2024/01/a.jpg", old)
+	write("2024/01/keep.jpg", time.Now())
+	write("2024/02/b.jpg", old)
+	write("2024/02/c.txt", old)
+	write("fresh/d.jpg", old)
+	if err := os.MkdirAll(filepath.Join(base, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"2024/01", "2024/02", "2024", "empty"} {
+		chtimes(dir, old)
+	}
+
+	report, err := RunRetention(base, RetentionPolicy{MaxAge: 24 * time.Hour, Patterns: []string{"*.jpg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != 4 || report.Deleted != 3 || report.BytesFreed != 3 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+
+	cases := map[string]bool{
+		"2024/01/a.jpg":    false,
+		"2024/01/keep.jpg": true,
+		"2024/02/b.jpg":    false,
+		"2024/02/c.txt":    true, // 不匹配 Patterns
+		"fresh/d.jpg":      false,
+		"fresh":            true, // 目录在保留期内修改过, 可能正在上传
+		"empty":            true, // 原本就是空目录
+	}
+	for rel, want := range cases {
+		if got := exists(rel); got != want {
+			t.Errorf("%s exists = %v, want %v", rel, got, want)
+		}
+	}
+
+	// 子目录被清空后父目录随之删除
+	write("2023/12/e.jpg", old)
+	chtimes("2023/12", old)
+	chtimes("2023", old)
+	if _, err = RunRetention(base, RetentionPolicy{MaxAge: 24 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if exists("2023") {
+		t.Error("2023 should be removed after its only sub dir is emptied")
+	}
+	if !exists("empty") {
+		t.Error("empty dir should be kept")
+	}
+}
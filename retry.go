@@ -0,0 +1,56 @@
+package libtools
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryMaxDelay 单次重试等待的上限
+var RetryMaxDelay = 30 * time.Second
+
+// RetryWithBackoff 最多执行 attempts 次 fn,失败后按 baseDelay 指数退避(带 ±20% 抖动)再试
+// ctx 取消时立即返回 ctx.Err()
+func RetryWithBackoff(ctx context.Context, attempts int, baseDelay time.Duration, fn func() error) error {
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if i == attempts-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(RetryDelay(baseDelay, i)):
+		}
+	}
+
+	return err
+}
+
+// RetryDelay 第 attempt 次(从 0 开始)失败后的等待时间
+func RetryDelay(baseDelay time.Duration, attempt int) time.Duration {
+	if baseDelay <= 0 {
+		return 0
+	}
+
+	delay := baseDelay
+	for i := 0; i < attempt && delay < RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > RetryMaxDelay {
+		delay = RetryMaxDelay
+	}
+
+	jitter := float64(delay) * (rand.Float64()*0.4 - 0.2)
+	return delay + time.Duration(jitter)
+}
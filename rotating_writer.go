@@ -0,0 +1,289 @@
+package libtools
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const rotatingBackupLayout = "20060102T150405.000"
+
+// RotatingWriter 追加写入的日志文件, 超过大小或跨天时切分, 旧文件可 gzip 压缩并按数量与天数清理
+// 可直接作为 NewWriterAuditSink 等需要 io.Writer 的地方使用
+type RotatingWriter struct {
+	filename   string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	day     string
+	millMu  sync.Mutex
+	nowFunc func() time.Time
+}
+
+// NewRotatingWriter maxSizeMB <= 0 时只按天切分; maxBackups/maxAgeDays <= 0 表示不按该条件清理
+func NewRotatingWriter(path string, maxSizeMB, maxBackups, maxAgeDays int, compress bool) (*RotatingWriter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("rotating writer need a file path")
+	}
+
+	w := &RotatingWriter{
+		filename:   path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		compress:   compress,
+		nowFunc:    time.Now,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write 实现 io.Writer, 单次写入不会被拆分到两个文件
+func (w *RotatingWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err = w.open(); err != nil {
+			return
+		}
+	}
+
+	today := w.nowFunc().Format("20060102")
+	if (w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize) || today != w.day {
+		if err = w.rotate(); err != nil {
+			return
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return
+}
+
+// Sync 刷盘
+func (w *RotatingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Rotate 手动切分, 如收到 SIGHUP 时
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open 打开已有文件继续追加, 文件的修改日期不是今天时先切分
+func (w *RotatingWriter) open() error {
+	now := w.nowFunc()
+	info, err := os.Stat(w.filename)
+	if err == nil && info.Size() > 0 && info.ModTime().Format("20060102") != now.Format("20060102") {
+		if err = w.backup(info.ModTime()); err != nil {
+			return err
+		}
+		info = nil
+	}
+
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.size = 0
+	if info != nil {
+		w.size = info.Size()
+	}
+	w.day = now.Format("20060102")
+
+	return nil
+}
+
+func (w *RotatingWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	if info, err := os.Stat(w.filename); err == nil && info.Size() > 0 {
+		// 跨天切分时文件内容属于前一天, 备份名使用前一天的最后时刻, 便于按日期查找
+		stamp := w.nowFunc()
+		if day, parseErr := time.ParseInLocation("20060102", w.day, time.Local); parseErr == nil && w.day != stamp.Format("20060102") {
+			stamp = day.Add(24*time.Hour - time.Millisecond)
+		}
+		if err = w.backup(stamp); err != nil {
+			return err
+		}
+	}
+
+	return w.open()
+}
+
+// backup 将当前文件重命名为 name-时间.ext, 同一毫秒内多次切分时追加序号, 如 name-时间-1.ext; 随后在后台压缩与清理
+func (w *RotatingWriter) backup(t time.Time) error {
+	ext := filepath.Ext(w.filename)
+	prefix := strings.TrimSuffix(w.filename, ext)
+	stamp := t.Format(rotatingBackupLayout)
+	name := fmt.Sprintf("%s-%s%s", prefix, stamp, ext)
+	for i := 1; fileOrGzipExists(name); i++ {
+		name = fmt.Sprintf("%s-%s-%d%s", prefix, stamp, i, ext)
+	}
+
+	if err := os.Rename(w.filename, name); err != nil {
+		return err
+	}
+
+	go w.mill()
+	return nil
+}
+
+func fileOrGzipExists(name string) bool {
+	if _, err := os.Lstat(name); err == nil {
+		return true
+	}
+	_, err := os.Lstat(name + ".gz")
+	return err == nil
+}
+
+type rotatingBackup struct {
+	path string
+	t    time.Time
+	seq  int // 同一时间的第几个备份
+}
+
+// backups 按时间倒序返回所有备份文件
+func (w *RotatingWriter) backups() []rotatingBackup {
+	ext := filepath.Ext(w.filename)
+	prefix := filepath.Base(strings.TrimSuffix(w.filename, ext)) + "-"
+
+	entries, err := ioutil.ReadDir(filepath.Dir(w.filename))
+	if err != nil {
+		return nil
+	}
+
+	var list []rotatingBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		var seq int
+		if len(stamp) > len(rotatingBackupLayout) {
+			n, err := strconv.Atoi(strings.TrimPrefix(stamp[len(rotatingBackupLayout):], "-"))
+			if err != nil || n <= 0 {
+				continue
+			}
+			stamp, seq = stamp[:len(rotatingBackupLayout)], n
+		}
+		t, err := time.ParseInLocation(rotatingBackupLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		list = append(list, rotatingBackup{path: filepath.Join(filepath.Dir(w.filename), name), t: t, seq: seq})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].t.Equal(list[j].t) {
+			return list[i].seq > list[j].seq
+		}
+		return list[i].t.After(list[j].t)
+	})
+	return list
+}
+
+// mill 压缩未压缩的备份, 删除超出数量或过期的备份
+func (w *RotatingWriter) mill() {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	now := w.nowFunc()
+	for i, b := range w.backups() {
+		expired := (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && now.Sub(b.t) > w.maxAge)
+		if expired {
+			if err := os.Remove(b.path); err != nil {
+				logs.Warning("[RotatingWriter] remove backup fail, path: %s, err: %v", b.path, err)
+			}
+			continue
+		}
+
+		if w.compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := gzipFile(b.path); err != nil {
+				logs.Warning("[RotatingWriter] compress backup fail, path: %s, err: %v", b.path, err)
+			}
+		}
+	}
+}
+
+// gzipFile 压缩为 filename.gz 后删除原文件
+func gzipFile(filename string) (err error) {
+	src, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filename+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(filename + ".gz")
+		return
+	}
+
+	_ = src.Close()
+	return os.Remove(filename)
+}
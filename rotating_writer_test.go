@@ -0,0 +1,118 @@
+package libtools
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rotatingFilesT 返回目录下的文件名与内容
+func rotatingFilesT(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		buf, _ := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		files[entry.Name()] = string(buf)
+	}
+	return files
+}
+
+func TestRotatingWriterCollision(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(filepath.Join(dir, "app.log"), 0, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 同一毫秒内多次切分不能覆盖之前的备份
+	now := time.Date(2024, 5, 12, 10, 0, 0, 0, time.Local)
+	w.mu.Lock()
+	w.nowFunc = func() time.Time { return now }
+	w.day = "20240512"
+	w.mu.Unlock()
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if _, err = w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := rotatingFilesT(t, dir)
+	want := map[string]string{
+		"app-20240512T100000.000.log":   "a\n",
+		"app-20240512T100000.000-1.log": "b\n",
+		"app-20240512T100000.000-2.log": "c\n",
+		"app.log":                       "",
+	}
+	for name, content := range want {
+		if got, ok := files[name]; !ok || got != content {
+			t.Errorf("%s: got %q, %v, files: %v", name, got, ok, files)
+		}
+	}
+
+	// 同一时间的备份按序号区分新旧, 最新的排在最前
+	var names []string
+	for _, b := range w.backups() {
+		names = append(names, filepath.Base(b.path))
+	}
+	if strings.Join(names, ",") != "app-20240512T100000.000-2.log,app-20240512T100000.000-1.log,app-20240512T100000.000.log" {
+		t.Errorf("unexpected backup order: %v", names)
+	}
+}
+
+func TestRotatingWriterDaily(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(filepath.Join(dir, "app.log"), 0, 2, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 后台的 mill 也会读取时间, 单独加锁
+	var clockMu sync.Mutex
+	now := time.Date(2024, 5, 12, 23, 59, 0, 0, time.Local)
+	w.mu.Lock()
+	w.nowFunc = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+	w.day = "20240512"
+	w.mu.Unlock()
+	_, _ = w.Write([]byte("day1\n"))
+
+	// 跨天后第一次写入时切分, 备份名为前一天
+	for i, day := range []int{13, 14, 15} {
+		clockMu.Lock()
+		now = time.Date(2024, 5, day, 0, 0, 1, 0, time.Local)
+		clockMu.Unlock()
+		_, _ = w.Write([]byte{byte('2' + i), '\n'})
+	}
+	w.mill()
+
+	files := rotatingFilesT(t, dir)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// maxBackups 为 2, 5 月 12 日的备份被清理
+	want := "app-20240513T235959.999.log,app-20240514T235959.999.log,app.log"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("unexpected files: %v", names)
+	}
+	if files["app-20240513T235959.999.log"] != "2\n" || files["app.log"] != "4\n" {
+		t.Errorf("unexpected content: %v", files)
+	}
+}
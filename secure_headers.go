@@ -0,0 +1,95 @@
+package libtools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCSPTemplate 适用于后台管理页面的 CSP, 内联脚本需带上 nonce
+const DefaultCSPTemplate = "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: https:; connect-src 'self' {api_domain}; frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
+
+// SecureHeadersOptions 安全响应头配置, 零值使用默认值
+type SecureHeadersOptions struct {
+	// HSTSMaxAge 默认 180 天, 小于 0 时不设置; 仅在 https 请求(含反向代理的 X-Forwarded-Proto)时下发
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// FrameOptions 默认 DENY, "-" 表示不设置
+	FrameOptions string
+	// ReferrerPolicy 默认 strict-origin-when-cross-origin
+	ReferrerPolicy string
+	// CSP 模板, 支持 {nonce} {h5_domain} {api_domain} 占位符, 为空时不设置, 可使用 DefaultCSPTemplate
+	CSP string
+	// CSPReportOnly 为 true 时使用 Content-Security-Policy-Report-Only, 用于上线前观察
+	CSPReportOnly bool
+}
+
+type cspNonceCtxKey struct{}
+
+// CSPNonceFrom 取出当前请求的 CSP nonce, 渲染页面时写入 <script nonce="...">
+func CSPNonceFrom(r *http.Request) string {
+	nonce, _ := r.Context().Value(cspNonceCtxKey{}).(string)
+	return nonce
+}
+
+// SecureHeaders 设置 HSTS、X-Content-Type-Options、X-Frame-Options、Referrer-Policy 与 CSP 等安全响应头
+func SecureHeaders(opts SecureHeadersOptions) func(http.Handler) http.Handler {
+	if opts.HSTSMaxAge == 0 {
+		opts.HSTSMaxAge = 180 * 24 * time.Hour
+	}
+	if opts.FrameOptions == "" {
+		opts.FrameOptions = "DENY"
+	}
+	if opts.ReferrerPolicy == "" {
+		opts.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+
+	hsts := ""
+	if opts.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(opts.HSTSMaxAge.Seconds()), 10)
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	cspHeader := "Content-Security-Policy"
+	if opts.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	// 域名未配置时占位符替换为空, 顺便去掉多余的空格
+	csp := strings.NewReplacer("{h5_domain}", InternalH5Domain(), "{api_domain}", InternalApiDomain()).Replace(opts.CSP)
+	csp = strings.Join(strings.Fields(csp), " ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if opts.FrameOptions != "-" {
+				h.Set("X-Frame-Options", opts.FrameOptions)
+			}
+			h.Set("Referrer-Policy", opts.ReferrerPolicy)
+			if hsts != "" && (r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+
+			if csp != "" {
+				policy := csp
+				if strings.Contains(policy, "{nonce}") {
+					buf := make([]byte, 16)
+					_, _ = rand.Read(buf)
+					nonce := base64.StdEncoding.EncodeToString(buf)
+					policy = strings.ReplaceAll(policy, "{nonce}", nonce)
+					r = r.WithContext(context.WithValue(r.Context(), cspNonceCtxKey{}, nonce))
+				}
+				h.Set(cspHeader, policy)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package libtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	var nonce string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = CSPNonceFrom(r)
+	})
+	serve := func(handler http.Handler, proto string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if proto != "" {
+			req.Header.Set("X-Forwarded-Proto", proto)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	handler := SecureHeaders(SecureHeadersOptions{HSTSIncludeSubdomains: true, CSP: DefaultCSPTemplate})(next)
+	h := serve(handler, "https")
+	if h.Get("X-Content-Type-Options") != "nosniff" || h.Get("X-Frame-Options") != "DENY" || h.Get("Referrer-Policy") != "strict-origin-when-cross-origin" {
+		t.Errorf("unexpected default headers: %v", h)
+	}
+	if h.Get("Strict-Transport-Security") != "max-age=15552000; includeSubDomains" {
+		t.Errorf("unexpected hsts: %s", h.Get("Strict-Transport-Security"))
+	}
+	csp := h.Get("Content-Security-Policy")
+	if nonce == "" || !strings.Contains(csp, "'nonce-"+nonce+"'") || strings.Contains(csp, "{") || strings.Contains(csp, "  ") {
+		t.Errorf("unexpected csp: %s, nonce: %s", csp, nonce)
+	}
+
+	// 每个请求的 nonce 不同, http 请求不下发 HSTS
+	first := nonce
+	if h = serve(handler, ""); nonce == first || h.Get("Strict-Transport-Security") != "" {
+		t.Errorf("nonce should change per request and hsts only for https, nonce: %s, hsts: %s", nonce, h.Get("Strict-Transport-Security"))
+	}
+
+	handler = SecureHeaders(SecureHeadersOptions{
+		HSTSMaxAge:    -1,
+		FrameOptions:  "-",
+		CSP:           "default-src 'self'",
+		CSPReportOnly: true,
+	})(next)
+	h = serve(handler, "https")
+	if h.Get("Strict-Transport-Security") != "" || h.Get("X-Frame-Options") != "" || h.Get("Content-Security-Policy") != "" {
+		t.Errorf("disabled headers should not be set: %v", h)
+	}
+	if h.Get("Content-Security-Policy-Report-Only") != "default-src 'self'" || nonce != "" {
+		t.Errorf("unexpected report-only csp: %v, nonce: %s", h, nonce)
+	}
+
+	h = serve(SecureHeaders(SecureHeadersOptions{HSTSMaxAge: time.Hour})(next), "HTTPS")
+	if h.Get("Strict-Transport-Security") != "max-age=3600" {
+		t.Errorf("unexpected hsts: %s", h.Get("Strict-Transport-Security"))
+	}
+}
@@ -15,6 +15,8 @@ const (
 )
 
 // IsInternalIPV1 超简算法
+//
+// Deprecated: 只识别部分网段, 判断内网请使用 IsPrivateIP, 按 ip 放行请使用 WithIPPolicy
 func IsInternalIPV1(ip string) bool {
 	if ip == "" {
 		logs.Warning("[IsInternalIPV1] get empty input")
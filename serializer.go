@@ -0,0 +1,168 @@
+package libtools
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Serializer 缓存、KVStore 等存储值的编解码方式
+type Serializer interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+const (
+	SerializerJSON    = "json"
+	SerializerMsgpack = "msgpack"
+	SerializerGob     = "gob"
+)
+
+// serializerMagic 带格式头的数据以该字节开头, 它不是合法的 json 开头, 也不会出现在 msgpack 中
+const serializerMagic = 0xC1
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Name() string                               { return SerializerJSON }
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) Name() string                          { return SerializerMsgpack }
+func (msgpackSerializer) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+func (msgpackSerializer) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type gobSerializer struct{}
+
+func (gobSerializer) Name() string { return SerializerGob }
+
+func (gobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobSerializer) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+var (
+	serializersMu     sync.RWMutex
+	serializers       = map[string]Serializer{}
+	defaultSerializer = SerializerJSON
+)
+
+func init() {
+	RegisterSerializer(jsonSerializer{})
+	RegisterSerializer(msgpackSerializer{})
+	RegisterSerializer(gobSerializer{})
+}
+
+// RegisterSerializer 注册自定义的编解码方式, 同名时覆盖; 名称会写入数据头, 长度不能超过 255
+func RegisterSerializer(s Serializer) {
+	serializersMu.Lock()
+	serializers[s.Name()] = s
+	serializersMu.Unlock()
+}
+
+// GetSerializer 按名称查找
+func GetSerializer(name string) (Serializer, bool) {
+	serializersMu.RLock()
+	s, ok := serializers[name]
+	serializersMu.RUnlock()
+	return s, ok
+}
+
+// SetDefaultSerializer 设置 EncodeValue 默认使用的编解码方式, 默认 json
+func SetDefaultSerializer(name string) error {
+	if _, ok := GetSerializer(name); !ok {
+		return fmt.Errorf("serializer %s is not registered", name)
+	}
+
+	serializersMu.Lock()
+	defaultSerializer = name
+	serializersMu.Unlock()
+	return nil
+}
+
+// EncodeValue 编码并在数据前加上编解码方式的名称, 之后切换默认格式也能正确解码旧数据
+func EncodeValue(v interface{}, serializer ...string) ([]byte, error) {
+	serializersMu.RLock()
+	name := defaultSerializer
+	serializersMu.RUnlock()
+	if len(serializer) > 0 && serializer[0] != "" {
+		name = serializer[0]
+	}
+
+	s, ok := GetSerializer(name)
+	if !ok {
+		return nil, fmt.Errorf("serializer %s is not registered", name)
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("serializer name is too long: %s", name)
+	}
+
+	payload, err := s.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal value with %s: %v", name, err)
+	}
+
+	data := make([]byte, 0, 2+len(name)+len(payload))
+	data = append(data, serializerMagic, byte(len(name)))
+	data = append(data, name...)
+	return append(data, payload...), nil
+}
+
+// DecodeValue 按数据头选择编解码方式, 没有数据头的旧数据按 json 解码
+func DecodeValue(data []byte, v interface{}) error {
+	name, payload := SerializerJSON, data
+	if len(data) >= 2 && data[0] == serializerMagic {
+		n := int(data[1])
+		if len(data) < 2+n {
+			return fmt.Errorf("serialized value header is truncated")
+		}
+		name, payload = string(data[2:2+n]), data[2+n:]
+	}
+
+	s, ok := GetSerializer(name)
+	if !ok {
+		return fmt.Errorf("serializer %s is not registered", name)
+	}
+	if err := s.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("could not unmarshal value with %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// KVSetValue 编码后写入 KVStore
+func KVSetValue(ctx context.Context, store KVStore, key string, v interface{}, ttl time.Duration, serializer ...string) error {
+	data, err := EncodeValue(v, serializer...)
+	if err != nil {
+		return err
+	}
+
+	return store.Set(ctx, key, data, ttl)
+}
+
+// KVGetValue 从 KVStore 读取并解码, key 不存在时返回 false
+func KVGetValue(ctx context.Context, store KVStore, key string, v interface{}) (bool, error) {
+	data, ok, err := store.Get(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return true, DecodeValue(data, v)
+}
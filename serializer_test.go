@@ -0,0 +1,47 @@
+package libtools
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSerializer(t *testing.T) {
+	type order struct {
+		OrderID int64
+		Amount  string
+		Tags    []string
+	}
+	in := order{OrderID: 10086, Amount: "12.50", Tags: []string{"a", "b"}}
+
+	for _, name := range []string{SerializerJSON, SerializerMsgpack, SerializerGob} {
+		data, err := EncodeValue(in, name)
+		if err != nil {
+			t.Fatalf("EncodeValue(%s) err: %v", name, err)
+		}
+
+		var out order
+		if err = DecodeValue(data, &out); err != nil || out.OrderID != in.OrderID || out.Amount != in.Amount || len(out.Tags) != 2 {
+			t.Errorf("DecodeValue(%s) get unexpected result: %+v, err: %v", name, out, err)
+		}
+	}
+
+	// 没有数据头的旧数据按 json 解码
+	var legacy order
+	if err := DecodeValue([]byte(`{"OrderID":1}`), &legacy); err != nil || legacy.OrderID != 1 {
+		t.Errorf("DecodeValue legacy json get unexpected result: %+v, err: %v", legacy, err)
+	}
+
+	store := NewMemoryKV()
+	ctx := context.Background()
+	if err := KVSetValue(ctx, store, "order", in, time.Minute, SerializerMsgpack); err != nil {
+		t.Fatal(err)
+	}
+	var got order
+	if ok, err := KVGetValue(ctx, store, "order", &got); !ok || err != nil || got.OrderID != in.OrderID {
+		t.Errorf("KVGetValue get unexpected result: %+v, %v, err: %v", got, ok, err)
+	}
+	if ok, err := KVGetValue(ctx, store, "missing", &got); ok || err != nil {
+		t.Errorf("KVGetValue missing key should return false, err: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package libtools
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ServeOptions Serve 的参数, 零值即只监听 addr 的 http 服务
+type ServeOptions struct {
+	// Context 结束时优雅退出, 为空时只响应 SIGINT/SIGTERM
+	Context context.Context
+	// ShutdownTimeout 等待处理中请求完成的最长时间, 默认 30 秒
+	ShutdownTimeout time.Duration
+	// OnShutdown 开始退出时依次调用, 如摘除注册中心、停止消费者
+	OnShutdown []func()
+
+	// TLSCertFile 与 TLSKeyFile 不为空时 addr 使用 https, 证书文件更新后自动加载, 无需重启
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// InternalAddr 不为空时额外监听一个仅内网可访问的端口, 用于 MountDebug、HealthHandler 等
+	InternalAddr    string
+	InternalHandler http.Handler
+	// InternalAllow 内网端口允许的网段, 默认为私有网段与回环地址
+	InternalAllow []string
+
+	ReadHeaderTimeout time.Duration // 默认 10 秒
+	IdleTimeout       time.Duration // 默认 120 秒
+}
+
+var serveDefaultInternalAllow = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8", "::1", "fc00::/7"}
+
+// certReloadInterval 检查证书文件是否更新的最小间隔
+const certReloadInterval = 10 * time.Second
+
+// certReloader 握手时按修改时间重新加载证书
+type certReloader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *certReloader) reload() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load tls cert: %v", err)
+	}
+
+	c.cert = &cert
+	c.modTime = info.ModTime()
+	return nil
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checkedAt) >= certReloadInterval {
+		c.checkedAt = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			// 证书与私钥可能不是同时写完, 加载失败时继续使用旧证书, 下次检查时重试
+			if err = c.reload(); err != nil {
+				logs.Warning("[Serve] reload tls cert fail, keep old cert, err: %v", err)
+			} else {
+				logs.Notice("[Serve] tls cert reloaded: %s", c.certFile)
+			}
+		}
+	}
+
+	return c.cert, nil
+}
+
+// Serve 启动 http(s) 服务并阻塞, 收到 SIGINT/SIGTERM 或 Context 结束时停止接收新连接, 等待处理中的请求完成后返回
+// 正常退出返回 nil
+func Serve(addr string, handler http.Handler, opts ...ServeOptions) error {
+	var opt ServeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Context == nil {
+		opt.Context = context.Background()
+	}
+	if opt.ShutdownTimeout <= 0 {
+		opt.ShutdownTimeout = 30 * time.Second
+	}
+	if opt.ReadHeaderTimeout <= 0 {
+		opt.ReadHeaderTimeout = 10 * time.Second
+	}
+	if opt.IdleTimeout <= 0 {
+		opt.IdleTimeout = 120 * time.Second
+	}
+
+	newServer := func(addr string, handler http.Handler) *http.Server {
+		return &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: opt.ReadHeaderTimeout,
+			IdleTimeout:       opt.IdleTimeout,
+		}
+	}
+
+	servers := []*http.Server{newServer(addr, handler)}
+	useTLS := opt.TLSCertFile != "" && opt.TLSKeyFile != ""
+	if useTLS {
+		reloader, err := newCertReloader(opt.TLSCertFile, opt.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		servers[0].TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: reloader.GetCertificate}
+	}
+
+	if opt.InternalAddr != "" {
+		internalHandler := opt.InternalHandler
+		if internalHandler == nil {
+			internalHandler = handler
+		}
+		allow := opt.InternalAllow
+		if len(allow) == 0 {
+			allow = serveDefaultInternalAllow
+		}
+		servers = append(servers, newServer(opt.InternalAddr, WithIPPolicy(allow, nil)(internalHandler)))
+	}
+
+	errChan := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server *http.Server, tlsEnabled bool) {
+			logs.Notice("[Serve] listening on %s, tls: %v", server.Addr, tlsEnabled)
+			var err error
+			if tlsEnabled {
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errChan <- fmt.Errorf("serve %s fail: %v", server.Addr, err)
+			}
+		}(server, i == 0 && useTLS)
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signalChan)
+
+	var serveErr error
+	select {
+	case sig := <-signalChan:
+		logs.Notice("[Serve] receive signal %v, shutting down", sig)
+	case <-opt.Context.Done():
+		logs.Notice("[Serve] context done, shutting down")
+	case serveErr = <-errChan:
+		logs.Error("[Serve] %v, shutting down", serveErr)
+	}
+
+	for _, fn := range opt.OnShutdown {
+		fn()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opt.ShutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				logs.Warning("[Serve] shutdown %s fail, err: %v", server.Addr, err)
+			}
+		}(server)
+	}
+	wg.Wait()
+
+	return serveErr
+}
@@ -0,0 +1,66 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeGracefulShutdown(t *testing.T) {
+	port, err := GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	internalPort, err := GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(fmt.Sprintf("127.0.0.1:%d", port), handler, ServeOptions{
+			Context:      ctx,
+			InternalAddr: fmt.Sprintf("127.0.0.1:%d", internalPort),
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", internalPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("internal status: %d", resp.StatusCode)
+	}
+
+	// 退出时处理中的请求应当正常完成
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		buf, _ := io.ReadAll(resp.Body)
+		body <- string(buf)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if got := <-body; got != "ok" {
+		t.Errorf("in-flight request: %s", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package libtools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// SessionDeviceKey Issue 的 meta 中包含该字段时, 会话与设备绑定, Validate 时需传入相同的设备 ID
+const SessionDeviceKey = "device_id"
+
+var (
+	ErrSessionInvalid        = errors.New("session is invalid or expired")
+	ErrSessionDeviceMismatch = errors.New("session device mismatch")
+)
+
+// SessionOptions 会话配置, 零值使用默认值
+type SessionOptions struct {
+	// Store 默认使用进程内存储, 多实例部署时需使用 redis 实现
+	Store KVStore
+	// TTL 无操作多久后过期, 每次 Validate 都会顺延(滑动过期), 默认 7 天
+	TTL time.Duration
+	// MaxLifetime 从签发起的最长有效期, 到期后必须重新登录, 默认 30 天
+	MaxLifetime time.Duration
+}
+
+// Session 会话信息
+type Session struct {
+	UserID    string            `json:"user_id"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	CreatedAt int64             `json:"created_at"` // 毫秒
+	ExpiresAt int64             `json:"expires_at"` // 毫秒
+	Gen       int64             `json:"gen"`        // 签发时用户的会话代数, RevokeAllForUser 后旧会话失效
+}
+
+// Sessions 统一的会话 token 签发与校验
+// token 为 32 字节随机数, 存储中只保存其摘要, 存储泄露也无法还原 token
+//
+//	sessions := NewSessions(SessionOptions{Store: redisKV})
+//	token, _ := sessions.Issue("10086", map[string]string{SessionDeviceKey: deviceID, "platform": "android"})
+//	sess, err := sessions.Validate(token, deviceID)
+type Sessions struct {
+	opts SessionOptions
+}
+
+func NewSessions(opts ...SessionOptions) *Sessions {
+	var opt SessionOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	if opt.Store == nil {
+		opt.Store = NewMemoryKV()
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = 7 * 24 * time.Hour
+	}
+	if opt.MaxLifetime <= 0 {
+		opt.MaxLifetime = 30 * 24 * time.Hour
+	}
+	if opt.MaxLifetime < opt.TTL {
+		opt.MaxLifetime = opt.TTL
+	}
+
+	return &Sessions{opts: opt}
+}
+
+// Issue 签发新会话
+func (s *Sessions) Issue(userID string, meta map[string]string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	ctx := context.Background()
+	gen, err := s.userGen(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	now := GetUnixMillis()
+	sess := &Session{
+		UserID:    userID,
+		Meta:      meta,
+		CreatedAt: now,
+		ExpiresAt: now + s.opts.TTL.Milliseconds(),
+		Gen:       gen,
+	}
+	if err = s.save(ctx, token, sess); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Validate 校验 token 并顺延有效期; 会话绑定了设备时 deviceID 必须一致
+func (s *Sessions) Validate(token string, deviceID ...string) (*Session, error) {
+	ctx := context.Background()
+	sess, err := s.load(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := GetUnixMillis()
+	if sess.ExpiresAt <= now || sess.CreatedAt+s.opts.MaxLifetime.Milliseconds() <= now {
+		_ = s.opts.Store.Delete(ctx, s.key(token))
+		return nil, ErrSessionInvalid
+	}
+
+	if bound := sess.Meta[SessionDeviceKey]; bound != "" {
+		if len(deviceID) == 0 || deviceID[0] != bound {
+			return nil, ErrSessionDeviceMismatch
+		}
+	}
+
+	gen, err := s.userGen(ctx, sess.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Gen != gen {
+		_ = s.opts.Store.Delete(ctx, s.key(token))
+		return nil, ErrSessionInvalid
+	}
+
+	// 剩余时间不足一半时才顺延, 减少存储写入
+	if sess.ExpiresAt-now < s.opts.TTL.Milliseconds()/2 {
+		sess.ExpiresAt = now + s.opts.TTL.Milliseconds()
+		if err = s.save(ctx, token, sess); err != nil {
+			return nil, err
+		}
+	}
+
+	return sess, nil
+}
+
+// Revoke 注销单个会话, 如用户退出登录
+func (s *Sessions) Revoke(token string) error {
+	return s.opts.Store.Delete(context.Background(), s.key(token))
+}
+
+// RevokeAllForUser 注销用户的全部会话, 如修改密码、封号
+// 通过递增用户的会话代数实现, 不需要维护用户的 token 列表
+// 代数永不过期, 否则过期后从 0 重新计数, 旧代数签发的会话会重新生效;
+// Store 实现了 KVIncrementer 时原子递增, 否则为读后写, 并发注销时可能只递增一次, 但旧会话仍会失效
+func (s *Sessions) RevokeAllForUser(userID string) error {
+	ctx := context.Background()
+	if incr, ok := s.opts.Store.(KVIncrementer); ok {
+		_, err := incr.Incr(ctx, s.genKey(userID), 0)
+		return err
+	}
+
+	gen, err := s.userGen(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.opts.Store.Set(ctx, s.genKey(userID), []byte(strconv.FormatInt(gen+1, 10)), 0)
+}
+
+func (s *Sessions) key(token string) string {
+	return "session:" + Sha256(token)
+}
+
+func (s *Sessions) genKey(userID string) string {
+	return "session_gen:" + userID
+}
+
+func (s *Sessions) userGen(ctx context.Context, userID string) (int64, error) {
+	value, ok, err := s.opts.Store.Get(ctx, s.genKey(userID))
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	gen, _ := strconv.ParseInt(string(value), 10, 64)
+	return gen, nil
+}
+
+func (s *Sessions) load(ctx context.Context, token string) (*Session, error) {
+	if token == "" {
+		return nil, ErrSessionInvalid
+	}
+
+	value, ok, err := s.opts.Store.Get(ctx, s.key(token))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSessionInvalid
+	}
+
+	var sess Session
+	if err = json.Unmarshal(value, &sess); err != nil {
+		return nil, ErrSessionInvalid
+	}
+
+	return &sess, nil
+}
+
+func (s *Sessions) save(ctx context.Context, token string, sess *Session) error {
+	value, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+
+	// 存储的过期时间不超过会话的绝对有效期
+	ttl := time.Duration(sess.ExpiresAt-GetUnixMillis()) * time.Millisecond
+	if left := time.Duration(sess.CreatedAt+s.opts.MaxLifetime.Milliseconds()-GetUnixMillis()) * time.Millisecond; left < ttl {
+		ttl = left
+	}
+	if ttl <= 0 {
+		return ErrSessionInvalid
+	}
+
+	return s.opts.Store.Set(ctx, s.key(token), value, ttl)
+}
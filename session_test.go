@@ -0,0 +1,66 @@
+package libtools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionsRevokeAllForUser(t *testing.T) {
+	kv := NewMemoryKV()
+	sessions := NewSessions(SessionOptions{Store: kv, TTL: time.Hour, MaxLifetime: 2 * time.Hour})
+
+	old, err := sessions.Issue("10086", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sessions.RevokeAllForUser("10086"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sessions.Validate(old); !errors.Is(err, ErrSessionInvalid) {
+		t.Fatalf("old session should be invalid, got: %v", err)
+	}
+
+	token, _ := sessions.Issue("10086", nil)
+	if sess, err := sessions.Validate(token); err != nil || sess.Gen != 1 {
+		t.Fatalf("new session should be valid, got: %+v, %v", sess, err)
+	}
+
+	// 代数不能过期, 否则下一次注销会重新写入 1, 让上面的会话复活
+	if ttl, ok := kv.(*memoryKV).cache.TTL(sessions.genKey("10086")); !ok || ttl != 0 {
+		t.Fatalf("session gen should never expire, ttl: %v, %v", ttl, ok)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = sessions.RevokeAllForUser("10086")
+		}()
+	}
+	wg.Wait()
+	if gen, _ := sessions.userGen(context.Background(), "10086"); gen != 21 {
+		t.Fatalf("concurrent revoke should be atomic, gen: %d", gen)
+	}
+	if _, err = sessions.Validate(token); !errors.Is(err, ErrSessionInvalid) {
+		t.Fatalf("session should be invalid after revoke, got: %v", err)
+	}
+}
+
+func TestSessionsDevice(t *testing.T) {
+	sessions := NewSessions()
+	token, _ := sessions.Issue("1", map[string]string{SessionDeviceKey: "dev-a"})
+	if _, err := sessions.Validate(token, "dev-b"); !errors.Is(err, ErrSessionDeviceMismatch) {
+		t.Fatalf("expect device mismatch, got: %v", err)
+	}
+	if _, err := sessions.Validate(token, "dev-a"); err != nil {
+		t.Fatal(err)
+	}
+	_ = sessions.Revoke(token)
+	if _, err := sessions.Validate(token, "dev-a"); !errors.Is(err, ErrSessionInvalid) {
+		t.Fatalf("revoked session should be invalid, got: %v", err)
+	}
+}
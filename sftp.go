@@ -0,0 +1,310 @@
+package libtools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPConfig SFTP 连接参数, 密码与私钥至少配置一个; 必须配置 KnownHostsFile 或 HostKeyFingerprint 之一用于校验服务端
+type SFTPConfig struct {
+	Addr                 string // host:port, 不带端口时默认 22
+	User                 string
+	Password             string
+	PrivateKey           []byte // PEM 格式的私钥
+	PrivateKeyPassphrase string
+
+	// KnownHostsFile known_hosts 格式的文件
+	KnownHostsFile string
+	// HostKeyFingerprint 服务端公钥指纹, 格式同 ssh-keygen -lf 的输出, 如 SHA256:xxxx
+	HostKeyFingerprint string
+	// InsecureSkipHostKey 跳过服务端校验, 只能用于测试环境
+	InsecureSkipHostKey bool
+
+	Timeout time.Duration // 建立连接的超时时间, 默认 30 秒
+}
+
+func (cfg SFTPConfig) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if len(cfg.PrivateKey) > 0 {
+		var signer ssh.Signer
+		var err error
+		if cfg.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(cfg.PrivateKey, []byte(cfg.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(cfg.PrivateKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse sftp private key: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		password := cfg.Password
+		auth = append(auth, ssh.Password(password), ssh.KeyboardInteractive(
+			func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("sftp: password or private key is required")
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case cfg.KnownHostsFile != "":
+		callback, err := knownhosts.New(cfg.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load known hosts: %v", err)
+		}
+		hostKeyCallback = callback
+	case cfg.HostKeyFingerprint != "":
+		want := cfg.HostKeyFingerprint
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != want {
+				return fmt.Errorf("sftp: host key mismatch for %s, got: %s, want: %s", hostname, got, want)
+			}
+			return nil
+		}
+	case cfg.InsecureSkipHostKey:
+		logs.Warning("[SFTP] host key verification is disabled, addr: %s", cfg.Addr)
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("sftp: KnownHostsFile or HostKeyFingerprint is required")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &ssh.ClientConfig{User: cfg.User, Auth: auth, HostKeyCallback: hostKeyCallback, Timeout: timeout}, nil
+}
+
+// sftpSession 一次 SFTP 会话
+type sftpSession struct {
+	conn *ssh.Client
+	*sftp.Client
+}
+
+func sftpDial(cfg SFTPConfig) (*sftpSession, error) {
+	clientConfig, err := cfg.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := cfg.Addr
+	if _, _, splitErr := net.SplitHostPort(addr); splitErr != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	conn, err := ssh.Dial("tcp", addr, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("sftp dial %s fail: %v", addr, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("sftp start subsystem fail: %v", err)
+	}
+
+	return &sftpSession{conn: conn, Client: client}, nil
+}
+
+func (s *sftpSession) Close() {
+	_ = s.Client.Close()
+	_ = s.conn.Close()
+}
+
+// transferPartSuffix SFTP/FTP 传输中的临时文件后缀, 完成后重命名, 对方不会读到不完整的文件
+const transferPartSuffix = ".part"
+
+// transferMetaSuffix 与 .part 一起保存的源文件大小与修改时间, 断点续传前核对, 防止同名文件重新生成后拼接到旧数据上
+const transferMetaSuffix = ".part.meta"
+
+func transferMeta(size int64, mtime time.Time) string {
+	return fmt.Sprintf("%d %d\n", size, mtime.Unix())
+}
+
+// SFTPPut 上传文件, 先写入 remotePath.part, 完成后重命名为 remotePath(已存在时覆盖)
+// 上次中断留下的 .part 对应的本地文件大小与修改时间未变时从断点继续上传, 否则重新上传
+func SFTPPut(cfg SFTPConfig, localPath, remotePath string) (err error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return
+	}
+
+	s, err := sftpDial(cfg)
+	if err != nil {
+		return
+	}
+	defer s.Close()
+
+	part, metaPath := remotePath+transferPartSuffix, remotePath+transferMetaSuffix
+	meta := transferMeta(info.Size(), info.ModTime())
+	var offset int64
+	if partInfo, statErr := s.Stat(part); statErr == nil && partInfo.Size() <= info.Size() && s.readSmallFile(metaPath) == meta {
+		offset = partInfo.Size()
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+		if err = s.writeSmallFile(metaPath, meta); err != nil {
+			return
+		}
+	} else {
+		logs.Notice("[SFTPPut] resume upload %s from offset %d", remotePath, offset)
+	}
+	dst, err := s.OpenFile(part, flags)
+	if err != nil {
+		return
+	}
+	if _, err = dst.Seek(offset, io.SeekStart); err == nil {
+		_, err = dst.ReadFrom(io.NewSectionReader(src, offset, info.Size()-offset))
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+
+	// SFTP v3 的 rename 在目标存在时失败, 服务端支持 posix-rename 扩展时直接覆盖, 否则先删除旧文件
+	if _, ok := s.HasExtension("posix-rename@openssh.com"); ok {
+		err = s.PosixRename(part, remotePath)
+	} else {
+		if _, statErr := s.Stat(remotePath); statErr == nil {
+			if err = s.Remove(remotePath); err != nil {
+				return
+			}
+		}
+		err = s.Rename(part, remotePath)
+	}
+	if err != nil {
+		return
+	}
+	_ = s.Remove(metaPath)
+	return nil
+}
+
+// SFTPGet 下载文件, 先写入 localPath.part, 完成后重命名为 localPath
+// 已有的 .part 对应的远端文件大小与修改时间未变时从断点继续下载, 否则重新下载
+func SFTPGet(cfg SFTPConfig, remotePath, localPath string) (err error) {
+	s, err := sftpDial(cfg)
+	if err != nil {
+		return
+	}
+	defer s.Close()
+
+	remoteInfo, err := s.Stat(remotePath)
+	if err != nil {
+		return
+	}
+
+	part, metaPath := localPath+transferPartSuffix, localPath+transferMetaSuffix
+	meta := transferMeta(remoteInfo.Size(), remoteInfo.ModTime())
+	var offset int64
+	if info, statErr := os.Stat(part); statErr == nil && info.Size() <= remoteInfo.Size() {
+		if saved, _ := ioutil.ReadFile(metaPath); string(saved) == meta {
+			offset = info.Size()
+		}
+	}
+	if err = os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return
+	}
+	flags := os.O_CREATE | os.O_WRONLY
+	if offset == 0 {
+		flags |= os.O_TRUNC
+		if err = ioutil.WriteFile(metaPath, []byte(meta), 0644); err != nil {
+			return
+		}
+	} else {
+		logs.Notice("[SFTPGet] resume download %s from offset %d", remotePath, offset)
+	}
+	dst, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return
+	}
+
+	src, err := s.Open(remotePath)
+	if err != nil {
+		_ = dst.Close()
+		return
+	}
+	if _, err = src.Seek(offset, io.SeekStart); err == nil {
+		if _, err = dst.Seek(offset, io.SeekStart); err == nil {
+			_, err = io.Copy(dst, src)
+		}
+	}
+	_ = src.Close()
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+
+	if err = os.Rename(part, localPath); err != nil {
+		return
+	}
+	_ = os.Remove(metaPath)
+	return nil
+}
+
+// readSmallFile 读取远端的小文件(如 .part.meta), 失败时返回空字符串
+func (s *sftpSession) readSmallFile(path string) string {
+	f, err := s.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, 1024))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (s *sftpSession) writeSmallFile(path, content string) error {
+	f, err := s.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write([]byte(content)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SFTPList 列出远端目录下的文件, 不包含 . 与 ..
+func SFTPList(cfg SFTPConfig, remoteDir string) ([]os.FileInfo, error) {
+	s, err := sftpDial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	return s.ReadDir(remoteDir)
+}
@@ -0,0 +1,191 @@
+package libtools
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startSFTPServerT 启动只支持密码登录(user/pass)的 ssh 服务, sftp 子系统的相对路径位于 root 下
+func startSFTPServerT(t *testing.T, root string) (addr string, hostKey ssh.PublicKey) {
+	t.Helper()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "user" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, io.ErrUnexpectedEOF
+		},
+	}
+	config.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, requests, _ := newChannel.Accept()
+					go func() {
+						for req := range requests {
+							_ = req.Reply(req.Type == "subsystem", nil)
+							if req.Type == "subsystem" {
+								go func() {
+									if server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root)); err == nil {
+										_ = server.Serve()
+									}
+									_ = channel.Close()
+								}()
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), signer.PublicKey()
+}
+
+func TestSFTP(t *testing.T) {
+	root := filepath.Join(testutil.TempDirT(t), "remote")
+	_ = os.MkdirAll(filepath.Join(root, "upload"), 0755)
+	addr, hostKey := startSFTPServerT(t, root)
+	cfg := SFTPConfig{Addr: addr, User: "user", Password: "pass", HostKeyFingerprint: ssh.FingerprintSHA256(hostKey)}
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 10000)
+	localFile := testutil.WriteTempFileT(t, "recon.csv", data)
+
+	localInfo, _ := os.Stat(localFile)
+	remotePart := filepath.Join(root, "upload", "recon.csv.part")
+	remoteMeta := filepath.Join(root, "upload", "recon.csv.part.meta")
+
+	// 远端有上次中断留下的 .part, 内容用 X 填充以确认是续传而不是重新上传
+	resumed := 40000
+	_ = os.WriteFile(remotePart, bytes.Repeat([]byte("X"), resumed), 0644)
+	_ = os.WriteFile(remoteMeta, []byte(transferMeta(localInfo.Size(), localInfo.ModTime())), 0644)
+	_ = os.WriteFile(filepath.Join(root, "upload", "recon.csv"), []byte("old"), 0644)
+	if err := SFTPPut(cfg, localFile, "upload/recon.csv"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(filepath.Join(root, "upload", "recon.csv"))
+	if !bytes.Equal(got[resumed:], data[resumed:]) || !bytes.Equal(got[:resumed], bytes.Repeat([]byte("X"), resumed)) {
+		t.Fatal("upload should resume from .part")
+	}
+	for _, path := range []string{remotePart, remoteMeta} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s should be removed after upload", path)
+		}
+	}
+
+	// 本地文件重新生成过(大小或修改时间不同), 旧的 .part 不能续传
+	for _, meta := range []string{"", transferMeta(localInfo.Size()+1, localInfo.ModTime())} {
+		_ = os.WriteFile(remotePart, bytes.Repeat([]byte("X"), resumed), 0644)
+		if meta != "" {
+			_ = os.WriteFile(remoteMeta, []byte(meta), 0644)
+		}
+		if err := SFTPPut(cfg, localFile, "upload/recon.csv"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ = os.ReadFile(filepath.Join(root, "upload", "recon.csv")); !bytes.Equal(got, data) {
+			t.Fatalf("stale .part should be discarded, meta: %q", meta)
+		}
+	}
+
+	download := filepath.Join(testutil.TempDirT(t), "download", "recon.csv")
+	_ = os.MkdirAll(filepath.Dir(download), 0755)
+	remoteInfo, _ := os.Stat(filepath.Join(root, "upload", "recon.csv"))
+	_ = os.WriteFile(download+".part", data[:12345], 0644)
+	_ = os.WriteFile(download+".part.meta", []byte(transferMeta(remoteInfo.Size(), remoteInfo.ModTime())), 0644)
+	if err := SFTPGet(cfg, "upload/recon.csv", download); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = os.ReadFile(download); !bytes.Equal(got, data) {
+		t.Fatal("download content mismatch")
+	}
+	if _, err := os.Stat(download + ".part.meta"); !os.IsNotExist(err) {
+		t.Fatal(".part.meta should be removed after download")
+	}
+
+	// 没有 .part.meta 的旧 .part 重新下载
+	_ = os.WriteFile(download+".part", bytes.Repeat([]byte("Y"), 12345), 0644)
+	if err := SFTPGet(cfg, "upload/recon.csv", download); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = os.ReadFile(download); !bytes.Equal(got, data) {
+		t.Fatal("stale local .part should be discarded")
+	}
+
+	list, err := SFTPList(cfg, "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name() != "recon.csv" || list[0].Size() != int64(len(data)) || list[0].IsDir() {
+		t.Fatalf("unexpected list: %v", list)
+	}
+
+	if err = SFTPGet(cfg, "upload/missing.csv", download); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expect not exist, got: %v", err)
+	}
+}
+
+func TestSFTPAuthAndHostKey(t *testing.T) {
+	addr, hostKey := startSFTPServerT(t, testutil.TempDirT(t))
+
+	cases := []SFTPConfig{
+		{Addr: addr, User: "user", Password: "pass"},
+		{Addr: addr, User: "user", Password: "pass", HostKeyFingerprint: "SHA256:bad"},
+		{Addr: addr, User: "user", Password: "wrong", HostKeyFingerprint: ssh.FingerprintSHA256(hostKey)},
+		{Addr: addr, User: "user", HostKeyFingerprint: ssh.FingerprintSHA256(hostKey)},
+	}
+	for i, cfg := range cases {
+		if _, err := SFTPList(cfg, "."); err == nil {
+			t.Fatalf("case %d should fail", i)
+		}
+	}
+
+	knownHosts := testutil.WriteTempFileT(t, "known_hosts", []byte(knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)+"\n"))
+	list, err := SFTPList(SFTPConfig{Addr: addr, User: "user", Password: "pass", KnownHostsFile: knownHosts}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(list))
+	for _, info := range list {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 1 || names[0] != "known_hosts" {
+		t.Fatalf("unexpected list: %v", names)
+	}
+}
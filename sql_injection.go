@@ -0,0 +1,85 @@
+package libtools
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SQLInjectionRule 一条检测规则, 命中后累加 Weight
+type SQLInjectionRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Weight  int
+}
+
+// SQLInjectionReport 检测结果
+type SQLInjectionReport struct {
+	Suspicious bool     `json:"suspicious"`
+	Score      int      `json:"score"`
+	Matched    []string `json:"matched,omitempty"` // 命中的规则名称
+}
+
+// DefaultSQLInjectionRules 内置规则, 可复制后增删或调整权重
+var DefaultSQLInjectionRules = []SQLInjectionRule{
+	{"union_select", regexp.MustCompile(`\bunion\s+(all\s+|distinct\s+)?select\b`), 10},
+	{"tautology", regexp.MustCompile(`\b(or|and)\s+['"]?\w*['"]?\s*(=|<>|!=|<|>|\blike\b)\s*['"]?\w*['"]?\s*($|--|#|/\*|\))`), 8},
+	{"quote_tautology", regexp.MustCompile(`['"]\s*(or|and)\s*['"]?[^'"]*['"]?\s*=\s*['"]`), 10},
+	{"comment_terminator", regexp.MustCompile(`['"\)]\s*(--|#|/\*)`), 10},
+	{"stacked_query", regexp.MustCompile(`;\s*(select|insert|update|delete|drop|alter|create|truncate|exec|declare)\b`), 10},
+	{"time_based", regexp.MustCompile(`\b(sleep|benchmark|pg_sleep)\s*\(|\bwaitfor\s+delay\b`), 10},
+	{"schema_probe", regexp.MustCompile(`\b(information_schema|sysobjects|syscolumns|pg_catalog|mysql\.user)\b`), 8},
+	{"dangerous_function", regexp.MustCompile(`\b(load_file|xp_cmdshell|into\s+(outfile|dumpfile))\b`), 10},
+	{"quote_keyword", regexp.MustCompile(`['"]\s*(\)\s*)?(or|and|union|order\s+by|group\s+by|having)\b`), 5},
+	{"select_from", regexp.MustCompile(`\bselect\b.+\bfrom\b`), 5},
+	{"encoded_payload", regexp.MustCompile(`\b(char|chr|concat|concat_ws)\s*\(|\b0x[0-9a-f]{8,}\b`), 3},
+}
+
+// SQLInjectionDetector 可调整规则与阈值的检测器
+type SQLInjectionDetector struct {
+	Rules     []SQLInjectionRule
+	Threshold int // 得分达到阈值即判定为可疑, 默认 10
+}
+
+var defaultSQLInjectionDetector = &SQLInjectionDetector{Rules: DefaultSQLInjectionRules, Threshold: 10}
+
+var sqlInlineComment = regexp.MustCompile(`/\*.*?\*/`)
+
+// Detect 检测输入, 会先做 url 解码、转小写, 并将 /**/ 注释替换为空格(常用于绕过空格过滤)
+func (d *SQLInjectionDetector) Detect(input string) SQLInjectionReport {
+	var report SQLInjectionReport
+	if strings.TrimSpace(input) == "" {
+		return report
+	}
+
+	lowered := strings.ToLower(input)
+	if decoded, err := url.QueryUnescape(lowered); err == nil {
+		lowered = decoded
+	}
+	normalized := strings.Join(strings.Fields(sqlInlineComment.ReplaceAllString(lowered, " ")), " ")
+
+	for _, rule := range d.Rules {
+		if rule.Pattern.MatchString(lowered) || rule.Pattern.MatchString(normalized) {
+			report.Score += rule.Weight
+			report.Matched = append(report.Matched, rule.Name)
+		}
+	}
+
+	threshold := d.Threshold
+	if threshold <= 0 {
+		threshold = 10
+	}
+	report.Suspicious = report.Score >= threshold
+
+	return report
+}
+
+// DetectSQLInjection 使用内置规则检测, 返回命中明细
+func DetectSQLInjection(input string) SQLInjectionReport {
+	return defaultSQLInjectionDetector.Detect(input)
+}
+
+// LooksLikeSQLInjection 输入是否疑似 sql 注入, 仅用于记录与告警, 不能代替参数化查询
+func LooksLikeSQLInjection(input string) bool {
+	return DetectSQLInjection(input).Suspicious
+}
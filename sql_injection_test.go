@@ -0,0 +1,26 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestLooksLikeSQLInjection(t *testing.T) {
+	cases := map[string]bool{
+		"1' OR '1'='1": true,
+		"admin'--":     true,
+		"1 UNION SELECT username, password FROM users": true,
+		"1/**/union/**/select/**/1,2":                  true,
+		"1; DROP TABLE loan_order":                     true,
+		"1 AND SLEEP(5)":                               true,
+		"1%27%20or%201%3D1--":                          true,
+		"O'Brien":                                      false,
+		"select a product from the list":               false,
+		"13800138000":                                  false,
+		"张三 or 李四":                                     false,
+	}
+	for input, want := range cases {
+		if got := LooksLikeSQLInjection(input); got != want {
+			t.Errorf("LooksLikeSQLInjection(%q) = %v, want %v, report: %+v", input, got, want, DetectSQLInjection(input))
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package libtools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认禁止访问的网段: 回环、内网、链路本地(含云厂商 metadata 169.254.169.254)、CGNAT、组播等
+var ssrfDeniedCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// SSRFPolicy 出站请求的安全策略
+type SSRFPolicy struct {
+	// AllowedSchemes 为空时只允许 http/https
+	AllowedSchemes []string
+	// AllowedPorts 为空时不限制端口
+	AllowedPorts []int
+	// AllowedHosts 白名单域名,跳过 ip 检查,用于确实需要访问的内部服务
+	AllowedHosts []string
+	// DeniedCIDRs 在默认禁止网段之外追加的网段
+	DeniedCIDRs []string
+	// AllowPrivate 为 true 时不检查默认禁止网段,只检查 DeniedCIDRs
+	AllowPrivate bool
+	// ResolverAddr 为空时使用系统 DNS
+	ResolverAddr string
+}
+
+func DefaultSSRFPolicy() SSRFPolicy {
+	return SSRFPolicy{
+		AllowedSchemes: []string{"http", "https"},
+	}
+}
+
+// IsPrivateIP 判断 ip 是否属于内网/回环/链路本地等不应被外部 url 访问的地址
+func IsPrivateIP(ip string) bool {
+	for _, cidr := range ssrfDeniedCIDRs {
+		if CIDRContains(cidr, ip) {
+			return true
+		}
+	}
+
+	// ipv4-mapped ipv6, 如 ::ffff:127.0.0.1
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil && strings.Contains(ip, ":") {
+		return IsPrivateIP(parsed.To4().String())
+	}
+
+	// NAT64 与 6to4 地址会被网关转发到其中嵌入的 ipv4, 如 64:ff9b::7f00:1 => 127.0.0.1
+	if embedded := embeddedIPv4(net.ParseIP(ip)); embedded != nil {
+		return IsPrivateIP(embedded.String())
+	}
+
+	return false
+}
+
+var (
+	ssrfNAT64Prefix = net.IPNet{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)}
+	ssrf6to4Prefix  = net.IPNet{IP: net.ParseIP("2002::"), Mask: net.CIDRMask(16, 128)}
+)
+
+// embeddedIPv4 返回 NAT64(64:ff9b::/96, 后 32 位)或 6to4(2002::/16, 第 16~48 位)地址中嵌入的 ipv4, 其他地址返回 nil
+func embeddedIPv4(ip net.IP) net.IP {
+	if ip == nil || ip.To4() != nil {
+		return nil
+	}
+	switch {
+	case ssrfNAT64Prefix.Contains(ip):
+		return net.IPv4(ip[12], ip[13], ip[14], ip[15])
+	case ssrf6to4Prefix.Contains(ip):
+		return net.IPv4(ip[2], ip[3], ip[4], ip[5])
+	}
+
+	return nil
+}
+
+func (p SSRFPolicy) isAllowedHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range p.AllowedHosts {
+		if strings.ToLower(allowed) == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p SSRFPolicy) checkIP(ip string) error {
+	if !p.AllowPrivate && IsPrivateIP(ip) {
+		return fmt.Errorf("ssrf policy: ip %s is private or reserved", ip)
+	}
+
+	for _, cidr := range p.DeniedCIDRs {
+		if CIDRContains(cidr, ip) {
+			return fmt.Errorf("ssrf policy: ip %s is in denied cidr %s", ip, cidr)
+		}
+	}
+
+	return nil
+}
+
+func (p SSRFPolicy) checkPort(port int) error {
+	if len(p.AllowedPorts) == 0 {
+		return nil
+	}
+
+	for _, allowed := range p.AllowedPorts {
+		if allowed == port {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("ssrf policy: port %d is not allowed", port)
+}
+
+// SafeHTTPTarget 校验用户提交的 url 能否安全访问: 检查协议、端口,并解析域名拒绝内网地址
+// 返回校验通过的 ip 列表
+func SafeHTTPTarget(rawURL string, policy SSRFPolicy) ([]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ssrf policy: invalid url: %v", err)
+	}
+
+	schemes := policy.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	if !InSlice(strings.ToLower(u.Scheme), schemes) {
+		return nil, fmt.Errorf("ssrf policy: scheme %s is not allowed", u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("ssrf policy: empty host")
+	}
+
+	port := 80
+	if strings.ToLower(u.Scheme) == "https" {
+		port = 443
+	}
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return nil, fmt.Errorf("ssrf policy: invalid port %s", u.Port())
+		}
+	}
+	if err = policy.checkPort(port); err != nil {
+		return nil, err
+	}
+
+	return policy.resolve(context.Background(), host)
+}
+
+func (p SSRFPolicy) resolve(ctx context.Context, host string) ([]string, error) {
+	ips, err := ResolveIPs(ctx, host, p.ResolverAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ssrf policy: %v", err)
+	}
+
+	if p.isAllowedHost(host) {
+		return ips, nil
+	}
+
+	for _, ip := range ips {
+		if err = p.checkIP(ip); err != nil {
+			return nil, err
+		}
+	}
+
+	return ips, nil
+}
+
+// SSRFSafeDialContext 在建连时再次解析并校验 ip,并直接连接校验过的 ip,
+// 可防止 DNS rebinding 以及跳转(redirect)到内网地址
+func SSRFSafeDialContext(policy SSRFPolicy, dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, portStr, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		port, _ := strconv.Atoi(portStr)
+		if err = policy.checkPort(port); err != nil {
+			return nil, err
+		}
+
+		ips, err := policy.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var conn net.Conn
+		for _, ip := range ips {
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, portStr))
+			if err == nil {
+				return conn, nil
+			}
+		}
+
+		return nil, err
+	}
+}
+
+// NewSSRFSafeClient 返回一个只会连接策略允许地址的 http 客户端
+func NewSSRFSafeClient(policy SSRFPolicy, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // 走代理时无法校验真实目标
+			DialContext:           SSRFSafeDialContext(policy, nil),
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+}
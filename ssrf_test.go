@@ -0,0 +1,45 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestSafeHTTPTarget(t *testing.T) {
+	policy := DefaultSSRFPolicy()
+
+	rejected := []string{
+		"http://127.0.0.1/admin",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.1.2.3:8080/",
+		"http://[::1]/",
+		"http://[::ffff:127.0.0.1]/",
+		"http://[64:ff9b::a9fe:a9fe]/",
+		"http://[64:ff9b::127.0.0.1]/",
+		"http://[2002:7f00:1::]/",
+		"http://[2002:c0a8:101::1]/",
+		"file:///etc/passwd",
+		"gopher://8.8.8.8/",
+	}
+	for _, u := range rejected {
+		if _, err := SafeHTTPTarget(u, policy); err == nil {
+			t.Errorf("SafeHTTPTarget should reject: %s", u)
+		}
+	}
+
+	ips, err := SafeHTTPTarget("https://8.8.8.8/", policy)
+	if err != nil || len(ips) != 1 {
+		t.Errorf("SafeHTTPTarget should allow public ip, ips: %v, err: %v", ips, err)
+	}
+
+	// 嵌入公网 ipv4 的 NAT64/6to4 地址仍然允许
+	for _, ip := range []string{"64:ff9b::808:808", "2002:808:808::1"} {
+		if IsPrivateIP(ip) {
+			t.Errorf("IsPrivateIP should allow %s", ip)
+		}
+	}
+
+	policy.AllowedPorts = []int{443}
+	if _, err = SafeHTTPTarget("http://8.8.8.8:22/", policy); err == nil {
+		t.Errorf("SafeHTTPTarget should reject port not in allowlist")
+	}
+}
@@ -0,0 +1,113 @@
+package libtools
+
+import (
+	"math"
+	"sort"
+)
+
+// StatNumber 统计函数支持的数值类型
+type StatNumber interface {
+	~int | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// statValues 转为 float64 并剔除 NaN/Inf, 不修改入参
+func statValues[T StatNumber](vals []T) []float64 {
+	list := make([]float64, 0, len(vals))
+	for _, v := range vals {
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		list = append(list, f)
+	}
+
+	return list
+}
+
+// Mean 平均值, 忽略 NaN/Inf, 没有有效值时返回 0
+func Mean[T StatNumber](vals []T) float64 {
+	list := statValues(vals)
+	if len(list) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range list {
+		sum += v
+	}
+
+	return sum / float64(len(list))
+}
+
+// Median 中位数, 即 Percentile(vals, 50)
+func Median[T StatNumber](vals []T) float64 {
+	return Percentile(vals, 50)
+}
+
+// Percentile p 分位数(p 取值 [0, 100]), 相邻两值之间线性插值, 忽略 NaN/Inf, 没有有效值时返回 0
+func Percentile[T StatNumber](vals []T, p float64) float64 {
+	list := statValues(vals)
+	if len(list) == 0 {
+		return 0
+	}
+	sort.Float64s(list)
+
+	return percentileSorted(list, p)
+}
+
+// percentileSorted list 需已升序排列且非空
+func percentileSorted(list []float64, p float64) float64 {
+	if p <= 0 || math.IsNaN(p) {
+		return list[0]
+	}
+	if p >= 100 {
+		return list[len(list)-1]
+	}
+
+	rank := p / 100 * float64(len(list)-1)
+	lower := int(rank)
+	if lower+1 >= len(list) {
+		return list[lower]
+	}
+
+	return list[lower] + (list[lower+1]-list[lower])*(rank-float64(lower))
+}
+
+// StdDev 总体标准差, 忽略 NaN/Inf, 没有有效值时返回 0
+func StdDev[T StatNumber](vals []T) float64 {
+	list := statValues(vals)
+	if len(list) == 0 {
+		return 0
+	}
+
+	mean := Mean(list)
+	var sum float64
+	for _, v := range list {
+		sum += (v - mean) * (v - mean)
+	}
+
+	return math.Sqrt(sum / float64(len(list)))
+}
+
+// MinMax 最小值与最大值, 浮点数忽略 NaN, 没有有效值时返回零值
+func MinMax[T StatNumber](vals []T) (min, max T) {
+	first := true
+	for _, v := range vals {
+		if f := float64(v); math.IsNaN(f) {
+			continue
+		}
+		if first {
+			min, max = v, v
+			first = false
+			continue
+		}
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	return
+}
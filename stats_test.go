@@ -0,0 +1,29 @@
+package libtools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	vals := []float64{3, 1, math.NaN(), 4, 2}
+
+	if m := Mean(vals); m != 2.5 {
+		t.Errorf("Mean = %v", m)
+	}
+	if m := Median(vals); m != 2.5 {
+		t.Errorf("Median = %v", m)
+	}
+	if p := Percentile([]int64{10, 20, 30, 40, 50}, 90); p != 46 {
+		t.Errorf("Percentile(90) = %v", p)
+	}
+	if s := StdDev([]int{2, 4, 4, 4, 5, 5, 7, 9}); s != 2 {
+		t.Errorf("StdDev = %v", s)
+	}
+	if min, max := MinMax(vals); min != 1 || max != 4 {
+		t.Errorf("MinMax = %v, %v", min, max)
+	}
+	if m := Mean([]float64{}); m != 0 {
+		t.Errorf("Mean of empty = %v", m)
+	}
+}
@@ -588,6 +588,31 @@ func NicknameMask(nickname string) string {
 	return s
 }
 
+var (
+	scrubSecretRegexp = regexp.MustCompile(`(?i)\b(password|passwd|pwd|token|access_token|secret|api_key|apikey|sign|signature)(["']?\s*[:=]\s*["']?)[^\s&"',;]+`)
+	scrubBearerRegexp = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	scrubEmailRegexp  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	scrubDigitsRegexp = regexp.MustCompile(`\+?\d{9,19}`)
+)
+
+// ScrubPII 对自由文本(如错误信息、日志)中的敏感信息脱敏: 密码与 token 等参数值、Authorization 凭证、邮箱、
+// 9-12 位或带 + 的手机号(见 MobileDesensitization)、13-19 位的银行卡号与身份证号(只保留前 4 位与后 4 位)
+func ScrubPII(s string) string {
+	s = scrubSecretRegexp.ReplaceAllString(s, "${1}${2}***")
+	s = scrubBearerRegexp.ReplaceAllString(s, "${1} ***")
+	s = scrubEmailRegexp.ReplaceAllString(s, "${1}***@${2}")
+
+	return scrubDigitsRegexp.ReplaceAllStringFunc(s, func(digits string) string {
+		number := strings.TrimPrefix(digits, "+")
+		// 带国际区号的一律按手机号处理
+		if len(number) <= 12 || number != digits {
+			return strings.TrimSuffix(digits, number) + MobileDesensitization(number)
+		}
+
+		return number[:4] + strings.Repeat("*", len(number)-8) + number[len(number)-4:]
+	})
+}
+
 func TrimTags(s string) string {
 	re := regexp.MustCompile(`#(\S+)`)
 	out := strings.TrimSpace(re.ReplaceAllString(s, ""))
@@ -0,0 +1,165 @@
+package libtools
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+type TableStyle int
+
+const (
+	// TableStyleASCII +---+ 边框, 适合终端与日志
+	TableStyleASCII TableStyle = iota
+	// TableStyleMarkdown 可直接贴到工单、wiki
+	TableStyleMarkdown
+)
+
+type TableAlign int
+
+const (
+	TableAlignLeft TableAlign = iota
+	TableAlignRight
+	TableAlignCenter
+)
+
+// TableOptions RenderTable 的参数, 零值为左对齐的 ascii 表格
+type TableOptions struct {
+	Style TableStyle
+	// Align 按列指定对齐方式, 未指定的列左对齐; 金额、数量等数字列建议右对齐
+	Align []TableAlign
+}
+
+// DisplayWidth 字符串在等宽终端中的显示宽度, 中文等全角字符占 2 列, 组合字符与控制字符不占宽度
+func DisplayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r) || unicode.IsControl(r) || r == 0x200B || r == 0xFEFF:
+		case width.LookupRune(r).Kind() == width.EastAsianWide || width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+
+	return n
+}
+
+// RenderTable 渲染对齐的表格, 行的列数可以与表头不同, 缺少的单元格留空
+func RenderTable(headers []string, rows [][]string, opts ...TableOptions) string {
+	var opt TableOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	columns := len(headers)
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	cell := func(row []string, i int) string {
+		if i >= len(row) {
+			return ""
+		}
+		// 单元格内换行会破坏表格结构
+		s := strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(row[i])
+		if opt.Style == TableStyleMarkdown {
+			s = strings.ReplaceAll(s, "|", `\|`)
+		}
+		return s
+	}
+	align := func(i int) TableAlign {
+		if i < len(opt.Align) {
+			return opt.Align[i]
+		}
+		return TableAlignLeft
+	}
+
+	widths := make([]int, columns)
+	if opt.Style == TableStyleMarkdown {
+		// 分隔行至少需要 3 个字符
+		for i := range widths {
+			widths[i] = 3
+		}
+	}
+	for _, row := range append([][]string{headers}, rows...) {
+		for i := 0; i < columns; i++ {
+			if w := DisplayWidth(cell(row, i)); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			b.WriteString(" " + tablePad(cell(row, i), widths[i], align(i)) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeBorder := func() {
+		b.WriteString("+")
+		for _, w := range widths {
+			b.WriteString(strings.Repeat("-", w+2) + "+")
+		}
+		b.WriteString("\n")
+	}
+
+	if opt.Style == TableStyleMarkdown {
+		writeRow(headers)
+		b.WriteString("|")
+		for i, w := range widths {
+			switch align(i) {
+			case TableAlignRight:
+				b.WriteString(" " + strings.Repeat("-", w-1) + ": |")
+			case TableAlignCenter:
+				b.WriteString(" :" + strings.Repeat("-", w-2) + ": |")
+			default:
+				b.WriteString(" " + strings.Repeat("-", w) + " |")
+			}
+		}
+		b.WriteString("\n")
+		for _, row := range rows {
+			writeRow(row)
+		}
+		return b.String()
+	}
+
+	writeBorder()
+	if len(headers) > 0 {
+		writeRow(headers)
+		writeBorder()
+	}
+	for _, row := range rows {
+		writeRow(row)
+	}
+	if len(rows) > 0 {
+		writeBorder()
+	}
+
+	return b.String()
+}
+
+func tablePad(s string, w int, align TableAlign) string {
+	gap := w - DisplayWidth(s)
+	if gap <= 0 {
+		return s
+	}
+
+	switch align {
+	case TableAlignRight:
+		return strings.Repeat(" ", gap) + s
+	case TableAlignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+
+	return s + strings.Repeat(" ", gap)
+}
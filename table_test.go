@@ -0,0 +1,36 @@
+package libtools
+
+import (
+	"testing"
+)
+
+func TestRenderTable(t *testing.T) {
+	headers := []string{"渠道", "笔数", "金额"}
+	rows := [][]string{
+		{"支付宝", "12", "1,024.00"},
+		{"bank", "3", "8.50"},
+	}
+
+	ascii := RenderTable(headers, rows, TableOptions{Align: []TableAlign{TableAlignLeft, TableAlignRight, TableAlignRight}})
+	want := "+--------+------+----------+\n" +
+		"| 渠道   | 笔数 |     金额 |\n" +
+		"+--------+------+----------+\n" +
+		"| 支付宝 |   12 | 1,024.00 |\n" +
+		"| bank   |    3 |     8.50 |\n" +
+		"+--------+------+----------+\n"
+	if ascii != want {
+		t.Errorf("RenderTable ascii get:\n%s\nwant:\n%s", ascii, want)
+	}
+
+	markdown := RenderTable([]string{"a", "b"}, [][]string{{"x|y"}}, TableOptions{Style: TableStyleMarkdown, Align: []TableAlign{TableAlignLeft, TableAlignCenter}})
+	want = "| a    |  b  |\n" +
+		"| ---- | :-: |\n" +
+		"| x\\|y |     |\n"
+	if markdown != want {
+		t.Errorf("RenderTable markdown get:\n%s\nwant:\n%s", markdown, want)
+	}
+
+	if w := DisplayWidth("ａb中\u0301"); w != 5 {
+		t.Errorf("DisplayWidth get %d", w)
+	}
+}
@@ -0,0 +1,101 @@
+// Package testutil 测试辅助函数, 只应在 _test.go 中使用, 清理动作都注册在 t.Cleanup 上
+// 独立为子包, 避免 libtools 的非测试代码引入 testing 包; 本包不能依赖 libtools, 否则 libtools 的测试无法引用
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var tempDirs sync.Map // testing.TB -> string
+
+// TempDirT 返回当前测试专用的临时目录, 同一个 t 多次调用返回同一目录, 测试结束后自动删除
+func TempDirT(t testing.TB) string {
+	t.Helper()
+	if dir, ok := tempDirs.Load(t); ok {
+		return dir.(string)
+	}
+
+	dir := t.TempDir()
+	tempDirs.Store(t, dir)
+	t.Cleanup(func() { tempDirs.Delete(t) })
+	return dir
+}
+
+// WriteTempFileT 在 TempDirT 下写入文件并返回完整路径, name 可以带子目录(如 a/b.txt), 写入失败时终止测试
+func WriteTempFileT(t testing.TB, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(TempDirT(t), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("WriteTempFileT mkdir fail: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteTempFileT write fail: %v", err)
+	}
+
+	return path
+}
+
+// WithTempEnvT 在测试期间设置环境变量, 测试结束后恢复原值(原来未设置的会被删除); 不能用于 t.Parallel 的测试
+func WithTempEnvT(t testing.TB, key, val string) {
+	t.Helper()
+	t.Setenv(key, val)
+}
+
+// assertSecondsUpperBound 小于该值的时间戳按秒处理(1e11 毫秒约为 1973 年, 1e11 秒约为 5138 年)
+const assertSecondsUpperBound = int64(1e11)
+
+// toAssertMillis 统一为毫秒时间戳
+func toAssertMillis(ts int64) int64 {
+	if ts > -assertSecondsUpperBound && ts < assertSecondsUpperBound {
+		return ts * 1000
+	}
+	return ts
+}
+
+func formatAssertMillis(ms int64) string {
+	return time.UnixMilli(ms).Format("2006-01-02 15:04:05.000")
+}
+
+// AssertTimeWithin 断言两个时间戳相差不超过 delta, 秒与毫秒时间戳可以混用(按数值大小自动识别), 不相等时报告错误并返回 false
+func AssertTimeWithin(t testing.TB, want, got int64, delta time.Duration) bool {
+	t.Helper()
+	wantMs, gotMs := toAssertMillis(want), toAssertMillis(got)
+	diff := gotMs - wantMs
+	if diff < 0 {
+		diff = -diff
+	}
+	if time.Duration(diff)*time.Millisecond <= delta {
+		return true
+	}
+
+	t.Errorf("time not within %v, want: %d (%s), got: %d (%s), diff: %v", delta,
+		want, formatAssertMillis(wantMs), got, formatAssertMillis(gotMs), time.Duration(diff)*time.Millisecond)
+	return false
+}
+
+// AssertAmountEqual 按数值比较两个金额字符串, 如 "1.5" 与 "1.50" 相等, 无法解析或不相等时报告错误并返回 false
+func AssertAmountEqual(t testing.TB, wantStr, gotStr string) bool {
+	t.Helper()
+	want, err := decimal.NewFromString(wantStr)
+	if err != nil {
+		t.Errorf("AssertAmountEqual want is not a number: %q", wantStr)
+		return false
+	}
+	got, err := decimal.NewFromString(gotStr)
+	if err != nil {
+		t.Errorf("AssertAmountEqual got is not a number: %q", gotStr)
+		return false
+	}
+	if !want.Equal(got) {
+		t.Errorf("amount not equal, want: %s, got: %s", wantStr, gotStr)
+		return false
+	}
+
+	return true
+}
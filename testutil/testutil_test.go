@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTempFixtures(t *testing.T) {
+	var dir string
+	t.Run("fixtures", func(t *testing.T) {
+		dir = TempDirT(t)
+		if TempDirT(t) != dir {
+			t.Fatal("TempDirT should return same dir for same t")
+		}
+
+		path := WriteTempFileT(t, "a/b.txt", []byte("hello"))
+		if path != filepath.Join(dir, "a", "b.txt") {
+			t.Fatalf("unexpected path: %s", path)
+		}
+		if buf, _ := os.ReadFile(path); string(buf) != "hello" {
+			t.Fatalf("unexpected content: %s", buf)
+		}
+
+		WithTempEnvT(t, "LIBTOOLS_TEMP_ENV_TEST", "1")
+		if os.Getenv("LIBTOOLS_TEMP_ENV_TEST") != "1" {
+			t.Fatal("env not set")
+		}
+	})
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("temp dir should be removed, err: %v", err)
+	}
+	if _, ok := os.LookupEnv("LIBTOOLS_TEMP_ENV_TEST"); ok {
+		t.Fatal("env should be restored")
+	}
+}
+
+// recordTB 记录 Errorf 调用, 用于测试断言失败的情况
+type recordTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordTB) Helper() {}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.failed = true
+}
+
+func TestAssertTimeWithin(t *testing.T) {
+	now := time.Now().UnixMilli()
+	if !AssertTimeWithin(t, now, now/1000, time.Second) || !AssertTimeWithin(t, now/1000, now/1000*1000+500, time.Second) {
+		t.Fatal("seconds and millis should be comparable")
+	}
+
+	r := &recordTB{TB: t}
+	if AssertTimeWithin(r, now, now+2000, time.Second) || !r.failed {
+		t.Fatal("diff over delta should fail")
+	}
+}
+
+func TestAssertAmountEqual(t *testing.T) {
+	if !AssertAmountEqual(t, "1.5", "1.50") || !AssertAmountEqual(t, "-0", "0.00") {
+		t.Fatal("equal amounts should pass")
+	}
+
+	r := &recordTB{TB: t}
+	if AssertAmountEqual(r, "1.5", "1.51") || !r.failed {
+		t.Fatal("different amounts should fail")
+	}
+	r = &recordTB{TB: t}
+	if AssertAmountEqual(r, "1.5", "abc") || !r.failed {
+		t.Fatal("invalid amount should fail")
+	}
+}
@@ -0,0 +1,63 @@
+package libtools
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracer 链路追踪的最小接口, 库本身不依赖 OpenTelemetry
+// 使用 otel 时以 -tags otel 编译并调用 EnableTracing(tp), 也可以自行实现后通过 SetTracer 接入其他系统
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span 一次操作的追踪区间
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+var (
+	tracerMu sync.RWMutex
+	tracer   Tracer
+)
+
+// SetTracer 设置全局 Tracer, 传入 nil 关闭追踪
+func SetTracer(t Tracer) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	tracer = t
+}
+
+// StartSpan 开始一个 span, 未启用追踪时返回不做任何事的 span, 调用方无需判断
+func StartSpan(ctx context.Context, spanName string) (context.Context, Span) {
+	tracerMu.RLock()
+	t := tracer
+	tracerMu.RUnlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+
+	return t.Start(ctx, spanName)
+}
+
+// endSpan 记录错误并结束 span, 一般配合命名返回值在 defer 中使用
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
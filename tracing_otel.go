@@ -0,0 +1,66 @@
+//go:build otel
+// +build otel
+
+package libtools
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelInstrumentationName 作为 instrumentation scope 出现在 span 上
+const otelInstrumentationName = "github.com/chester84/libtools"
+
+// EnableTracing 使用 OpenTelemetry 的 TracerProvider 为 HttpRequest、FileDownload 等生成 span, 传入 nil 关闭
+// 仅在以 -tags otel 编译时可用
+func EnableTracing(tp trace.TracerProvider) {
+	if tp == nil {
+		SetTracer(nil)
+		return
+	}
+
+	SetTracer(otelTracer{tracer: tp.Tracer(otelInstrumentationName)})
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, spanName)
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s otelSpan) End() {
+	s.span.End()
+}
@@ -0,0 +1,60 @@
+package libtools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+
+func (s *testSpan) RecordError(err error) { s.err = err }
+
+func (s *testSpan) End() { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &testSpan{name: spanName, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestHttpRequestSpan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	_, _, _ = HttpRequest(HttpMethodGet, server.URL+"/path?token=secret", nil, HttpApplicationJSON, nil)
+	_, _, _ = HttpRequest(HttpMethodGet, "http://127.0.0.1:0/", nil, HttpApplicationJSON, nil)
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("spans: %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "HTTP GET" || !span.ended || span.attrs["url.full"] != server.URL+"/path" || span.attrs["http.response.status_code"] != http.StatusTeapot {
+		t.Errorf("span: %+v", span)
+	}
+	if span = tracer.spans[1]; span.err == nil || !span.ended {
+		t.Errorf("failed request span: %+v", span)
+	}
+}
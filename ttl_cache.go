@@ -0,0 +1,129 @@
+package libtools
+
+import (
+	"sync"
+	"time"
+)
+
+// 每写入多少次顺带清理一次过期数据
+const ttlCacheSweepEvery = 1024
+
+type ttlCacheItem struct {
+	value    interface{}
+	expireAt int64 // 毫秒, 0 表示永不过期
+}
+
+// TTLCache 进程内带过期时间的 kv 缓存,并发安全
+type TTLCache struct {
+	mu         sync.RWMutex
+	items      map[string]ttlCacheItem
+	defaultTTL time.Duration
+	writes     int
+}
+
+// NewTTLCache defaultTTL <= 0 时,未指定 ttl 的数据永不过期
+func NewTTLCache(defaultTTL time.Duration) *TTLCache {
+	return &TTLCache{
+		items:      make(map[string]ttlCacheItem),
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Set 写入缓存, 不传 ttl 时使用默认过期时间
+func (c *TTLCache) Set(key string, value interface{}, ttl ...time.Duration) {
+	expire := c.defaultTTL
+	if len(ttl) > 0 {
+		expire = ttl[0]
+	}
+
+	var expireAt int64
+	if expire > 0 {
+		expireAt = GetUnixMillis() + expire.Milliseconds()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items[key] = ttlCacheItem{value: value, expireAt: expireAt}
+	c.writes++
+	if c.writes >= ttlCacheSweepEvery {
+		c.writes = 0
+		c.deleteExpired(GetUnixMillis())
+	}
+}
+
+func (c *TTLCache) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	item, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if item.expireAt > 0 && item.expireAt <= GetUnixMillis() {
+		c.deleteIfExpired(key)
+		return nil, false
+	}
+
+	return item.value, true
+}
+
+// deleteIfExpired 释放读锁后其他 goroutine 可能已重新 Set, 需在写锁内再次确认已过期
+func (c *TTLCache) deleteIfExpired(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.items[key]; ok && item.expireAt > 0 && item.expireAt <= GetUnixMillis() {
+		delete(c.items, key)
+	}
+}
+
+// TTL 返回剩余有效时间, 不存在返回 false, 永不过期返回 0
+func (c *TTLCache) TTL(key string) (time.Duration, bool) {
+	c.mu.RLock()
+	item, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return 0, false
+	}
+	if item.expireAt == 0 {
+		return 0, true
+	}
+
+	left := item.expireAt - GetUnixMillis()
+	if left <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(left) * time.Millisecond, true
+}
+
+func (c *TTLCache) Delete(key string) {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+}
+
+// Len 包含尚未清理的过期数据
+func (c *TTLCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.items)
+}
+
+func (c *TTLCache) DeleteExpired() {
+	c.mu.Lock()
+	c.deleteExpired(GetUnixMillis())
+	c.mu.Unlock()
+}
+
+func (c *TTLCache) deleteExpired(now int64) {
+	for k, item := range c.items {
+		if item.expireAt > 0 && item.expireAt <= now {
+			delete(c.items, k)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package libtools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// UploadPolicy 某一类业务文件的命名规则
+// Pattern 支持 ExpandPattern 的全部占位符({env}、{Y}/{m}/{d}、{rand6} 等), 以及:
+// {hash}: BuildHashName 生成的 XX/YYYY/md5.ext(不含环境前缀), 内容相同的文件得到相同的 key
+// {md5}: 文件 md5; {ext}: 扩展名; {key}: 业务主键, 如订单号
+type UploadPolicy struct {
+	Pattern string
+	// DefaultExt 上传方未给出扩展名时使用
+	DefaultExt string
+}
+
+// UploadMeta 生成文件名所需的信息, 按 Pattern 用到的占位符填写
+type UploadMeta struct {
+	Md5 string
+	Ext string
+	Key string
+	// Time 毫秒, 用于日期占位符, 为 0 时取当前时间
+	Time int64
+}
+
+// UploadNamer 按业务类型生成上传文件的存储路径(如 S3 key), 替代散落各处的 fmt.Sprintf 拼接
+//
+//	namer := NewUploadNamer()
+//	namer.Register("idcard", UploadPolicy{Pattern: "{env}/idcard/{Y}/{m}/{d}/{hash}"})
+//	namer.Register("contract", UploadPolicy{Pattern: "{env}/contract/{key}/{key}-{YmdHis}.{ext}", DefaultExt: "pdf"})
+//	key, err := namer.NameBytes("idcard", buf, "jpg", "")
+type UploadNamer struct {
+	mu       sync.RWMutex
+	policies map[string]UploadPolicy
+}
+
+var (
+	// uploadKeyRegexp 业务主键只保留安全字符, 避免 "../" 等跳出目录
+	uploadKeyRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	uploadExtRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+func NewUploadNamer() *UploadNamer {
+	return &UploadNamer{policies: make(map[string]UploadPolicy)}
+}
+
+// Register 注册或替换某类业务的规则, Pattern 必须包含 {hash}、{md5}、{key}、{rand} 之一, 保证文件名不会互相覆盖
+func (n *UploadNamer) Register(category string, policy UploadPolicy) error {
+	if category == "" {
+		return fmt.Errorf("upload category is empty")
+	}
+	unique := false
+	for _, token := range []string{"{hash}", "{md5}", "{key}", "{rand"} {
+		if strings.Contains(policy.Pattern, token) {
+			unique = true
+			break
+		}
+	}
+	if !unique {
+		return fmt.Errorf("upload pattern of %s must contain {hash}, {md5}, {key} or {rand}: %s", category, policy.Pattern)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.policies[category] = policy
+	return nil
+}
+
+// Name 按 category 的规则生成文件路径
+func (n *UploadNamer) Name(category string, meta UploadMeta) (string, error) {
+	n.mu.RLock()
+	policy, ok := n.policies[category]
+	n.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("upload category is not registered: %s", category)
+	}
+
+	ext := strings.ToLower(uploadExtRegexp.ReplaceAllString(strings.TrimPrefix(meta.Ext, "."), ""))
+	if ext == "" {
+		ext = policy.DefaultExt
+	}
+	key := uploadKeyRegexp.ReplaceAllString(meta.Key, "_")
+
+	if (strings.Contains(policy.Pattern, "{hash}") || strings.Contains(policy.Pattern, "{md5}")) && meta.Md5 == "" {
+		return "", fmt.Errorf("upload category %s need file md5", category)
+	}
+	if strings.Contains(policy.Pattern, "{key}") && strings.Trim(key, "_") == "" {
+		return "", fmt.Errorf("upload category %s need business key", category)
+	}
+
+	var hashPath string
+	if meta.Md5 != "" {
+		hashDir, hashName := BuildHashName(meta.Md5, ext)
+		// BuildHashName 的目录以环境名开头, 环境前缀交给 Pattern 中的 {env} 决定
+		hashPath = strings.TrimPrefix(hashName, hashDir[:strings.Index(hashDir, "/")+1])
+	}
+
+	// 先展开日期等占位符, 再替换业务值, 业务值中的 {..} 不会被再次展开
+	name := ExpandPattern(policy.Pattern, meta.Time)
+	name = strings.NewReplacer("{hash}", hashPath, "{md5}", meta.Md5, "{ext}", ext, "{key}", key).Replace(name)
+
+	return strings.TrimPrefix(name, "/"), nil
+}
+
+// NameBytes 按文件内容计算 md5 后生成路径
+func (n *UploadNamer) NameBytes(category string, buf []byte, ext, key string) (string, error) {
+	return n.Name(category, UploadMeta{Md5: Md5Bytes(buf), Ext: ext, Key: key})
+}
+
+// NameFile 按本地文件的内容与扩展名生成路径
+func (n *UploadNamer) NameFile(category, localFile, key string) (string, error) {
+	_, _, fileMd5, err := BuildFileHashName(localFile)
+	if err != nil {
+		return "", err
+	}
+
+	return n.Name(category, UploadMeta{Md5: fileMd5, Ext: GetFileExt(localFile), Key: key})
+}
@@ -0,0 +1,36 @@
+package libtools
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUploadNamer(t *testing.T) {
+	namer := NewUploadNamer()
+	if err := namer.Register("avatar", UploadPolicy{Pattern: "avatar/{Y}/{m}"}); err == nil {
+		t.Error("pattern without unique token should be rejected")
+	}
+	_ = namer.Register("idcard", UploadPolicy{Pattern: "{env}/idcard/{Y}/{m}/{d}/{hash}"})
+	_ = namer.Register("contract", UploadPolicy{Pattern: "contract/{key}/{key}-{Ymd}.{ext}", DefaultExt: "pdf"})
+
+	buf := []byte("fake id card image")
+	md5 := Md5Bytes(buf)
+	got, err := namer.NameBytes("idcard", buf, ".JPG", "")
+	want := `^[^/]+/idcard/\d{4}/\d{2}/\d{2}/` + md5[:2] + `/` + md5[2:6] + `/` + md5 + `\.jpg$`
+	if err != nil || !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("idcard: %s, %v", got, err)
+	}
+
+	ts := time.Date(2024, 5, 12, 10, 0, 0, 0, time.Local).UnixMilli()
+	if got, err = namer.Name("contract", UploadMeta{Key: "../LN2024{Y}", Time: ts}); err != nil || got != "contract/_LN2024_Y_/_LN2024_Y_-20240512.pdf" {
+		t.Errorf("contract: %s, %v", got, err)
+	}
+
+	if _, err = namer.Name("contract", UploadMeta{}); err == nil {
+		t.Error("missing key should fail")
+	}
+	if _, err = namer.Name("unknown", UploadMeta{Md5: md5}); err == nil {
+		t.Error("unknown category should fail")
+	}
+}
@@ -0,0 +1,152 @@
+package libtools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// UploadCategory 上传文件的业务类型, 不同类型允许的文件格式、大小与图片尺寸不同
+type UploadCategory string
+
+// 内置的业务类型, 规则可以用 RegisterUploadRule 覆盖
+const (
+	UploadAvatar      UploadCategory = "avatar"      // 头像
+	UploadIDCard      UploadCategory = "id_card"     // 证件照、手持照等 KYC 图片
+	UploadDocument    UploadCategory = "document"    // 合同、证明等, 图片或 pdf
+	UploadSpreadsheet UploadCategory = "spreadsheet" // 批量导入的 xlsx/csv
+)
+
+var (
+	ErrUploadCategory       = errors.New("upload category is not registered")
+	ErrUploadEmpty          = errors.New("upload file is empty")
+	ErrUploadTooLarge       = errors.New("upload file is too large")
+	ErrUploadTypeNotAllowed = errors.New("upload file type is not allowed")
+	ErrUploadDimension      = errors.New("upload image dimension is not allowed")
+)
+
+// UploadRule 一类业务文件的校验规则, 零值字段表示不限制
+type UploadRule struct {
+	// AllowedTypes 允许的文件类型, 写法同 FileDownloadOptions.AllowedTypes: 扩展名(pdf)、MIME(image/jpeg)或 MIME 大类(image/*)
+	// 按文件头识别, 识别不了的文本文件按 http.DetectContentType 取 MIME, 如 text/plain
+	AllowedTypes []string
+	MaxBytes     int64
+	// 图片尺寸(像素), 设置任意一项时文件必须是可解码的图片(jpeg/png/gif)
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+}
+
+func (r UploadRule) checkDimension() bool {
+	return r.MinWidth > 0 || r.MinHeight > 0 || r.MaxWidth > 0 || r.MaxHeight > 0
+}
+
+var (
+	uploadRulesMu sync.RWMutex
+	uploadRules   = map[UploadCategory]UploadRule{
+		UploadAvatar: {
+			AllowedTypes: []string{"jpg", "png", "gif"},
+			MaxBytes:     2 << 20,
+			MinWidth:     64, MinHeight: 64, MaxWidth: 4096, MaxHeight: 4096,
+		},
+		UploadIDCard: {
+			AllowedTypes: []string{"jpg", "png"},
+			MaxBytes:     10 << 20,
+			MinWidth:     400, MinHeight: 300, MaxWidth: 8000, MaxHeight: 8000,
+		},
+		UploadDocument: {
+			AllowedTypes: []string{"jpg", "png", "pdf"},
+			MaxBytes:     20 << 20,
+		},
+		UploadSpreadsheet: {
+			AllowedTypes: []string{"xlsx", "xls", "text/plain", "text/csv"},
+			MaxBytes:     20 << 20,
+		},
+	}
+)
+
+// RegisterUploadRule 注册或替换某类业务的校验规则
+func RegisterUploadRule(category UploadCategory, rule UploadRule) {
+	uploadRulesMu.Lock()
+	defer uploadRulesMu.Unlock()
+	uploadRules[category] = rule
+}
+
+// GetUploadRule 取某类业务的校验规则
+func GetUploadRule(category UploadCategory) (UploadRule, bool) {
+	uploadRulesMu.RLock()
+	defer uploadRulesMu.RUnlock()
+	rule, ok := uploadRules[category]
+	return rule, ok
+}
+
+// ValidateUpload 按业务类型校验上传文件的内容: 文件头识别的类型、大小与图片尺寸, 不信任客户端给出的文件名与 Content-Type
+// 返回的错误可用 errors.Is 判断 ErrUploadTooLarge、ErrUploadTypeNotAllowed 等
+func ValidateUpload(buf []byte, category UploadCategory) error {
+	rule, ok := GetUploadRule(category)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUploadCategory, category)
+	}
+	if len(buf) == 0 {
+		return ErrUploadEmpty
+	}
+	if rule.MaxBytes > 0 && int64(len(buf)) > rule.MaxBytes {
+		return fmt.Errorf("%w: %d bytes, limit: %d", ErrUploadTooLarge, len(buf), rule.MaxBytes)
+	}
+
+	extension, mime := DetectUploadType(buf)
+	if !isAllowedFileType(rule.AllowedTypes, extension, mime) {
+		return fmt.Errorf("%w: %s, extension: %s, mime: %s", ErrUploadTypeNotAllowed, category, extension, mime)
+	}
+
+	if rule.checkDimension() {
+		config, _, err := image.DecodeConfig(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("%w: could not decode image: %v", ErrUploadDimension, err)
+		}
+		if config.Width < rule.MinWidth || config.Height < rule.MinHeight ||
+			(rule.MaxWidth > 0 && config.Width > rule.MaxWidth) || (rule.MaxHeight > 0 && config.Height > rule.MaxHeight) {
+			return fmt.Errorf("%w: %dx%d", ErrUploadDimension, config.Width, config.Height)
+		}
+	}
+
+	return nil
+}
+
+// DetectUploadType 按文件头识别扩展名与 MIME, 识别不了时按 http.DetectContentType 取 MIME(不含 charset 等参数), 扩展名为空
+func DetectUploadType(buf []byte) (extension, mime string) {
+	extension, mime, err := DetectFileByteType(buf)
+	if err == nil && mime != "" {
+		return extension, mime
+	}
+
+	mime = http.DetectContentType(buf)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = strings.TrimSpace(mime[:i])
+	}
+	return "", mime
+}
+
+// isAllowedFileType allowed 为空时不限制, 支持扩展名、MIME 与 MIME 大类(image/*)
+func isAllowedFileType(allowed []string, extension, mime string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, item := range allowed {
+		item = strings.ToLower(item)
+		if (extension != "" && item == extension) || item == mime {
+			return true
+		}
+		if strings.HasSuffix(item, "/*") && strings.HasPrefix(mime, strings.TrimSuffix(item, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
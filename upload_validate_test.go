@@ -0,0 +1,98 @@
+package libtools
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"testing"
+)
+
+func pngBytesT(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateUpload(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+	csv := []byte("mobile,amount\n08123456789,150000\n")
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		category UploadCategory
+		want     error
+	}{
+		{"avatar ok", pngBytesT(t, 128, 128), UploadAvatar, nil},
+		{"avatar too small", pngBytesT(t, 32, 32), UploadAvatar, ErrUploadDimension},
+		{"avatar pdf", pdf, UploadAvatar, ErrUploadTypeNotAllowed},
+		{"id card ok", pngBytesT(t, 800, 500), UploadIDCard, nil},
+		{"id card too small", pngBytesT(t, 300, 200), UploadIDCard, ErrUploadDimension},
+		{"document pdf", pdf, UploadDocument, nil},
+		{"document small image", pngBytesT(t, 10, 10), UploadDocument, nil},
+		{"document csv", csv, UploadDocument, ErrUploadTypeNotAllowed},
+		{"spreadsheet csv", csv, UploadSpreadsheet, nil},
+		{"spreadsheet pdf", pdf, UploadSpreadsheet, ErrUploadTypeNotAllowed},
+		{"empty", nil, UploadDocument, ErrUploadEmpty},
+		{"unknown category", pdf, UploadCategory("unknown"), ErrUploadCategory},
+	}
+	for _, c := range cases {
+		if err := ValidateUpload(c.buf, c.category); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	RegisterUploadRule("receipt", UploadRule{AllowedTypes: []string{"image/*"}, MaxBytes: 100})
+	if err := ValidateUpload(pngBytesT(t, 10, 10), "receipt"); err != nil {
+		t.Errorf("receipt: %v", err)
+	}
+	if err := ValidateUpload(pngBytesT(t, 600, 600), "receipt"); !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("receipt too large: %v", err)
+	}
+	// 声明了尺寸限制但不是图片
+	RegisterUploadRule("banner", UploadRule{MinWidth: 100})
+	if err := ValidateUpload(pdf, "banner"); !errors.Is(err, ErrUploadDimension) {
+		t.Errorf("banner: %v", err)
+	}
+}
+
+func TestGetFileExtension(t *testing.T) {
+	files := map[string][]byte{
+		"a.PNG":       pngBytesT(t, 1, 1),
+		"orders.CSV":  []byte("id,amount\n1,100\n"),
+		"contract.md": []byte("%PDF-1.4\n"),
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, content := range files {
+		w, _ := mw.CreateFormFile(name, name)
+		_, _ = w.Write(content)
+	}
+	_ = mw.Close()
+
+	form, err := multipart.NewReader(&body, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.PNG": "png", "orders.CSV": "csv", "contract.md": "pdf"}
+	for name, ext := range want {
+		h := form.File[name][0]
+		f, _ := h.Open()
+		got, err := GetFileExtension(f, h)
+		if err != nil || got != ext {
+			t.Errorf("%s: got %s, %v, want %s", name, got, err, ext)
+		}
+		// 读取后回到开头
+		head := make([]byte, 4)
+		_, _ = f.Read(head)
+		if !bytes.Equal(head, files[name][:4]) {
+			t.Errorf("%s: file offset is not reset", name)
+		}
+		_ = f.Close()
+	}
+}
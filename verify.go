@@ -2,6 +2,8 @@ package libtools
 
 import (
 	"regexp"
+	"strings"
+	"time"
 )
 
 // email verify
@@ -37,3 +39,27 @@ func VerifyHttpUserAgent(userAgent string) bool {
 
 	return false
 }
+
+// idCardWeights 18 位身份证号前 17 位的加权因子, 见 GB 11643-1999
+var idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// idCardCheckDigit 按 ISO 7064 MOD 11-2 计算前 17 位对应的校验码
+func idCardCheckDigit(first17 string) byte {
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(first17[i]-'0') * idCardWeights[i]
+	}
+	return "10X98765432"[sum%11]
+}
+
+// VerifyIDCard 18 位居民身份证号校验: 格式、出生日期与校验码, 末位 x 不区分大小写
+func VerifyIDCard(id string) bool {
+	if len(id) != 18 || !IsNumber(id[:17]) {
+		return false
+	}
+	if _, err := time.Parse("20060102", id[6:14]); err != nil {
+		return false
+	}
+
+	return idCardCheckDigit(id) == strings.ToUpper(id[17:])[0]
+}
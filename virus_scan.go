@@ -0,0 +1,309 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+var (
+	// ErrVirusFound 文件被识别为病毒, 具体名称见 VirusFoundError.Threat
+	ErrVirusFound = errors.New("virus found")
+	// ErrScanUnavailable 扫描服务不可用(连接失败、超时等)且配置为 fail-closed
+	ErrScanUnavailable = errors.New("virus scan unavailable")
+	// ErrScanRejected 扫描服务拒绝扫描该文件, 如超过 clamd 的 StreamMaxLength, 不受 fail-open 影响
+	ErrScanRejected = errors.New("virus scan rejected")
+)
+
+// VirusFoundError 扫描发现的病毒, errors.Is(err, ErrVirusFound) 为 true
+type VirusFoundError struct {
+	Scanner string
+	Threat  string
+}
+
+func (e *VirusFoundError) Error() string {
+	return fmt.Sprintf("virus found by %s: %s", e.Scanner, e.Threat)
+}
+
+func (e *VirusFoundError) Is(target error) bool {
+	return target == ErrVirusFound
+}
+
+// Scanner 病毒扫描, 文件干净返回 nil, 发现病毒返回 *VirusFoundError, 其他错误表示扫描本身失败
+// 扫描失败时, 只有传输层错误(net.Error、超时等)或包装了 ErrScanUnavailable 的错误会按 fail-open 放行
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+var (
+	virusScannerMu sync.RWMutex
+	virusScanner   Scanner
+	scanFailOpen   bool
+)
+
+// SetScanner 设置 ScanBytes/ScanFile 使用的扫描器, 传入 nil 关闭扫描
+// failOpen 为 true 时扫描服务不可用(连接失败、超时)只记录日志并放行, 为 false 时返回 ErrScanUnavailable 拒绝文件
+// 扫描服务正常返回但无法给出结论(文件过大等)时始终返回 ErrScanRejected 拒绝文件
+func SetScanner(s Scanner, failOpen bool) {
+	virusScannerMu.Lock()
+	defer virusScannerMu.Unlock()
+	virusScanner = s
+	scanFailOpen = failOpen
+}
+
+// ScanBytes 扫描上传的文件内容, 未设置扫描器时直接返回 nil
+func ScanBytes(buf []byte) error {
+	return scanReader(func() (io.Reader, func(), error) {
+		return bytes.NewReader(buf), func() {}, nil
+	})
+}
+
+// ScanFile 扫描本地文件, 未设置扫描器时直接返回 nil
+func ScanFile(path string) error {
+	return scanReader(func() (io.Reader, func(), error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, func() { _ = f.Close() }, nil
+	})
+}
+
+func scanReader(open func() (io.Reader, func(), error)) (err error) {
+	virusScannerMu.RLock()
+	s, failOpen := virusScanner, scanFailOpen
+	virusScannerMu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	r, closeFn, err := open()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	ctx, span := StartSpan(context.Background(), "VirusScan")
+	defer func() { endSpan(span, err) }()
+
+	err = s.Scan(ctx, r)
+	if err == nil || errors.Is(err, ErrVirusFound) {
+		if err != nil {
+			logs.Warning("[VirusScan] %v", err)
+		}
+		return err
+	}
+
+	if !isScanUnavailable(err) {
+		logs.Warning("[VirusScan] scan rejected, err: %v", err)
+		if errors.Is(err, ErrScanRejected) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrScanRejected, err)
+	}
+
+	if failOpen {
+		logs.Warning("[VirusScan] scan unavailable, file is allowed by fail-open, err: %v", err)
+		return nil
+	}
+	if errors.Is(err, ErrScanUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrScanUnavailable, err)
+}
+
+// isScanUnavailable 只有连接失败、超时、连接被断开等传输层错误才视为扫描服务不可用
+func isScanUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrScanUnavailable) || errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// SaveUploadFile 扫描上传的文件后按 md5 保存到 LocalHashDir, 返回 BuildHashName 生成的 hashName; 扫描不通过时不落盘
+func SaveUploadFile(buf []byte, suffix string) (hashName string, err error) {
+	if err = ScanBytes(buf); err != nil {
+		return
+	}
+
+	hashDir, hashName, _ := BuildUploadFileHashName(buf, suffix)
+	if err = os.MkdirAll(LocalHashDir(hashDir), 0755); err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(LocalHashDir(hashName), buf, 0644); err != nil {
+		return "", err
+	}
+	return hashName, nil
+}
+
+// ClamdScanner 通过 clamd 的 TCP 接口(INSTREAM)扫描, 文件内容不落盘
+type ClamdScanner struct {
+	Addr    string        // host:port, 一般为 127.0.0.1:3310
+	Timeout time.Duration // 连接与扫描的总超时, 默认 60 秒
+	// ChunkSize 每次发送的数据量, 默认 64KB; clamd 的 StreamMaxLength 限制总大小, 超过时返回错误
+	ChunkSize int
+}
+
+func (c *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	chunkSize := c.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 64 * 1024
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return fmt.Errorf("clamd dial %s fail: %w", c.Addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// z 前缀的命令以 \0 结尾, 响应也以 \0 结尾
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("clamd write fail: %w", err)
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err = conn.Write(buf[:4+n]); err != nil {
+				// clamd 超过大小限制时会先返回错误并关闭连接, 尝试读取其原因
+				if reply, replyErr := clamdReadReply(conn); replyErr == nil {
+					if replyErr = parseClamdReply(reply); replyErr != nil {
+						return replyErr
+					}
+				}
+				return fmt.Errorf("clamd write fail: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read file fail: %v", readErr)
+		}
+	}
+	if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("clamd write fail: %w", err)
+	}
+
+	reply, err := clamdReadReply(conn)
+	if err != nil {
+		return fmt.Errorf("clamd read reply fail: %w", err)
+	}
+	return parseClamdReply(reply)
+}
+
+func clamdReadReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseClamdReply 解析 "stream: OK" / "stream: Eicar-Signature FOUND" / "... ERROR", ERROR 返回 ErrScanRejected
+func parseClamdReply(reply string) error {
+	result := reply
+	if i := strings.Index(reply, ": "); i >= 0 {
+		result = reply[i+2:]
+	}
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &VirusFoundError{Scanner: "clamd", Threat: strings.TrimSuffix(result, " FOUND")}
+	}
+	return fmt.Errorf("%w: clamd: %s", ErrScanRejected, reply)
+}
+
+// HTTPScanner 通过 HTTP 接口扫描, 文件内容作为请求体 POST 到 URL
+// 默认按 {"infected": bool, "threat": "..."} 解析 2xx 响应, 其他格式通过 ParseResponse 自定义
+type HTTPScanner struct {
+	URL     string
+	Header  http.Header
+	Client  *http.Client // 为空时使用 60 秒超时的 client
+	Timeout time.Duration
+
+	// ParseResponse 自定义响应解析, 返回 nil 表示干净, *VirusFoundError 表示发现病毒
+	ParseResponse func(statusCode int, body []byte) error
+}
+
+func (s *HTTPScanner) Scan(ctx context.Context, r io.Reader) error {
+	client := s.Client
+	if client == nil {
+		timeout := s.Timeout
+		if timeout <= 0 {
+			timeout = 60 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, r)
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if s.ParseResponse != nil {
+		return s.ParseResponse(resp.StatusCode, body)
+	}
+	if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout {
+		return fmt.Errorf("%w: scan api get status code: %d", ErrScanUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("scan api get unexpected status code: %d, body: %s", resp.StatusCode, SubString(string(body), 0, 200))
+	}
+	var result struct {
+		Infected *bool  `json:"infected"`
+		Threat   string `json:"threat"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil || result.Infected == nil {
+		return fmt.Errorf("scan api get invalid response: %s", SubString(string(body), 0, 200))
+	}
+	if *result.Infected {
+		return &VirusFoundError{Scanner: "http", Threat: result.Threat}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package libtools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+// eicar 不使用真正的 EICAR 测试串, 避免本文件被杀毒软件拦截, 模拟服务按 EICAR 关键字报毒
+const eicar = "fake EICAR test payload"
+
+// startClamdT 模拟 clamd 的 INSTREAM, 内容包含 EICAR 时报毒, 超过 maxSize(大于 0 时)返回 size limit 错误
+func startClamdT(t *testing.T, maxSize int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+					_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if binary.Read(r, binary.BigEndian, &size) != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+						return
+					}
+					if maxSize > 0 && data.Len() > maxSize {
+						_, _ = conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+						return
+					}
+				}
+				reply := "stream: OK"
+				if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+					reply = "stream: Eicar-Test-Signature FOUND"
+				}
+				_, _ = conn.Write([]byte(reply + "\x00"))
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestClamdScanner(t *testing.T) {
+	s := &ClamdScanner{Addr: startClamdT(t, 0), ChunkSize: 16}
+
+	if err := s.Scan(context.Background(), bytes.NewReader(bytes.Repeat([]byte("clean"), 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.Scan(context.Background(), bytes.NewReader([]byte(eicar)))
+	var found *VirusFoundError
+	if !errors.As(err, &found) || found.Threat != "Eicar-Test-Signature" || !errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect virus found, got: %v", err)
+	}
+
+	cases := map[string]string{
+		"stream: OK":                          "",
+		"stream: Win.Test.EICAR_HDB-1 FOUND":  "found",
+		"INSTREAM size limit exceeded. ERROR": "error",
+	}
+	for reply, want := range cases {
+		err := parseClamdReply(reply)
+		switch want {
+		case "":
+			if err != nil {
+				t.Errorf("%s: %v", reply, err)
+			}
+		case "found":
+			if !errors.Is(err, ErrVirusFound) {
+				t.Errorf("%s: %v", reply, err)
+			}
+		default:
+			if err == nil || errors.Is(err, ErrVirusFound) {
+				t.Errorf("%s: %v", reply, err)
+			}
+		}
+	}
+}
+
+func TestHTTPScanner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Header.Get("X-Api-Key") != "secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case bytes.Contains(body, []byte("EICAR")):
+			_, _ = w.Write([]byte(`{"infected":true,"threat":"EICAR"}`))
+		default:
+			_, _ = w.Write([]byte(`{"infected":false}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := &HTTPScanner{URL: srv.URL, Header: http.Header{"X-Api-Key": {"secret"}}}
+	if err := s.Scan(context.Background(), bytes.NewReader([]byte("clean"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Scan(context.Background(), bytes.NewReader([]byte(eicar))); !errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect virus found, got: %v", err)
+	}
+
+	s.Header = nil
+	if err := s.Scan(context.Background(), bytes.NewReader([]byte("clean"))); err == nil || errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect scan error, got: %v", err)
+	}
+}
+
+func TestScanBytes(t *testing.T) {
+	defer SetScanner(nil, false)
+
+	if err := ScanBytes([]byte(eicar)); err != nil {
+		t.Fatalf("scan is disabled, got: %v", err)
+	}
+
+	SetScanner(&ClamdScanner{Addr: startClamdT(t, 0)}, false)
+	if err := ScanBytes([]byte("clean")); err != nil {
+		t.Fatal(err)
+	}
+	path := testutil.WriteTempFileT(t, "eicar.txt", []byte(eicar))
+	if err := ScanFile(path); !errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect virus found, got: %v", err)
+	}
+	if _, err := SaveUploadFile([]byte(eicar), "txt"); !errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect virus found, got: %v", err)
+	}
+
+	// 扫描服务不可用
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	down := ln.Addr().String()
+	_ = ln.Close()
+
+	SetScanner(&ClamdScanner{Addr: down}, false)
+	if err := ScanBytes([]byte("clean")); !errors.Is(err, ErrScanUnavailable) {
+		t.Fatalf("fail-closed expect unavailable, got: %v", err)
+	}
+	SetScanner(&ClamdScanner{Addr: down}, true)
+	if err := ScanBytes([]byte("clean")); err != nil {
+		t.Fatalf("fail-open expect nil, got: %v", err)
+	}
+	// fail-open 不放行病毒
+	SetScanner(&ClamdScanner{Addr: startClamdT(t, 0)}, true)
+	if err := ScanBytes([]byte(eicar)); !errors.Is(err, ErrVirusFound) {
+		t.Fatalf("expect virus found, got: %v", err)
+	}
+	// fail-open 不放行超过 clamd 大小限制的文件
+	SetScanner(&ClamdScanner{Addr: startClamdT(t, 64), ChunkSize: 16}, true)
+	if err := ScanBytes(bytes.Repeat([]byte("large"), 100)); !errors.Is(err, ErrScanRejected) {
+		t.Fatalf("expect scan rejected, got: %v", err)
+	}
+}
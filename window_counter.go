@@ -0,0 +1,134 @@
+package libtools
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type windowBucket struct {
+	slot   int64 // 所属时间片序号, 用于判断是否过期
+	count  int64
+	values []float64
+}
+
+// WindowCounter 滑动窗口统计, 窗口被切分为若干个桶, 随时间滚动淘汰最旧的桶
+// 如统计合作方接口最近 1 分钟的请求量、失败率与耗时分位数
+type WindowCounter struct {
+	mu      sync.Mutex
+	span    time.Duration // 每个桶的时长
+	buckets []windowBucket
+	nowFunc func() time.Time
+}
+
+// windowCounterMaxValues 单个桶最多保留的观测值个数, 超出后丢弃, 避免极端流量下内存失控
+const windowCounterMaxValues = 10000
+
+// NewWindowCounter 如 NewWindowCounter(time.Minute, 60), 每秒一个桶
+func NewWindowCounter(window time.Duration, buckets int) *WindowCounter {
+	if buckets <= 0 {
+		buckets = 10
+	}
+	span := window / time.Duration(buckets)
+	if span <= 0 {
+		span = time.Millisecond
+	}
+
+	return &WindowCounter{
+		span:    span,
+		buckets: make([]windowBucket, buckets),
+		nowFunc: time.Now,
+	}
+}
+
+// current 返回当前时间片对应的桶, 桶内是旧数据时先清空
+func (w *WindowCounter) current() *windowBucket {
+	slot := w.nowFunc().UnixNano() / int64(w.span)
+	b := &w.buckets[slot%int64(len(w.buckets))]
+	if b.slot != slot {
+		b.slot = slot
+		b.count = 0
+		b.values = b.values[:0]
+	}
+
+	return b
+}
+
+// valid 桶是否仍在窗口内
+func (w *WindowCounter) valid(b *windowBucket, slot int64) bool {
+	return b.slot > slot-int64(len(w.buckets)) && b.slot <= slot
+}
+
+// Incr 计数加一
+func (w *WindowCounter) Incr() {
+	w.IncrBy(1)
+}
+
+func (w *WindowCounter) IncrBy(n int64) {
+	w.mu.Lock()
+	w.current().count += n
+	w.mu.Unlock()
+}
+
+// Observe 计数加一并记录观测值(如耗时毫秒数), 用于 Percentile
+func (w *WindowCounter) Observe(v float64) {
+	w.mu.Lock()
+	b := w.current()
+	b.count++
+	if len(b.values) < windowCounterMaxValues {
+		b.values = append(b.values, v)
+	}
+	w.mu.Unlock()
+}
+
+// Count 窗口内的总计数
+func (w *WindowCounter) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slot := w.nowFunc().UnixNano() / int64(w.span)
+	var total int64
+	for i := range w.buckets {
+		if w.valid(&w.buckets[i], slot) {
+			total += w.buckets[i].count
+		}
+	}
+
+	return total
+}
+
+// Rate 窗口内平均每秒的计数
+func (w *WindowCounter) Rate() float64 {
+	window := w.span * time.Duration(len(w.buckets))
+	return float64(w.Count()) / window.Seconds()
+}
+
+// Percentile 窗口内观测值的 p 分位数, p 取值 [0, 100], 没有观测值时返回 0
+func (w *WindowCounter) Percentile(p float64) float64 {
+	w.mu.Lock()
+	slot := w.nowFunc().UnixNano() / int64(w.span)
+	var values []float64
+	for i := range w.buckets {
+		if w.valid(&w.buckets[i], slot) {
+			values = append(values, w.buckets[i].values...)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+
+	return percentileSorted(values, p)
+}
+
+// ErrorRate 以 total 为分母计算 w 的占比, 如 failCounter.ErrorRate(totalCounter), total 为 0 时返回 0
+func (w *WindowCounter) ErrorRate(total *WindowCounter) float64 {
+	n := total.Count()
+	if n == 0 {
+		return 0
+	}
+
+	return float64(w.Count()) / float64(n)
+}
@@ -0,0 +1,36 @@
+package libtools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowCounter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	w := NewWindowCounter(10*time.Second, 10)
+	w.nowFunc = func() time.Time { return now }
+
+	for i := 1; i <= 100; i++ {
+		w.Observe(float64(i))
+	}
+	if w.Count() != 100 {
+		t.Errorf("Count = %d", w.Count())
+	}
+	if w.Rate() != 10 {
+		t.Errorf("Rate = %v", w.Rate())
+	}
+	if p := w.Percentile(50); p != 50.5 {
+		t.Errorf("Percentile(50) = %v", p)
+	}
+
+	now = now.Add(5 * time.Second)
+	w.Incr()
+	if w.Count() != 101 {
+		t.Errorf("Count after 5s = %d", w.Count())
+	}
+
+	now = now.Add(6 * time.Second)
+	if w.Count() != 1 {
+		t.Errorf("Count after window = %d", w.Count())
+	}
+}
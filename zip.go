@@ -0,0 +1,465 @@
+package libtools
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ZipOptions ZipDirectoryWithOptions 的参数, 零值即单线程、默认压缩级别
+type ZipOptions struct {
+	// Workers 大于 1 时并发压缩文件, 压缩结果暂存后按目录顺序依次写入 zip
+	Workers int
+	// Level 压缩级别 1-9, 其他值使用默认级别
+	Level int
+	// StoreExts 只存储不压缩的扩展名(不带点, 不区分大小写), 为空时使用内置的已压缩格式列表, "*" 表示全部只存储
+	StoreExts []string
+	// SpoolSize 并发模式下单个文件压缩结果在内存中暂存的上限, 超过后写入临时文件, 默认 8MB
+	SpoolSize int64
+	// Password 不为空时文件使用 WinZip AES-256 加密, 可用 UnzipAndExtract 或 7-Zip 等工具解压
+	Password string
+}
+
+// zipDefaultStoreExts 本身已经压缩过的格式, 再压缩只会浪费 cpu
+var zipDefaultStoreExts = []string{
+	"zip", "gz", "tgz", "bz2", "xz", "zst", "7z", "rar", "jar", "apk",
+	"jpg", "jpeg", "png", "gif", "webp", "heic", "mp3", "mp4", "mov", "avi", "mkv",
+}
+
+func (o ZipOptions) isStored(name string) bool {
+	exts := o.StoreExts
+	if len(exts) == 0 {
+		exts = zipDefaultStoreExts
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	for _, e := range exts {
+		if e == "*" || strings.ToLower(e) == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o ZipOptions) level() int {
+	if o.Level < flate.BestSpeed || o.Level > flate.BestCompression {
+		return flate.DefaultCompression
+	}
+	return o.Level
+}
+
+type zipEntry struct {
+	path   string
+	header *zip.FileHeader
+}
+
+// ZipDirectory 把 sourceDir 下的文件与目录打包到 zipFile, zip 内的路径相对于 sourceDir
+func ZipDirectory(sourceDir, zipFile string) error {
+	return ZipDirectoryWithOptions(context.Background(), sourceDir, zipFile, ZipOptions{})
+}
+
+// ZipDirectoryWithOptions 同 ZipDirectory, 支持并发压缩与压缩级别; ctx 取消时中断, 出错时删除未完成的 zipFile
+// 符号链接等非普通文件会被跳过
+func ZipDirectoryWithOptions(ctx context.Context, sourceDir, zipFile string, opts ZipOptions) (err error) {
+	ctx, span := StartSpan(ctx, "ZipDirectory")
+	span.SetAttribute("zip.source", sourceDir)
+	span.SetAttribute("zip.workers", opts.Workers)
+	defer func() { endSpan(span, err) }()
+
+	entries, err := zipCollect(sourceDir, zipFile, opts)
+	if err != nil {
+		return
+	}
+	span.SetAttribute("zip.entries", len(entries))
+
+	return zipWriteFile(ctx, zipFile, entries, opts)
+}
+
+// zipWriteFile 把 entries 写入 zipFile, 出错时删除未完成的文件
+func zipWriteFile(ctx context.Context, zipFile string, entries []zipEntry, opts ZipOptions) (err error) {
+	f, err := os.Create(zipFile)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(zipFile)
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	level := opts.level()
+	zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+
+	// 加密需要先得到压缩后的大小, 与并发模式一样先压缩到暂存再写入
+	if opts.Workers > 1 || opts.Password != "" {
+		err = zipWriteParallel(ctx, zw, entries, opts)
+	} else {
+		err = zipWriteSequential(ctx, zw, entries)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return
+}
+
+// UnzipAndExtract 解压 zipFile 到 destDir, 返回解压出的文件路径; 加密的 zip 需要传入 password
+// 只支持 WinZip AES 加密, 遇到旧的 ZipCrypto 加密返回 ErrZipCryptoUnsupported; 路径越出 destDir 的条目(zip slip)直接报错
+func UnzipAndExtract(zipFile, destDir string, password ...string) (files []string, err error) {
+	var pwd string
+	if len(password) > 0 {
+		pwd = password[0]
+	}
+
+	zr, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return
+	}
+	defer zr.Close()
+
+	destAbs, err := filepath.Abs(destDir)
+	if err != nil {
+		return
+	}
+	for _, f := range zr.File {
+		target := filepath.Join(destAbs, filepath.FromSlash(f.Name))
+		if target != destAbs && !strings.HasPrefix(target, destAbs+string(os.PathSeparator)) {
+			return files, fmt.Errorf("zip: illegal file path: %s", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return
+			}
+			continue
+		}
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return
+		}
+		if err = zipExtractFile(f, target, pwd); err != nil {
+			return
+		}
+		files = append(files, target)
+	}
+
+	return
+}
+
+func zipExtractFile(f *zip.File, target, password string) error {
+	rc, err := zipOpenEntry(f, password)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	perm := f.Mode().Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, rc)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(target)
+		return fmt.Errorf("unzip %s fail: %w", f.Name, err)
+	}
+
+	_ = os.Chtimes(target, f.Modified, f.Modified)
+	return nil
+}
+
+// zipCollect 遍历目录, 按遍历顺序返回需要写入的条目
+func zipCollect(sourceDir, zipFile string, opts ZipOptions) ([]zipEntry, error) {
+	zipAbs, _ := filepath.Abs(zipFile)
+
+	var entries []zipEntry
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); abs == zipAbs {
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			logs.Warning("[ZipDirectory] skip non-regular file: %s", path)
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+			header.Method = zip.Store
+		} else if opts.isStored(path) {
+			header.Method = zip.Store
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		entries = append(entries, zipEntry{path: path, header: header})
+		return nil
+	})
+
+	return entries, err
+}
+
+func zipWriteSequential(ctx context.Context, zw *zip.Writer, entries []zipEntry) error {
+	buf := make([]byte, 32*1024)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		w, err := zw.CreateHeader(entry.header)
+		if err != nil {
+			return err
+		}
+		if entry.header.FileInfo().IsDir() {
+			continue
+		}
+
+		src, err := os.Open(entry.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyBuffer(w, ctxReader{ctx: ctx, r: src}, buf)
+		_ = src.Close()
+		if err != nil {
+			return fmt.Errorf("zip %s fail: %v", entry.path, err)
+		}
+	}
+
+	return nil
+}
+
+type zipJob struct {
+	entry zipEntry
+	done  chan struct{}
+	spool *zipSpool
+	err   error
+}
+
+// zipWriteParallel 多个 worker 并发把文件压缩到 zipSpool, 当前 goroutine 按顺序以 CreateRaw 写入
+// 同时在处理中的文件最多 2*Workers 个, 用于限制暂存占用的内存与磁盘
+func zipWriteParallel(ctx context.Context, zw *zip.Writer, entries []zipEntry, opts ZipOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	spoolSize := opts.SpoolSize
+	if spoolSize <= 0 {
+		spoolSize = 8 << 20
+	}
+	level := opts.level()
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make([]*zipJob, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.header.FileInfo().IsDir() {
+			jobs = append(jobs, &zipJob{entry: entry, done: make(chan struct{})})
+		}
+	}
+
+	tokens := make(chan struct{}, workers*2)
+	work := make(chan *zipJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				job.spool, job.err = zipCompressFile(ctx, job.entry, level, spoolSize)
+				close(job.done)
+			}
+		}()
+	}
+	go func() {
+		defer close(work)
+		for _, job := range jobs {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case work <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// 退出前等待 worker 结束, 再清理所有未写入的暂存
+	defer func() {
+		cancel()
+		wg.Wait()
+		for _, job := range jobs {
+			if job.spool != nil {
+				job.spool.Close()
+			}
+		}
+	}()
+
+	next := 0
+	for _, entry := range entries {
+		if entry.header.FileInfo().IsDir() {
+			if _, err := zw.CreateHeader(entry.header); err != nil {
+				return err
+			}
+			continue
+		}
+
+		job := jobs[next]
+		next++
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if job.err != nil {
+			return fmt.Errorf("zip %s fail: %v", entry.path, job.err)
+		}
+
+		if opts.Password != "" {
+			zipAESPrepare(entry.header)
+		}
+		w, err := zw.CreateRaw(entry.header)
+		if err != nil {
+			return err
+		}
+		r, err := job.spool.Reader()
+		if err == nil && opts.Password != "" {
+			err = zipAESCopy(w, r, opts.Password)
+		} else if err == nil {
+			_, err = io.Copy(w, r)
+		}
+		job.spool.Close()
+		job.spool = nil
+		<-tokens
+		if err != nil {
+			return fmt.Errorf("zip %s fail: %v", entry.path, err)
+		}
+	}
+
+	return nil
+}
+
+// zipCompressFile 按 header 的压缩方式压缩文件, 并填好 CreateRaw 需要的 CRC32 与大小
+func zipCompressFile(ctx context.Context, entry zipEntry, level int, spoolSize int64) (*zipSpool, error) {
+	src, err := os.Open(entry.path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	spool := &zipSpool{limit: spoolSize}
+	var dst io.WriteCloser = nopWriteCloser{spool}
+	if entry.header.Method == zip.Deflate {
+		if dst, err = flate.NewWriter(spool, level); err != nil {
+			spool.Close()
+			return nil, err
+		}
+	}
+
+	crc := crc32.NewIEEE()
+	n, err := io.Copy(io.MultiWriter(dst, crc), ctxReader{ctx: ctx, r: src})
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		spool.Close()
+		return nil, err
+	}
+
+	entry.header.CRC32 = crc.Sum32()
+	entry.header.UncompressedSize64 = uint64(n)
+	entry.header.CompressedSize64 = uint64(spool.size)
+	return spool, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// zipSpool 压缩结果的暂存, 超过 limit 后转存到临时文件
+type zipSpool struct {
+	limit int64
+	size  int64
+	mem   bytes.Buffer
+	file  *os.File
+}
+
+func (s *zipSpool) Write(p []byte) (int, error) {
+	if s.file == nil && int64(s.mem.Len()+len(p)) > s.limit {
+		f, err := os.CreateTemp("", "libtools-zip-*")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		if _, err = s.mem.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.mem.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+func (s *zipSpool) Reader() (io.Reader, error) {
+	if s.file == nil {
+		return &s.mem, nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+func (s *zipSpool) Close() {
+	s.mem = bytes.Buffer{}
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
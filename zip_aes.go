@@ -0,0 +1,267 @@
+package libtools
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// WinZip AES 加密(AE-2), 7-Zip、WinZip、WinRAR 等工具均可解压, 格式见 https://www.winzip.com/en/support/aes-encryption/
+
+const (
+	zipMethodWinZipAES = 99
+	zipAESExtraID      = 0x9901
+	zipAESMacSize      = 10
+	zipAESPwvSize      = 2
+)
+
+var (
+	// ErrZipCryptoUnsupported 旧的 ZipCrypto 加密强度太弱, 不支持解压, 需要对方改用 AES-256
+	ErrZipCryptoUnsupported = errors.New("zip: legacy ZipCrypto encryption is not supported, use AES-256 instead")
+	// ErrZipPassword 密码为空或错误
+	ErrZipPassword = errors.New("zip: password is missing or incorrect")
+	// ErrZipAuthFailed 数据校验失败, 文件被篡改或已损坏
+	ErrZipAuthFailed = errors.New("zip: aes authentication failed")
+)
+
+// zipAESSaltSize 按 AES 强度(1: 128, 2: 192, 3: 256)返回 salt 长度, key 长度为 salt 的两倍
+func zipAESSaltSize(strength byte) int {
+	switch strength {
+	case 1:
+		return 8
+	case 2:
+		return 12
+	case 3:
+		return 16
+	}
+	return 0
+}
+
+func zipAESKeys(password string, salt []byte) (encKey, authKey, pwv []byte) {
+	keyLen := len(salt) * 2
+	dk := pbkdf2.Key([]byte(password), salt, 1000, keyLen*2+zipAESPwvSize, sha1.New)
+	return dk[:keyLen], dk[keyLen : keyLen*2], dk[keyLen*2:]
+}
+
+// zipAESCTR WinZip 使用的 CTR 模式: 计数器从 1 开始, 按小端序递增, 与 cipher.NewCTR 不兼容
+type zipAESCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	pos     int
+}
+
+func newZipAESCTR(key []byte) (*zipAESCTR, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &zipAESCTR{block: block, pos: aes.BlockSize}, nil
+}
+
+func (c *zipAESCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.pos == aes.BlockSize {
+			for j := 0; j < 8; j++ {
+				c.counter[j]++
+				if c.counter[j] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.stream[:], c.counter[:])
+			c.pos = 0
+		}
+		dst[i] = src[i] ^ c.stream[c.pos]
+		c.pos++
+	}
+}
+
+// zipAESPrepare 在 CreateRaw 之前调用, 把已压缩条目的 header 改为 AES-256 加密的格式
+func zipAESPrepare(header *zip.FileHeader) {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], zipAESExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], 2) // AE-2, 不写 CRC32
+	copy(extra[6:], "AE")
+	extra[8] = 3 // AES-256
+	binary.LittleEndian.PutUint16(extra[9:], header.Method)
+
+	header.Extra = append(header.Extra, extra...)
+	header.Method = zipMethodWinZipAES
+	header.Flags |= 0x1
+	header.CRC32 = 0
+	header.CompressedSize64 += uint64(zipAESSaltSize(3) + zipAESPwvSize + zipAESMacSize)
+}
+
+// zipAESCopy 把 src 中已压缩的数据加密写入 w: salt + 密码校验值 + 密文 + HMAC-SHA1 的前 10 字节
+func zipAESCopy(w io.Writer, src io.Reader, password string) error {
+	salt := make([]byte, zipAESSaltSize(3))
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	encKey, authKey, pwv := zipAESKeys(password, salt)
+	ctr, err := newZipAESCTR(encKey)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha1.New, authKey)
+
+	if _, err = w.Write(append(salt, pwv...)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			ctr.XORKeyStream(buf[:n], buf[:n])
+			_, _ = mac.Write(buf[:n])
+			if _, err = w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	_, err = w.Write(mac.Sum(nil)[:zipAESMacSize])
+	return err
+}
+
+// zipAESExtra 解析 0x9901 扩展字段, 返回 AES 强度与实际的压缩方式
+func zipAESExtra(extra []byte) (strength byte, method uint16, ok bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:])
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			return
+		}
+		if id == zipAESExtraID && size >= 7 {
+			return extra[8], binary.LittleEndian.Uint16(extra[9:]), true
+		}
+		extra = extra[4+size:]
+	}
+	return
+}
+
+// zipOpenEntry 打开 zip 中的文件, 加密的条目按 WinZip AES 解密
+func zipOpenEntry(f *zip.File, password string) (io.ReadCloser, error) {
+	if f.Flags&0x1 == 0 {
+		return f.Open()
+	}
+	if f.Method != zipMethodWinZipAES {
+		return nil, fmt.Errorf("%w: %s", ErrZipCryptoUnsupported, f.Name)
+	}
+	if password == "" {
+		return nil, fmt.Errorf("%w: %s", ErrZipPassword, f.Name)
+	}
+
+	strength, method, ok := zipAESExtra(f.Extra)
+	saltSize := zipAESSaltSize(strength)
+	overhead := uint64(saltSize + zipAESPwvSize + zipAESMacSize)
+	if !ok || saltSize == 0 || f.CompressedSize64 < overhead {
+		return nil, fmt.Errorf("zip: invalid aes entry: %s", f.Name)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, saltSize+zipAESPwvSize)
+	if _, err = io.ReadFull(raw, head); err != nil {
+		return nil, err
+	}
+	encKey, authKey, pwv := zipAESKeys(password, head[:saltSize])
+	if !hmac.Equal(pwv, head[saltSize:]) {
+		return nil, fmt.Errorf("%w: %s", ErrZipPassword, f.Name)
+	}
+	ctr, err := newZipAESCTR(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := &zipAESReader{
+		r:   io.LimitReader(raw, int64(f.CompressedSize64-overhead)),
+		raw: raw,
+		ctr: ctr,
+		mac: hmac.New(sha1.New, authKey),
+	}
+	switch method {
+	case zip.Store:
+		return &zipAESEntry{Reader: dec, dec: dec}, nil
+	case zip.Deflate:
+		fr := flate.NewReader(dec)
+		return &zipAESEntry{Reader: fr, dec: dec, closer: fr}, nil
+	}
+
+	return nil, fmt.Errorf("zip: unsupported compression method %d in aes entry: %s", method, f.Name)
+}
+
+// zipAESReader 解密并在读完后校验 HMAC
+type zipAESReader struct {
+	r   io.Reader
+	raw io.Reader
+	ctr *zipAESCTR
+	mac hash.Hash
+	end error // 读完密文后的结果, io.EOF 或 ErrZipAuthFailed
+}
+
+func (z *zipAESReader) Read(p []byte) (int, error) {
+	if z.end != nil {
+		return 0, z.end
+	}
+
+	n, err := z.r.Read(p)
+	if n > 0 {
+		_, _ = z.mac.Write(p[:n])
+		z.ctr.XORKeyStream(p[:n], p[:n])
+	}
+	if err == io.EOF {
+		z.end = io.EOF
+		want := make([]byte, zipAESMacSize)
+		if _, readErr := io.ReadFull(z.raw, want); readErr != nil || !hmac.Equal(want, z.mac.Sum(nil)[:zipAESMacSize]) {
+			z.end = ErrZipAuthFailed
+		}
+		return n, z.end
+	}
+	return n, err
+}
+
+// zipAESEntry 解压结束时把剩余的密文读完, 保证 HMAC 一定被校验
+type zipAESEntry struct {
+	io.Reader
+	dec    *zipAESReader
+	closer io.Closer
+}
+
+func (e *zipAESEntry) Read(p []byte) (int, error) {
+	n, err := e.Reader.Read(p)
+	if err == io.EOF {
+		if _, drainErr := io.Copy(io.Discard, e.dec); drainErr != nil {
+			return n, drainErr
+		}
+	}
+	return n, err
+}
+
+func (e *zipAESEntry) Close() error {
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package libtools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestZipAESRoundTrip(t *testing.T) {
+	src := filepath.Join(testutil.TempDirT(t), "src")
+	big := bytes.Repeat([]byte("reconciliation,100.00\n"), 5000)
+	testutil.WriteTempFileT(t, "src/recon.csv", big)
+	testutil.WriteTempFileT(t, "src/img/logo.png", []byte("stored entry"))
+	testutil.WriteTempFileT(t, "src/empty.txt", nil)
+
+	zipFile := filepath.Join(testutil.TempDirT(t), "enc.zip")
+	if err := ZipDirectoryWithOptions(context.Background(), src, zipFile, ZipOptions{Password: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() && (f.Method != zipMethodWinZipAES || f.Flags&0x1 == 0) {
+			t.Fatalf("entry should be aes encrypted: %s", f.Name)
+		}
+	}
+	_ = zr.Close()
+
+	dest := filepath.Join(testutil.TempDirT(t), "out")
+	files, err := UnzipAndExtract(zipFile, dest, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	if buf, _ := os.ReadFile(filepath.Join(dest, "recon.csv")); !bytes.Equal(buf, big) {
+		t.Fatal("content mismatch")
+	}
+	if buf, _ := os.ReadFile(filepath.Join(dest, "img", "logo.png")); string(buf) != "stored entry" {
+		t.Fatalf("content mismatch: %s", buf)
+	}
+
+	if _, err = UnzipAndExtract(zipFile, dest, "wrong"); !errors.Is(err, ErrZipPassword) {
+		t.Fatalf("expect ErrZipPassword, got: %v", err)
+	}
+	if _, err = UnzipAndExtract(zipFile, dest); !errors.Is(err, ErrZipPassword) {
+		t.Fatalf("expect ErrZipPassword without password, got: %v", err)
+	}
+}
+
+func TestZipAESTampered(t *testing.T) {
+	testutil.WriteTempFileT(t, "src/a.txt", bytes.Repeat([]byte("a"), 1000))
+	zipFile := filepath.Join(testutil.TempDirT(t), "enc.zip")
+	if err := ZipDirectoryWithOptions(context.Background(), filepath.Join(testutil.TempDirT(t), "src"), zipFile, ZipOptions{Password: "p", StoreExts: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 篡改密文中间的一个字节
+	buf, _ := os.ReadFile(zipFile)
+	i := bytes.Index(buf, []byte("a.txt")) + 5 + 11 + 16 + 2 + 100
+	buf[i] ^= 0xff
+	_ = os.WriteFile(zipFile, buf, 0644)
+
+	if _, err := UnzipAndExtract(zipFile, filepath.Join(testutil.TempDirT(t), "out"), "p"); !errors.Is(err, ErrZipAuthFailed) {
+		t.Fatalf("expect ErrZipAuthFailed, got: %v", err)
+	}
+}
+
+func TestUnzipAndExtractRejects(t *testing.T) {
+	dir := testutil.TempDirT(t)
+
+	// 旧的 ZipCrypto 加密
+	legacy := filepath.Join(dir, "legacy.zip")
+	f, _ := os.Create(legacy)
+	zw := zip.NewWriter(f)
+	w, _ := zw.CreateRaw(&zip.FileHeader{Name: "a.txt", Method: zip.Store, Flags: 0x1, CompressedSize64: 15, UncompressedSize64: 3})
+	_, _ = w.Write(make([]byte, 15))
+	_ = zw.Close()
+	_ = f.Close()
+	if _, err := UnzipAndExtract(legacy, filepath.Join(dir, "out"), "p"); !errors.Is(err, ErrZipCryptoUnsupported) {
+		t.Fatalf("expect ErrZipCryptoUnsupported, got: %v", err)
+	}
+
+	// zip slip
+	slip := filepath.Join(dir, "slip.zip")
+	f, _ = os.Create(slip)
+	zw = zip.NewWriter(f)
+	w, _ = zw.Create("../evil.txt")
+	_, _ = w.Write([]byte("x"))
+	_ = zw.Close()
+	_ = f.Close()
+	if _, err := UnzipAndExtract(slip, filepath.Join(dir, "out")); err == nil {
+		t.Fatal("zip slip should be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatal("evil file should not be written")
+	}
+}
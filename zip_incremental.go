@@ -0,0 +1,142 @@
+package libtools
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ZipManifest 增量备份的文件清单, 记录备份时每个文件的大小、修改时间与 md5
+type ZipManifest struct {
+	CreatedAt int64                      `json:"created_at"` // 毫秒
+	Files     map[string]ZipManifestFile `json:"files"`
+	// Changed 本次打包的文件(新增或修改), Deleted 上次清单中有但本次已删除的文件, 路径均为 zip 内的路径
+	Changed []string `json:"changed"`
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// ZipManifestFile 清单中的单个文件
+type ZipManifestFile struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // 毫秒
+	Md5     string `json:"md5"`
+}
+
+// ZipManifestPath 与 zipFile 对应的清单文件路径
+func ZipManifestPath(zipFile string) string {
+	return zipFile + ".manifest.json"
+}
+
+// LoadZipManifest 读取清单文件
+func LoadZipManifest(path string) (*ZipManifest, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ZipManifest
+	if err = json.Unmarshal(buf, &manifest); err != nil {
+		return nil, fmt.Errorf("could not parse zip manifest %s: %v", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]ZipManifestFile)
+	}
+
+	return &manifest, nil
+}
+
+// ZipDirectoryIncremental 只打包相对 sinceManifest 新增或修改的文件, 新的清单写入 ZipManifestPath(zipFile) 并返回
+// 大小与修改时间都没变的文件视为未修改, 否则比较 md5, 只是被 touch 过的文件不会重复打包
+// sinceManifest 为空时打包全部文件, 即全量备份; 下次增量时传入本次的清单路径
+func ZipDirectoryIncremental(sourceDir, zipFile, sinceManifest string) (manifest *ZipManifest, err error) {
+	ctx, span := StartSpan(context.Background(), "ZipDirectoryIncremental")
+	span.SetAttribute("zip.source", sourceDir)
+	defer func() { endSpan(span, err) }()
+
+	previous := &ZipManifest{Files: map[string]ZipManifestFile{}}
+	if sinceManifest != "" {
+		if previous, err = LoadZipManifest(sinceManifest); err != nil {
+			return
+		}
+	}
+
+	manifestPath := ZipManifestPath(zipFile)
+	// 清单文件位于源目录内时不计入备份
+	skip := map[string]bool{}
+	for _, path := range []string{manifestPath, sinceManifest} {
+		if abs, absErr := filepath.Abs(path); absErr == nil && path != "" {
+			skip[abs] = true
+		}
+	}
+
+	entries, err := zipCollect(sourceDir, zipFile, ZipOptions{})
+	if err != nil {
+		return
+	}
+
+	manifest = &ZipManifest{CreatedAt: GetUnixMillis(), Files: make(map[string]ZipManifestFile, len(entries))}
+	var changed []zipEntry
+	for _, entry := range entries {
+		info := entry.header.FileInfo()
+		if abs, _ := filepath.Abs(entry.path); info.IsDir() || skip[abs] {
+			continue
+		}
+
+		file := ZipManifestFile{Size: info.Size(), ModTime: GetUnixMillisByTime(info.ModTime())}
+		old, ok := previous.Files[entry.header.Name]
+		if ok && old.Size == file.Size && old.ModTime == file.ModTime {
+			file.Md5 = old.Md5
+		} else {
+			if file.Md5, err = zipFileMd5(ctx, entry.path); err != nil {
+				return nil, err
+			}
+		}
+
+		manifest.Files[entry.header.Name] = file
+		if !ok || old.Md5 != file.Md5 {
+			changed = append(changed, entry)
+			manifest.Changed = append(manifest.Changed, entry.header.Name)
+		}
+	}
+
+	for name := range previous.Files {
+		if _, ok := manifest.Files[name]; !ok {
+			manifest.Deleted = append(manifest.Deleted, name)
+		}
+	}
+	sort.Strings(manifest.Deleted)
+	span.SetAttribute("zip.changed", len(manifest.Changed))
+	span.SetAttribute("zip.deleted", len(manifest.Deleted))
+
+	if err = zipWriteFile(ctx, zipFile, changed, ZipOptions{}); err != nil {
+		return nil, err
+	}
+
+	buf, _ := json.MarshalIndent(manifest, "", "  ")
+	if err = os.WriteFile(manifestPath, buf, 0644); err != nil {
+		_ = os.Remove(zipFile)
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func zipFileMd5(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err = io.Copy(hash, ctxReader{ctx: ctx, r: f}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
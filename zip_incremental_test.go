@@ -0,0 +1,52 @@
+package libtools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+func TestZipDirectoryIncremental(t *testing.T) {
+	src := filepath.Join(testutil.TempDirT(t), "src")
+	testutil.WriteTempFileT(t, "src/a.txt", []byte("a"))
+	testutil.WriteTempFileT(t, "src/sub/b.txt", []byte("b"))
+	testutil.WriteTempFileT(t, "src/c.txt", []byte("c"))
+
+	full := filepath.Join(testutil.TempDirT(t), "full.zip")
+	m1, err := ZipDirectoryIncremental(src, full, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m1.Changed, []string{"a.txt", "c.txt", "sub/b.txt"}) || len(readZipT(t, full)) != 3 {
+		t.Fatalf("full backup should contain all files: %v", m1.Changed)
+	}
+
+	// 修改 a, 删除 c, 新增 d, b 只 touch 不修改内容
+	later := time.Now().Add(time.Minute)
+	testutil.WriteTempFileT(t, "src/a.txt", []byte("a2"))
+	_ = os.Remove(filepath.Join(src, "c.txt"))
+	testutil.WriteTempFileT(t, "src/d.txt", []byte("d"))
+	_ = os.Chtimes(filepath.Join(src, "sub/b.txt"), later, later)
+
+	diff := filepath.Join(testutil.TempDirT(t), "diff.zip")
+	m2, err := ZipDirectoryIncremental(src, diff, ZipManifestPath(full))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m2.Changed, []string{"a.txt", "d.txt"}) || !reflect.DeepEqual(m2.Deleted, []string{"c.txt"}) {
+		t.Fatalf("unexpected manifest: %+v", m2)
+	}
+	got := readZipT(t, diff)
+	if len(got) != 2 || string(got["a.txt"]) != "a2" {
+		t.Fatalf("unexpected diff zip: %v", got)
+	}
+
+	loaded, err := LoadZipManifest(ZipManifestPath(diff))
+	if err != nil || len(loaded.Files) != 3 || loaded.Files["sub/b.txt"].Md5 != m1.Files["sub/b.txt"].Md5 {
+		t.Fatalf("unexpected loaded manifest: %+v, err: %v", loaded, err)
+	}
+}
@@ -0,0 +1,101 @@
+package libtools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chester84/libtools/testutil"
+)
+
+// readZipT 读取 zip 内所有文件, 目录条目的内容为空
+func readZipT(t *testing.T, zipFile string) map[string][]byte {
+	t.Helper()
+	zr, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("read %s fail: %v", f.Name, err)
+		}
+		files[f.Name] = buf
+	}
+
+	return files
+}
+
+func TestZipDirectory(t *testing.T) {
+	src := filepath.Join(testutil.TempDirT(t), "src")
+	big := bytes.Repeat([]byte("libtools zip "), 20000)
+	want := map[string][]byte{
+		"a.txt":         []byte("hello"),
+		"sub/b.log":     big,
+		"sub/c.png":     []byte("not really a png"),
+		"sub/deep/d.md": []byte("# d"),
+	}
+	for name, data := range want {
+		testutil.WriteTempFileT(t, "src/"+name, data)
+	}
+
+	for _, opts := range []ZipOptions{{}, {Workers: 4, Level: 9, SpoolSize: 1024}} {
+		zipFile := filepath.Join(testutil.TempDirT(t), "out.zip")
+		if err := ZipDirectoryWithOptions(context.Background(), src, zipFile, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		got := readZipT(t, zipFile)
+		for name, data := range want {
+			if !bytes.Equal(got[name], data) {
+				t.Fatalf("workers %d: content mismatch: %s", opts.Workers, name)
+			}
+		}
+		if _, ok := got["sub/deep/"]; !ok {
+			t.Fatalf("workers %d: missing dir entry: %v", opts.Workers, got)
+		}
+
+		zr, _ := zip.OpenReader(zipFile)
+		for _, f := range zr.File {
+			if f.Name == "sub/c.png" && f.Method != zip.Store {
+				t.Fatal("png should be stored")
+			}
+			if f.Name == "sub/b.log" && (f.Method != zip.Deflate || f.CompressedSize64 >= f.UncompressedSize64) {
+				t.Fatal("log should be deflated")
+			}
+		}
+		_ = zr.Close()
+	}
+
+	// zip 文件位于源目录内时不打包自身
+	inner := filepath.Join(src, "self.zip")
+	if err := ZipDirectory(src, inner); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readZipT(t, inner)["self.zip"]; ok {
+		t.Fatal("zip should not contain itself")
+	}
+	_ = os.Remove(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := filepath.Join(testutil.TempDirT(t), "cancel.zip")
+	if err := ZipDirectoryWithOptions(ctx, src, out, ZipOptions{Workers: 2}); err == nil {
+		t.Fatal("canceled ctx should fail")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatal("partial zip should be removed")
+	}
+}